@@ -5,7 +5,7 @@ root.go — это ядро CLI
 Он содержит:
 
 Корневую команду (sib)
-Регистрацию всех подкоманд (init, add, commit)
+Регистрацию всех подкоманд (init, add, commit, status, ...)
 Глобальные настройки (флаги, версия, help)
 */
 
@@ -39,5 +39,29 @@ func init() {
 	// Глобальные флаги (если понадобятся)
 	rootCmd.AddCommand(cli.InitCmd)
 	rootCmd.AddCommand(cli.AddCmd)
-	//rootCmd.AddCommand(cli.CommitCmd)
+	rootCmd.AddCommand(cli.CommitCmd)
+	rootCmd.AddCommand(cli.StatusCmd)
+	rootCmd.AddCommand(cli.LogCmd)
+	rootCmd.AddCommand(cli.RevParseCmd)
+	rootCmd.AddCommand(cli.DiffCmd)
+	rootCmd.AddCommand(cli.CheckoutCmd)
+	rootCmd.AddCommand(cli.SwitchCmd)
+	rootCmd.AddCommand(cli.BranchCmd)
+	rootCmd.AddCommand(cli.TagCmd)
+	rootCmd.AddCommand(cli.MergeCmd)
+	rootCmd.AddCommand(cli.MergeBaseCmd)
+	rootCmd.AddCommand(cli.RebaseCmd)
+	rootCmd.AddCommand(cli.CherryPickCmd)
+	rootCmd.AddCommand(cli.RevertCmd)
+	rootCmd.AddCommand(cli.ResetCmd)
+	rootCmd.AddCommand(cli.RestoreCmd)
+	rootCmd.AddCommand(cli.RmCmd)
+	rootCmd.AddCommand(cli.MvCmd)
+	rootCmd.AddCommand(cli.CheckIgnoreCmd)
+	rootCmd.AddCommand(cli.ConfigCmd)
+	rootCmd.AddCommand(cli.RepackCmd)
+	rootCmd.AddCommand(cli.GCCmd)
+	rootCmd.AddCommand(cli.PruneCmd)
+	rootCmd.AddCommand(cli.FsckCmd)
+	rootCmd.AddCommand(cli.CountObjectsCmd)
 }
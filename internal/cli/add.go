@@ -8,24 +8,37 @@ import (
 	"sib/internal/commands"
 )
 
+var (
+	addUpdate bool
+	addAll    bool
+)
+
 // AddCmd - cobra команда для add
 var AddCmd = &cobra.Command{
-	Use:   "add [path...]",
+	Use:   "add [-u | -A] [<pathspec>...]",
 	Short: "Add file contents to the index",
-	Long: `Add files to the staging area (index). 
-Use '.' to add all files in the current directory.`,
+	Long: `Add files to the staging area (index).
+Paths may be files, directories, globs like '*.go' or ':(exclude)<path>'.
+Use '.' to add all files in the repository.
+
+  -u, --update   stage modified and deleted tracked files only
+  -A, --all      stage new and modified files and remove deleted ones`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			args = []string{"."}
+		if len(args) == 0 && !addUpdate && !addAll {
+			fmt.Println("Nothing specified, nothing added.")
+			fmt.Println("hint: Maybe you wanted to say 'sib add .'?")
+			return
 		}
 
-		// Пока поддерживаем только sib add .
-		if len(args) == 1 && args[0] == "." {
-			if err := commands.Add("."); err != nil {
-				fmt.Printf("error: %v\n", err)
-			}
-		} else {
-			fmt.Println("error: only 'sib add .' is supported for now")
+		opts := commands.AddOptions{Update: addUpdate, All: addAll}
+		if err := commands.Add(".", args, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
 		}
 	},
 }
+
+func init() {
+	AddCmd.Flags().BoolVarP(&addUpdate, "update", "u", false, "update tracked files")
+	AddCmd.Flags().BoolVarP(&addAll, "all", "A", false, "add changes from all tracked and untracked files")
+	AddCmd.MarkFlagsMutuallyExclusive("update", "all")
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	branchDelete      bool
+	branchForceDelete bool
+	branchMove        bool
+	branchForceMove   bool
+	branchCopy        bool
+	branchForceCopy   bool
+	branchForce       bool
+	branchContains    string
+	branchMerged      string
+	branchNoMerged    string
+)
+
+// BranchCmd - cobra команда для branch
+var BranchCmd = &cobra.Command{
+	Use:   "branch [<name> [<start-point>]]",
+	Short: "List, create, or delete branches",
+	Long: `With no arguments, list branches; the current branch is marked with '*'.
+
+  sib branch <name> [<start-point>]   create a branch (at HEAD by default)
+  sib branch -d|-D <name>...          delete branches (-D also deletes unmerged ones)
+  sib branch -m|-M [<old>] <new>      rename a branch (the current one by default)
+  sib branch -c|-C [<old>] <new>      copy a branch (the current one by default)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runBranch(args); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func runBranch(args []string) error {
+	switch {
+	case branchDelete || branchForceDelete:
+		if len(args) == 0 {
+			return fmt.Errorf("branch name required")
+		}
+		for _, name := range args {
+			if err := commands.DeleteBranch(".", name, branchForceDelete || branchForce); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case branchMove || branchForceMove || branchCopy || branchForceCopy:
+		var oldName, newName string
+		switch len(args) {
+		case 1:
+			newName = args[0]
+		case 2:
+			oldName, newName = args[0], args[1]
+		default:
+			return fmt.Errorf("expected [<old>] <new>")
+		}
+		if branchMove || branchForceMove {
+			return commands.RenameBranch(".", oldName, newName, branchForceMove || branchForce)
+		}
+		return commands.CopyBranch(".", oldName, newName, branchForceCopy || branchForce)
+
+	case len(args) > 0:
+		if len(args) > 2 {
+			return fmt.Errorf("too many arguments")
+		}
+		var start string
+		if len(args) == 2 {
+			start = args[1]
+		}
+		return commands.CreateBranch(".", args[0], start, branchForce)
+
+	default:
+		return commands.PrintBranches(".", commands.BranchListOptions{
+			Contains: branchContains,
+			Merged:   branchMerged,
+			NoMerged: branchNoMerged,
+		})
+	}
+}
+
+func init() {
+	flags := BranchCmd.Flags()
+	flags.BoolVarP(&branchDelete, "delete", "d", false, "delete a fully merged branch")
+	flags.BoolVarP(&branchForceDelete, "force-delete", "D", false, "delete a branch even if it is not merged")
+	flags.BoolVarP(&branchMove, "move", "m", false, "rename a branch")
+	flags.BoolVarP(&branchForceMove, "force-move", "M", false, "rename a branch even if the new name exists")
+	flags.BoolVarP(&branchCopy, "copy", "c", false, "copy a branch")
+	flags.BoolVarP(&branchForceCopy, "force-copy", "C", false, "copy a branch even if the new name exists")
+	flags.BoolVarP(&branchForce, "force", "f", false, "reset an existing branch to the start point")
+
+	// Фильтры без значения относятся к HEAD, как в git
+	flags.StringVar(&branchContains, "contains", "", "list only branches that contain the commit")
+	flags.StringVar(&branchMerged, "merged", "", "list only branches merged into the commit")
+	flags.StringVar(&branchNoMerged, "no-merged", "", "list only branches not merged into the commit")
+	for _, name := range []string{"contains", "merged", "no-merged"} {
+		flags.Lookup(name).NoOptDefVal = "HEAD"
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	checkIgnoreVerbose     bool
+	checkIgnoreNonMatching bool
+)
+
+// CheckIgnoreCmd - cobra команда для check-ignore
+var CheckIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore [-v [-n]] <path>...",
+	Short: "Debug .sibignore / exclude files",
+	Long: `Print each path that is ignored by .sibignore files, .sib/info/exclude
+or the global excludes file. With -v, print the matching rule as
+<source>:<line>:<pattern> before the path. Exit status is 0 if at least one
+path is ignored and 1 otherwise.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ignored, err := commands.CheckIgnore(".", args, commands.CheckIgnoreOptions{
+			Verbose:     checkIgnoreVerbose,
+			NonMatching: checkIgnoreNonMatching,
+		})
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+		if ignored == 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	CheckIgnoreCmd.Flags().BoolVarP(&checkIgnoreVerbose, "verbose", "v", false, "output details about the matching pattern")
+	CheckIgnoreCmd.Flags().BoolVarP(&checkIgnoreNonMatching, "non-matching", "n", false, "show non-matching input paths (with -v)")
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	checkoutBranch string
+	checkoutDetach bool
+	checkoutForce  bool
+)
+
+// CheckoutCmd - cobra команда для checkout
+var CheckoutCmd = &cobra.Command{
+	Use:   "checkout [<branch>|<commit>] [-- <path>...]",
+	Short: "Switch branches or restore working tree files",
+	Long: `Switch to a branch or a commit (detaching HEAD), or restore files.
+
+  sib checkout <branch>             switch to a branch
+  sib checkout <commit>             detach HEAD at a commit
+  sib checkout <commit> -- <path>   restore paths from a commit into the index and working tree
+  sib checkout -- <path>            restore paths from the index`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			if dash > 1 {
+				fmt.Println("error: only one revision may be given before '--'")
+				return
+			}
+			var rev string
+			if dash == 1 {
+				rev = args[0]
+			}
+			if err := commands.CheckoutPaths(".", rev, args[dash:]); err != nil {
+				fmt.Printf("error: %v\n", err)
+			}
+			return
+		}
+
+		if len(args) > 1 {
+			fmt.Println("error: use '--' to separate paths from the revision")
+			return
+		}
+		var target string
+		if len(args) == 1 {
+			target = args[0]
+		}
+
+		opts := commands.SwitchOptions{
+			Create: checkoutBranch,
+			Detach: checkoutDetach,
+			Force:  checkoutForce,
+		}
+		if err := commands.Checkout(".", target, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	CheckoutCmd.Flags().StringVarP(&checkoutBranch, "branch", "b", "", "create a new branch and check it out")
+	CheckoutCmd.Flags().BoolVar(&checkoutDetach, "detach", false, "detach HEAD at the commit")
+	CheckoutCmd.Flags().BoolVarP(&checkoutForce, "force", "f", false, "discard local changes")
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	cherryPickMainline int
+	cherryPickNoCommit bool
+	cherryPickContinue bool
+	cherryPickAbort    bool
+)
+
+// CherryPickCmd - cobra команда для cherry-pick
+var CherryPickCmd = &cobra.Command{
+	Use:   "cherry-pick [-m <parent>] [-n] <commit>... | --continue | --abort",
+	Short: "Apply the changes introduced by some existing commits",
+	Long: `Apply the change each commit introduces relative to its parent and record
+a new commit with the original author. The message gets a
+"(cherry picked from commit ...)" trailer. For a merge commit, -m selects
+the parent the change is taken against.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch {
+		case cherryPickContinue:
+			err = commands.CherryPickContinue(".")
+		case cherryPickAbort:
+			err = commands.CherryPickAbort(".")
+		default:
+			err = commands.CherryPick(".", args, commands.PickOptions{
+				Mainline: cherryPickMainline,
+				NoCommit: cherryPickNoCommit,
+			})
+		}
+		// Как в git: остановка на конфликте - код 1, прочие ошибки - 128.
+		// О конфликте команда уже сообщила
+		switch {
+		case err == nil:
+		case errors.Is(err, commands.ErrPickConflict):
+			os.Exit(1)
+		default:
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+	},
+}
+
+func init() {
+	CherryPickCmd.Flags().IntVarP(&cherryPickMainline, "mainline", "m", 0, "parent number of a merge commit to take the change against")
+	CherryPickCmd.Flags().BoolVarP(&cherryPickNoCommit, "no-commit", "n", false, "apply the changes without committing")
+	CherryPickCmd.Flags().BoolVar(&cherryPickContinue, "continue", false, "continue after resolving conflicts")
+	CherryPickCmd.Flags().BoolVar(&cherryPickAbort, "abort", false, "cancel and return to the pre-sequence state")
+}
@@ -1 +1,43 @@
 package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	commitMessage    string
+	commitAllowEmpty bool
+	commitAmend      bool
+	commitAuthor     string
+)
+
+// CommitCmd - cobra команда для commit
+var CommitCmd = &cobra.Command{
+	Use:   "commit",
+	Short: "Record changes to the repository",
+	Long: `Create a new commit containing the current contents of the index
+and advance the current branch to point at it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.CommitOptions{
+			Message:    commitMessage,
+			AllowEmpty: commitAllowEmpty,
+			Amend:      commitAmend,
+			Author:     commitAuthor,
+		}
+
+		if _, err := commands.Commit(".", opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	CommitCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "commit message")
+	CommitCmd.Flags().BoolVar(&commitAllowEmpty, "allow-empty", false, "allow recording a commit with no changes")
+	CommitCmd.Flags().BoolVar(&commitAmend, "amend", false, "replace the tip of the current branch")
+	CommitCmd.Flags().StringVar(&commitAuthor, "author", "", "override the commit author (\"Name <email>\")")
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+	"sib/internal/core/config"
+)
+
+var (
+	configSystem     bool
+	configGlobal     bool
+	configLocal      bool
+	configGet        bool
+	configGetAll     bool
+	configSet        bool
+	configUnset      bool
+	configList       bool
+	configShowOrigin bool
+)
+
+// ConfigCmd - cobra команда для config
+var ConfigCmd = &cobra.Command{
+	Use:   "config [<scope>] [--get | --get-all | --set | --unset | --list] [<key> [<value>]]",
+	Short: "Get and set repository or global options",
+	Long: `Query and modify options stored in git-style INI files.
+
+  sib config <key>                    print the value of <key>
+  sib config [--set] <key> <value>    set <key> to <value>
+  sib config --get-all <key>          print all values of a multi-valued key
+  sib config --unset <key>            remove <key>
+  sib config --list [--show-origin]   list all options
+
+Values are read from the system file (/etc/sibconfig), the global file
+(~/.sibconfig), the repository file (.sib/config) and SIB_CONFIG_COUNT,
+SIB_CONFIG_KEY_<n>, SIB_CONFIG_VALUE_<n>; later ones override earlier ones.
+--system, --global and --local restrict reads to one file and choose where
+writes go (the repository file by default).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfig(args); err != nil {
+			if errors.Is(err, config.ErrNotFound) {
+				os.Exit(1)
+			}
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+	},
+}
+
+func runConfig(args []string) error {
+	opts := commands.ConfigOptions{ShowOrigin: configShowOrigin}
+	switch {
+	case configSystem:
+		opts.Scope = config.ScopeSystem
+	case configGlobal:
+		opts.Scope = config.ScopeGlobal
+	case configLocal:
+		opts.Scope = config.ScopeLocal
+	}
+
+	switch {
+	case configList:
+		if len(args) != 0 {
+			return fmt.Errorf("--list takes no arguments")
+		}
+		_, err := commands.ConfigList(".", opts)
+		return err
+
+	case configUnset:
+		if len(args) != 1 {
+			return fmt.Errorf("--unset requires exactly one key")
+		}
+		return commands.ConfigUnset(".", args[0], opts)
+
+	case configSet || (!configGet && !configGetAll && len(args) == 2):
+		if len(args) != 2 {
+			return fmt.Errorf("--set requires a key and a value")
+		}
+		return commands.ConfigSet(".", args[0], args[1], opts)
+
+	default:
+		if len(args) != 1 {
+			return fmt.Errorf("wrong number of arguments, expected a key")
+		}
+		_, err := commands.ConfigGet(".", args[0], configGetAll, opts)
+		return err
+	}
+}
+
+func init() {
+	ConfigCmd.Flags().BoolVar(&configSystem, "system", false, "use the system config file")
+	ConfigCmd.Flags().BoolVar(&configGlobal, "global", false, "use the global config file")
+	ConfigCmd.Flags().BoolVar(&configLocal, "local", false, "use the repository config file")
+	ConfigCmd.Flags().BoolVar(&configGet, "get", false, "get the value of a key")
+	ConfigCmd.Flags().BoolVar(&configGetAll, "get-all", false, "get all values of a multi-valued key")
+	ConfigCmd.Flags().BoolVar(&configSet, "set", false, "set the value of a key")
+	ConfigCmd.Flags().BoolVar(&configUnset, "unset", false, "remove a key")
+	ConfigCmd.Flags().BoolVarP(&configList, "list", "l", false, "list all options")
+	ConfigCmd.Flags().BoolVar(&configShowOrigin, "show-origin", false, "show where each value comes from")
+	ConfigCmd.MarkFlagsMutuallyExclusive("system", "global", "local")
+	ConfigCmd.MarkFlagsMutuallyExclusive("get", "get-all", "set", "unset", "list")
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var countObjectsVerbose bool
+
+// CountObjectsCmd - cobra команда для count-objects
+var CountObjectsCmd = &cobra.Command{
+	Use:   "count-objects [-v]",
+	Short: "Count objects and their disk usage",
+	Long: `Print the number of loose objects and the disk space they use. With -v,
+also report packed objects, pack files, leftover temporary files and, for
+large files stored in content-defined chunks (see core.chunkThreshold), the
+deduplication ratio: total size of chunked files divided by the size of the
+distinct chunks actually stored.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.CountObjectsOptions{Verbose: countObjectsVerbose}
+		if _, err := commands.CountObjects(".", opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	CountObjectsCmd.Flags().BoolVarP(&countObjectsVerbose, "verbose", "v", false, "report detailed statistics")
+}
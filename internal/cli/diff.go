@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+	"sib/internal/core/diff"
+)
+
+var (
+	diffCached    bool
+	diffContext   int
+	diffAlgorithm string
+)
+
+// DiffCmd - cobra команда для diff
+var DiffCmd = &cobra.Command{
+	Use:   "diff [--cached] [<revision>...] [-- <path>...]",
+	Short: "Show changes between commits, the index and the working tree",
+	Long: `Show changes in unified format.
+
+  sib diff                    working tree against the index
+  sib diff --cached [<rev>]   index against HEAD (or <rev>)
+  sib diff <rev>              working tree against <rev>
+  sib diff <a> <b>, <a>..<b>  one commit against another`,
+	Run: func(cmd *cobra.Command, args []string) {
+		algo, err := diff.ParseAlgorithm(diffAlgorithm)
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
+
+		opts := commands.DiffOptions{
+			Cached:    diffCached,
+			Context:   diffContext,
+			Algorithm: algo,
+		}
+
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			opts.Revisions = args[:dash]
+			opts.Paths = args[dash:]
+		} else {
+			opts.Revisions = args
+		}
+
+		if err := commands.Diff(".", opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	DiffCmd.Flags().BoolVar(&diffCached, "cached", false, "compare the index against HEAD or the given revision")
+	DiffCmd.Flags().BoolVar(&diffCached, "staged", false, "synonym for --cached")
+	DiffCmd.Flags().IntVarP(&diffContext, "unified", "U", diff.DefaultContext, "number of context lines")
+	DiffCmd.Flags().StringVar(&diffAlgorithm, "diff-algorithm", "myers", "diff algorithm: myers, patience or histogram")
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	fsckUnreachable bool
+	fsckNoDangling  bool
+)
+
+// FsckCmd - cobra команда для fsck
+var FsckCmd = &cobra.Command{
+	Use:   "fsck [--unreachable] [--no-dangling]",
+	Short: "Verify the connectivity and validity of objects",
+	Long: `Check every loose and packed object: its hash, header and content, that
+trees, commits and tags parse, and that every object they reference exists
+with the expected type. Refs and HEAD must point at existing commits.
+
+Each problem is printed on its own line as tab-separated fields:
+
+    <kind> <type> <id> <detail>
+
+where kind is one of corrupt, missing, wrongtype, badref, badpack, dangling
+or unreachable, and empty fields are "-". Dangling objects (unreachable
+and not referenced by any other object) are reported but are not errors.
+
+Exits with status 1 if any error was found.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.FsckOptions{Unreachable: fsckUnreachable, NoDangling: fsckNoDangling}
+		report, err := commands.Fsck(".", opts)
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+		if !report.OK() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	FsckCmd.Flags().BoolVar(&fsckUnreachable, "unreachable", false, "report all unreachable objects, not only dangling ones")
+	FsckCmd.Flags().BoolVar(&fsckNoDangling, "no-dangling", false, "do not report dangling objects")
+}
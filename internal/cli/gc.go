@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	gcPrune  string
+	gcDryRun bool
+
+	pruneExpire string
+	pruneDryRun bool
+)
+
+// GCCmd - cobra команда для gc
+var GCCmd = &cobra.Command{
+	Use:   "gc [--prune=<date>] [--dry-run]",
+	Short: "Pack reachable objects and remove unreachable ones",
+	Long: `Clean up the object store. Objects reachable from refs, HEAD, the index
+and in-progress merge, cherry-pick, revert or rebase state are packed into
+a pack file, and the generation numbers of reachable commits are written to
+objects/info/commit-graph to speed up ancestry queries such as merge-base.
+Unreachable loose objects and temporary files left by
+interrupted writes are removed once they are older than --prune (default:
+gc.pruneExpire, or 2.weeks.ago). The date may be "now", "never",
+"<n>.<unit>.ago" or YYYY-MM-DD.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.GCOptions{Prune: gcPrune, DryRun: gcDryRun}
+		if _, err := commands.GC(".", opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+// PruneCmd - cobra команда для prune
+var PruneCmd = &cobra.Command{
+	Use:   "prune [-n] [--expire <date>]",
+	Short: "Remove unreachable loose objects",
+	Long: `Remove loose objects that are not reachable from refs, HEAD, the index
+or in-progress operation state and are older than --expire (default:
+gc.pruneExpire, or 2.weeks.ago). Nothing is packed; see sib gc.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.PruneOptions{Expire: pruneExpire, DryRun: pruneDryRun}
+		if _, err := commands.Prune(".", opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	GCCmd.Flags().StringVar(&gcPrune, "prune", "", "prune unreachable objects older than date")
+	GCCmd.Flags().BoolVarP(&gcDryRun, "dry-run", "n", false, "only report what would be done")
+	PruneCmd.Flags().StringVar(&pruneExpire, "expire", "", "prune unreachable objects older than date")
+	PruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "only report what would be removed")
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	logMaxCount int
+	logOneline  bool
+	logAuthor   string
+	logGrep     string
+	logSince    string
+	logUntil    string
+	logTopo     bool
+)
+
+// LogCmd - cobra команда для log
+var LogCmd = &cobra.Command{
+	Use:   "log [<revision>...] [-- <path>...]",
+	Short: "Show commit logs",
+	Long: `Show the commit history reachable from the given revisions (HEAD by default).
+Paths after '--' limit the output to commits that change them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.LogOptions{
+			MaxCount: logMaxCount,
+			Oneline:  logOneline,
+			Author:   logAuthor,
+			Grep:     logGrep,
+			Topo:     logTopo,
+		}
+
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			opts.Revisions = args[:dash]
+			opts.Paths = args[dash:]
+		} else {
+			opts.Revisions = args
+		}
+
+		now := time.Now()
+		var err error
+		if logSince != "" {
+			if opts.Since, err = commands.ParseDate(logSince, now); err != nil {
+				fmt.Printf("error: %v\n", err)
+				return
+			}
+		}
+		if logUntil != "" {
+			if opts.Until, err = commands.ParseDate(logUntil, now); err != nil {
+				fmt.Printf("error: %v\n", err)
+				return
+			}
+		}
+
+		if _, err := commands.Log(".", opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	LogCmd.Flags().IntVarP(&logMaxCount, "max-count", "n", 0, "limit the number of commits to output")
+	LogCmd.Flags().BoolVar(&logOneline, "oneline", false, "show each commit on a single line")
+	LogCmd.Flags().StringVar(&logAuthor, "author", "", "show only commits whose author matches the pattern")
+	LogCmd.Flags().StringVar(&logGrep, "grep", "", "show only commits whose message matches the pattern")
+	LogCmd.Flags().StringVar(&logSince, "since", "", "show commits more recent than a date")
+	LogCmd.Flags().StringVar(&logUntil, "until", "", "show commits older than a date")
+	LogCmd.Flags().BoolVar(&logTopo, "topo-order", false, "show no parents before all of their children")
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	mergeMessage  string
+	mergeNoFF     bool
+	mergeFFOnly   bool
+	mergeDiff3    bool
+	mergeAbort    bool
+	mergeContinue bool
+)
+
+// MergeCmd - cobra команда для merge
+var MergeCmd = &cobra.Command{
+	Use:   "merge [--no-ff | --ff-only] [-m <msg>] <commit> | --abort | --continue",
+	Short: "Join two development histories together",
+	Long: `Merge the named commit into the current branch.
+If the current branch is an ancestor of the commit, the branch is fast-forwarded.
+Otherwise a three-way merge is performed; conflicting paths are left with
+conflict markers in the working tree and recorded as unmerged in the index.
+Resolve them, "sib add" the files and run "sib merge --continue".`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch {
+		case mergeAbort:
+			err = commands.MergeAbort(".")
+		case mergeContinue:
+			_, err = commands.MergeContinue(".")
+		case len(args) == 0:
+			err = fmt.Errorf("specify a commit to merge")
+		default:
+			_, err = commands.Merge(".", args[0], commands.MergeOptions{
+				Message: mergeMessage,
+				NoFF:    mergeNoFF,
+				FFOnly:  mergeFFOnly,
+				Diff3:   mergeDiff3,
+			})
+		}
+		// Как в git: остановка на конфликте - код 1, прочие ошибки - 128.
+		// О конфликтах команда уже сообщила
+		switch {
+		case err == nil:
+		case errors.Is(err, commands.ErrMergeConflict):
+			os.Exit(1)
+		default:
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+	},
+}
+
+func init() {
+	MergeCmd.Flags().StringVarP(&mergeMessage, "message", "m", "", "merge commit message")
+	MergeCmd.Flags().BoolVar(&mergeNoFF, "no-ff", false, "create a merge commit even when a fast-forward is possible")
+	MergeCmd.Flags().BoolVar(&mergeFFOnly, "ff-only", false, "refuse to merge unless a fast-forward is possible")
+	MergeCmd.Flags().BoolVar(&mergeDiff3, "diff3", false, "include the base version in conflict markers")
+	MergeCmd.Flags().BoolVar(&mergeAbort, "abort", false, "abort the current merge and restore the pre-merge state")
+	MergeCmd.Flags().BoolVar(&mergeContinue, "continue", false, "conclude the merge after resolving conflicts")
+}
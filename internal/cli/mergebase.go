@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	mergeBaseAll        bool
+	mergeBaseOctopus    bool
+	mergeBaseIsAncestor bool
+)
+
+// MergeBaseCmd - cobra команда для merge-base
+var MergeBaseCmd = &cobra.Command{
+	Use:   "merge-base [--all] <commit> <commit>... | --octopus <commit>... | --is-ancestor <commit> <commit>",
+	Short: "Find as good common ancestors as possible for a merge",
+	Long: `Print the best common ancestor of the first commit and any of the others.
+With --octopus, print the best common ancestor of all commits at once.
+With --is-ancestor, exit with status 0 if the first commit is an ancestor
+of the second and with status 1 otherwise.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if mergeBaseIsAncestor {
+			if len(args) != 2 {
+				fmt.Println("error: --is-ancestor takes exactly two commits")
+				os.Exit(128)
+			}
+			ok, err := commands.IsAncestor(".", args[0], args[1])
+			if err != nil {
+				fmt.Printf("error: %v\n", err)
+				os.Exit(128)
+			}
+			if !ok {
+				os.Exit(1)
+			}
+			return
+		}
+
+		bases, err := commands.MergeBase(".", args, commands.MergeBaseOptions{
+			All:     mergeBaseAll,
+			Octopus: mergeBaseOctopus,
+		})
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+		// Как в git: нет общего предка - код 1 без вывода
+		if len(bases) == 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	MergeBaseCmd.Flags().BoolVarP(&mergeBaseAll, "all", "a", false, "output all merge bases")
+	MergeBaseCmd.Flags().BoolVar(&mergeBaseOctopus, "octopus", false, "compute the best common ancestor of all commits")
+	MergeBaseCmd.Flags().BoolVar(&mergeBaseIsAncestor, "is-ancestor", false, "check if the first commit is an ancestor of the second")
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var mvForce bool
+
+// MvCmd - cobra команда для mv
+var MvCmd = &cobra.Command{
+	Use:   "mv [-f] <source>... <destination>",
+	Short: "Move or rename a file or a directory",
+	Long: `Rename a tracked file or directory, or move several of them into an
+existing directory. The index is updated together with the working tree.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		last := len(args) - 1
+		if err := commands.Mv(".", args[:last], args[last], commands.MvOptions{Force: mvForce}); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	MvCmd.Flags().BoolVarP(&mvForce, "force", "f", false, "overwrite an existing destination file")
+}
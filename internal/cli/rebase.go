@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	rebaseOnto        string
+	rebaseInteractive bool
+	rebaseContinue    bool
+	rebaseSkip        bool
+	rebaseAbort       bool
+)
+
+// RebaseCmd - cobra команда для rebase
+var RebaseCmd = &cobra.Command{
+	Use:   "rebase [-i] [--onto <newbase>] <upstream> | --continue | --skip | --abort",
+	Short: "Reapply commits on top of another base tip",
+	Long: `Replay the commits of the current branch that are not in <upstream>
+on top of <upstream> (or <newbase> with --onto), then move the branch there.
+
+With --interactive, the list of steps is written to a file and opened with
+$SIB_SEQUENCE_EDITOR; it supports pick, reword, edit, squash, fixup, drop and exec.
+Messages for reword and squash are edited with $SIB_EDITOR when it is set.
+Progress is kept in .sib/rebase-merge, so --continue, --skip and --abort work
+after a conflict or an interrupted run.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch {
+		case rebaseContinue:
+			err = commands.RebaseContinue(".")
+		case rebaseSkip:
+			err = commands.RebaseSkip(".")
+		case rebaseAbort:
+			err = commands.RebaseAbort(".")
+		case len(args) == 0:
+			err = fmt.Errorf("specify an upstream to rebase onto")
+		default:
+			err = commands.Rebase(".", args[0], commands.RebaseOptions{
+				Onto:        rebaseOnto,
+				Interactive: rebaseInteractive,
+			})
+		}
+		// Как в git: остановка на конфликте или упавшем exec - код 1, прочие ошибки - 128.
+		// О конфликте команда уже сообщила
+		switch {
+		case err == nil:
+		case errors.Is(err, commands.ErrRebaseConflict):
+			os.Exit(1)
+		case errors.Is(err, commands.ErrRebaseExec):
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		default:
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+	},
+}
+
+func init() {
+	RebaseCmd.Flags().StringVar(&rebaseOnto, "onto", "", "rebase onto the given commit instead of <upstream>")
+	RebaseCmd.Flags().BoolVarP(&rebaseInteractive, "interactive", "i", false, "edit the list of commits to rebase")
+	RebaseCmd.Flags().BoolVar(&rebaseContinue, "continue", false, "continue after resolving a conflict or editing a commit")
+	RebaseCmd.Flags().BoolVar(&rebaseSkip, "skip", false, "skip the current commit and continue")
+	RebaseCmd.Flags().BoolVar(&rebaseAbort, "abort", false, "abort and restore the original branch")
+}
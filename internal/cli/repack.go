@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var repackDelete bool
+
+// RepackCmd - cobra команда для repack
+var RepackCmd = &cobra.Command{
+	Use:   "repack [-d]",
+	Short: "Pack loose objects into a pack file",
+	Long: `Pack all objects stored as separate files under .sib/objects into a
+single pack file with an index. Similar objects are stored as deltas
+against each other. With -d the loose copies of packed objects are removed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.RepackOptions{Delete: repackDelete}
+		if _, err := commands.Repack(".", opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	RepackCmd.Flags().BoolVarP(&repackDelete, "delete", "d", false, "remove loose objects that were packed")
+}
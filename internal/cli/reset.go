@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	resetSoft  bool
+	resetMixed bool
+	resetHard  bool
+)
+
+// ResetCmd - cobra команда для reset
+var ResetCmd = &cobra.Command{
+	Use:   "reset [--soft | --mixed | --hard] [<commit>]",
+	Short: "Reset current HEAD to the specified state",
+	Long: `Move the current branch to <commit> (HEAD by default).
+
+  --soft    only move the branch; the index and working tree are kept
+  --mixed   also reset the index to the commit's tree (default)
+  --hard    also overwrite the working tree; local changes are lost`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var rev string
+		if len(args) == 1 {
+			rev = args[0]
+		}
+
+		opts := commands.ResetOptions{Mode: commands.ResetMixed}
+		switch {
+		case resetSoft:
+			opts.Mode = commands.ResetSoft
+		case resetHard:
+			opts.Mode = commands.ResetHard
+		}
+		if err := commands.Reset(".", rev, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	ResetCmd.Flags().BoolVar(&resetSoft, "soft", false, "move the branch only")
+	ResetCmd.Flags().BoolVar(&resetMixed, "mixed", false, "reset the branch and the index (default)")
+	ResetCmd.Flags().BoolVar(&resetHard, "hard", false, "reset the branch, the index and the working tree")
+	ResetCmd.MarkFlagsMutuallyExclusive("soft", "mixed", "hard")
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	restoreSource   string
+	restoreStaged   bool
+	restoreWorktree bool
+)
+
+// RestoreCmd - cobra команда для restore
+var RestoreCmd = &cobra.Command{
+	Use:   "restore [--source=<rev>] [--staged] [--worktree] <path>...",
+	Short: "Restore working tree files",
+	Long: `Restore paths in the working tree and/or the index.
+
+  sib restore <path>                   discard unstaged changes (from the index)
+  sib restore --staged <path>          unstage changes (from HEAD)
+  sib restore --source=<rev> <path>    take the files from a commit`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.RestoreOptions{
+			Source:   restoreSource,
+			Staged:   restoreStaged,
+			Worktree: restoreWorktree,
+		}
+		if err := commands.Restore(".", args, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	RestoreCmd.Flags().StringVarP(&restoreSource, "source", "s", "", "restore from the given commit")
+	RestoreCmd.Flags().BoolVarP(&restoreStaged, "staged", "S", false, "restore the index")
+	RestoreCmd.Flags().BoolVarP(&restoreWorktree, "worktree", "W", false, "restore the working tree (default)")
+}
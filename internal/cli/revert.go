@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	revertMainline int
+	revertNoCommit bool
+	revertContinue bool
+	revertAbort    bool
+)
+
+// RevertCmd - cobra команда для revert
+var RevertCmd = &cobra.Command{
+	Use:   "revert [-m <parent>] [-n] <commit>... | --continue | --abort",
+	Short: "Revert some existing commits",
+	Long: `Record new commits that undo the change each commit introduced relative
+to its parent. The message says "This reverts commit ...". For a merge
+commit, -m selects the parent whose side of history is kept.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch {
+		case revertContinue:
+			err = commands.RevertContinue(".")
+		case revertAbort:
+			err = commands.RevertAbort(".")
+		default:
+			err = commands.Revert(".", args, commands.PickOptions{
+				Mainline: revertMainline,
+				NoCommit: revertNoCommit,
+			})
+		}
+		// Как в git: остановка на конфликте - код 1, прочие ошибки - 128.
+		// О конфликте команда уже сообщила
+		switch {
+		case err == nil:
+		case errors.Is(err, commands.ErrPickConflict):
+			os.Exit(1)
+		default:
+			fmt.Printf("error: %v\n", err)
+			os.Exit(128)
+		}
+	},
+}
+
+func init() {
+	RevertCmd.Flags().IntVarP(&revertMainline, "mainline", "m", 0, "parent number of a merge commit to revert against")
+	RevertCmd.Flags().BoolVarP(&revertNoCommit, "no-commit", "n", false, "apply the changes without committing")
+	RevertCmd.Flags().BoolVar(&revertContinue, "continue", false, "continue after resolving conflicts")
+	RevertCmd.Flags().BoolVar(&revertAbort, "abort", false, "cancel and return to the pre-sequence state")
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	revParseVerify bool
+	revParseShort  bool
+)
+
+// RevParseCmd - cobra команда для rev-parse
+var RevParseCmd = &cobra.Command{
+	Use:   "rev-parse <revision>...",
+	Short: "Resolve revision expressions to object ids",
+	Long: `Resolve branch and tag names, HEAD, short hashes, ~N and ^N suffixes,
+@{upstream} and A..B / A...B ranges to full object ids. The upstream of a
+branch comes from branch.<name>.remote and branch.<name>.merge.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.RevParseOptions{
+			Verify: revParseVerify,
+			Short:  revParseShort,
+		}
+
+		if _, err := commands.RevParse(".", args, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	RevParseCmd.Flags().BoolVar(&revParseVerify, "verify", false, "require exactly one valid revision")
+	RevParseCmd.Flags().BoolVar(&revParseShort, "short", false, "print abbreviated object ids")
+}
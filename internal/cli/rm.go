@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	rmCached    bool
+	rmForce     bool
+	rmRecursive bool
+)
+
+// RmCmd - cobra команда для rm
+var RmCmd = &cobra.Command{
+	Use:   "rm [--cached] [-f] [-r] <pathspec>...",
+	Short: "Remove files from the working tree and from the index",
+	Long: `Remove tracked files from the index and the working tree.
+With --cached the files are only removed from the index and kept on disk.
+Files whose changes would be lost are refused unless -f is given.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := commands.RmOptions{
+			Cached:    rmCached,
+			Force:     rmForce,
+			Recursive: rmRecursive,
+		}
+		if err := commands.Rm(".", args, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	RmCmd.Flags().BoolVar(&rmCached, "cached", false, "only remove from the index")
+	RmCmd.Flags().BoolVarP(&rmForce, "force", "f", false, "override the up-to-date check")
+	RmCmd.Flags().BoolVarP(&rmRecursive, "recursive", "r", false, "allow recursive removal")
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	statusShort     bool
+	statusPorcelain bool
+)
+
+// StatusCmd - cobra команда для status
+var StatusCmd = &cobra.Command{
+	Use:   "status [<pathspec>...]",
+	Short: "Show the working tree status",
+	Long: `Show changes staged in the index relative to HEAD, changes in the
+working tree that are not staged yet, and files that are not tracked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := commands.StatusLong
+		if statusShort {
+			format = commands.StatusShort
+		}
+		if statusPorcelain {
+			format = commands.StatusPorcelain
+		}
+
+		if _, err := commands.Status(".", format, args); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	StatusCmd.Flags().BoolVarP(&statusShort, "short", "s", false, "give the output in the short format")
+	StatusCmd.Flags().BoolVar(&statusPorcelain, "porcelain", false, "give the output in a stable, easy-to-parse format for scripts")
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	switchCreate string
+	switchDetach bool
+	switchForce  bool
+)
+
+// SwitchCmd - cobra команда для switch
+var SwitchCmd = &cobra.Command{
+	Use:   "switch [-c <new-branch>] [--detach] <branch>",
+	Short: "Switch branches",
+	Long: `Switch to a branch, updating the index and the working tree to match it.
+With --detach, switch to a commit and leave HEAD detached.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var target string
+		if len(args) > 0 {
+			target = args[0]
+		}
+
+		opts := commands.SwitchOptions{
+			Create: switchCreate,
+			Detach: switchDetach,
+			Force:  switchForce,
+		}
+		if err := commands.Switch(".", target, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	SwitchCmd.Flags().StringVarP(&switchCreate, "create", "c", "", "create a new branch and switch to it")
+	SwitchCmd.Flags().BoolVarP(&switchDetach, "detach", "d", false, "switch to a commit with a detached HEAD")
+	SwitchCmd.Flags().BoolVarP(&switchForce, "force", "f", false, "discard local changes")
+}
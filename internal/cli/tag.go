@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sib/internal/commands"
+)
+
+var (
+	tagAnnotate bool
+	tagMessage  string
+	tagForce    bool
+	tagDelete   bool
+	tagList     bool
+	tagVerify   bool
+	tagLines    int
+)
+
+// TagCmd - cobra команда для tag
+var TagCmd = &cobra.Command{
+	Use:   "tag [<name> [<object>]]",
+	Short: "Create, list, delete or verify tags",
+	Long: `With no arguments, list tags.
+
+  sib tag <name> [<object>]           create a lightweight tag (at HEAD by default)
+  sib tag -a -m <msg> <name> [<obj>]  create an annotated tag
+  sib tag -l [<pattern>]              list tags matching a glob pattern
+  sib tag -d <name>...                delete tags
+  sib tag -v <name>...                show and verify annotated tags`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTag(args); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	},
+}
+
+func runTag(args []string) error {
+	switch {
+	case tagDelete:
+		if len(args) == 0 {
+			return fmt.Errorf("tag name required")
+		}
+		for _, name := range args {
+			if err := commands.DeleteTag(".", name); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case tagVerify:
+		if len(args) == 0 {
+			return fmt.Errorf("tag name required")
+		}
+		for _, name := range args {
+			if _, err := commands.VerifyTag(".", name); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case tagList || len(args) == 0:
+		var pattern string
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+		return commands.PrintTags(".", pattern, tagLines)
+
+	default:
+		if len(args) > 2 {
+			return fmt.Errorf("too many arguments")
+		}
+		var target string
+		if len(args) == 2 {
+			target = args[1]
+		}
+		_, err := commands.CreateTag(".", args[0], target, commands.TagOptions{
+			Annotate: tagAnnotate,
+			Message:  tagMessage,
+			Force:    tagForce,
+		})
+		return err
+	}
+}
+
+func init() {
+	TagCmd.Flags().BoolVarP(&tagAnnotate, "annotate", "a", false, "create an annotated tag")
+	TagCmd.Flags().StringVarP(&tagMessage, "message", "m", "", "tag message (implies -a)")
+	TagCmd.Flags().BoolVarP(&tagForce, "force", "f", false, "replace an existing tag")
+	TagCmd.Flags().BoolVarP(&tagDelete, "delete", "d", false, "delete tags")
+	TagCmd.Flags().BoolVarP(&tagList, "list", "l", false, "list tags, optionally matching a pattern")
+	TagCmd.Flags().BoolVarP(&tagVerify, "verify", "v", false, "show and verify annotated tags")
+	TagCmd.Flags().IntVarP(&tagLines, "lines", "n", 0, "print <n> lines of each tag message")
+}
@@ -2,87 +2,91 @@ package commands
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"sort"
 
 	"sib/internal/core/index"
 	"sib/internal/core/objects"
-	"sib/internal/core/storage"
+	"sib/internal/core/pathspec"
+	"sib/internal/core/repository"
 )
 
-func Add(repoPath string) error {
-	if repoPath == "" {
-		repoPath = "."
-	}
+// AddOptions - параметры add
+type AddOptions struct {
+	Update bool // -u: обновить только отслеживаемые файлы, включая удаленные
+	All    bool // -A: добавить новые и измененные файлы и удалить из индекса удаленные
+}
 
-	// Проверяем, что это sib репозиторий
-	sibDir := filepath.Join(repoPath, ".sib")
-	if _, err := os.Stat(sibDir); os.IsNotExist(err) {
-		return fmt.Errorf("not a sib repository")
+// Add добавляет в индекс файлы рабочего каталога, выбранные pathspec
+// (пустой список - весь репозиторий). С Update или All из индекса также
+// удаляются файлы, которых больше нет в рабочем каталоге
+func Add(repoPath string, paths []string, opts AddOptions) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
 	}
 
-	// Загружаем индекс
-	idx, err := index.NewIndex(repoPath)
+	spec, err := pathspec.ParseRelative(repo.Prefix, paths)
 	if err != nil {
-		return fmt.Errorf("failed to load index: %w", err)
+		return err
 	}
 
-	// Создаем хранилище объектов
-	store, err := storage.NewObjectStore(repoPath)
+	idx, err := repo.LockIndex()
 	if err != nil {
-		return fmt.Errorf("failed to create object store: %w", err)
+		return err
 	}
+	defer idx.Unlock()
 
-	// Сканируем все файлы
-	addedCount := 0
-	err = filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	// Рабочий каталог без игнорируемых файлов (отслеживаемые остаются)
+	worktree, err := idx.WorkingFiles(repo.WorkTree)
+	if err != nil {
+		return err
+	}
 
-		// Пропускаем:
-		// - Директории
-		// - Файл .sib и всё внутри
-		// - Скрытые файлы (начинающиеся с .)
-		if info.IsDir() {
-			if info.Name() == ".sib" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	// Отслеживаемые пути: записи индекса и неразрешенные конфликты
+	tracked := make(map[string]bool)
+	for path := range idx.Entries {
+		tracked[path] = true
+	}
+	for _, path := range idx.UnmergedPaths() {
+		tracked[path] = true
+	}
 
-		// Пропускаем файлы внутри .sib
-		if isInsideSibDir(path, repoPath) {
-			return nil
-		}
+	known := make([]string, 0, len(worktree)+len(tracked))
+	for path := range worktree {
+		known = append(known, path)
+	}
+	for path := range tracked {
+		known = append(known, path)
+	}
+	if unmatched := spec.Unmatched(known); len(unmatched) > 0 {
+		return fmt.Errorf("pathspec '%s' did not match any files", unmatched[0])
+	}
 
-		// Пропускаем скрытые файлы (опционально)
-		if filepath.Base(path)[0] == '.' && filepath.Base(path) != "." {
-			return nil
+	selected := make([]string, 0, len(worktree))
+	for path := range worktree {
+		if spec.Match(path) && (!opts.Update || tracked[path]) {
+			selected = append(selected, path)
 		}
+	}
+	sort.Strings(selected)
 
-		// Получаем относительный путь
-		relPath, err := filepath.Rel(repoPath, path)
-		if err != nil {
-			return nil // пропускаем ошибки
-		}
+	addedCount := 0
+	for _, relPath := range selected {
+		info := worktree[relPath]
 
-		// Читаем файл
-		content, err := os.ReadFile(path)
+		// Открываем файл: содержимое читается потоком, большие файлы в память не загружаются
+		file, size, err := openWorktreeFile(repo.WorkPath(relPath))
 		if err != nil {
 			fmt.Printf("warning: could not read %s: %v\n", relPath, err)
-			return nil
+			continue
 		}
 
-		// Создаем blob
-		blob := objects.NewBlob(content)
-
 		// Сохраняем в хранилище
-		hash, err := store.WriteObject(blob)
+		hash, err := repo.Objects.WriteStream(objects.NewBlobStream(file, size))
+		file.Close()
 		if err != nil {
 			fmt.Printf("warning: could not save %s: %v\n", relPath, err)
-			return nil
+			continue
 		}
 
 		// Определяем режим файла
@@ -91,16 +95,28 @@ func Add(repoPath string) error {
 		// Добавляем в индекс
 		if err := idx.Add(relPath, hash.String(), info.Size(), mode, info.ModTime()); err != nil {
 			fmt.Printf("warning: could not add %s to index: %v\n", relPath, err)
-			return nil
+			continue
 		}
 
 		addedCount++
 		fmt.Printf("added %s\n", relPath)
-		return nil
-	})
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to scan directory: %w", err)
+	// Удаленные из рабочего каталога файлы покидают индекс только с -u и -A
+	if opts.Update || opts.All {
+		removed := make([]string, 0)
+		for path := range tracked {
+			if _, ok := worktree[path]; !ok && spec.Match(path) {
+				removed = append(removed, path)
+			}
+		}
+		sort.Strings(removed)
+		for _, path := range removed {
+			if err := idx.Remove(path); err != nil {
+				return err
+			}
+			fmt.Printf("removed %s\n", path)
+		}
 	}
 
 	// Сохраняем индекс
@@ -111,12 +127,3 @@ func Add(repoPath string) error {
 	fmt.Printf("Added %d files to index\n", addedCount)
 	return nil
 }
-
-func isInsideSibDir(path, repoPath string) bool {
-	sibDir := filepath.Join(repoPath, ".sib")
-	rel, err := filepath.Rel(sibDir, path)
-	if err != nil {
-		return false
-	}
-	return !strings.HasPrefix(rel, "..")
-}
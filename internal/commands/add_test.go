@@ -4,9 +4,26 @@ package commands
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"sib/internal/core/index"
 )
 
+// indexPaths возвращает пути индекса через пробел в порядке сортировки
+func indexPaths(t *testing.T, repoPath string) string {
+	t.Helper()
+	idx, err := index.NewIndex(repoPath)
+	if err != nil {
+		t.Fatalf("NewIndex failed: %v", err)
+	}
+	var paths []string
+	for _, entry := range idx.GetAllEntries() {
+		paths = append(paths, entry.Path)
+	}
+	return strings.Join(paths, " ")
+}
+
 func TestAdd(t *testing.T) {
 	t.Run("Add all files in repo", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -31,7 +48,7 @@ func TestAdd(t *testing.T) {
 		}
 
 		// 3. Add
-		err = Add(tmpDir)
+		err = Add(tmpDir, nil, AddOptions{})
 		if err != nil {
 			t.Fatalf("Add failed: %v", err)
 		}
@@ -51,9 +68,99 @@ func TestAdd(t *testing.T) {
 	t.Run("Add fails outside repo", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
-		err := Add(tmpDir)
+		err := Add(tmpDir, nil, AddOptions{})
 		if err == nil {
 			t.Error("Expected error when adding outside repo")
 		}
 	})
+
+	t.Run("Pathspec selects files", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		writeFiles(t, tmpDir, map[string]string{
+			"main.go": "package main\n", "src/util.go": "// util\n", "src/data.txt": "data\n", "README.md": "# Test\n",
+		})
+
+		if err := Add(tmpDir, []string{"*.go", ":(exclude)src/util.go"}, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "main.go" {
+			t.Errorf("Index = %q", got)
+		}
+
+		if err := Add(tmpDir, []string{"src"}, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "main.go src/data.txt src/util.go" {
+			t.Errorf("Index = %q", got)
+		}
+
+		if err := Add(tmpDir, []string{"nope"}, AddOptions{}); err == nil {
+			t.Error("Expected error for a pathspec that matches nothing")
+		}
+	})
+
+	t.Run("Update and all stage deletions", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "b.txt": "b\n", "c.txt": "c\n"})
+
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "changed a\n", "new.txt": "new\n"})
+		os.Remove(filepath.Join(tmpDir, "b.txt"))
+
+		if err := Add(tmpDir, []string{"."}, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "a.txt b.txt c.txt new.txt" {
+			t.Errorf("Plain add should keep deleted files, index = %q", got)
+		}
+
+		os.Remove(filepath.Join(tmpDir, "c.txt"))
+		writeFiles(t, tmpDir, map[string]string{"other.txt": "other\n"})
+		if err := Add(tmpDir, []string{"c.txt"}, AddOptions{Update: true}); err != nil {
+			t.Fatalf("Add -u failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "a.txt b.txt new.txt" {
+			t.Errorf("Add -u should stage only the selected deletion, index = %q", got)
+		}
+
+		if err := Add(tmpDir, nil, AddOptions{Update: true}); err != nil {
+			t.Fatalf("Add -u failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "a.txt new.txt" {
+			t.Errorf("Add -u should not add untracked files, index = %q", got)
+		}
+
+		if err := Add(tmpDir, nil, AddOptions{All: true}); err != nil {
+			t.Fatalf("Add -A failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "a.txt new.txt other.txt" {
+			t.Errorf("Index = %q", got)
+		}
+	})
+	t.Run("Add from subdirectory", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := Init(tmpDir); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		writeFiles(t, tmpDir, map[string]string{"top.txt": "top\n", "src/a.go": "a\n", "src/lib/b.go": "b\n"})
+
+		src := filepath.Join(tmpDir, "src")
+		if err := Add(src, []string{"a.go"}, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "src/a.go" {
+			t.Errorf("Path should be relative to the subdirectory, index = %q", got)
+		}
+
+		if err := Add(src, []string{"."}, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "src/a.go src/lib/b.go" {
+			t.Errorf("'.' should select the subdirectory only, index = %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(src, ".sib")); !os.IsNotExist(err) {
+			t.Error("No .sib should appear in the subdirectory")
+		}
+	})
 }
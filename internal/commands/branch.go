@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/revwalk"
+)
+
+// BranchInfo - ветка в выводе sib branch
+type BranchInfo struct {
+	Name    string       // Короткое имя (без refs/heads/)
+	Hash    objects.Hash // Коммит, на который указывает ветка
+	Current bool         // Ветка, на которую указывает HEAD
+}
+
+// BranchListOptions - фильтры списка веток
+// Пустая строка означает "фильтр не задан"
+type BranchListOptions struct {
+	Contains string // Только ветки, содержащие этот коммит
+	Merged   string // Только ветки, слитые в этот коммит
+	NoMerged string // Только ветки, не слитые в этот коммит
+}
+
+// ListBranches возвращает ветки, отсортированные по имени
+func ListBranches(repoPath string, opts BranchListOptions) ([]BranchInfo, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _, err := repo.Refs.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	parser := newRevParser(repo)
+	resolve := func(rev string) (objects.Hash, error) {
+		if rev == "" {
+			return "", nil
+		}
+		return parser.ResolveCommit(rev)
+	}
+	contains, err := resolve(opts.Contains)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := resolve(opts.Merged)
+	if err != nil {
+		return nil, err
+	}
+	noMerged, err := resolve(opts.NoMerged)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := repo.Refs.List(refs.HeadsPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Общий граф кеширует коммиты и поколения между проверками разных веток
+	graph := revwalk.NewGraph(repo.Objects)
+	var branches []BranchInfo
+	for _, ref := range list {
+		if !contains.IsEmpty() {
+			ok, err := graph.IsAncestor(contains, ref.Target)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		if !merged.IsEmpty() {
+			ok, err := graph.IsAncestor(ref.Target, merged)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		if !noMerged.IsEmpty() {
+			ok, err := graph.IsAncestor(ref.Target, noMerged)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				continue
+			}
+		}
+
+		branches = append(branches, BranchInfo{
+			Name:    strings.TrimPrefix(ref.Name, refs.HeadsPrefix),
+			Hash:    ref.Target,
+			Current: ref.Name == current,
+		})
+	}
+
+	return branches, nil
+}
+
+// PrintBranches печатает список веток; текущая отмечается '*'.
+// При отсоединенном HEAD первой строкой выводится его положение
+func PrintBranches(repoPath string, opts BranchListOptions) error {
+	branches, err := ListBranches(repoPath, opts)
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	current, head, err := repo.Refs.Head()
+	if err != nil {
+		return err
+	}
+	if current == "" && opts == (BranchListOptions{}) {
+		fmt.Printf("* (HEAD detached at %s)\n", shortHash(head))
+	}
+
+	for _, b := range branches {
+		marker := " "
+		if b.Current {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, b.Name)
+	}
+
+	return nil
+}
+
+// CreateBranch создает ветку в точке startPoint (HEAD, если пусто).
+// Существующая ветка перезаписывается только с force и только если она не текущая
+func CreateBranch(repoPath, name, startPoint string, force bool) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	if err := refs.ValidateBranchName(name); err != nil {
+		return err
+	}
+
+	if startPoint == "" {
+		startPoint = refs.HEAD
+	}
+	parser := newRevParser(repo)
+	hash, err := parser.ResolveCommit(startPoint)
+	if err != nil {
+		return fmt.Errorf("not a valid object name: '%s'", startPoint)
+	}
+
+	return writeBranch(repo, refs.HeadsPrefix+name, hash, force)
+}
+
+// writeBranch создает ветку или, с force, перемещает существующую (кроме текущей)
+func writeBranch(repo *repository.Repository, refName string, hash objects.Hash, force bool) error {
+	name := strings.TrimPrefix(refName, refs.HeadsPrefix)
+	if !repo.Refs.Exists(refName) {
+		return repo.Refs.CompareAndSwap(refName, "", hash)
+	}
+	if !force {
+		return fmt.Errorf("a branch named '%s' already exists", name)
+	}
+
+	current, _, err := repo.Refs.Head()
+	if err != nil {
+		return err
+	}
+	if current == refName {
+		return fmt.Errorf("cannot force update the current branch '%s'", name)
+	}
+	return repo.Refs.Set(refName, hash)
+}
+
+// DeleteBranch удаляет ветку. Без force ветка должна быть слита в HEAD
+func DeleteBranch(repoPath, name string, force bool) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+
+	refName := refs.HeadsPrefix + name
+	hash, err := repo.Refs.Resolve(refName)
+	if err != nil {
+		return fmt.Errorf("branch '%s' not found", name)
+	}
+
+	current, head, err := repo.Refs.Head()
+	if err != nil {
+		return err
+	}
+	if current == refName {
+		return fmt.Errorf("cannot delete branch '%s' checked out at '%s'", name, repo.WorkTree)
+	}
+
+	if !force {
+		merged := false
+		if !head.IsEmpty() {
+			if merged, err = revwalk.IsAncestor(repo.Objects, hash, head); err != nil {
+				return err
+			}
+		}
+		if !merged {
+			return fmt.Errorf("the branch '%s' is not fully merged.\nIf you are sure you want to delete it, run 'sib branch -D %s'", name, name)
+		}
+	}
+
+	if err := repo.Refs.Delete(refName, hash); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted branch %s (was %s).\n", name, shortHash(hash))
+	return nil
+}
+
+// RenameBranch переименовывает ветку; HEAD, указывающий на неё, следует за ней.
+// Пустое oldName означает текущую ветку
+func RenameBranch(repoPath, oldName, newName string, force bool) error {
+	return moveBranch(repoPath, oldName, newName, force, true)
+}
+
+// CopyBranch копирует ветку под новым именем.
+// Пустое oldName означает текущую ветку
+func CopyBranch(repoPath, oldName, newName string, force bool) error {
+	return moveBranch(repoPath, oldName, newName, force, false)
+}
+
+func moveBranch(repoPath, oldName, newName string, force, rename bool) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	if err := refs.ValidateBranchName(newName); err != nil {
+		return err
+	}
+
+	current, _, err := repo.Refs.Head()
+	if err != nil {
+		return err
+	}
+	oldRef := refs.HeadsPrefix + oldName
+	if oldName == "" {
+		if current == "" {
+			return fmt.Errorf("no branch is checked out (HEAD is detached)")
+		}
+		oldRef = current
+	}
+	newRef := refs.HeadsPrefix + newName
+	if oldRef == newRef {
+		return nil
+	}
+
+	hash, err := repo.Refs.Resolve(oldRef)
+	if err != nil {
+		return fmt.Errorf("branch '%s' not found", strings.TrimPrefix(oldRef, refs.HeadsPrefix))
+	}
+
+	if err := writeBranch(repo, newRef, hash, force); err != nil {
+		return err
+	}
+	if !rename {
+		return nil
+	}
+
+	if current == oldRef {
+		if err := repo.Refs.SetSymbolic(refs.HEAD, newRef); err != nil {
+			return err
+		}
+	}
+	return repo.Refs.Delete(oldRef, hash)
+}
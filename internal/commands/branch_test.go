@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"sib/internal/core/refs"
+)
+
+// branchNames возвращает имена веток через пробел; текущая помечена '*'
+func branchNames(t *testing.T, repoPath string, opts BranchListOptions) string {
+	t.Helper()
+	branches, err := ListBranches(repoPath, opts)
+	if err != nil {
+		t.Fatalf("ListBranches failed: %v", err)
+	}
+	var names []string
+	for _, b := range branches {
+		if b.Current {
+			names = append(names, "*"+b.Name)
+		} else {
+			names = append(names, b.Name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "one", map[string]string{"a.txt": "1"})
+
+	if err := CreateBranch(tmpDir, "old", "", false); err != nil {
+		t.Fatalf("CreateBranch failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "two", map[string]string{"a.txt": "2"})
+	if err := CreateBranch(tmpDir, "topic", "", false); err != nil {
+		t.Fatalf("CreateBranch failed: %v", err)
+	}
+	if err := Switch(tmpDir, "topic", SwitchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, tmpDir, "three", map[string]string{"a.txt": "3"})
+
+	t.Run("List", func(t *testing.T) {
+		if got := branchNames(t, tmpDir, BranchListOptions{}); got != "master old *topic" {
+			t.Errorf("Branches = %q", got)
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		if got := branchNames(t, tmpDir, BranchListOptions{Merged: "master"}); got != "master old" {
+			t.Errorf("--merged master = %q", got)
+		}
+		if got := branchNames(t, tmpDir, BranchListOptions{NoMerged: "master"}); got != "*topic" {
+			t.Errorf("--no-merged master = %q", got)
+		}
+		if got := branchNames(t, tmpDir, BranchListOptions{Contains: "master"}); got != "master *topic" {
+			t.Errorf("--contains master = %q", got)
+		}
+	})
+
+	t.Run("Create validation", func(t *testing.T) {
+		if err := CreateBranch(tmpDir, "old", "", false); err == nil {
+			t.Error("Expected error for existing branch")
+		}
+		if err := CreateBranch(tmpDir, "bad..name", "", false); err == nil {
+			t.Error("Expected error for invalid name")
+		}
+		if err := CreateBranch(tmpDir, "old", "HEAD", true); err != nil {
+			t.Errorf("Force create failed: %v", err)
+		}
+		if err := CreateBranch(tmpDir, "topic", "master", true); err == nil {
+			t.Error("Expected error when force-updating the current branch")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := DeleteBranch(tmpDir, "topic", true); err == nil {
+			t.Error("Expected error deleting the current branch")
+		}
+		if err := Switch(tmpDir, "master", SwitchOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := DeleteBranch(tmpDir, "old", false); err == nil {
+			t.Error("Expected -d to refuse an unmerged branch")
+		}
+		if err := DeleteBranch(tmpDir, "old", true); err != nil {
+			t.Errorf("-D failed: %v", err)
+		}
+		if got := branchNames(t, tmpDir, BranchListOptions{}); got != "*master topic" {
+			t.Errorf("Branches after delete = %q", got)
+		}
+	})
+
+	t.Run("Rename current and copy", func(t *testing.T) {
+		if err := RenameBranch(tmpDir, "", "main", false); err != nil {
+			t.Fatalf("RenameBranch failed: %v", err)
+		}
+		if err := CopyBranch(tmpDir, "topic", "topic-copy", false); err != nil {
+			t.Fatalf("CopyBranch failed: %v", err)
+		}
+		if got := branchNames(t, tmpDir, BranchListOptions{}); got != "*main topic topic-copy" {
+			t.Errorf("Branches after rename = %q", got)
+		}
+
+		refStore, _ := refs.NewRefStore(tmpDir)
+		if branch, _, _ := refStore.Head(); branch != "refs/heads/main" {
+			t.Errorf("HEAD did not follow rename: %q", branch)
+		}
+		if err := RenameBranch(tmpDir, "topic", "main", false); err == nil {
+			t.Error("Expected error renaming onto an existing branch")
+		}
+	})
+}
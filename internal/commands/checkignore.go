@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sib/internal/core/ignore"
+	"sib/internal/core/repository"
+)
+
+// CheckIgnoreOptions - параметры check-ignore
+type CheckIgnoreOptions struct {
+	Verbose     bool // -v: показать правило, решившее судьбу пути
+	NonMatching bool // -n: вместе с -v выводить и пути без совпавших правил
+}
+
+// CheckIgnore печатает, какие из путей игнорируются, и возвращает количество
+// игнорируемых. Отслеживаемые файлы не игнорируются, даже если подходят под правило.
+// С Verbose для каждого пути выводится совпавшее правило - включая правила
+// с '!', которые возвращают путь в неигнорируемые
+func CheckIgnore(repoPath string, paths []string, opts CheckIgnoreOptions) (int, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return 0, err
+	}
+	if len(paths) == 0 {
+		return 0, fmt.Errorf("no path specified")
+	}
+
+	idx, err := repo.Index()
+	if err != nil {
+		return 0, err
+	}
+	matcher, err := ignore.New(repo.WorkTree, repo.SibDir)
+	if err != nil {
+		return 0, err
+	}
+
+	ignoredCount := 0
+	for _, arg := range paths {
+		path, err := cleanRepoPath(repo.Prefix, arg)
+		if err != nil {
+			return ignoredCount, err
+		}
+		if path == "" {
+			return ignoredCount, fmt.Errorf("'%s' is outside repository", arg)
+		}
+
+		var rule *ignore.Rule
+		if _, tracked := idx.Entries[path]; !tracked {
+			isDir := strings.HasSuffix(arg, "/")
+			if info, err := os.Stat(filepath.Join(repo.WorkTree, filepath.FromSlash(path))); err == nil {
+				isDir = info.IsDir()
+			}
+			if rule, err = matcher.Match(path, isDir); err != nil {
+				return ignoredCount, err
+			}
+		}
+
+		ignored := rule != nil && !rule.Negate
+		if ignored {
+			ignoredCount++
+		}
+		switch {
+		case opts.Verbose && rule != nil:
+			fmt.Printf("%s:%d:%s\t%s\n", rule.Source, rule.Line, rule.Pattern, arg)
+		case opts.Verbose && opts.NonMatching:
+			fmt.Printf("::\t%s\n", arg)
+		case !opts.Verbose && ignored:
+			fmt.Println(arg)
+		}
+	}
+
+	return ignoredCount, nil
+}
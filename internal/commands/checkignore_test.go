@@ -0,0 +1,56 @@
+package commands
+
+import "testing"
+
+func TestIgnoreRules(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	Init(tmpDir)
+	commitFiles(t, tmpDir, "base", map[string]string{"tracked.log": "kept\n"})
+
+	writeFiles(t, tmpDir, map[string]string{
+		".sibignore":                "*.log\nnode_modules/\n.env\n!important.log\n",
+		"web/.sibignore":            "dist\n",
+		".env":                      "SECRET=1\n",
+		".env.example":              "SECRET=\n",
+		".github/workflows/ci.yml":  "on: push\n",
+		"debug.log":                 "noise\n",
+		"important.log":             "keep me\n",
+		"node_modules/pkg/index.js": "module\n",
+		"web/dist/app.js":           "built\n",
+		"web/src/app.js":            "source\n",
+		"tracked.log":               "changed\n",
+	})
+
+	t.Run("Add skips ignored files", func(t *testing.T) {
+		if err := Add(tmpDir, []string{"."}, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		want := ".env.example .github/workflows/ci.yml .sibignore important.log tracked.log web/.sibignore web/src/app.js"
+		if got := indexPaths(t, tmpDir); got != want {
+			t.Errorf("Index = %q\nwant    %q", got, want)
+		}
+
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Untracked) != 0 {
+			t.Errorf("Ignored files should not be listed as untracked: %v", status.Untracked)
+		}
+	})
+
+	t.Run("Check ignore", func(t *testing.T) {
+		ignored, err := CheckIgnore(tmpDir, []string{"debug.log", "node_modules/pkg/index.js", "web/dist", "important.log", "tracked.log", "web/src/app.js"}, CheckIgnoreOptions{Verbose: true})
+		if err != nil {
+			t.Fatalf("CheckIgnore failed: %v", err)
+		}
+		if ignored != 3 {
+			t.Errorf("Ignored = %d, want 3", ignored)
+		}
+
+		if ignored, _ := CheckIgnore(tmpDir, []string{"web/src/app.js"}, CheckIgnoreOptions{}); ignored != 0 {
+			t.Errorf("web/src/app.js should not be ignored")
+		}
+		if _, err := CheckIgnore(tmpDir, []string{"../outside"}, CheckIgnoreOptions{}); err == nil {
+			t.Error("Expected error for a path outside the repository")
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/pathspec"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/storage"
+)
+
+// SwitchOptions - параметры switch и checkout
+type SwitchOptions struct {
+	Create string // -c/-b: создать ветку с этим именем в целевой точке
+	Detach bool   // --detach: отсоединить HEAD, даже если цель - ветка
+	Force  bool   // --force: отбросить локальные изменения
+}
+
+// Switch переключает HEAD на ветку (или на коммит при Detach)
+// и приводит рабочий каталог и индекс к её дереву
+func Switch(repoPath, target string, opts SwitchOptions) error {
+	return switchTo(repoPath, target, opts, false)
+}
+
+// Checkout - как Switch, но цель, не являющаяся веткой, отсоединяет HEAD автоматически
+func Checkout(repoPath, target string, opts SwitchOptions) error {
+	return switchTo(repoPath, target, opts, true)
+}
+
+func switchTo(repoPath, target string, opts SwitchOptions, autoDetach bool) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+
+	store := repo.Objects
+	refStore := repo.Refs
+	parser := newRevParser(repo)
+
+	currentBranch, head, err := refStore.Head()
+	if err != nil {
+		return err
+	}
+
+	// Определяем целевой коммит и ветку, на которую встанет HEAD (пусто - отсоединенный)
+	var branchRef string
+	var hash objects.Hash
+	switch {
+	case opts.Create != "":
+		if err := refs.ValidateBranchName(opts.Create); err != nil {
+			return err
+		}
+		branchRef = refs.HeadsPrefix + opts.Create
+		if refStore.Exists(branchRef) {
+			return fmt.Errorf("a branch named '%s' already exists", opts.Create)
+		}
+		if target == "" {
+			hash = head
+		} else if hash, err = parser.ResolveCommit(target); err != nil {
+			return err
+		}
+
+	case target == "":
+		return fmt.Errorf("missing branch or commit argument")
+
+	case !opts.Detach && refStore.Exists(refs.HeadsPrefix+target):
+		branchRef = refs.HeadsPrefix + target
+		if hash, err = refStore.Resolve(branchRef); err != nil {
+			return err
+		}
+
+	case !opts.Detach && !autoDetach:
+		return fmt.Errorf("a branch is expected, got '%s' (use --detach to switch to a commit)", target)
+
+	default:
+		if hash, err = parser.ResolveCommit(target); err != nil {
+			return err
+		}
+	}
+
+	// Ветка без коммитов (только что созданный репозиторий): переключаем только HEAD
+	if hash.IsEmpty() {
+		if err := refStore.SetSymbolic(refs.HEAD, branchRef); err != nil {
+			return err
+		}
+		fmt.Printf("Switched to a new branch '%s'\n", refDisplayName(branchRef))
+		return nil
+	}
+
+	var fromTree objects.Hash
+	if !head.IsEmpty() {
+		commit, err := store.ReadCommit(head)
+		if err != nil {
+			return err
+		}
+		fromTree = commit.Tree()
+	}
+	commit, err := store.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+	if err := checkoutTree(repo.WorkTree, store, idx, fromTree, commit.Tree(), opts.Force); err != nil {
+		return err
+	}
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	if opts.Create != "" {
+		if err := refStore.CompareAndSwap(branchRef, "", hash); err != nil {
+			return err
+		}
+	}
+
+	if branchRef == "" {
+		if err := refStore.DetachHead(hash); err != nil {
+			return err
+		}
+		fmt.Printf("HEAD is now at %s %s\n", shortHash(hash), firstLine(commit.Message()))
+		return nil
+	}
+
+	if err := refStore.SetSymbolic(refs.HEAD, branchRef); err != nil {
+		return err
+	}
+	switch {
+	case opts.Create != "":
+		fmt.Printf("Switched to a new branch '%s'\n", opts.Create)
+	case branchRef == currentBranch:
+		fmt.Printf("Already on '%s'\n", refDisplayName(branchRef))
+	default:
+		fmt.Printf("Switched to branch '%s'\n", refDisplayName(branchRef))
+	}
+
+	return nil
+}
+
+// CheckoutPaths восстанавливает файлы в рабочем каталоге.
+// С ревизией файлы берутся из её дерева и записываются также в индекс,
+// без ревизии - из индекса. Локальные изменения этих файлов теряются
+func CheckoutPaths(repoPath, rev string, paths []string) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths specified")
+	}
+
+	store := repo.Objects
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+
+	// Источник файлов: дерево ревизии или индекс
+	source := make(map[string]storage.TreeFile)
+	from := "the index"
+	if rev != "" {
+		parser := newRevParser(repo)
+		hash, err := parser.ResolveCommit(rev)
+		if err != nil {
+			return err
+		}
+		commit, err := store.ReadCommit(hash)
+		if err != nil {
+			return err
+		}
+		if source, err = store.FlattenTree(commit.Tree()); err != nil {
+			return err
+		}
+		from = shortHash(hash)
+	} else {
+		for _, entry := range idx.GetAllEntries() {
+			source[entry.Path] = storage.TreeFile{
+				Path: entry.Path,
+				Mode: objects.FileMode(entry.Mode),
+				Hash: objects.Hash(entry.Hash),
+			}
+		}
+	}
+
+	spec, err := pathspec.ParseRelative(repo.Prefix, paths)
+	if err != nil {
+		return err
+	}
+	known := make([]string, 0, len(source))
+	for path := range source {
+		known = append(known, path)
+	}
+	if unmatched := spec.Unmatched(known); len(unmatched) > 0 {
+		return fmt.Errorf("pathspec '%s' did not match any file(s) known to sib", unmatched[0])
+	}
+
+	var selected []string
+	for _, path := range known {
+		if spec.Match(path) {
+			selected = append(selected, path)
+		}
+	}
+	sort.Strings(selected)
+
+	updated := 0
+	for _, path := range selected {
+		file := source[path]
+		if err := checkoutFile(repo.WorkTree, store, idx, path, file.Mode, file.Hash); err != nil {
+			return err
+		}
+		updated++
+	}
+
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	noun := "paths"
+	if updated == 1 {
+		noun = "path"
+	}
+	fmt.Printf("Updated %d %s from %s\n", updated, noun, from)
+	return nil
+}
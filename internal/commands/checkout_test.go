@@ -0,0 +1,242 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"sib/internal/core/index"
+	"sib/internal/core/refs"
+)
+
+// readFile возвращает содержимое файла репозитория или "<missing>"
+func readFile(t *testing.T, repoPath, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repoPath, path))
+	if os.IsNotExist(err) {
+		return "<missing>"
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSwitch(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "base", map[string]string{"shared.txt": "base", "dir/old.txt": "old"})
+
+	if err := Switch(tmpDir, "", SwitchOptions{Create: "feature"}); err != nil {
+		t.Fatalf("Switch -c failed: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(tmpDir, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	idx, _ := index.NewIndex(tmpDir)
+	idx.Remove("dir/old.txt")
+	idx.Save()
+	commitFiles(t, tmpDir, "feature work", map[string]string{"shared.txt": "feature", "new.txt": "new"})
+
+	t.Run("Back to master", func(t *testing.T) {
+		if err := Switch(tmpDir, "master", SwitchOptions{}); err != nil {
+			t.Fatalf("Switch failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "shared.txt"); got != "base" {
+			t.Errorf("shared.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "new.txt"); got != "<missing>" {
+			t.Errorf("new.txt should be removed, got %q", got)
+		}
+		if got := readFile(t, tmpDir, "dir/old.txt"); got != "old" {
+			t.Errorf("dir/old.txt = %q", got)
+		}
+
+		status, err := CollectStatus(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.IsClean() || status.Branch != "refs/heads/master" {
+			t.Errorf("Expected clean master, got %+v", status)
+		}
+	})
+
+	t.Run("Refuses to overwrite local changes", func(t *testing.T) {
+		writeFiles(t, tmpDir, map[string]string{"shared.txt": "local edit"})
+		err := Switch(tmpDir, "feature", SwitchOptions{})
+		if !errors.Is(err, ErrLocalChanges) {
+			t.Fatalf("Expected ErrLocalChanges, got %v", err)
+		}
+		if got := readFile(t, tmpDir, "shared.txt"); got != "local edit" {
+			t.Errorf("Local edit was lost: %q", got)
+		}
+	})
+
+	t.Run("Refuses to overwrite untracked files", func(t *testing.T) {
+		writeFiles(t, tmpDir, map[string]string{"shared.txt": "base", "new.txt": "untracked"})
+		err := Switch(tmpDir, "feature", SwitchOptions{})
+		if !errors.Is(err, ErrLocalChanges) {
+			t.Fatalf("Expected ErrLocalChanges, got %v", err)
+		}
+		os.Remove(filepath.Join(tmpDir, "new.txt"))
+	})
+
+	t.Run("Carries unrelated changes", func(t *testing.T) {
+		commitFiles(t, tmpDir, "notes", map[string]string{"notes.txt": "v1"})
+		if err := Switch(tmpDir, "", SwitchOptions{Create: "notes"}); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, tmpDir, map[string]string{"notes.txt": "wip"})
+		if err := Switch(tmpDir, "master", SwitchOptions{}); err != nil {
+			t.Fatalf("Switch with unrelated change failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "notes.txt"); got != "wip" {
+			t.Errorf("notes.txt = %q, expected local change to survive", got)
+		}
+	})
+
+	t.Run("Force discards local changes", func(t *testing.T) {
+		if err := Switch(tmpDir, "feature", SwitchOptions{Force: true}); err != nil {
+			t.Fatalf("Switch --force failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "notes.txt"); got != "<missing>" {
+			t.Errorf("notes.txt should be gone on feature, got %q", got)
+		}
+		if got := readFile(t, tmpDir, "shared.txt"); got != "feature" {
+			t.Errorf("shared.txt = %q", got)
+		}
+	})
+
+	t.Run("Commit requires detach", func(t *testing.T) {
+		if err := Switch(tmpDir, "master~1", SwitchOptions{}); err == nil {
+			t.Error("Expected error switching to a commit without --detach")
+		}
+	})
+
+	t.Run("Detached HEAD", func(t *testing.T) {
+		if err := Checkout(tmpDir, "master~1", SwitchOptions{}); err != nil {
+			t.Fatalf("Checkout commit failed: %v", err)
+		}
+		refStore, _ := refs.NewRefStore(tmpDir)
+		branch, head, err := refStore.Head()
+		if err != nil || branch != "" || head.IsEmpty() {
+			t.Errorf("Expected detached HEAD, got %q %s %v", branch, head, err)
+		}
+		if got := readFile(t, tmpDir, "shared.txt"); got != "base" {
+			t.Errorf("shared.txt = %q", got)
+		}
+	})
+}
+
+func TestCheckoutFileModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes and symlinks are not portable")
+	}
+
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	writeFiles(t, tmpDir, map[string]string{"target.txt": "data"})
+	if err := os.WriteFile(filepath.Join(tmpDir, "run"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, tmpDir, "modes", nil)
+
+	for _, name := range []string{"run", "link", "target.txt"} {
+		os.Remove(filepath.Join(tmpDir, name))
+	}
+	if err := Switch(tmpDir, "master", SwitchOptions{Force: true}); err != nil {
+		t.Fatalf("Switch --force failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(tmpDir, "run"))
+	if err != nil || info.Mode()&0100 == 0 {
+		t.Errorf("run should be executable: %v %v", info, err)
+	}
+	if target, err := os.Readlink(filepath.Join(tmpDir, "link")); err != nil || target != "target.txt" {
+		t.Errorf("link = %q, %v", target, err)
+	}
+}
+
+func TestCheckoutPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "one", map[string]string{"a.txt": "1", "b.txt": "1"})
+	commitFiles(t, tmpDir, "two", map[string]string{"a.txt": "2", "b.txt": "2"})
+
+	t.Run("From commit", func(t *testing.T) {
+		if err := CheckoutPaths(tmpDir, "HEAD~1", []string{"a.txt"}); err != nil {
+			t.Fatalf("CheckoutPaths failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "1" {
+			t.Errorf("a.txt = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 1 || status.Staged[0].Path != "a.txt" {
+			t.Errorf("Expected a.txt staged, got %+v", status.Staged)
+		}
+	})
+
+	t.Run("From index", func(t *testing.T) {
+		writeFiles(t, tmpDir, map[string]string{"b.txt": "scratch"})
+		if err := CheckoutPaths(tmpDir, "", []string{"b.txt"}); err != nil {
+			t.Fatalf("CheckoutPaths failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "b.txt"); got != "2" {
+			t.Errorf("b.txt = %q", got)
+		}
+	})
+
+	t.Run("Unknown path", func(t *testing.T) {
+		if err := CheckoutPaths(tmpDir, "HEAD", []string{"nope.txt"}); err == nil {
+			t.Error("Expected error for unmatched pathspec")
+		}
+	})
+}
+
+func TestSwitchFromSubdirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "base", map[string]string{"top.txt": "top\n", "src/a.go": "a\n"})
+	Switch(tmpDir, "", SwitchOptions{Create: "feature"})
+	commitFiles(t, tmpDir, "feature", map[string]string{"top.txt": "feature top\n", "src/a.go": "feature a\n"})
+
+	// Файлы пишутся от корня рабочего каталога, а не от текущего подкаталога
+	src := filepath.Join(tmpDir, "src")
+	if err := Switch(src, "master", SwitchOptions{}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	if got := readFile(t, tmpDir, "top.txt"); got != "top\n" {
+		t.Errorf("top.txt = %q", got)
+	}
+	if got := readFile(t, tmpDir, "src/a.go"); got != "a\n" {
+		t.Errorf("src/a.go = %q", got)
+	}
+
+	writeFiles(t, tmpDir, map[string]string{"src/a.go": "dirty\n"})
+	if err := CheckoutPaths(src, "", []string{"a.go"}); err != nil {
+		t.Fatalf("CheckoutPaths failed: %v", err)
+	}
+	if got := readFile(t, tmpDir, "src/a.go"); got != "a\n" {
+		t.Errorf("src/a.go = %q", got)
+	}
+	if entries, _ := os.ReadDir(src); len(entries) != 1 {
+		t.Errorf("Stray files in the subdirectory: %v", entries)
+	}
+	status, _ := CollectStatus(tmpDir)
+	if status.Branch != "refs/heads/master" || !status.IsClean() {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
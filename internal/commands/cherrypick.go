@@ -0,0 +1,477 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"sib/internal/core/merge"
+	"sib/internal/core/objects"
+	"sib/internal/core/repository"
+)
+
+// Файлы состояния cherry-pick и revert
+const (
+	cherryPickHeadFile = "CHERRY_PICK_HEAD" // Коммит, перенос которого остановился на конфликте
+	revertHeadFile     = "REVERT_HEAD"      // Коммит, отмена которого остановилась на конфликте
+	sequencerDir       = "sequencer"        // Каталог с оставшимися шагами серии
+)
+
+// Действия серии cherry-pick/revert
+const (
+	actionPick   = "pick"
+	actionRevert = "revert"
+)
+
+// ErrPickConflict - перенос или отмена коммита остановились на конфликте
+var ErrPickConflict = errors.New("could not apply commit; resolve conflicts and continue")
+
+// PickOptions - параметры cherry-pick и revert
+type PickOptions struct {
+	Mainline int  // -m: номер родителя коммита слияния, относительно которого берется изменение
+	NoCommit bool // -n: только применить изменения к индексу и рабочему каталогу
+}
+
+// CherryPick переносит изменения коммитов на текущую ветку, создавая новые коммиты
+func CherryPick(repoPath string, revs []string, opts PickOptions) error {
+	return startSequence(repoPath, actionPick, revs, opts)
+}
+
+// Revert создает коммиты, отменяющие изменения указанных коммитов
+func Revert(repoPath string, revs []string, opts PickOptions) error {
+	return startSequence(repoPath, actionRevert, revs, opts)
+}
+
+// CherryPickContinue продолжает серию cherry-pick после разрешения конфликта
+func CherryPickContinue(repoPath string) error {
+	return continueSequence(repoPath, actionPick)
+}
+
+// RevertContinue продолжает серию revert после разрешения конфликта
+func RevertContinue(repoPath string) error {
+	return continueSequence(repoPath, actionRevert)
+}
+
+// CherryPickAbort отменяет серию cherry-pick и возвращает ветку в исходное состояние
+func CherryPickAbort(repoPath string) error {
+	return abortSequence(repoPath, actionPick)
+}
+
+// RevertAbort отменяет серию revert и возвращает ветку в исходное состояние
+func RevertAbort(repoPath string) error {
+	return abortSequence(repoPath, actionRevert)
+}
+
+// sequence - серия cherry-pick или revert; состояние хранится в .sib/sequencer
+// и переживает остановку на конфликте
+type sequence struct {
+	*repository.Repository
+	dir    string
+	action string
+	opts   PickOptions
+}
+
+func openSequence(repoPath, action string) (*sequence, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return &sequence{
+		Repository: repo,
+		dir:        repo.Path(sequencerDir),
+		action:     action,
+	}, nil
+}
+
+func startSequence(repoPath, action string, revs []string, opts PickOptions) error {
+	seq, err := openSequence(repoPath, action)
+	if err != nil {
+		return err
+	}
+	seq.opts = opts
+	if len(revs) == 0 {
+		return fmt.Errorf("no commits given")
+	}
+	if _, err := os.Stat(seq.dir); err == nil {
+		return fmt.Errorf("a cherry-pick or revert is already in progress; use --continue or --abort")
+	}
+	for _, name := range []string{mergeHeadFile, cherryPickHeadFile, revertHeadFile} {
+		if value, err := readStateFile(seq.SibDir, name); err != nil {
+			return err
+		} else if value != "" {
+			return fmt.Errorf("an operation is already in progress (%s exists)", name)
+		}
+	}
+
+	_, head, err := seq.Refs.Head()
+	if err != nil {
+		return err
+	}
+	if head.IsEmpty() {
+		return fmt.Errorf("cannot %s onto an empty branch", seq.verb())
+	}
+
+	idx, err := seq.Index()
+	if err != nil {
+		return err
+	}
+	if idx.HasConflicts() {
+		return fmt.Errorf("%s is not possible because you have unmerged files", seq.verb())
+	}
+	// Без --no-commit результат коммитится, поэтому подготовленные изменения попали бы в него
+	if !opts.NoCommit {
+		headCommit, err := seq.Objects.ReadCommit(head)
+		if err != nil {
+			return err
+		}
+		headFiles, err := flattenCommitTree(seq.Objects, headCommit.Tree())
+		if err != nil {
+			return err
+		}
+		if staged := diffIndexAgainstTree(idx, headFiles); len(staged) > 0 {
+			return fmt.Errorf("%w by %s: your index contains uncommitted changes", ErrLocalChanges, seq.verb())
+		}
+	}
+
+	parser := newRevParser(seq.Repository)
+	// Ошибки в аргументах выявляем до записи состояния
+	var todo []string
+	for _, rev := range revs {
+		hash, err := parser.ResolveCommit(rev)
+		if err != nil {
+			return err
+		}
+		commit, err := seq.Objects.ReadCommit(hash)
+		if err != nil {
+			return err
+		}
+		if _, err := seq.parentOf(commit); err != nil {
+			return err
+		}
+		todo = append(todo, hash.String())
+	}
+
+	if err := os.MkdirAll(seq.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sequencer state: %w", err)
+	}
+	if err := seq.write("head", head.String()); err != nil {
+		return err
+	}
+	if err := seq.write("opts", fmt.Sprintf("action=%s\nmainline=%d\nno-commit=%t", action, opts.Mainline, opts.NoCommit)); err != nil {
+		return err
+	}
+	if err := seq.write("todo", strings.Join(todo, "\n")); err != nil {
+		return err
+	}
+
+	return seq.run()
+}
+
+func continueSequence(repoPath, action string) error {
+	seq, err := openSequence(repoPath, action)
+	if err != nil {
+		return err
+	}
+	if err := seq.load(); err != nil {
+		return err
+	}
+
+	idx, err := seq.Index()
+	if err != nil {
+		return err
+	}
+	if idx.HasConflicts() {
+		return fmt.Errorf("you must edit all merge conflicts and then mark them as resolved using sib add:\n\t%s",
+			strings.Join(idx.UnmergedPaths(), "\n\t"))
+	}
+
+	// Коммитим разрешенный конфликт; сообщение и автор берутся из файлов состояния
+	pending, err := readStateFile(seq.SibDir, seq.headFile())
+	if err != nil {
+		return err
+	}
+	if pending != "" {
+		if seq.opts.NoCommit {
+			if err := removeStateFiles(seq.SibDir, seq.headFile(), mergeMsgFile); err != nil {
+				return err
+			}
+		} else if _, err := createCommit(seq.Repository, CommitOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return seq.run()
+}
+
+func abortSequence(repoPath, action string) error {
+	seq, err := openSequence(repoPath, action)
+	if err != nil {
+		return err
+	}
+	if err := seq.load(); err != nil {
+		return err
+	}
+
+	origValue, err := seq.read("head")
+	if err != nil {
+		return err
+	}
+	orig, err := seq.Objects.ReadCommit(objects.Hash(origValue))
+	if err != nil {
+		return err
+	}
+
+	branch, head, err := seq.Refs.Head()
+	if err != nil {
+		return err
+	}
+	headCommit, err := seq.Objects.ReadCommit(head)
+	if err != nil {
+		return err
+	}
+
+	idx, err := seq.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+	if err := resetToTree(seq.Repository, idx, headCommit.Tree()); err != nil {
+		return err
+	}
+	if err := checkoutTree(seq.WorkTree, seq.Objects, idx, headCommit.Tree(), orig.Tree(), true); err != nil {
+		return err
+	}
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	if head != orig.Hash() {
+		if err := advanceHead(seq.Refs, branch, head, orig.Hash()); err != nil {
+			return err
+		}
+	}
+
+	if err := removeStateFiles(seq.SibDir, seq.headFile(), mergeMsgFile); err != nil {
+		return err
+	}
+	return os.RemoveAll(seq.dir)
+}
+
+// run применяет оставшиеся коммиты серии; при конфликте останавливается с ErrPickConflict
+func (seq *sequence) run() error {
+	for {
+		todoText, err := seq.read("todo")
+		if err != nil {
+			return err
+		}
+		todo := strings.Fields(todoText)
+		if len(todo) == 0 {
+			return os.RemoveAll(seq.dir)
+		}
+
+		// Снимаем шаг до выполнения: после конфликта --continue продолжит со следующего
+		if err := seq.write("todo", strings.Join(todo[1:], "\n")); err != nil {
+			return err
+		}
+		if err := seq.apply(objects.Hash(todo[0])); err != nil {
+			return err
+		}
+	}
+}
+
+// apply переносит (или отменяет) один коммит
+func (seq *sequence) apply(hash objects.Hash) error {
+	commit, err := seq.Objects.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+
+	// Изменение берется относительно выбранного родителя
+	parent, err := seq.parentOf(commit)
+	if err != nil {
+		return err
+	}
+	var parentTree objects.Hash
+	if !parent.IsEmpty() {
+		parentCommit, err := seq.Objects.ReadCommit(parent)
+		if err != nil {
+			return err
+		}
+		parentTree = parentCommit.Tree()
+	}
+
+	branch, head, err := seq.Refs.Head()
+	if err != nil {
+		return err
+	}
+	headCommit, err := seq.Objects.ReadCommit(head)
+	if err != nil {
+		return err
+	}
+	idx, err := seq.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+
+	// С --no-commit изменения копятся в индексе, поэтому применяем поверх него
+	ours := headCommit.Tree()
+	if seq.opts.NoCommit {
+		if ours, err = idx.WriteTree(seq.Objects); err != nil {
+			return fmt.Errorf("failed to write tree: %w", err)
+		}
+	}
+
+	label := commitLabel(commit)
+	base, target := parentTree, commit.Tree()
+	labels := merge.Labels{Base: "parent of " + label, Ours: "HEAD", Theirs: label}
+	message := fmt.Sprintf("%s\n\n(cherry picked from commit %s)", strings.TrimRight(commit.Message(), "\n"), hash)
+	if seq.action == actionRevert {
+		base, target = commit.Tree(), parentTree
+		labels = merge.Labels{Base: label, Ours: "HEAD", Theirs: "parent of " + label}
+		message = fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.", firstLine(commit.Message()), hash)
+		if commit.IsMerge() {
+			message += fmt.Sprintf("\nReversing changes made to %s.", parent)
+		}
+	}
+
+	conflicts, err := applyChange(seq.Repository, idx, ours, base, target, labels, seq.verb())
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		if err := writeStateFile(seq.SibDir, seq.headFile(), hash.String()); err != nil {
+			return err
+		}
+		if err := writeStateFile(seq.SibDir, mergeMsgFile, message); err != nil {
+			return err
+		}
+		for _, conflict := range conflicts {
+			printConflict(conflict)
+		}
+		fmt.Printf("error: could not %s %s... %s\n", seq.action, shortHash(hash), firstLine(commit.Message()))
+		fmt.Printf("hint: after resolving the conflicts, mark them with \"sib add\" and run \"sib %s --continue\"\n", seq.verb())
+		return ErrPickConflict
+	}
+
+	if seq.opts.NoCommit {
+		return nil
+	}
+
+	treeHash, err := idx.WriteTree(seq.Objects)
+	if err != nil {
+		return fmt.Errorf("failed to write tree: %w", err)
+	}
+	if treeHash == headCommit.Tree() {
+		fmt.Printf("skipping %s %s -- the change is already present\n", shortHash(hash), firstLine(commit.Message()))
+		return nil
+	}
+
+	now := time.Now()
+	committer, err := defaultSignature(seq.Config, now)
+	if err != nil {
+		return err
+	}
+	author := *committer
+	if seq.action == actionPick {
+		author = commit.Author()
+	}
+
+	newCommit, err := objects.NewCommit(treeHash, []objects.Hash{head}, author, *committer, message)
+	if err != nil {
+		return fmt.Errorf("failed to create commit: %w", err)
+	}
+	newHash, err := seq.Objects.WriteObject(newCommit)
+	if err != nil {
+		return fmt.Errorf("failed to write commit: %w", err)
+	}
+	if err := advanceHead(seq.Refs, branch, head, newHash); err != nil {
+		return err
+	}
+
+	fmt.Printf("[%s %s] %s\n", refDisplayName(branch), shortHash(newHash), firstLine(message))
+	return nil
+}
+
+// parentOf выбирает родителя, относительно которого берется изменение коммита:
+// для слияния - заданный через -m, иначе единственного. Пустой хеш - корневой коммит
+func (seq *sequence) parentOf(commit *objects.Commit) (objects.Hash, error) {
+	parents := commit.Parents()
+	switch {
+	case commit.IsMerge() && seq.opts.Mainline == 0:
+		return "", fmt.Errorf("commit %s is a merge but no -m option was given", shortHash(commit.Hash()))
+	case commit.IsMerge() && (seq.opts.Mainline < 1 || seq.opts.Mainline > len(parents)):
+		return "", fmt.Errorf("commit %s does not have parent %d", shortHash(commit.Hash()), seq.opts.Mainline)
+	case commit.IsMerge():
+		return parents[seq.opts.Mainline-1], nil
+	case seq.opts.Mainline > 0:
+		return "", fmt.Errorf("mainline was specified but commit %s is not a merge", shortHash(commit.Hash()))
+	case commit.IsRoot():
+		return "", nil
+	}
+	return parents[0], nil
+}
+
+// load проверяет, что серия нужного вида начата, и читает её параметры
+func (seq *sequence) load() error {
+	data, err := seq.read("opts")
+	if err != nil {
+		return err
+	}
+	if data == "" {
+		return fmt.Errorf("no %s in progress", seq.verb())
+	}
+
+	values := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[key] = value
+		}
+	}
+	if values["action"] != seq.action {
+		return fmt.Errorf("no %s in progress (a %s is in progress instead)", seq.verb(), values["action"])
+	}
+	seq.opts.Mainline, _ = strconv.Atoi(values["mainline"])
+	seq.opts.NoCommit = values["no-commit"] == "true"
+	return nil
+}
+
+// verb возвращает имя команды для сообщений
+func (seq *sequence) verb() string {
+	if seq.action == actionRevert {
+		return "revert"
+	}
+	return "cherry-pick"
+}
+
+// headFile возвращает файл, отмечающий остановившийся шаг
+func (seq *sequence) headFile() string {
+	if seq.action == actionRevert {
+		return revertHeadFile
+	}
+	return cherryPickHeadFile
+}
+
+// read читает файл состояния серии; отсутствующий файл - пустая строка
+func (seq *sequence) read(name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(seq.dir, name))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read sequencer state %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// write записывает файл состояния серии
+func (seq *sequence) write(name, value string) error {
+	if err := os.WriteFile(filepath.Join(seq.dir, name), []byte(value+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write sequencer state %s: %w", name, err)
+	}
+	return nil
+}
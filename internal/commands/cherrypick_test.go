@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// headCommit читает коммит, на который указывает HEAD
+func headCommit(t *testing.T, repoPath string) *objects.Commit {
+	t.Helper()
+	hashes, err := RevParse(repoPath, []string{"HEAD"}, RevParseOptions{})
+	if err != nil {
+		t.Fatalf("RevParse failed: %v", err)
+	}
+	store, _ := storage.NewObjectStore(repoPath)
+	commit, err := store.ReadCommit(objects.Hash(hashes[0]))
+	if err != nil {
+		t.Fatalf("ReadCommit failed: %v", err)
+	}
+	return commit
+}
+
+func TestCherryPick(t *testing.T) {
+	t.Run("Picks a commit with trailer and original author", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"},
+			map[string]string{"a.txt": "a1\nfix\na3\n"})
+		fix, _ := RevParse(tmpDir, []string{"feature"}, RevParseOptions{})
+
+		t.Setenv("SIB_AUTHOR_NAME", "Backporter")
+		if err := CherryPick(tmpDir, []string{"feature"}, PickOptions{}); err != nil {
+			t.Fatalf("CherryPick failed: %v", err)
+		}
+
+		if got := readFile(t, tmpDir, "a.txt"); got != "a1\nfix\na3\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "f1.txt"); got != "<missing>" {
+			t.Errorf("Only the picked change should be applied, f1.txt = %q", got)
+		}
+
+		commit := headCommit(t, tmpDir)
+		if want := "f2\n\n(cherry picked from commit " + fix[0] + ")"; commit.Message() != want {
+			t.Errorf("Message = %q, want %q", commit.Message(), want)
+		}
+		if author := commit.Author(); author.Name() == "Backporter" {
+			t.Error("Cherry-pick should keep the original author")
+		}
+	})
+
+	t.Run("Conflict, continue and abort", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "master\na2\na3\n"},
+			map[string]string{"a.txt": "feature\na2\na3\n"},
+			map[string]string{"b.txt": "b\n"})
+
+		err := CherryPick(tmpDir, []string{"feature~1", "feature"}, PickOptions{})
+		if !errors.Is(err, ErrPickConflict) {
+			t.Fatalf("Expected ErrPickConflict, got %v", err)
+		}
+		if err := CherryPick(tmpDir, []string{"feature"}, PickOptions{}); err == nil {
+			t.Error("Starting a new cherry-pick during one should fail")
+		}
+		if err := RevertContinue(tmpDir); err == nil {
+			t.Error("RevertContinue should refuse to continue a cherry-pick")
+		}
+
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "resolved\na2\na3\n"})
+		if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if err := CherryPickContinue(tmpDir); err != nil {
+			t.Fatalf("CherryPickContinue failed: %v", err)
+		}
+
+		if got := messages(t, tmpDir, LogOptions{MaxCount: 3}); !strings.HasPrefix(got, "f2") {
+			t.Errorf("History = %q", got)
+		}
+		if got := readFile(t, tmpDir, "b.txt"); got != "b\n" {
+			t.Errorf("Remaining commit was not picked, b.txt = %q", got)
+		}
+
+		before := headCommit(t, tmpDir).Hash()
+		if err := CherryPick(tmpDir, []string{"feature~1"}, PickOptions{}); !errors.Is(err, ErrPickConflict) {
+			t.Fatalf("Expected ErrPickConflict, got %v", err)
+		}
+		if err := CherryPickAbort(tmpDir); err != nil {
+			t.Fatalf("CherryPickAbort failed: %v", err)
+		}
+		if headCommit(t, tmpDir).Hash() != before {
+			t.Error("Abort should restore HEAD")
+		}
+		status, _ := CollectStatus(tmpDir)
+		if !status.IsClean() {
+			t.Errorf("Working tree should be clean after abort: %+v", status)
+		}
+	})
+
+	t.Run("No commit", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"},
+			map[string]string{"f2.txt": "two\n"})
+		before := headCommit(t, tmpDir).Hash()
+
+		if err := CherryPick(tmpDir, []string{"feature~1", "feature"}, PickOptions{NoCommit: true}); err != nil {
+			t.Fatalf("CherryPick -n failed: %v", err)
+		}
+		if headCommit(t, tmpDir).Hash() != before {
+			t.Error("--no-commit should not move HEAD")
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 2 {
+			t.Errorf("Expected both changes staged, got %+v", status.Staged)
+		}
+	})
+
+	t.Run("Merge commit needs mainline", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f.txt": "feature\n"})
+		if _, err := Merge(tmpDir, "feature", MergeOptions{NoFF: true}); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+
+		if err := Revert(tmpDir, []string{"HEAD"}, PickOptions{}); err == nil {
+			t.Error("Reverting a merge without -m should fail")
+		}
+		if err := Revert(tmpDir, []string{"HEAD~1"}, PickOptions{Mainline: 1}); err == nil {
+			t.Error("-m on a non-merge commit should fail")
+		}
+
+		if err := Revert(tmpDir, []string{"HEAD"}, PickOptions{Mainline: 1}); err != nil {
+			t.Fatalf("Revert -m 1 failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "f.txt"); got != "<missing>" {
+			t.Errorf("Reverting the merge should drop feature changes, f.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "m.txt"); got != "master\n" {
+			t.Errorf("Mainline changes should stay, m.txt = %q", got)
+		}
+	})
+}
+
+func TestRevert(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "one\n"})
+	commitFiles(t, tmpDir, "change", map[string]string{"a.txt": "two\n", "b.txt": "new\n"})
+	change := headCommit(t, tmpDir).Hash()
+
+	if err := Revert(tmpDir, []string{"HEAD"}, PickOptions{}); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+
+	if got := readFile(t, tmpDir, "a.txt"); got != "one\n" {
+		t.Errorf("a.txt = %q", got)
+	}
+	if got := readFile(t, tmpDir, "b.txt"); got != "<missing>" {
+		t.Errorf("b.txt = %q", got)
+	}
+	want := "Revert \"change\"\n\nThis reverts commit " + change.String() + "."
+	if got := headCommit(t, tmpDir).Message(); got != want {
+		t.Errorf("Message = %q, want %q", got, want)
+	}
+	if got := messages(t, tmpDir, LogOptions{}); got != "Revert \"change\" change base" {
+		t.Errorf("History = %q", got)
+	}
+}
@@ -1 +1,283 @@
 package commands
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+	"time"
+
+	"sib/internal/core/config"
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+)
+
+// CommitOptions - параметры команды commit
+type CommitOptions struct {
+	Message    string // Сообщение коммита (-m)
+	AllowEmpty bool   // Разрешить коммит без изменений (--allow-empty)
+	Amend      bool   // Заменить последний коммит (--amend)
+	Author     string // Автор в формате "Name <email>" (--author)
+}
+
+// Commit создает новый коммит из текущего состояния индекса
+// и передвигает на него текущую ветку (или HEAD, если он отсоединен)
+func Commit(repoPath string, opts CommitOptions) (objects.Hash, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return "", err
+	}
+	return createCommit(repo, opts)
+}
+
+// createCommit создает коммит в открытом репозитории
+func createCommit(repo *repository.Repository, opts CommitOptions) (objects.Hash, error) {
+	idx, err := repo.Index()
+	if err != nil {
+		return "", err
+	}
+	if idx.HasConflicts() {
+		return "", fmt.Errorf("committing is not possible because you have unmerged files:\n\t%s",
+			strings.Join(idx.UnmergedPaths(), "\n\t"))
+	}
+
+	// Незавершенное слияние: сливаемый коммит станет вторым родителем
+	mergeHead, err := readStateFile(repo.SibDir, mergeHeadFile)
+	if err != nil {
+		return "", err
+	}
+	if mergeHead != "" && opts.Amend {
+		return "", fmt.Errorf("you are in the middle of a merge -- cannot amend")
+	}
+
+	// Незавершенный cherry-pick или revert: сообщение подготовлено в MERGE_MSG
+	pickHead, err := readStateFile(repo.SibDir, cherryPickHeadFile)
+	if err != nil {
+		return "", err
+	}
+	revertHead, err := readStateFile(repo.SibDir, revertHeadFile)
+	if err != nil {
+		return "", err
+	}
+
+	store := repo.Objects
+	refStore := repo.Refs
+
+	// Определяем, куда указывает HEAD
+	branchRef, headHash, err := refStore.Head()
+	if err != nil {
+		return "", err
+	}
+
+	var headCommit *objects.Commit
+	if !headHash.IsEmpty() {
+		headCommit, err = store.ReadCommit(headHash)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Родители и значения по умолчанию зависят от --amend
+	var parents []objects.Hash
+	message := opts.Message
+	var author *objects.Signature
+
+	if opts.Amend {
+		if headCommit == nil {
+			return "", fmt.Errorf("you have nothing to amend")
+		}
+		parents = headCommit.Parents()
+		if strings.TrimSpace(message) == "" {
+			message = headCommit.Message()
+		}
+		previous := headCommit.Author()
+		author = &previous
+	} else if headCommit != nil {
+		parents = []objects.Hash{headHash}
+	}
+	if mergeHead != "" {
+		parents = append(parents, objects.Hash(mergeHead))
+		if strings.TrimSpace(message) == "" {
+			saved, err := readStateFile(repo.SibDir, mergeMsgFile)
+			if err != nil {
+				return "", err
+			}
+			message = stripCommentLines(saved)
+		}
+	}
+	if (pickHead != "" || revertHead != "") && !opts.Amend && strings.TrimSpace(message) == "" {
+		saved, err := readStateFile(repo.SibDir, mergeMsgFile)
+		if err != nil {
+			return "", err
+		}
+		message = stripCommentLines(saved)
+	}
+	// Перенесенный коммит сохраняет исходного автора
+	if pickHead != "" && !opts.Amend {
+		picked, err := store.ReadCommit(objects.Hash(pickHead))
+		if err != nil {
+			return "", err
+		}
+		original := picked.Author()
+		author = &original
+	}
+
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("aborting commit due to empty commit message")
+	}
+
+	// Корневой коммит без файлов - только с --allow-empty
+	if idx.Count() == 0 && len(parents) == 0 && !opts.AllowEmpty {
+		return "", fmt.Errorf("nothing to commit (use \"sib add\" to track files)")
+	}
+
+	// Строим дерево из индекса; пустой индекс дает пустое дерево
+	treeHash, err := idx.WriteTree(store)
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree: %w", err)
+	}
+
+	// Отказываемся коммитить, если индекс совпадает с деревом родителя.
+	// Коммит слияния нужен и без изменений дерева - он фиксирует историю
+	if !opts.AllowEmpty && mergeHead == "" && len(parents) > 0 {
+		parent, err := store.ReadCommit(parents[0])
+		if err != nil {
+			return "", err
+		}
+		if parent.Tree() == treeHash {
+			if opts.Amend {
+				return "", fmt.Errorf("amending would make the commit empty (use --allow-empty)")
+			}
+			return "", fmt.Errorf("nothing to commit, working tree clean")
+		}
+	}
+
+	now := time.Now()
+	committer, err := defaultSignature(repo.Config, now)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Author != "" {
+		author, err = parseSignature(opts.Author, now)
+		if err != nil {
+			return "", err
+		}
+	} else if author == nil {
+		author = committer
+	}
+
+	commit, err := objects.NewCommit(treeHash, parents, *author, *committer, message)
+	if err != nil {
+		return "", fmt.Errorf("failed to create commit: %w", err)
+	}
+
+	commitHash, err := store.WriteObject(commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to write commit: %w", err)
+	}
+
+	// Передвигаем ветку или отсоединенный HEAD, только если их никто не сдвинул
+	targetRef := refs.HEAD
+	if branchRef != "" {
+		targetRef = branchRef
+	}
+	if err := refStore.CompareAndSwap(targetRef, headHash, commitHash); err != nil {
+		return "", fmt.Errorf("failed to update %s: %w", refDisplayName(branchRef), err)
+	}
+
+	if err := removeStateFiles(repo.SibDir, mergeHeadFile, mergeMsgFile, cherryPickHeadFile, revertHeadFile); err != nil {
+		return "", err
+	}
+
+	label := refDisplayName(branchRef)
+	if len(parents) == 0 {
+		label += " (root-commit)"
+	}
+	fmt.Printf("[%s %s] %s\n", label, shortHash(commitHash), firstLine(commit.Message()))
+
+	return commitHash, nil
+}
+
+// stripCommentLines убирает строки-комментарии ('#') из подготовленного сообщения
+func stripCommentLines(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// signatureRe разбирает строку вида "Name <email>"
+var signatureRe = regexp.MustCompile(`^\s*(.+?)\s*<([^<>]+)>\s*$`)
+
+// parseSignature создает подпись из строки "Name <email>"
+func parseSignature(value string, when time.Time) (*objects.Signature, error) {
+	match := signatureRe.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("invalid author %q: expected format 'Name <email>'", value)
+	}
+	return objects.NewSignature(match[1], match[2], when)
+}
+
+// defaultSignature определяет подпись пользователя: из окружения
+// SIB_AUTHOR_NAME / SIB_AUTHOR_EMAIL, затем из настроек user.name / user.email,
+// иначе из имени пользователя ОС
+func defaultSignature(cfg *config.Config, when time.Time) (*objects.Signature, error) {
+	name := os.Getenv("SIB_AUTHOR_NAME")
+	email := os.Getenv("SIB_AUTHOR_EMAIL")
+	if name == "" {
+		name, _ = cfg.Get("user.name")
+	}
+	if email == "" {
+		email, _ = cfg.Get("user.email")
+	}
+
+	if name == "" || email == "" {
+		username := "unknown"
+		if u, err := user.Current(); err == nil && u.Username != "" {
+			username = u.Username
+		}
+		if name == "" {
+			name = username
+		}
+		if email == "" {
+			host, err := os.Hostname()
+			if err != nil || host == "" {
+				host = "localhost"
+			}
+			email = username + "@" + host
+		}
+	}
+
+	return objects.NewSignature(name, email, when)
+}
+
+// refDisplayName возвращает короткое имя ветки для вывода
+func refDisplayName(refName string) string {
+	if refName == "" {
+		return "detached HEAD"
+	}
+	return strings.TrimPrefix(refName, refs.HeadsPrefix)
+}
+
+// shortHash возвращает сокращенный хеш для вывода
+func shortHash(hash objects.Hash) string {
+	s := hash.String()
+	if len(s) > 7 {
+		return s[:7]
+	}
+	return s
+}
+
+// firstLine возвращает первую строку сообщения
+func firstLine(message string) string {
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		return message[:i]
+	}
+	return message
+}
@@ -0,0 +1,227 @@
+// internal/commands/commit_test.go
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// initRepoWithFiles создает репозиторий с файлами и добавляет их в индекс
+func initRepoWithFiles(t *testing.T, files map[string]string) string {
+	tmpDir := t.TempDir()
+
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	writeFiles(t, tmpDir, files)
+
+	if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	return tmpDir
+}
+
+// writeFiles создает файлы в рабочем каталоге
+func writeFiles(t *testing.T, repoPath string, files map[string]string) {
+	for path, content := range files {
+		fullPath := filepath.Join(repoPath, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func TestCommit(t *testing.T) {
+	t.Run("Root commit updates branch", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{
+			"main.go":     "package main",
+			"src/util.go": "// util",
+		})
+
+		hash, err := Commit(tmpDir, CommitOptions{Message: "initial"})
+		if err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".sib", "refs", "heads", "master"))
+		if err != nil {
+			t.Fatalf("Branch ref not created: %v", err)
+		}
+		if strings.TrimSpace(string(data)) != hash.String() {
+			t.Errorf("Branch points to %s, expected %s", strings.TrimSpace(string(data)), hash)
+		}
+
+		store, _ := storage.NewObjectStore(tmpDir)
+		commit, err := store.ReadCommit(hash)
+		if err != nil {
+			t.Fatalf("Failed to read commit: %v", err)
+		}
+		if !commit.IsRoot() {
+			t.Error("First commit should be a root commit")
+		}
+	})
+
+	t.Run("Second commit has parent", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one"})
+
+		first, err := Commit(tmpDir, CommitOptions{Message: "first"})
+		if err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "two, longer"})
+		if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+
+		second, err := Commit(tmpDir, CommitOptions{Message: "second"})
+		if err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		store, _ := storage.NewObjectStore(tmpDir)
+		commit, _ := store.ReadCommit(second)
+		parents := commit.Parents()
+		if len(parents) != 1 || parents[0] != first {
+			t.Errorf("Expected parent %s, got %v", first, parents)
+		}
+	})
+
+	t.Run("Refuses commit without changes", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one"})
+
+		if _, err := Commit(tmpDir, CommitOptions{Message: "first"}); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		if _, err := Commit(tmpDir, CommitOptions{Message: "again"}); err == nil {
+			t.Error("Expected error when index matches HEAD tree")
+		}
+
+		if _, err := Commit(tmpDir, CommitOptions{Message: "again", AllowEmpty: true}); err != nil {
+			t.Errorf("--allow-empty commit failed: %v", err)
+		}
+	})
+
+	t.Run("Removing every file commits an empty tree", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one", "dir/b.txt": "two"})
+		if _, err := Commit(tmpDir, CommitOptions{Message: "first"}); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		if err := Rm(tmpDir, []string{"."}, RmOptions{Recursive: true, Force: true}); err != nil {
+			t.Fatalf("Rm failed: %v", err)
+		}
+		second, err := Commit(tmpDir, CommitOptions{Message: "remove all"})
+		if err != nil {
+			t.Fatalf("Commit of empty index failed: %v", err)
+		}
+
+		store, _ := storage.NewObjectStore(tmpDir)
+		commit, err := store.ReadCommit(second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := store.ReadTree(commit.Tree())
+		if err != nil || len(tree.Entries()) != 0 {
+			t.Errorf("Expected empty tree, got %v, %v", tree, err)
+		}
+
+		// Файлы не возвращаются при reset --hard на новый коммит
+		if err := Reset(tmpDir, "HEAD", ResetOptions{Mode: ResetHard}); err != nil {
+			t.Fatalf("Reset failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "a.txt")); !os.IsNotExist(err) {
+			t.Error("a.txt was restored by reset --hard")
+		}
+		if _, err := Commit(tmpDir, CommitOptions{Message: "again"}); err == nil {
+			t.Error("Expected error when the empty index matches HEAD")
+		}
+	})
+
+	t.Run("Root commit needs files", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, nil)
+		if _, err := Commit(tmpDir, CommitOptions{Message: "empty"}); err == nil {
+			t.Error("Expected error for an empty root commit")
+		}
+		if _, err := Commit(tmpDir, CommitOptions{Message: "empty", AllowEmpty: true}); err != nil {
+			t.Errorf("--allow-empty root commit failed: %v", err)
+		}
+	})
+
+	t.Run("Amend replaces tip", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one"})
+
+		first, err := Commit(tmpDir, CommitOptions{Message: "first"})
+		if err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		amended, err := Commit(tmpDir, CommitOptions{
+			Message: "first, reworded",
+			Amend:   true,
+			Author:  "Jane Doe <jane@example.com>",
+		})
+		if err != nil {
+			t.Fatalf("Amend failed: %v", err)
+		}
+		if amended == first {
+			t.Fatal("Amended commit should have a new hash")
+		}
+
+		store, _ := storage.NewObjectStore(tmpDir)
+		commit, _ := store.ReadCommit(amended)
+		if !commit.IsRoot() {
+			t.Error("Amended root commit should stay a root commit")
+		}
+		author := commit.Author()
+		if author.Name() != "Jane Doe" || author.Email() != "jane@example.com" {
+			t.Errorf("Unexpected author: %s <%s>", author.Name(), author.Email())
+		}
+		if commit.Message() != "first, reworded" {
+			t.Errorf("Unexpected message: %q", commit.Message())
+		}
+	})
+
+	t.Run("Detached HEAD", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one"})
+
+		first, err := Commit(tmpDir, CommitOptions{Message: "first"})
+		if err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		headPath := filepath.Join(tmpDir, ".sib", "HEAD")
+		if err := os.WriteFile(headPath, []byte(first.String()+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to detach HEAD: %v", err)
+		}
+
+		second, err := Commit(tmpDir, CommitOptions{Message: "detached", AllowEmpty: true})
+		if err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(headPath)
+		if objects.Hash(strings.TrimSpace(string(data))) != second {
+			t.Errorf("Detached HEAD was not advanced")
+		}
+	})
+
+	t.Run("Invalid author", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one"})
+
+		if _, err := Commit(tmpDir, CommitOptions{Message: "x", Author: "nobody"}); err == nil {
+			t.Error("Expected error for malformed --author")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"sib/internal/core/config"
+	"sib/internal/core/repository"
+)
+
+// ConfigOptions - параметры sib config
+type ConfigOptions struct {
+	Scope      config.Scope // --system, --global, --local; 0 - чтение всех уровней, запись в локальный
+	ShowOrigin bool         // --show-origin: печатать источник каждого значения
+}
+
+// ConfigGet печатает значение переменной (с all - все значения многозначной).
+// Незаданная переменная - ошибка config.ErrNotFound
+func ConfigGet(repoPath, key string, all bool, opts ConfigOptions) ([]string, error) {
+	if _, err := config.CanonicalKey(key); err != nil {
+		return nil, err
+	}
+	cfg, err := loadConfig(repoPath, opts.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := cfg.GetAll(key)
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", config.ErrNotFound, key)
+	}
+	if !all {
+		entries = entries[len(entries)-1:]
+	}
+
+	values := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if opts.ShowOrigin {
+			fmt.Printf("%s\t%s\n", entry.Origin, entry.Value)
+		} else {
+			fmt.Println(entry.Value)
+		}
+		values = append(values, entry.Value)
+	}
+	return values, nil
+}
+
+// ConfigList печатает все значения в виде key=value в порядке чтения уровней
+func ConfigList(repoPath string, opts ConfigOptions) ([]config.Entry, error) {
+	cfg, err := loadConfig(repoPath, opts.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := cfg.Entries()
+	for _, entry := range entries {
+		if opts.ShowOrigin {
+			fmt.Printf("%s\t", entry.Origin)
+		}
+		fmt.Printf("%s=%s\n", entry.Key, entry.Value)
+	}
+	return entries, nil
+}
+
+// ConfigSet записывает значение в файл выбранного уровня (по умолчанию - локальный)
+func ConfigSet(repoPath, key, value string, opts ConfigOptions) error {
+	path, err := configFile(repoPath, opts.Scope)
+	if err != nil {
+		return err
+	}
+	return config.SetValue(path, key, value)
+}
+
+// ConfigUnset удаляет переменную из файла выбранного уровня (по умолчанию - локальный)
+func ConfigUnset(repoPath, key string, opts ConfigOptions) error {
+	path, err := configFile(repoPath, opts.Scope)
+	if err != nil {
+		return err
+	}
+	return config.UnsetValue(path, key)
+}
+
+// loadConfig читает настройки одного уровня или, при scope == 0, всех уровней.
+// Вне репозитория читаются все уровни, кроме локального
+func loadConfig(repoPath string, scope config.Scope) (*config.Config, error) {
+	if scope != 0 {
+		path, err := configFile(repoPath, scope)
+		if err != nil {
+			return nil, err
+		}
+		return config.LoadFile(path, scope)
+	}
+
+	repo, err := repository.Discover(repoPath)
+	if errors.Is(err, repository.ErrNotRepository) {
+		return config.Load("")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return repo.Config, nil
+}
+
+// configFile возвращает путь к файлу уровня scope; 0 означает локальный
+func configFile(repoPath string, scope config.Scope) (string, error) {
+	switch scope {
+	case config.ScopeSystem:
+		return config.SystemPath(), nil
+	case config.ScopeGlobal:
+		path := config.GlobalPath()
+		if path == "" {
+			return "", fmt.Errorf("$HOME not set")
+		}
+		return path, nil
+	case 0, config.ScopeLocal:
+		repo, err := repository.Discover(repoPath)
+		if err != nil {
+			return "", err
+		}
+		return config.LocalPath(repo.SibDir), nil
+	}
+	return "", fmt.Errorf("unsupported config scope %s", scope)
+}
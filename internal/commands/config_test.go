@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"sib/internal/core/config"
+)
+
+func TestConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(config.EnvNoSystem, "1")
+	t.Setenv(config.EnvGlobal, filepath.Join(home, ".sibconfig"))
+	t.Setenv("SIB_AUTHOR_NAME", "")
+	t.Setenv("SIB_AUTHOR_EMAIL", "")
+
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("Set, get and unset", func(t *testing.T) {
+		if err := ConfigSet(tmpDir, "user.name", "Local Name", ConfigOptions{}); err != nil {
+			t.Fatalf("ConfigSet failed: %v", err)
+		}
+		if err := ConfigSet(tmpDir, "user.name", "Global Name", ConfigOptions{Scope: config.ScopeGlobal}); err != nil {
+			t.Fatalf("ConfigSet --global failed: %v", err)
+		}
+
+		values, err := ConfigGet(tmpDir, "user.name", false, ConfigOptions{})
+		if err != nil || len(values) != 1 || values[0] != "Local Name" {
+			t.Errorf("ConfigGet = %v, %v", values, err)
+		}
+		values, _ = ConfigGet(tmpDir, "user.name", true, ConfigOptions{})
+		if len(values) != 2 || values[0] != "Global Name" {
+			t.Errorf("ConfigGet --get-all = %v", values)
+		}
+		values, _ = ConfigGet(tmpDir, "user.name", false, ConfigOptions{Scope: config.ScopeGlobal})
+		if len(values) != 1 || values[0] != "Global Name" {
+			t.Errorf("ConfigGet --global = %v", values)
+		}
+
+		if err := ConfigUnset(tmpDir, "user.name", ConfigOptions{}); err != nil {
+			t.Fatalf("ConfigUnset failed: %v", err)
+		}
+		if err := ConfigUnset(tmpDir, "user.name", ConfigOptions{}); !errors.Is(err, config.ErrNotFound) {
+			t.Errorf("Second unset: got %v, want ErrNotFound", err)
+		}
+		if _, err := ConfigGet(tmpDir, "no.such", false, ConfigOptions{}); !errors.Is(err, config.ErrNotFound) {
+			t.Errorf("Got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Outside repository", func(t *testing.T) {
+		outside := t.TempDir()
+		t.Setenv("SIB_CEILING_DIRECTORIES", filepath.Dir(outside))
+		entries, err := ConfigList(outside, ConfigOptions{})
+		if err != nil {
+			t.Fatalf("ConfigList failed: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Scope != config.ScopeGlobal {
+			t.Errorf("Only the global file should be read, got %+v", entries)
+		}
+		if err := ConfigSet(outside, "a.b", "c", ConfigOptions{}); err == nil {
+			t.Error("Expected error when writing the local file outside a repository")
+		}
+	})
+
+	t.Run("Commit uses user.name and user.email", func(t *testing.T) {
+		if err := ConfigSet(tmpDir, "user.name", "Config Author", ConfigOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigSet(tmpDir, "user.email", "author@example.com", ConfigOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		commitFiles(t, tmpDir, "configured", map[string]string{"a.txt": "a\n"})
+
+		commits, err := Log(tmpDir, LogOptions{})
+		if err != nil || len(commits) == 0 {
+			t.Fatalf("Log failed: %v", err)
+		}
+		author := commits[0].Author()
+		if author.Name() != "Config Author" || author.Email() != "author@example.com" {
+			t.Errorf("Author = %s <%s>", author.Name(), author.Email())
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/repository"
+)
+
+// CountObjectsOptions - параметры count-objects
+type CountObjectsOptions struct {
+	Verbose bool // -v: подробная статистика
+}
+
+// ObjectCounts - статистика хранилища объектов
+type ObjectCounts struct {
+	Count       int   // Объекты, хранящиеся отдельными файлами
+	Size        int64 // Их размер на диске
+	InPack      int   // Объекты в pack-файлах
+	Packs       int   // Число pack-файлов
+	SizePack    int64 // Размер pack-файлов и их индексов
+	Garbage     int   // Временные файлы прерванных записей
+	SizeGarbage int64 // Их размер
+
+	ChunkedBlobs int   // Blob'ы, записанные частями
+	Chunks       int   // Различные куски этих blob'ов
+	SizeChunked  int64 // Суммарный размер blob'ов, записанных частями
+	SizeChunks   int64 // Суммарный размер различных кусков
+}
+
+// DedupRatio возвращает коэффициент дедупликации: во сколько раз содержимое
+// blob'ов, записанных частями, больше того, что реально хранится в кусках.
+// 0 - таких blob'ов нет
+func (c *ObjectCounts) DedupRatio() float64 {
+	if c.SizeChunks == 0 {
+		return 0
+	}
+	return float64(c.SizeChunked) / float64(c.SizeChunks)
+}
+
+// CountObjects считает объекты хранилища и занимаемое ими место, а для
+// blob'ов, записанных частями, - достигнутую дедупликацию
+func CountObjects(repoPath string, opts CountObjectsOptions) (*ObjectCounts, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	counts := &ObjectCounts{}
+
+	loose, err := repo.Objects.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+	counts.Count = len(loose)
+	for _, hash := range loose {
+		if info, err := repo.Objects.StatLoose(hash); err == nil {
+			counts.Size += info.Size()
+		}
+	}
+
+	packed, err := repo.Objects.PackedObjects()
+	if err != nil {
+		return nil, err
+	}
+	counts.InPack = len(packed)
+	packFiles, err := os.ReadDir(repo.Path("objects", "pack"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range packFiles {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "pack-") {
+			continue
+		}
+		if strings.HasSuffix(name, ".pack") {
+			counts.Packs++
+		}
+		if info, err := entry.Info(); err == nil {
+			counts.SizePack += info.Size()
+		}
+	}
+
+	tempFiles, err := repo.Objects.TempFiles()
+	if err != nil {
+		return nil, err
+	}
+	counts.Garbage = len(tempFiles)
+	for _, path := range tempFiles {
+		if info, err := os.Stat(path); err == nil {
+			counts.SizeGarbage += info.Size()
+		}
+	}
+
+	// Объект может лежать и отдельным файлом, и в pack-файле - считаем один раз
+	chunks := make(map[objects.Hash]int64)
+	seen := make(map[objects.Hash]bool)
+	for _, hash := range append(loose, packed...) {
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		manifest, err := repo.Objects.ReadManifest(hash)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			continue
+		}
+		counts.ChunkedBlobs++
+		counts.SizeChunked += manifest.Size()
+		for _, chunk := range manifest.Chunks() {
+			chunks[chunk.Hash()] = chunk.Size()
+		}
+	}
+	counts.Chunks = len(chunks)
+	for _, size := range chunks {
+		counts.SizeChunks += size
+	}
+
+	if opts.Verbose {
+		fmt.Printf("count: %d\n", counts.Count)
+		fmt.Printf("size: %s\n", formatSize(counts.Size))
+		fmt.Printf("in-pack: %d\n", counts.InPack)
+		fmt.Printf("packs: %d\n", counts.Packs)
+		fmt.Printf("size-pack: %s\n", formatSize(counts.SizePack))
+		fmt.Printf("garbage: %d\n", counts.Garbage)
+		fmt.Printf("size-garbage: %s\n", formatSize(counts.SizeGarbage))
+		fmt.Printf("chunked-blobs: %d\n", counts.ChunkedBlobs)
+		fmt.Printf("chunks: %d\n", counts.Chunks)
+		fmt.Printf("size-chunked: %s\n", formatSize(counts.SizeChunked))
+		fmt.Printf("size-chunks: %s\n", formatSize(counts.SizeChunks))
+		fmt.Printf("dedup-ratio: %.2f\n", counts.DedupRatio())
+		return counts, nil
+	}
+
+	fmt.Printf("%d objects, %s\n", counts.Count, formatSize(counts.Size))
+	if counts.ChunkedBlobs > 0 {
+		fmt.Printf("%d chunked blobs (%s) stored in %d chunks (%s), dedup ratio %.2f\n",
+			counts.ChunkedBlobs, formatSize(counts.SizeChunked), counts.Chunks, formatSize(counts.SizeChunks), counts.DedupRatio())
+	}
+	return counts, nil
+}
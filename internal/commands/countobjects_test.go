@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestChunkedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir)
+	if err := ConfigSet(tmpDir, "core.chunkThreshold", "256k", ConfigOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	v1 := make([]byte, 1<<20)
+	rand.New(rand.NewSource(5)).Read(v1)
+	v2 := string(v1[:1000]) + "small edit" + string(v1[1000:])
+	commitFiles(t, tmpDir, "v1", map[string]string{"asset.bin": string(v1), "small.txt": "small\n"})
+	commitFiles(t, tmpDir, "v2", map[string]string{"asset.bin": v2})
+
+	counts, err := CountObjects(tmpDir, CountObjectsOptions{Verbose: true})
+	if err != nil {
+		t.Fatalf("CountObjects failed: %v", err)
+	}
+	// Две версии по мегабайту делят почти все куски
+	if counts.ChunkedBlobs != 2 || counts.SizeChunked != int64(len(v1)+len(v2)) || counts.DedupRatio() < 1.5 {
+		t.Errorf("Counts = %+v, ratio %.2f", counts, counts.DedupRatio())
+	}
+
+	if status, err := CollectStatus(tmpDir); err != nil || len(status.Unstaged) != 0 {
+		t.Errorf("Chunked file shows as modified: %+v, %v", status, err)
+	}
+
+	// Куски достижимы через манифест: gc их не удаляет, fsck не считает висячими
+	if _, err := GC(tmpDir, GCOptions{Prune: "now"}); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	report, err := Fsck(tmpDir, FsckOptions{})
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if !report.OK() || len(report.Dangling) != 0 {
+		t.Errorf("Fsck report = %+v", report)
+	}
+
+	if err := Checkout(tmpDir, "HEAD~1", SwitchOptions{}); err != nil {
+		t.Fatalf("Checkout failed: %v", err)
+	}
+	if got := readFile(t, tmpDir, "asset.bin"); got != string(v1) {
+		t.Error("asset.bin was not reassembled correctly")
+	}
+}
@@ -0,0 +1,318 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sib/internal/core/diff"
+	"sib/internal/core/index"
+	"sib/internal/core/objects"
+	"sib/internal/core/pathspec"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/storage"
+)
+
+// nullPath - имя отсутствующей стороны в заголовках ---/+++
+const nullPath = "/dev/null"
+
+// DiffOptions - параметры команды diff
+type DiffOptions struct {
+	Cached    bool           // --cached: индекс против HEAD (или указанной ревизии)
+	Revisions []string       // Ноль, одна или две ревизии (или диапазон A..B)
+	Paths     []string       // Ограничить вывод этими путями
+	Context   int            // Строк контекста (-U)
+	Algorithm diff.Algorithm // Алгоритм сопоставления строк
+}
+
+// diffSide - версия файла на одной из сторон сравнения
+type diffSide struct {
+	Mode     string       // Режим файла ("100644", "100755")
+	Hash     objects.Hash // Хеш содержимого как blob'а
+	FullPath string       // Путь в рабочем каталоге; пусто, если содержимое в хранилище
+}
+
+// Diff печатает разницу в unified-формате:
+//
+//	sib diff                  рабочий каталог против индекса
+//	sib diff --cached [<rev>] индекс против HEAD или <rev>
+//	sib diff <rev>            рабочий каталог против <rev>
+//	sib diff <a> <b>, <a>..<b> коммит против коммита
+func Diff(repoPath string, opts DiffOptions) error {
+	return WriteDiff(os.Stdout, repoPath, opts)
+}
+
+// WriteDiff - как Diff, но пишет в w
+func WriteDiff(w io.Writer, repoPath string, opts DiffOptions) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+
+	store := repo.Objects
+
+	oldSide, newSide, err := diffSides(repo, opts)
+	if err != nil {
+		return err
+	}
+
+	spec, err := pathspec.ParseRelative(repo.Prefix, opts.Paths)
+	if err != nil {
+		return err
+	}
+
+	all := make(map[string]bool)
+	for path := range oldSide {
+		all[path] = true
+	}
+	for path := range newSide {
+		all[path] = true
+	}
+	sorted := make([]string, 0, len(all))
+	for path := range all {
+		if spec.Match(path) {
+			sorted = append(sorted, path)
+		}
+	}
+	sort.Strings(sorted)
+
+	for _, path := range sorted {
+		oldFile, hasOld := oldSide[path]
+		newFile, hasNew := newSide[path]
+		if hasOld && hasNew && oldFile.Hash == newFile.Hash && oldFile.Mode == newFile.Mode {
+			continue
+		}
+
+		var oldPtr, newPtr *diffSide
+		if hasOld {
+			oldPtr = &oldFile
+		}
+		if hasNew {
+			newPtr = &newFile
+		}
+		if err := writeFileDiff(w, store, path, oldPtr, newPtr, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffSides определяет, что с чем сравнивается, и возвращает обе стороны
+func diffSides(repo *repository.Repository, opts DiffOptions) (map[string]diffSide, map[string]diffSide, error) {
+	revisions := opts.Revisions
+	if len(revisions) == 1 {
+		if left, right, ok := strings.Cut(revisions[0], ".."); ok && !strings.Contains(revisions[0], "...") {
+			revisions = []string{orHeadRev(left), orHeadRev(right)}
+		}
+	}
+	if len(revisions) > 2 {
+		return nil, nil, fmt.Errorf("too many revisions: %s", strings.Join(revisions, " "))
+	}
+
+	parser := newRevParser(repo)
+	treeSide := func(rev string) (map[string]diffSide, error) {
+		hash, err := parser.ResolveCommit(rev)
+		if err != nil {
+			return nil, err
+		}
+		return commitSide(repo.Objects, hash)
+	}
+
+	if len(revisions) == 2 {
+		if opts.Cached {
+			return nil, nil, fmt.Errorf("--cached accepts at most one revision")
+		}
+		oldSide, err := treeSide(revisions[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		newSide, err := treeSide(revisions[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return oldSide, newSide, nil
+	}
+
+	idx, err := repo.Index()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var oldSide map[string]diffSide
+	switch {
+	case len(revisions) == 1:
+		if oldSide, err = treeSide(revisions[0]); err != nil {
+			return nil, nil, err
+		}
+	case opts.Cached:
+		// Без ревизии --cached сравнивает с HEAD; на нерожденной ветке - с пустым деревом
+		_, head, err := repo.Refs.Head()
+		if err != nil {
+			return nil, nil, err
+		}
+		oldSide = make(map[string]diffSide)
+		if !head.IsEmpty() {
+			if oldSide, err = commitSide(repo.Objects, head); err != nil {
+				return nil, nil, err
+			}
+		}
+	default:
+		oldSide = indexSide(idx)
+	}
+
+	if opts.Cached {
+		return oldSide, indexSide(idx), nil
+	}
+
+	newSide, err := worktreeSide(repo.WorkTree, idx, oldSide)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldSide, newSide, nil
+}
+
+// orHeadRev подставляет HEAD вместо пустой стороны диапазона
+func orHeadRev(side string) string {
+	if side == "" {
+		return refs.HEAD
+	}
+	return side
+}
+
+// commitSide возвращает файлы дерева коммита
+func commitSide(store *storage.ObjectStore, hash objects.Hash) (map[string]diffSide, error) {
+	commit, err := store.ReadCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	files, err := store.FlattenTree(commit.Tree())
+	if err != nil {
+		return nil, err
+	}
+
+	side := make(map[string]diffSide, len(files))
+	for path, file := range files {
+		side[path] = diffSide{Mode: string(file.Mode), Hash: file.Hash}
+	}
+	return side, nil
+}
+
+// indexSide возвращает файлы из индекса
+func indexSide(idx *index.Index) map[string]diffSide {
+	side := make(map[string]diffSide)
+	for _, entry := range idx.GetAllEntries() {
+		side[entry.Path] = diffSide{Mode: entry.Mode, Hash: objects.Hash(entry.Hash)}
+	}
+	return side
+}
+
+// worktreeSide возвращает отслеживаемые файлы рабочего каталога.
+// Отслеживаемыми считаются файлы из индекса и со старой стороны сравнения;
+// неотслеживаемые файлы, как и в git, в diff не попадают. Файлы, у которых
+// размер и время совпадают с индексом, не читаются - берется хеш из индекса
+func worktreeSide(workTree string, idx *index.Index, tracked map[string]diffSide) (map[string]diffSide, error) {
+	paths := make(map[string]bool)
+	for _, entry := range idx.GetAllEntries() {
+		paths[entry.Path] = true
+	}
+	for path := range tracked {
+		paths[path] = true
+	}
+
+	side := make(map[string]diffSide)
+	for path := range paths {
+		fullPath := filepath.Join(workTree, filepath.FromSlash(path))
+		info, err := os.Lstat(fullPath)
+		if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		var hash objects.Hash
+		if entry, err := idx.Get(path); err == nil && entry.StatMatches(info) {
+			hash = objects.Hash(entry.Hash)
+		} else if hash, err = hashWorktreeFile(fullPath); err != nil {
+			return nil, err
+		}
+
+		side[path] = diffSide{Mode: index.DetectFileMode(info), Hash: hash, FullPath: fullPath}
+	}
+	return side, nil
+}
+
+// writeFileDiff печатает заголовок и ханки для одного файла.
+// nil на одной из сторон означает добавление или удаление
+func writeFileDiff(w io.Writer, store *storage.ObjectStore, path string, oldFile, newFile *diffSide, opts DiffOptions) error {
+	oldName, newName := "a/"+path, "b/"+path
+
+	var header strings.Builder
+	fmt.Fprintf(&header, "diff --sib %s %s\n", oldName, newName)
+
+	oldHash, newHash := strings.Repeat("0", 7), strings.Repeat("0", 7)
+	if oldFile != nil {
+		oldHash = shortHash(oldFile.Hash)
+	}
+	if newFile != nil {
+		newHash = shortHash(newFile.Hash)
+	}
+
+	switch {
+	case oldFile == nil:
+		fmt.Fprintf(&header, "new file mode %s\n", newFile.Mode)
+		fmt.Fprintf(&header, "index %s..%s\n", oldHash, newHash)
+		oldName = nullPath
+	case newFile == nil:
+		fmt.Fprintf(&header, "deleted file mode %s\n", oldFile.Mode)
+		fmt.Fprintf(&header, "index %s..%s\n", oldHash, newHash)
+		newName = nullPath
+	case oldFile.Mode != newFile.Mode:
+		fmt.Fprintf(&header, "old mode %s\nnew mode %s\n", oldFile.Mode, newFile.Mode)
+		if oldFile.Hash != newFile.Hash {
+			fmt.Fprintf(&header, "index %s..%s\n", oldHash, newHash)
+		}
+	default:
+		fmt.Fprintf(&header, "index %s..%s %s\n", oldHash, newHash, oldFile.Mode)
+	}
+
+	if _, err := io.WriteString(w, header.String()); err != nil {
+		return err
+	}
+
+	oldContent, err := readDiffSide(store, oldFile)
+	if err != nil {
+		return err
+	}
+	newContent, err := readDiffSide(store, newFile)
+	if err != nil {
+		return err
+	}
+
+	return diff.Unified(w, oldName, newName, oldContent, newContent, diff.Options{
+		Context:   opts.Context,
+		Algorithm: opts.Algorithm,
+	})
+}
+
+// readDiffSide читает содержимое файла из рабочего каталога или хранилища
+func readDiffSide(store *storage.ObjectStore, file *diffSide) ([]byte, error) {
+	if file == nil {
+		return nil, nil
+	}
+	if file.FullPath != "" {
+		content, _, err := readWorktreeFile(file.FullPath)
+		return content, err
+	}
+
+	blob, err := store.ReadBlob(file.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return blob.Content(), nil
+}
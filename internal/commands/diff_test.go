@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sib/internal/core/diff"
+	"sib/internal/core/index"
+)
+
+// diffOutput возвращает вывод diff в виде строки
+func diffOutput(t *testing.T, repoPath string, opts DiffOptions) string {
+	t.Helper()
+	if opts.Context == 0 {
+		opts.Context = diff.DefaultContext
+	}
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, repoPath, opts); err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "first", map[string]string{
+		"a.txt":     "one\ntwo\nthree\n",
+		"gone.txt":  "bye\n",
+		"image.bin": "\x00\x01",
+	})
+
+	t.Run("Clean tree", func(t *testing.T) {
+		if out := diffOutput(t, tmpDir, DiffOptions{}); out != "" {
+			t.Errorf("Expected empty diff, got:\n%s", out)
+		}
+	})
+
+	writeFiles(t, tmpDir, map[string]string{"a.txt": "one\n2\nthree\n"})
+
+	t.Run("Worktree against index", func(t *testing.T) {
+		out := diffOutput(t, tmpDir, DiffOptions{})
+		want := "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+		if !strings.HasPrefix(out, "diff --sib a/a.txt b/a.txt\nindex ") || !strings.HasSuffix(out, want) {
+			t.Errorf("Unexpected diff:\n%s", out)
+		}
+		if diffOutput(t, tmpDir, DiffOptions{Cached: true}) != "" {
+			t.Error("Expected nothing staged")
+		}
+	})
+
+	// Индексируем изменения, удаляем файл, добавляем новый
+	if err := os.Remove(filepath.Join(tmpDir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, tmpDir, map[string]string{"new.txt": "fresh\n", "image.bin": "\x00\x02"})
+	if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	idx, err := index.NewIndex(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Remove("gone.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Cached against HEAD", func(t *testing.T) {
+		out := diffOutput(t, tmpDir, DiffOptions{Cached: true})
+		for _, want := range []string{
+			"-two\n+2\n",
+			"Binary files a/image.bin and b/image.bin differ\n",
+			"new file mode 100644\nindex 0000000..",
+			"--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+fresh\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Cached diff missing %q:\n%s", want, out)
+			}
+		}
+		if diffOutput(t, tmpDir, DiffOptions{}) != "" {
+			t.Error("Expected worktree to match index")
+		}
+	})
+
+	t.Run("Path limiting", func(t *testing.T) {
+		out := diffOutput(t, tmpDir, DiffOptions{Cached: true, Paths: []string{"new.txt"}})
+		if strings.Contains(out, "a.txt") || !strings.Contains(out, "new.txt") {
+			t.Errorf("Path-limited diff:\n%s", out)
+		}
+	})
+
+	if _, err := Commit(tmpDir, CommitOptions{Message: "second"}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	t.Run("Commit against commit", func(t *testing.T) {
+		out := diffOutput(t, tmpDir, DiffOptions{Revisions: []string{"HEAD~1", "HEAD"}})
+		if !strings.Contains(out, "deleted file mode 100644") || !strings.Contains(out, "-bye\n") {
+			t.Errorf("Expected deleted gone.txt:\n%s", out)
+		}
+		if ranged := diffOutput(t, tmpDir, DiffOptions{Revisions: []string{"HEAD~1..HEAD"}}); ranged != out {
+			t.Errorf("A..B differs from A B:\n%s", ranged)
+		}
+	})
+
+	t.Run("Worktree against revision", func(t *testing.T) {
+		out := diffOutput(t, tmpDir, DiffOptions{Revisions: []string{"HEAD~1"}, Paths: []string{"a.txt"}})
+		if !strings.Contains(out, "-two\n+2\n") {
+			t.Errorf("Unexpected diff:\n%s", out)
+		}
+	})
+
+	t.Run("Stat match skips reading", func(t *testing.T) {
+		// Как и status, diff доверяет размеру и времени из индекса и не читает файл
+		path := filepath.Join(tmpDir, "new.txt")
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, tmpDir, map[string]string{"new.txt": "FRESH\n"})
+		if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+		if out := diffOutput(t, tmpDir, DiffOptions{}); out != "" {
+			t.Errorf("Expected file with unchanged stat to be skipped:\n%s", out)
+		}
+
+		if err := os.Chtimes(path, info.ModTime(), info.ModTime().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if out := diffOutput(t, tmpDir, DiffOptions{}); !strings.Contains(out, "-fresh\n+FRESH\n") {
+			t.Errorf("Expected change after mtime moved:\n%s", out)
+		}
+	})
+
+	t.Run("Too many revisions", func(t *testing.T) {
+		if err := WriteDiff(&bytes.Buffer{}, tmpDir, DiffOptions{Revisions: []string{"HEAD", "HEAD", "HEAD"}}); err == nil {
+			t.Error("Expected error")
+		}
+	})
+}
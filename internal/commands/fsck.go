@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/revwalk"
+)
+
+// Виды проблем, которые сообщает fsck
+const (
+	FsckCorrupt     = "corrupt"     // Объект не читается: хеш, заголовок или содержимое
+	FsckMissing     = "missing"     // На объект ссылаются, но его нет в хранилище
+	FsckWrongType   = "wrongtype"   // Объект другого типа, чем объявлено в ссылке на него
+	FsckBadRef      = "badref"      // Ссылка указывает на отсутствующий объект или не на коммит
+	FsckBadPack     = "badpack"     // Поврежден pack-файл или его индекс
+	FsckDangling    = "dangling"    // Недостижимый объект, на который никто не ссылается
+	FsckUnreachable = "unreachable" // Объект, недостижимый из ссылок, HEAD и индекса
+)
+
+// FsckOptions - параметры fsck
+type FsckOptions struct {
+	Unreachable bool // --unreachable: сообщать все недостижимые объекты, а не только висячие
+	NoDangling  bool // --no-dangling: не сообщать висячие объекты
+}
+
+// FsckProblem - одна строка отчета fsck
+type FsckProblem struct {
+	Kind   string             // Вид: FsckCorrupt, FsckMissing...
+	Type   objects.ObjectType // Тип объекта, если известен
+	ID     string             // Хеш объекта, имя ссылки или pack-файла
+	Detail string             // Пояснение
+}
+
+// String форматирует проблему для машинного разбора:
+// "<вид>\t<тип>\t<id>\t<пояснение>", пустые поля заменяются на "-"
+func (p FsckProblem) String() string {
+	fields := []string{p.Kind, string(p.Type), p.ID, p.Detail}
+	for i, field := range fields {
+		if field == "" {
+			fields[i] = "-"
+		}
+	}
+	return strings.Join(fields, "\t")
+}
+
+// FsckReport - итог fsck
+type FsckReport struct {
+	Checked     int           // Проверено объектов
+	Errors      []FsckProblem // Повреждения: corrupt, missing, wrongtype, badref, badpack
+	Dangling    []FsckProblem // Висячие объекты
+	Unreachable []FsckProblem // Все недостижимые объекты
+}
+
+// OK сообщает, что повреждений не найдено (висячие объекты повреждением не считаются)
+func (r *FsckReport) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// Fsck проверяет все объекты хранилища (отдельные файлы и pack-файлы): хеши,
+// заголовки, разбор деревьев, коммитов и тегов, наличие и типы объектов, на
+// которые они ссылаются, и ссылки. Печатает по строке на проблему (см. FsckProblem)
+func Fsck(repoPath string, opts FsckOptions) (*FsckReport, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	report := &FsckReport{}
+
+	// Все объекты хранилища
+	present := make(map[objects.Hash]bool)
+	loose, err := repo.Objects.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range loose {
+		present[hash] = true
+	}
+	packs, err := repo.Objects.VerifyPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, pack := range packs {
+		if pack.Err != nil {
+			report.Errors = append(report.Errors, FsckProblem{Kind: FsckBadPack, ID: pack.Name, Detail: pack.Err.Error()})
+		}
+		for _, hash := range pack.Objects {
+			present[hash] = true
+		}
+	}
+
+	// Каждый объект читается и разбирается; запоминаем тип и исходящие ссылки
+	types := make(map[objects.Hash]objects.ObjectType, len(present))
+	links := make(map[objects.Hash][]revwalk.Link, len(present))
+	for _, hash := range sortedHashes(present) {
+		report.Checked++
+		objType, obj, err := repo.Objects.CheckObject(hash)
+		if err != nil {
+			report.Errors = append(report.Errors, FsckProblem{Kind: FsckCorrupt, ID: hash.String(), Detail: err.Error()})
+			continue
+		}
+		types[hash] = objType
+		if obj != nil {
+			links[hash] = revwalk.Links(obj)
+		}
+	}
+
+	referenced := make(map[objects.Hash]bool)
+	for _, hash := range sortedHashes(present) {
+		for _, link := range links[hash] {
+			referenced[link.Hash] = true
+			from := fmt.Sprintf("referenced by %s %s", types[hash], hash)
+			if problem, ok := checkLink(present, types, link, from); ok {
+				report.Errors = append(report.Errors, problem)
+			}
+		}
+	}
+
+	// Достижимость от корней по уже собранному графу
+	roots, err := objectRoots(repo)
+	if err != nil {
+		return nil, err
+	}
+	reachable := make(map[objects.Hash]bool)
+	var stack []objects.Hash
+	for _, root := range roots {
+		link := revwalk.Link{Hash: root.hash, Type: root.objType}
+		if problem, bad := checkLink(present, types, link, "referenced by "+root.from); bad {
+			// Для ссылок и HEAD сообщаем саму ссылку, а не объект
+			if root.from == refs.HEAD || strings.HasPrefix(root.from, "refs/") {
+				detail := fmt.Sprintf("points to missing object %s", root.hash)
+				if problem.Kind == FsckWrongType {
+					detail = fmt.Sprintf("points to %s %s, expected %s", types[root.hash], root.hash, root.objType)
+				}
+				problem = FsckProblem{Kind: FsckBadRef, ID: root.from, Detail: detail}
+			}
+			report.Errors = append(report.Errors, problem)
+		}
+		stack = append(stack, root.hash)
+	}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reachable[hash] || !present[hash] {
+			continue
+		}
+		reachable[hash] = true
+		for _, link := range links[hash] {
+			stack = append(stack, link.Hash)
+		}
+	}
+
+	for _, hash := range sortedHashes(present) {
+		if reachable[hash] {
+			continue
+		}
+		problem := FsckProblem{Kind: FsckUnreachable, Type: types[hash], ID: hash.String()}
+		report.Unreachable = append(report.Unreachable, problem)
+		if !referenced[hash] {
+			problem.Kind = FsckDangling
+			report.Dangling = append(report.Dangling, problem)
+		}
+	}
+
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		if report.Errors[i].Kind != report.Errors[j].Kind {
+			return report.Errors[i].Kind < report.Errors[j].Kind
+		}
+		return report.Errors[i].ID < report.Errors[j].ID
+	})
+	for _, problem := range report.Errors {
+		fmt.Println(problem)
+	}
+	switch {
+	case opts.Unreachable:
+		for _, problem := range report.Unreachable {
+			fmt.Println(problem)
+		}
+	case !opts.NoDangling:
+		for _, problem := range report.Dangling {
+			fmt.Println(problem)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Checked %d objects, %d errors\n", report.Checked, len(report.Errors))
+
+	return report, nil
+}
+
+// checkLink проверяет, что объект ссылки есть в хранилище и имеет ожидаемый тип.
+// Поврежденный объект здесь не сообщается - о нем уже есть строка corrupt
+func checkLink(present map[objects.Hash]bool, types map[objects.Hash]objects.ObjectType, link revwalk.Link, from string) (FsckProblem, bool) {
+	if !present[link.Hash] {
+		return FsckProblem{Kind: FsckMissing, Type: link.Type, ID: link.Hash.String(), Detail: from}, true
+	}
+	if actual, ok := types[link.Hash]; ok && link.Type != "" && actual != link.Type {
+		detail := fmt.Sprintf("is a %s, expected %s; %s", actual, link.Type, from)
+		return FsckProblem{Kind: FsckWrongType, Type: actual, ID: link.Hash.String(), Detail: detail}, true
+	}
+	return FsckProblem{}, false
+}
+
+// sortedHashes возвращает ключи множества по возрастанию
+func sortedHashes(set map[objects.Hash]bool) []objects.Hash {
+	hashes := make([]objects.Hash, 0, len(set))
+	for hash := range set {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	return hashes
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/repository"
+)
+
+// hasProblem ищет в списке проблему заданного вида с заданным id
+func hasProblem(problems []FsckProblem, kind, id string) bool {
+	for _, problem := range problems {
+		if problem.Kind == kind && problem.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFsck(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir)
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n"})
+	commitFiles(t, tmpDir, "second", map[string]string{"a.txt": "a2\n"})
+
+	repo, err := repository.Discover(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Clean repository", func(t *testing.T) {
+		report, err := Fsck(tmpDir, FsckOptions{})
+		if err != nil {
+			t.Fatalf("Fsck failed: %v", err)
+		}
+		// 2 коммита, 3 дерева (корень дважды и dir), 3 blob
+		if !report.OK() || len(report.Dangling) != 0 || report.Checked != 8 {
+			t.Errorf("Report = %+v", report)
+		}
+	})
+
+	t.Run("Packed objects are checked too", func(t *testing.T) {
+		if _, err := Repack(tmpDir, RepackOptions{Delete: true}); err != nil {
+			t.Fatal(err)
+		}
+		report, err := Fsck(tmpDir, FsckOptions{})
+		if err != nil {
+			t.Fatalf("Fsck failed: %v", err)
+		}
+		if !report.OK() || report.Checked != 8 {
+			t.Errorf("Report = %+v", report)
+		}
+	})
+
+	t.Run("Dangling and unreachable objects", func(t *testing.T) {
+		blob, _ := repo.Objects.WriteObject(objects.NewBlob([]byte("orphan\n")))
+		tree := objects.NewTree()
+		entry, _ := objects.NewTreeEntry(objects.FileModeRegular, "orphan.txt", blob, objects.BlobObject)
+		tree.AddEntry(*entry)
+		treeHash, _ := repo.Objects.WriteObject(tree)
+
+		report, err := Fsck(tmpDir, FsckOptions{})
+		if err != nil {
+			t.Fatalf("Fsck failed: %v", err)
+		}
+		if !report.OK() {
+			t.Errorf("Dangling objects are not errors: %+v", report.Errors)
+		}
+		// Blob недостижим, но на него ссылается дерево - висячим считается только дерево
+		if !hasProblem(report.Dangling, FsckDangling, treeHash.String()) || hasProblem(report.Dangling, FsckDangling, blob.String()) {
+			t.Errorf("Dangling = %+v", report.Dangling)
+		}
+		if !hasProblem(report.Unreachable, FsckUnreachable, blob.String()) || len(report.Unreachable) != 2 {
+			t.Errorf("Unreachable = %+v", report.Unreachable)
+		}
+		repo.Objects.RemoveLoose(blob)
+		repo.Objects.RemoveLoose(treeHash)
+	})
+
+	t.Run("Refs to missing or non-commit objects", func(t *testing.T) {
+		missing := objects.Hash(strings.Repeat("ab", 32))
+		blob, _ := repo.Objects.WriteObject(objects.NewBlob([]byte("not a commit\n")))
+		repo.Refs.Set("refs/heads/missing", missing)
+		repo.Refs.Set("refs/heads/blob", blob)
+		defer repo.Refs.Delete("refs/heads/missing", "")
+		defer repo.Refs.Delete("refs/heads/blob", "")
+
+		report, err := Fsck(tmpDir, FsckOptions{})
+		if err != nil {
+			t.Fatalf("Fsck failed: %v", err)
+		}
+		if report.OK() || !hasProblem(report.Errors, FsckBadRef, "refs/heads/missing") || !hasProblem(report.Errors, FsckBadRef, "refs/heads/blob") {
+			t.Errorf("Errors = %+v", report.Errors)
+		}
+	})
+}
+
+func TestFsckDamagedObjects(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir)
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+
+	repo, err := repository.Discover(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashA, _ := repo.Objects.WriteObject(objects.NewBlob([]byte("a\n")))
+	hashB, _ := repo.Objects.WriteObject(objects.NewBlob([]byte("b\n")))
+
+	// a.txt удален, b.txt заменен мусором
+	repo.Objects.RemoveLoose(hashA)
+	pathB := repo.Path("objects", hashB.String()[:2], hashB.String()[2:])
+	os.Chmod(pathB, 0644)
+	if err := os.WriteFile(pathB, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Fsck(tmpDir, FsckOptions{})
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if report.OK() {
+		t.Fatal("Expected errors")
+	}
+	if !hasProblem(report.Errors, FsckMissing, hashA.String()) {
+		t.Errorf("Missing blob not reported: %+v", report.Errors)
+	}
+	if !hasProblem(report.Errors, FsckCorrupt, hashB.String()) || hasProblem(report.Errors, FsckMissing, hashB.String()) {
+		t.Errorf("Corrupt blob reported wrong: %+v", report.Errors)
+	}
+	for _, problem := range report.Errors {
+		if fields := strings.Split(problem.String(), "\t"); len(fields) != 4 {
+			t.Errorf("Line %q should have 4 fields", problem.String())
+		}
+	}
+}
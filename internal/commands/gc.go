@@ -0,0 +1,351 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"sib/internal/core/lockfile"
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/revwalk"
+)
+
+// defaultPruneExpire - порог возраста недостижимых объектов, если gc.pruneExpire не задан
+const defaultPruneExpire = "2.weeks.ago"
+
+// GCOptions - параметры gc
+type GCOptions struct {
+	Prune  string // --prune=<date>: удалять недостижимые объекты старше даты ("" - gc.pruneExpire)
+	DryRun bool   // --dry-run: только сообщить, что будет сделано
+}
+
+// PruneOptions - параметры prune
+type PruneOptions struct {
+	Expire string // --expire <date>: удалять недостижимые объекты старше даты ("" - gc.pruneExpire)
+	DryRun bool   // -n: только перечислить объекты, которые будут удалены
+}
+
+// GCResult - итог gc и prune. При DryRun - что было бы сделано
+type GCResult struct {
+	Pack      string // Имя нового pack-файла ("" - не создавался)
+	Packed    int    // Достижимые объекты, перенесенные в pack-файл
+	Pruned    int    // Удаленные недостижимые объекты
+	Kept      int    // Недостижимые объекты моложе порога, оставленные на месте
+	TempFiles int    // Удаленные временные файлы tmp-*
+	Commits   int    // Коммиты, записанные в commit-graph (только gc)
+	Reclaimed int64  // Освобожденное место в байтах (без учета упаковки при DryRun)
+}
+
+// GC упаковывает достижимые объекты, хранящиеся отдельными файлами, обновляет
+// commit-graph, удаляет недостижимые объекты старше порога и временные файлы
+// прерванных записей
+func GC(repoPath string, opts GCOptions) (*GCResult, error) {
+	return collectGarbage(repoPath, opts.Prune, true, opts.DryRun)
+}
+
+// Prune удаляет недостижимые объекты старше порога, ничего не упаковывая
+func Prune(repoPath string, opts PruneOptions) (*GCResult, error) {
+	return collectGarbage(repoPath, opts.Expire, false, opts.DryRun)
+}
+
+// collectGarbage - общая часть gc и prune
+func collectGarbage(repoPath, expireValue string, pack, dryRun bool) (*GCResult, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Два gc одновременно удаляли бы файлы друг у друга из-под рук
+	lock, err := lockfile.Acquire(repo.Path("gc"), 0)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	if expireValue == "" {
+		expireValue = defaultPruneExpire
+		if value, ok := repo.Config.Get("gc.pruneExpire"); ok {
+			expireValue = value
+		}
+	}
+	expire, err := parseExpire(expireValue, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	reachable, err := reachableObjects(repo)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine reachable objects, nothing was removed: %w", err)
+	}
+
+	loose, err := repo.Objects.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+	var toPack, unreachable []objects.Hash
+	for _, hash := range loose {
+		if _, ok := reachable[hash]; ok {
+			toPack = append(toPack, hash)
+		} else {
+			unreachable = append(unreachable, hash)
+		}
+	}
+
+	result := &GCResult{}
+	if pack && len(toPack) > 0 {
+		result.Packed = len(toPack)
+		if dryRun {
+			fmt.Printf("Would pack %d loose objects\n", len(toPack))
+		} else if err := packLoose(repo, toPack, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// Номера поколений всех достижимых коммитов - чтобы merge-base и
+	// проверки предков не обходили историю до корня
+	if pack && !dryRun {
+		var commits []objects.Hash
+		for hash, objType := range reachable {
+			if objType == objects.CommitObject {
+				commits = append(commits, hash)
+			}
+		}
+		if result.Commits, err = revwalk.WriteCommitGraph(repo.Objects, commits); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Wrote commit-graph with %d commits\n", result.Commits)
+	}
+
+	// Недостижимые объекты: старые удаляем, свежие могут принадлежать
+	// выполняющейся прямо сейчас команде, которая еще не записала ссылку
+	for _, hash := range unreachable {
+		info, err := repo.Objects.StatLoose(hash)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if expire.IsZero() || !info.ModTime().Before(expire) {
+			result.Kept++
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("Would prune %s\n", hash)
+		} else if err := repo.Objects.RemoveLoose(hash); err != nil {
+			return nil, err
+		}
+		result.Pruned++
+		result.Reclaimed += info.Size()
+	}
+
+	tempFiles, err := repo.Objects.TempFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range tempFiles {
+		info, err := os.Stat(path)
+		if err != nil || expire.IsZero() || !info.ModTime().Before(expire) {
+			continue
+		}
+		if dryRun {
+			fmt.Printf("Would remove %s\n", path)
+		} else if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove temporary file: %w", err)
+		}
+		result.TempFiles++
+		result.Reclaimed += info.Size()
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("%s %d unreachable objects and %d temporary files", verb, result.Pruned, result.TempFiles)
+	if result.Kept > 0 {
+		fmt.Printf(" (%d recent unreachable objects kept)", result.Kept)
+	}
+	fmt.Println()
+	if dryRun {
+		fmt.Printf("Would reclaim %s\n", formatSize(result.Reclaimed))
+	} else {
+		fmt.Printf("Reclaimed %s\n", formatSize(result.Reclaimed))
+	}
+	return result, nil
+}
+
+// packLoose упаковывает объекты и удаляет их отдельные файлы
+func packLoose(repo *repository.Repository, hashes []objects.Hash, result *GCResult) error {
+	var looseSize int64
+	for _, hash := range hashes {
+		if info, err := repo.Objects.StatLoose(hash); err == nil {
+			looseSize += info.Size()
+		}
+	}
+
+	name, err := repo.Objects.WritePack(hashes)
+	if err != nil {
+		return err
+	}
+	if _, err := repo.Objects.PrunePacked(); err != nil {
+		return err
+	}
+	result.Pack = name
+	fmt.Printf("Packed %d objects into %s\n", len(hashes), name)
+
+	if info, err := os.Stat(repo.Path("objects", "pack", name+".pack")); err == nil {
+		result.Reclaimed += looseSize - info.Size()
+	}
+	return nil
+}
+
+// fullHashPattern находит полные хеши в файлах состояния незавершенных операций
+var fullHashPattern = regexp.MustCompile(`\b[0-9a-f]{64}\b`)
+
+// objectRoot - стартовая точка поиска достижимых объектов
+type objectRoot struct {
+	hash    objects.Hash
+	objType objects.ObjectType // "" - любой тип
+	from    string             // Откуда взят хеш: имя ссылки, путь в индексе, файл состояния
+}
+
+// reachableObjects возвращает объекты, достижимые из objectRoots
+func reachableObjects(repo *repository.Repository) (map[objects.Hash]objects.ObjectType, error) {
+	roots, err := objectRoots(repo)
+	if err != nil {
+		return nil, err
+	}
+	walker := revwalk.NewObjectWalker(repo.Objects)
+	for _, root := range roots {
+		walker.Push(root.hash, root.objType, root.from)
+	}
+	return walker.Walk()
+}
+
+// objectRoots собирает корни достижимости: ссылки, HEAD, индекс и файлы
+// состояния незавершенных merge, cherry-pick, revert и rebase.
+// Reflog и stash в sib пока нет - когда появятся, их записи тоже станут корнями
+func objectRoots(repo *repository.Repository) ([]objectRoot, error) {
+	var roots []objectRoot
+
+	allRefs, err := repo.Refs.List("refs/")
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range allRefs {
+		if ref.IsSymbolic() {
+			continue
+		}
+		// Ветки указывают только на коммиты, теги - на что угодно
+		var objType objects.ObjectType
+		if strings.HasPrefix(ref.Name, refs.HeadsPrefix) {
+			objType = objects.CommitObject
+		}
+		roots = append(roots, objectRoot{ref.Target, objType, ref.Name})
+	}
+	if _, head, err := repo.Refs.Head(); err != nil {
+		return nil, err
+	} else if !head.IsEmpty() {
+		roots = append(roots, objectRoot{head, objects.CommitObject, refs.HEAD})
+	}
+
+	idx, err := repo.Index()
+	if err != nil {
+		return nil, err
+	}
+	for path, entry := range idx.Entries {
+		roots = append(roots, objectRoot{objects.Hash(entry.Hash), objects.BlobObject, "index entry " + path})
+	}
+	for path, stages := range idx.Unmerged {
+		for _, entry := range stages {
+			roots = append(roots, objectRoot{objects.Hash(entry.Hash), objects.BlobObject, "index entry " + path})
+		}
+	}
+
+	stateFiles := []string{origHeadFile, mergeHeadFile, cherryPickHeadFile, revertHeadFile}
+	for _, dir := range []string{sequencerDir, rebaseDir} {
+		entries, err := os.ReadDir(repo.Path(dir))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				stateFiles = append(stateFiles, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	for _, name := range stateFiles {
+		data, err := os.ReadFile(repo.Path(name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range fullHashPattern.FindAllString(string(data), -1) {
+			if hash := objects.Hash(token); repo.Objects.ObjectExists(hash) {
+				roots = append(roots, objectRoot{hash, "", filepath.ToSlash(name)})
+			}
+		}
+	}
+
+	return roots, nil
+}
+
+// parseExpire разбирает порог возраста: "now", "never", "<n>.<unit>.ago"
+// (как "2.weeks.ago" или "3 days ago") или дату "2006-01-02".
+// Нулевое время означает "никогда не удалять"
+func parseExpire(value string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "never", "false":
+		return time.Time{}, nil
+	case "now", "all":
+		return now, nil
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool { return r == '.' || r == ' ' })
+	if len(fields) == 3 && fields[2] == "ago" {
+		n, err := strconv.Atoi(fields[0])
+		unit, ok := expireUnits[strings.TrimSuffix(fields[1], "s")]
+		if err == nil && ok && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry date '%s'", value)
+}
+
+// expireUnits - единицы "<n>.<unit>.ago"; месяц и год приблизительные, как в git
+var expireUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// formatSize печатает размер в байтах в удобных единицах
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.2f GiB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.2f MiB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.2f KiB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/repository"
+)
+
+// looseObject записывает blob и делает его файл старше на age
+func looseObject(t *testing.T, repo *repository.Repository, content string, age time.Duration) objects.Hash {
+	t.Helper()
+	hash, err := repo.Objects.WriteObject(objects.NewBlob([]byte(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-age)
+	path := repo.Path("objects", hash.String()[:2], hash.String()[2:])
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestGC(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir)
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n"})
+	writeFiles(t, tmpDir, map[string]string{"staged.txt": "staged\n"})
+	if err := Add(tmpDir, []string{"staged.txt"}, AddOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := repository.Discover(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	month := 30 * 24 * time.Hour
+	garbage := looseObject(t, repo, "garbage\n", month)
+	recent := looseObject(t, repo, "recent garbage\n", 0)
+	tmpFile := repo.Path("objects", "ab", "tmp-123")
+	os.MkdirAll(filepath.Dir(tmpFile), 0755)
+	os.WriteFile(tmpFile, []byte("partial"), 0644)
+	old := time.Now().Add(-month)
+	os.Chtimes(tmpFile, old, old)
+
+	t.Run("Dry run changes nothing", func(t *testing.T) {
+		result, err := GC(tmpDir, GCOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("GC --dry-run failed: %v", err)
+		}
+		if result.Pruned != 1 || result.Kept != 1 || result.TempFiles != 1 || result.Reclaimed == 0 {
+			t.Errorf("Result = %+v", result)
+		}
+		if !repo.Objects.ObjectExists(garbage) {
+			t.Error("Dry run removed an object")
+		}
+		if _, err := os.Stat(tmpFile); err != nil {
+			t.Error("Dry run removed a temporary file")
+		}
+	})
+
+	t.Run("Packs reachable and prunes old unreachable objects", func(t *testing.T) {
+		result, err := GC(tmpDir, GCOptions{})
+		if err != nil {
+			t.Fatalf("GC failed: %v", err)
+		}
+		if result.Pack == "" || result.Pruned != 1 || result.TempFiles != 1 || result.Commits != 1 {
+			t.Errorf("Result = %+v", result)
+		}
+		if graph, err := repo.Objects.ReadCommitGraph(); err != nil || graph.Count() != 1 {
+			t.Errorf("commit-graph = %v, %v", graph, err)
+		}
+		if repo.Objects.ObjectExists(garbage) {
+			t.Error("Old unreachable object should be pruned")
+		}
+		if !repo.Objects.ObjectExists(recent) {
+			t.Error("Recent unreachable object should be kept")
+		}
+		if loose, _ := repo.Objects.LooseObjects(); len(loose) != 1 || loose[0] != recent {
+			t.Errorf("Only the recent object should stay loose, got %v", loose)
+		}
+
+		// История, индекс и рабочий каталог по-прежнему читаются
+		if got := messages(t, tmpDir, LogOptions{}); got != "base" {
+			t.Errorf("History = %q", got)
+		}
+		commitFiles(t, tmpDir, "after gc", map[string]string{"a.txt": "changed\n"})
+		status, err := CollectStatus(tmpDir)
+		if err != nil || !status.IsClean() {
+			t.Errorf("Status after gc: %+v, %v", status, err)
+		}
+	})
+
+	t.Run("Prune with expire now", func(t *testing.T) {
+		result, err := Prune(tmpDir, PruneOptions{Expire: "now"})
+		if err != nil {
+			t.Fatalf("Prune failed: %v", err)
+		}
+		if result.Pruned != 1 || result.Pack != "" {
+			t.Errorf("Result = %+v", result)
+		}
+		if repo.Objects.ObjectExists(recent) {
+			t.Error("Unreachable object should be pruned with --expire=now")
+		}
+	})
+}
+
+func TestParseExpire(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+	cases := map[string]time.Time{
+		"now":            now,
+		"never":          {},
+		"2.weeks.ago":    now.Add(-14 * 24 * time.Hour),
+		"1 day ago":      now.Add(-24 * time.Hour),
+		"30.minutes.ago": now.Add(-30 * time.Minute),
+		"2024-01-02":     time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local),
+	}
+	for value, want := range cases {
+		if got, err := parseExpire(value, now); err != nil || !got.Equal(want) {
+			t.Errorf("parseExpire(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "soon", "2.fortnights.ago", "-1.days.ago"} {
+		if _, err := parseExpire(bad, now); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/pathspec"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/revwalk"
+	"sib/internal/core/storage"
+	"sib/internal/core/treediff"
+)
+
+// LogOptions - параметры команды log
+type LogOptions struct {
+	Revisions []string  // Стартовые точки (по умолчанию HEAD)
+	Paths     []string  // Показывать только коммиты, меняющие эти пути
+	MaxCount  int       // -n: максимальное число коммитов (0 - без ограничения)
+	Oneline   bool      // --oneline: одна строка на коммит
+	Author    string    // --author: регулярное выражение по "Name <email>"
+	Grep      string    // --grep: регулярное выражение по сообщению
+	Since     time.Time // --since: только коммиты не старше
+	Until     time.Time // --until: только коммиты не новее
+	Topo      bool      // --topo-order: топологический порядок вместо порядка по дате
+}
+
+// Log печатает историю коммитов и возвращает показанные коммиты
+func Log(repoPath string, opts LogOptions) ([]*objects.Commit, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	store := repo.Objects
+	refStore := repo.Refs
+	parser := newRevParser(repo)
+
+	var authorRe, grepRe *regexp.Regexp
+	if opts.Author != "" {
+		if authorRe, err = regexp.Compile(opts.Author); err != nil {
+			return nil, fmt.Errorf("invalid --author pattern: %w", err)
+		}
+	}
+	if opts.Grep != "" {
+		if grepRe, err = regexp.Compile(opts.Grep); err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+	}
+
+	order := revwalk.OrderDate
+	if opts.Topo {
+		order = revwalk.OrderTopo
+	}
+	walker := revwalk.NewWalker(store, order)
+
+	revisions := opts.Revisions
+	if len(revisions) == 0 {
+		revisions = []string{refs.HEAD}
+	}
+	if len(opts.Revisions) == 0 {
+		branch, head, err := refStore.Head()
+		if err != nil {
+			return nil, err
+		}
+		if head.IsEmpty() {
+			return nil, fmt.Errorf("your current branch '%s' does not have any commits yet", refDisplayName(branch))
+		}
+	}
+	for _, rev := range revisions {
+		rng, err := parser.ParseRange(rev)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range rng.Include {
+			commitHash, err := parser.ResolveCommit(hash.String())
+			if err != nil {
+				return nil, err
+			}
+			if err := walker.Push(commitHash); err != nil {
+				return nil, err
+			}
+		}
+		for _, hash := range rng.Exclude {
+			if err := walker.Hide(hash); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	spec, err := pathspec.ParseRelative(repo.Prefix, opts.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var shown []*objects.Commit
+	err = walker.ForEach(func(commit *objects.Commit) error {
+		if opts.MaxCount > 0 && len(shown) >= opts.MaxCount {
+			return errStopWalk
+		}
+
+		committer := commit.Committer()
+		when := committer.Time()
+		if !opts.Since.IsZero() && when.Before(opts.Since) {
+			return nil
+		}
+		if !opts.Until.IsZero() && when.After(opts.Until) {
+			return nil
+		}
+
+		if authorRe != nil {
+			author := commit.Author()
+			if !authorRe.MatchString(fmt.Sprintf("%s <%s>", author.Name(), author.Email())) {
+				return nil
+			}
+		}
+		if grepRe != nil && !grepRe.MatchString(commit.Message()) {
+			return nil
+		}
+
+		if !spec.IsEmpty() {
+			touches, err := commitTouchesPaths(store, commit, spec)
+			if err != nil {
+				return err
+			}
+			if !touches {
+				return nil
+			}
+		}
+
+		shown = append(shown, commit)
+		printLogEntry(commit, opts.Oneline)
+		return nil
+	})
+	if err != nil && err != errStopWalk {
+		return nil, err
+	}
+
+	return shown, nil
+}
+
+// errStopWalk останавливает обход истории досрочно
+var errStopWalk = errors.New("stop walk")
+
+// commitTouchesPaths проверяет, меняет ли коммит хотя бы один из выбранных путей.
+// Коммит-слияние считается меняющим путь, только если он отличается от всех родителей
+func commitTouchesPaths(store *storage.ObjectStore, commit *objects.Commit, spec *pathspec.Pathspec) (bool, error) {
+	if commit.IsRoot() {
+		return treeChangesMatch(store, "", commit.Tree(), spec)
+	}
+
+	for _, parentHash := range commit.Parents() {
+		parent, err := store.ReadCommit(parentHash)
+		if err != nil {
+			return false, err
+		}
+		touches, err := treeChangesMatch(store, parent.Tree(), commit.Tree(), spec)
+		if err != nil || !touches {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// treeChangesMatch проверяет, что среди различий двух деревьев есть выбранный путь
+func treeChangesMatch(store *storage.ObjectStore, oldTree, newTree objects.Hash, spec *pathspec.Pathspec) (bool, error) {
+	changes, err := treediff.Trees(store, oldTree, newTree, treediff.Options{})
+	if err != nil {
+		return false, err
+	}
+	for _, change := range changes {
+		if spec.Match(change.Path()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// printLogEntry печатает один коммит
+func printLogEntry(commit *objects.Commit, oneline bool) {
+	if oneline {
+		fmt.Printf("%s %s\n", shortHash(commit.Hash()), firstLine(commit.Message()))
+		return
+	}
+
+	fmt.Printf("commit %s\n", commit.Hash())
+	if commit.IsMerge() {
+		short := make([]string, 0, len(commit.Parents()))
+		for _, parent := range commit.Parents() {
+			short = append(short, shortHash(parent))
+		}
+		fmt.Printf("Merge: %s\n", strings.Join(short, " "))
+	}
+
+	author := commit.Author()
+	fmt.Printf("Author: %s <%s>\n", author.Name(), author.Email())
+	fmt.Printf("Date:   %s\n\n", author.Time().Format("Mon Jan 2 15:04:05 2006 -0700"))
+
+	for _, line := range strings.Split(commit.Message(), "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+	fmt.Println()
+}
+
+// relativeDateRe разбирает даты вида "2 weeks ago" или "3.days"
+var relativeDateRe = regexp.MustCompile(`^(\d+)[ .]*(second|minute|hour|day|week|month|year)s?(?:[ .]+ago)?$`)
+
+// ParseDate разбирает дату для --since/--until:
+// "2006-01-02", "2006-01-02 15:04:05", RFC 3339, unix-время или "N days ago"
+func ParseDate(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+
+	switch lower {
+	case "now":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	if match := relativeDateRe.FindStringSubmatch(lower); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -n), nil
+		case "week":
+			return now.AddDate(0, 0, -7*n), nil
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		case "year":
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date: %q", value)
+}
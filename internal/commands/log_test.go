@@ -0,0 +1,145 @@
+// internal/commands/log_test.go
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// commitFiles записывает файлы, индексирует их и создает коммит
+func commitFiles(t *testing.T, repoPath string, message string, files map[string]string) {
+	writeFiles(t, repoPath, files)
+	if err := Add(repoPath, nil, AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := Commit(repoPath, CommitOptions{Message: message}); err != nil {
+		t.Fatalf("Commit %q failed: %v", message, err)
+	}
+}
+
+// messages возвращает первые строки сообщений через пробел
+func messages(t *testing.T, repoPath string, opts LogOptions) string {
+	commits, err := Log(repoPath, opts)
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	var result []string
+	for _, c := range commits {
+		result = append(result, firstLine(c.Message()))
+	}
+	return strings.Join(result, " ")
+}
+
+func TestLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("No commits yet", func(t *testing.T) {
+		if _, err := Log(tmpDir, LogOptions{}); err == nil {
+			t.Error("Expected error on unborn branch")
+		}
+	})
+
+	commitFiles(t, tmpDir, "add readme", map[string]string{"README.md": "# v1"})
+	commitFiles(t, tmpDir, "add source\n\nfixes #42", map[string]string{"src/main.go": "package main"})
+	commitFiles(t, tmpDir, "update readme", map[string]string{"README.md": "# version 2"})
+
+	t.Run("Full history newest first", func(t *testing.T) {
+		got := messages(t, tmpDir, LogOptions{})
+		if got != "update readme add source add readme" {
+			t.Errorf("Log = %q", got)
+		}
+	})
+
+	t.Run("Max count", func(t *testing.T) {
+		got := messages(t, tmpDir, LogOptions{MaxCount: 2})
+		if got != "update readme add source" {
+			t.Errorf("Log -n 2 = %q", got)
+		}
+	})
+
+	t.Run("Grep", func(t *testing.T) {
+		got := messages(t, tmpDir, LogOptions{Grep: "#42"})
+		if got != "add source" {
+			t.Errorf("Log --grep = %q", got)
+		}
+	})
+
+	t.Run("Author", func(t *testing.T) {
+		if got := messages(t, tmpDir, LogOptions{Author: "^nobody-matches-this$"}); got != "" {
+			t.Errorf("Log --author = %q, expected nothing", got)
+		}
+	})
+
+	t.Run("Since in the future", func(t *testing.T) {
+		got := messages(t, tmpDir, LogOptions{Since: time.Now().Add(time.Hour)})
+		if got != "" {
+			t.Errorf("Log --since = %q, expected nothing", got)
+		}
+	})
+
+	t.Run("Path limiting", func(t *testing.T) {
+		if got := messages(t, tmpDir, LogOptions{Paths: []string{"README.md"}}); got != "update readme add readme" {
+			t.Errorf("Log -- README.md = %q", got)
+		}
+		if got := messages(t, tmpDir, LogOptions{Paths: []string{"src"}}); got != "add source" {
+			t.Errorf("Log -- src = %q", got)
+		}
+	})
+
+	t.Run("Unknown revision", func(t *testing.T) {
+		if _, err := Log(tmpDir, LogOptions{Revisions: []string{"nope"}}); err == nil {
+			t.Error("Expected error for unknown revision")
+		}
+	})
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2 days ago", now.AddDate(0, 0, -2)},
+		{"1 week ago", now.AddDate(0, 0, -7)},
+		{"3.hours", now.Add(-3 * time.Hour)},
+		{"2024-01-02T10:00:00Z", time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDate(tt.input, now)
+		if err != nil {
+			t.Errorf("ParseDate(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseDate("not a date", now); err == nil {
+		t.Error("Expected error for invalid date")
+	}
+}
+
+func TestLogRange(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	commitFiles(t, tmpDir, "one", map[string]string{"a.txt": "1"})
+	commitFiles(t, tmpDir, "two", map[string]string{"a.txt": "22"})
+	commitFiles(t, tmpDir, "three", map[string]string{"a.txt": "333"})
+
+	if got := messages(t, tmpDir, LogOptions{Revisions: []string{"HEAD~2..HEAD"}}); got != "three two" {
+		t.Errorf("Log HEAD~2..HEAD = %q", got)
+	}
+	if got := messages(t, tmpDir, LogOptions{Revisions: []string{"HEAD~1"}}); got != "two one" {
+		t.Errorf("Log HEAD~1 = %q", got)
+	}
+}
@@ -0,0 +1,485 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sib/internal/core/index"
+	"sib/internal/core/merge"
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/revwalk"
+	"sib/internal/core/storage"
+)
+
+// Файлы состояния незавершенного слияния в .sib
+const (
+	mergeHeadFile = "MERGE_HEAD" // Сливаемый коммит (второй родитель будущего коммита)
+	mergeMsgFile  = "MERGE_MSG"  // Подготовленное сообщение коммита
+	origHeadFile  = "ORIG_HEAD"  // HEAD до начала операции
+)
+
+// ErrMergeConflict - слияние остановилось на конфликтах, которые нужно разрешить вручную
+var ErrMergeConflict = errors.New("automatic merge failed; fix conflicts and then commit the result")
+
+// MergeOptions - параметры команды merge
+type MergeOptions struct {
+	Message string // -m: сообщение коммита слияния
+	NoFF    bool   // --no-ff: создавать коммит слияния даже при возможной перемотке
+	FFOnly  bool   // --ff-only: только перемотка, иначе ошибка
+	Diff3   bool   // Маркеры конфликта в стиле diff3 (с версией базы)
+}
+
+// Merge сливает ревизию rev в текущую ветку.
+// Возвращает новый HEAD; при конфликтах - текущий HEAD и ErrMergeConflict
+func Merge(repoPath, rev string, opts MergeOptions) (objects.Hash, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return "", err
+	}
+	if opts.NoFF && opts.FFOnly {
+		return "", fmt.Errorf("--no-ff and --ff-only are mutually exclusive")
+	}
+
+	if mergeHead, err := readStateFile(repo.SibDir, mergeHeadFile); err != nil {
+		return "", err
+	} else if mergeHead != "" {
+		return "", fmt.Errorf("you have not concluded your merge (MERGE_HEAD exists)")
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return "", err
+	}
+	defer idx.Unlock()
+	if idx.HasConflicts() {
+		return "", fmt.Errorf("merging is not possible because you have unmerged files")
+	}
+
+	parser := newRevParser(repo)
+	theirs, err := parser.ResolveCommit(rev)
+	if err != nil {
+		return "", err
+	}
+	theirsCommit, err := repo.Objects.ReadCommit(theirs)
+	if err != nil {
+		return "", err
+	}
+
+	branch, head, err := repo.Refs.Head()
+	if err != nil {
+		return "", err
+	}
+
+	// Ветка без коммитов: просто встаем на сливаемый коммит
+	if head.IsEmpty() {
+		return fastForward(repo, idx, branch, head, "", theirsCommit)
+	}
+
+	headCommit, err := repo.Objects.ReadCommit(head)
+	if err != nil {
+		return "", err
+	}
+
+	bases, err := parser.MergeBases(head, theirs)
+	if err != nil {
+		return "", err
+	}
+	if len(bases) == 0 {
+		return "", fmt.Errorf("refusing to merge unrelated histories")
+	}
+	for _, base := range bases {
+		if base == theirs {
+			fmt.Println("Already up to date.")
+			return head, nil
+		}
+	}
+	if bases[0] == head && !opts.NoFF {
+		return fastForward(repo, idx, branch, head, headCommit.Tree(), theirsCommit)
+	}
+	if opts.FFOnly {
+		return "", fmt.Errorf("not possible to fast-forward, aborting")
+	}
+
+	// Для слияния индекс должен совпадать с HEAD: иначе результат смешается с подготовленными изменениями
+	headFiles, err := flattenCommitTree(repo.Objects, headCommit.Tree())
+	if err != nil {
+		return "", err
+	}
+	if staged := diffIndexAgainstTree(idx, headFiles); len(staged) > 0 {
+		paths := make([]string, 0, len(staged))
+		for _, change := range staged {
+			paths = append(paths, change.Path)
+		}
+		return "", fmt.Errorf("%w by merge:\n\t%s\nPlease commit your changes before you merge",
+			ErrLocalChanges, strings.Join(paths, "\n\t"))
+	}
+
+	baseTree, err := mergeBaseTree(repo, revwalk.NewGraph(repo.Objects), bases)
+	if err != nil {
+		return "", err
+	}
+
+	style := merge.StyleMerge
+	if opts.Diff3 {
+		style = merge.StyleDiff3
+	}
+	result, err := merge.Trees(repo.Objects, baseTree, headCommit.Tree(), theirsCommit.Tree(), merge.Options{
+		Style:  style,
+		Labels: merge.Labels{Base: "merged common ancestors", Ours: "HEAD", Theirs: rev},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := applyMergeResult(repo, idx, "merge", headFiles, result); err != nil {
+		return "", err
+	}
+	if err := idx.Save(); err != nil {
+		return "", fmt.Errorf("failed to save index: %w", err)
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = defaultMergeMessage(repo.Refs, rev, branch)
+	}
+
+	if err := writeStateFile(repo.SibDir, origHeadFile, head.String()); err != nil {
+		return "", err
+	}
+	if err := writeStateFile(repo.SibDir, mergeHeadFile, theirs.String()); err != nil {
+		return "", err
+	}
+
+	if len(result.Conflicts) > 0 {
+		var msg strings.Builder
+		msg.WriteString(message)
+		msg.WriteString("\n\n# Conflicts:\n")
+		for _, conflict := range result.Conflicts {
+			printConflict(conflict)
+			fmt.Fprintf(&msg, "#\t%s\n", conflict.Path)
+		}
+		if err := writeStateFile(repo.SibDir, mergeMsgFile, msg.String()); err != nil {
+			return "", err
+		}
+		fmt.Println("Automatic merge failed; fix conflicts and then commit the result.")
+		return head, ErrMergeConflict
+	}
+
+	if err := writeStateFile(repo.SibDir, mergeMsgFile, message); err != nil {
+		return "", err
+	}
+	fmt.Println("Merge made by the 'three-way' strategy.")
+	return createCommit(repo, CommitOptions{})
+}
+
+// MergeContinue завершает слияние после разрешения конфликтов
+func MergeContinue(repoPath string) (objects.Hash, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return "", err
+	}
+	mergeHead, err := readStateFile(repo.SibDir, mergeHeadFile)
+	if err != nil {
+		return "", err
+	}
+	if mergeHead == "" {
+		return "", fmt.Errorf("there is no merge in progress (MERGE_HEAD missing)")
+	}
+	return createCommit(repo, CommitOptions{})
+}
+
+// MergeAbort отменяет незавершенное слияние: индекс и рабочий каталог
+// возвращаются к HEAD, файлы состояния удаляются
+func MergeAbort(repoPath string) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	mergeHead, err := readStateFile(repo.SibDir, mergeHeadFile)
+	if err != nil {
+		return err
+	}
+	if mergeHead == "" {
+		return fmt.Errorf("there is no merge to abort (MERGE_HEAD missing)")
+	}
+
+	_, head, err := repo.Refs.Head()
+	if err != nil {
+		return err
+	}
+	var headTree objects.Hash
+	if !head.IsEmpty() {
+		commit, err := repo.Objects.ReadCommit(head)
+		if err != nil {
+			return err
+		}
+		headTree = commit.Tree()
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+	if err := resetToTree(repo, idx, headTree); err != nil {
+		return err
+	}
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	return removeStateFiles(repo.SibDir, mergeHeadFile, mergeMsgFile)
+}
+
+// resetToTree приводит индекс и рабочий каталог к дереву treeHash,
+// включая пути с неразрешенными конфликтами
+func resetToTree(repo *repository.Repository, idx *index.Index, treeHash objects.Hash) error {
+	treeFiles, err := flattenCommitTree(repo.Objects, treeHash)
+	if err != nil {
+		return err
+	}
+
+	// Конфликтующие пути, которых нет в дереве, checkoutTree не увидит - удаляем сами
+	for _, path := range idx.UnmergedPaths() {
+		if err := idx.Remove(path); err != nil {
+			return err
+		}
+		if _, ok := treeFiles[path]; !ok {
+			if err := removeWorktreeFile(repo.WorkTree, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return checkoutTree(repo.WorkTree, repo.Objects, idx, treeHash, treeHash, true)
+}
+
+// fastForward перематывает текущую ветку (или отсоединенный HEAD) на коммит target
+func fastForward(repo *repository.Repository, idx *index.Index, branch string, head, headTree objects.Hash, target *objects.Commit) (objects.Hash, error) {
+	if err := checkoutTree(repo.WorkTree, repo.Objects, idx, headTree, target.Tree(), false); err != nil {
+		return "", err
+	}
+	if err := idx.Save(); err != nil {
+		return "", fmt.Errorf("failed to save index: %w", err)
+	}
+
+	if err := advanceHead(repo.Refs, branch, head, target.Hash()); err != nil {
+		return "", err
+	}
+	if !head.IsEmpty() {
+		if err := writeStateFile(repo.SibDir, origHeadFile, head.String()); err != nil {
+			return "", err
+		}
+		fmt.Printf("Updating %s..%s\n", shortHash(head), shortHash(target.Hash()))
+	}
+	fmt.Println("Fast-forward")
+
+	return target.Hash(), nil
+}
+
+// advanceHead передвигает ветку или отсоединенный HEAD, только если их никто не сдвинул
+func advanceHead(refStore *refs.RefStore, branch string, oldHash, newHash objects.Hash) error {
+	targetRef := refs.HEAD
+	if branch != "" {
+		targetRef = branch
+	}
+	if err := refStore.CompareAndSwap(targetRef, oldHash, newHash); err != nil {
+		return fmt.Errorf("failed to update %s: %w", refDisplayName(branch), err)
+	}
+	return nil
+}
+
+// mergeBaseTree возвращает дерево общей базы слияния. Если лучших общих предков
+// несколько (criss-cross), они по очереди сливаются между собой в виртуальную
+// базу, как в рекурсивной стратегии git: база каждого такого слияния строится
+// так же, а неразрешенные конфликты остаются в виртуальной базе с маркерами
+func mergeBaseTree(repo *repository.Repository, graph *revwalk.Graph, bases []objects.Hash) (objects.Hash, error) {
+	first, err := repo.Objects.ReadCommit(bases[0])
+	if err != nil {
+		return "", err
+	}
+	tree := first.Tree()
+
+	for i := 1; i < len(bases); i++ {
+		next, err := repo.Objects.ReadCommit(bases[i])
+		if err != nil {
+			return "", err
+		}
+
+		// Уже слитые предки - родители виртуального коммита
+		inner, err := graph.MergeBases(bases[i], bases[:i]...)
+		if err != nil {
+			return "", err
+		}
+		var innerTree objects.Hash
+		if len(inner) > 0 {
+			if innerTree, err = mergeBaseTree(repo, graph, inner); err != nil {
+				return "", err
+			}
+		}
+
+		result, err := merge.Trees(repo.Objects, innerTree, tree, next.Tree(), merge.Options{
+			Labels: merge.Labels{Base: "merged common ancestors", Ours: "Temporary merge branch 1", Theirs: "Temporary merge branch 2"},
+		})
+		if err != nil {
+			return "", err
+		}
+		if tree, err = result.WriteTree(repo.Objects); err != nil {
+			return "", err
+		}
+	}
+
+	return tree, nil
+}
+
+// applyMergeResult записывает результат слияния в индекс и рабочий каталог.
+// ourFiles - дерево, поверх которого применяется результат; op - имя операции для сообщений.
+// Слитые файлы попадают в индекс обычными записями, конфликты - стадиями 1-3
+// и файлом с маркерами в рабочем каталоге (при file/directory - под другим именем)
+func applyMergeResult(repo *repository.Repository, idx *index.Index, op string, ourFiles map[string]storage.TreeFile, result *merge.Result) error {
+	// Конфликтующие пути тоже будут перезаписаны: отмечаем их как цель с неизвестным содержимым
+	targetFiles := make(map[string]storage.TreeFile, len(result.Files)+len(result.Conflicts))
+	for path, file := range result.Files {
+		targetFiles[path] = file
+	}
+	for _, conflict := range result.Conflicts {
+		targetFiles[conflict.WorktreePath()] = storage.TreeFile{Path: conflict.WorktreePath(), Mode: conflict.Mode}
+	}
+
+	paths := make(map[string]bool)
+	for path, ours := range ourFiles {
+		if file, ok := targetFiles[path]; !ok || file.Hash != ours.Hash || file.Mode != ours.Mode {
+			paths[path] = true
+		}
+	}
+	for path := range targetFiles {
+		if _, ok := ourFiles[path]; !ok {
+			paths[path] = true
+		}
+	}
+	changed := make([]string, 0, len(paths))
+	for path := range paths {
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+
+	if err := checkLocalChanges(repo.WorkTree, idx, op, changed, ourFiles, targetFiles); err != nil {
+		return err
+	}
+
+	// Сначала удаления: на месте файла мог появиться каталог
+	for _, path := range changed {
+		if _, ok := targetFiles[path]; ok {
+			continue
+		}
+		if err := removeWorktreeFile(repo.WorkTree, path); err != nil {
+			return err
+		}
+		if _, err := idx.Get(path); err == nil {
+			if err := idx.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, path := range changed {
+		file, ok := result.Files[path]
+		if !ok {
+			continue
+		}
+		if err := checkoutFile(repo.WorkTree, repo.Objects, idx, path, file.Mode, file.Hash); err != nil {
+			return err
+		}
+	}
+
+	for _, conflict := range result.Conflicts {
+		fullPath := filepath.Join(repo.WorkTree, filepath.FromSlash(conflict.WorktreePath()))
+		if err := writeWorktreeFile(fullPath, conflict.Mode, conflict.Content); err != nil {
+			return err
+		}
+
+		var stages []index.IndexEntry
+		for _, side := range []struct {
+			file  *storage.TreeFile
+			stage int
+		}{
+			{conflict.Base, index.StageBase},
+			{conflict.Ours, index.StageOurs},
+			{conflict.Theirs, index.StageTheirs},
+		} {
+			if side.file == nil {
+				continue
+			}
+			stages = append(stages, index.IndexEntry{
+				Hash:  side.file.Hash.String(),
+				Mode:  string(side.file.Mode),
+				Stage: side.stage,
+			})
+		}
+		if err := idx.SetConflict(conflict.Path, stages...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printConflict сообщает о конфликте слияния в формате git
+func printConflict(conflict merge.Conflict) {
+	if conflict.Kind == merge.ConflictFileDirectory {
+		fmt.Printf("CONFLICT (%s): directory in the way of %s; moving it to %s instead\n",
+			conflict.Kind, conflict.Path, conflict.Renamed)
+		return
+	}
+	fmt.Printf("CONFLICT (%s): Merge conflict in %s\n", conflict.Kind, conflict.Path)
+}
+
+// defaultMergeMessage формирует сообщение вида "Merge branch 'x' into y"
+func defaultMergeMessage(refStore *refs.RefStore, rev, branch string) string {
+	message := fmt.Sprintf("Merge commit '%s'", rev)
+	if refStore.Exists(refs.HeadsPrefix + rev) {
+		message = fmt.Sprintf("Merge branch '%s'", rev)
+	}
+	if name := refDisplayName(branch); branch != "" && name != "master" && name != "main" {
+		message += " into " + name
+	}
+	return message
+}
+
+// readStateFile читает файл состояния операции из .sib; пустая строка - файла нет
+func readStateFile(sibDir, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(sibDir, name))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// writeStateFile записывает файл состояния операции в .sib
+func writeStateFile(sibDir, name, content string) error {
+	path := filepath.Join(sibDir, name)
+	if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// removeStateFiles удаляет файлы состояния; отсутствующие файлы пропускаются
+func removeStateFiles(sibDir string, names ...string) error {
+	for _, name := range names {
+		err := os.Remove(filepath.Join(sibDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sib/internal/core/index"
+	"sib/internal/core/repository"
+	"sib/internal/core/storage"
+)
+
+// divergedRepo создает репозиторий, где master и feature расходятся от общего
+// коммита base: на master - коммит m1 с masterFiles, на feature - коммиты
+// f1, f2, ... с featureCommits. Текущая ветка - master
+func divergedRepo(t *testing.T, masterFiles map[string]string, featureCommits ...map[string]string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a1\na2\na3\n", "b.txt": "b1\nb2\nb3\n"})
+
+	if err := Switch(tmpDir, "", SwitchOptions{Create: "feature"}); err != nil {
+		t.Fatalf("Switch -c failed: %v", err)
+	}
+	for i, files := range featureCommits {
+		commitFiles(t, tmpDir, "f"+string(rune('1'+i)), files)
+	}
+	if err := Switch(tmpDir, "master", SwitchOptions{}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "m1", masterFiles)
+
+	return tmpDir
+}
+
+func TestMerge(t *testing.T) {
+	t.Run("Fast-forward", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := Init(tmpDir); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "one"})
+		Switch(tmpDir, "", SwitchOptions{Create: "feature"})
+		commitFiles(t, tmpDir, "feature", map[string]string{"a.txt": "two"})
+		Switch(tmpDir, "master", SwitchOptions{})
+
+		if _, err := Merge(tmpDir, "feature", MergeOptions{}); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+		if got := messages(t, tmpDir, LogOptions{}); got != "feature base" {
+			t.Errorf("History after fast-forward = %s", got)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "two" {
+			t.Errorf("a.txt = %q", got)
+		}
+
+		if _, err := Merge(tmpDir, "feature", MergeOptions{}); err != nil {
+			t.Errorf("Merging an ancestor should be a no-op: %v", err)
+		}
+	})
+
+	t.Run("Clean three-way merge", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "A1\na2\na3\n"},
+			map[string]string{"a.txt": "a1\na2\nA3\n", "c.txt": "new\n"})
+
+		hash, err := Merge(tmpDir, "feature", MergeOptions{})
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "A1\na2\nA3\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "c.txt"); got != "new\n" {
+			t.Errorf("c.txt = %q", got)
+		}
+
+		store, _ := storage.NewObjectStore(tmpDir)
+		commit, err := store.ReadCommit(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commit.Parents()) != 2 {
+			t.Errorf("Merge commit has %d parents", len(commit.Parents()))
+		}
+		if commit.Message() != "Merge branch 'feature'" {
+			t.Errorf("Unexpected message: %q", commit.Message())
+		}
+
+		status, _ := CollectStatus(tmpDir)
+		if !status.IsClean() {
+			t.Errorf("Working tree should be clean after merge: %+v", status)
+		}
+	})
+
+	t.Run("Conflict, resolve and continue", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "ours\na2\na3\n"},
+			map[string]string{"a.txt": "theirs\na2\na3\n", "b.txt": "b1\nB2\nb3\n"})
+
+		_, err := Merge(tmpDir, "feature", MergeOptions{})
+		if !errors.Is(err, ErrMergeConflict) {
+			t.Fatalf("Expected ErrMergeConflict, got %v", err)
+		}
+
+		content := readFile(t, tmpDir, "a.txt")
+		if !strings.Contains(content, "<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> feature\n") {
+			t.Errorf("Missing conflict markers:\n%s", content)
+		}
+		if got := readFile(t, tmpDir, "b.txt"); got != "b1\nB2\nb3\n" {
+			t.Errorf("Clean path b.txt = %q", got)
+		}
+
+		idx, _ := index.NewIndex(tmpDir)
+		if stages := idx.ConflictStages("a.txt"); len(stages) != 3 {
+			t.Errorf("Expected 3 stages for a.txt, got %d", len(stages))
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Unmerged) != 1 || status.Unmerged[0] != "a.txt" {
+			t.Errorf("Unmerged = %v", status.Unmerged)
+		}
+		for _, path := range status.Untracked {
+			if path == "a.txt" {
+				t.Error("Conflicted path should not be reported as untracked")
+			}
+		}
+
+		if _, err := Commit(tmpDir, CommitOptions{Message: "too early"}); err == nil {
+			t.Error("Commit with unmerged paths should fail")
+		}
+		if _, err := Merge(tmpDir, "feature", MergeOptions{}); err == nil {
+			t.Error("Second merge during a merge should fail")
+		}
+
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "resolved\na2\na3\n"})
+		if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		hash, err := MergeContinue(tmpDir)
+		if err != nil {
+			t.Fatalf("MergeContinue failed: %v", err)
+		}
+
+		store, _ := storage.NewObjectStore(tmpDir)
+		commit, _ := store.ReadCommit(hash)
+		if len(commit.Parents()) != 2 {
+			t.Errorf("Merge commit has %d parents", len(commit.Parents()))
+		}
+		if commit.Message() != "Merge branch 'feature'" {
+			t.Errorf("Comment lines should be stripped, got %q", commit.Message())
+		}
+		if got, _ := readStateFile(filepath.Join(tmpDir, ".sib"), mergeHeadFile); got != "" {
+			t.Error("MERGE_HEAD should be removed after commit")
+		}
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "ours\na2\na3\n"},
+			map[string]string{"a.txt": "theirs\na2\na3\n", "c.txt": "new\n"})
+
+		if _, err := Merge(tmpDir, "feature", MergeOptions{}); !errors.Is(err, ErrMergeConflict) {
+			t.Fatalf("Expected ErrMergeConflict, got %v", err)
+		}
+		if err := MergeAbort(tmpDir); err != nil {
+			t.Fatalf("MergeAbort failed: %v", err)
+		}
+
+		if got := readFile(t, tmpDir, "a.txt"); got != "ours\na2\na3\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "c.txt"); got != "<missing>" {
+			t.Errorf("c.txt should be removed, got %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if !status.IsClean() {
+			t.Errorf("Working tree should be clean after abort: %+v", status)
+		}
+		if err := MergeAbort(tmpDir); err == nil {
+			t.Error("Abort without a merge in progress should fail")
+		}
+	})
+
+	t.Run("Refuses to overwrite local changes", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"b.txt": "b1\nb2\nB3\n"},
+			map[string]string{"a.txt": "a1\nA2\na3\n"})
+
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "dirty\n"})
+		if _, err := Merge(tmpDir, "feature", MergeOptions{}); !errors.Is(err, ErrLocalChanges) {
+			t.Errorf("Expected ErrLocalChanges, got %v", err)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "dirty\n" {
+			t.Errorf("Local change was lost: %q", got)
+		}
+	})
+}
+
+func TestMergeFileDirectory(t *testing.T) {
+	conflicted := func(t *testing.T) string {
+		tmpDir := divergedRepo(t,
+			map[string]string{"p": "file\n"},
+			map[string]string{"p/x.txt": "x\n"})
+
+		if _, err := Merge(tmpDir, "feature", MergeOptions{}); !errors.Is(err, ErrMergeConflict) {
+			t.Fatalf("Expected ErrMergeConflict, got %v", err)
+		}
+		return tmpDir
+	}
+
+	t.Run("Conflict", func(t *testing.T) {
+		tmpDir := conflicted(t)
+
+		// Каталог остается на месте, файл уходит рядом под именем стороны
+		if got := readFile(t, tmpDir, "p/x.txt"); got != "x\n" {
+			t.Errorf("p/x.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "p~HEAD"); got != "file\n" {
+			t.Errorf("p~HEAD = %q", got)
+		}
+		idx, _ := index.NewIndex(tmpDir)
+		if unmerged := idx.UnmergedPaths(); len(unmerged) != 1 || unmerged[0] != "p" {
+			t.Errorf("Unmerged paths = %v", unmerged)
+		}
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		tmpDir := conflicted(t)
+		if err := MergeAbort(tmpDir); err != nil {
+			t.Fatalf("MergeAbort failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "p"); got != "file\n" {
+			t.Errorf("p = %q", got)
+		}
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		tmpDir := conflicted(t)
+		if err := Add(tmpDir, []string{"p"}, AddOptions{All: true}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if _, err := MergeContinue(tmpDir); err != nil {
+			t.Fatalf("MergeContinue failed: %v", err)
+		}
+		repo, _ := repository.Discover(tmpDir)
+		_, head, _ := repo.Refs.Head()
+		commit, _ := repo.Objects.ReadCommit(head)
+		files, _ := repo.Objects.FlattenTree(commit.Tree())
+		if _, ok := files["p/x.txt"]; !ok || len(files) != 3 || len(commit.Parents()) != 2 {
+			t.Errorf("HEAD files = %v", files)
+		}
+	})
+}
+
+func TestMergeCrissCross(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "root", map[string]string{"f.txt": "a\n"})
+	Switch(tmpDir, "", SwitchOptions{Create: "side"})
+	commitFiles(t, tmpDir, "side 1", map[string]string{"g.txt": "x\n"})
+	Switch(tmpDir, "master", SwitchOptions{})
+	commitFiles(t, tmpDir, "master 1", map[string]string{"f.txt": "b\n"})
+	Switch(tmpDir, "", SwitchOptions{Create: "master-1"})
+	Switch(tmpDir, "master", SwitchOptions{})
+
+	// Перекрестные слияния дают двух лучших общих предков: master 1 и side 1
+	if _, err := Merge(tmpDir, "side", MergeOptions{}); err != nil {
+		t.Fatalf("Merge side failed: %v", err)
+	}
+	Switch(tmpDir, "side", SwitchOptions{})
+	if _, err := Merge(tmpDir, "master-1", MergeOptions{}); err != nil {
+		t.Fatalf("Merge master-1 failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "side 2", map[string]string{"g.txt": "y\n"})
+	Switch(tmpDir, "master", SwitchOptions{})
+	commitFiles(t, tmpDir, "master 2", map[string]string{"f.txt": "c\n"})
+
+	// С любым одним предком как базой был бы конфликт; виртуальная база (f=b, g=x) его снимает
+	if _, err := Merge(tmpDir, "side", MergeOptions{}); err != nil {
+		t.Fatalf("Criss-cross merge failed: %v", err)
+	}
+	if got := readFile(t, tmpDir, "f.txt"); got != "c\n" {
+		t.Errorf("f.txt = %q", got)
+	}
+	if got := readFile(t, tmpDir, "g.txt"); got != "y\n" {
+		t.Errorf("g.txt = %q", got)
+	}
+}
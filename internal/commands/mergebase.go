@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/repository"
+	"sib/internal/core/revwalk"
+)
+
+// MergeBaseOptions - параметры команды merge-base
+type MergeBaseOptions struct {
+	All     bool // --all: печатать всех лучших общих предков, а не одного
+	Octopus bool // --octopus: общий предок всех коммитов сразу
+}
+
+// MergeBase печатает лучшего общего предка первой ревизии и остальных
+// (или всех ревизий сразу с Octopus). Пустой результат - общей истории нет
+func MergeBase(repoPath string, revs []string, opts MergeBaseOptions) ([]objects.Hash, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) < 2 && !(opts.Octopus && len(revs) == 1) {
+		return nil, fmt.Errorf("merge-base needs at least two commits")
+	}
+
+	parser := newRevParser(repo)
+	hashes := make([]objects.Hash, 0, len(revs))
+	for _, rev := range revs {
+		hash, err := parser.ResolveCommit(rev)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	graph := revwalk.NewGraph(repo.Objects)
+	var bases []objects.Hash
+	if opts.Octopus {
+		bases, err = graph.OctopusBases(hashes...)
+	} else {
+		bases, err = graph.MergeBases(hashes[0], hashes[1:]...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.All && len(bases) > 1 {
+		bases = bases[:1]
+	}
+	for _, base := range bases {
+		fmt.Println(base)
+	}
+
+	return bases, nil
+}
+
+// IsAncestor проверяет, является ли ревизия ancestor предком descendant
+func IsAncestor(repoPath, ancestor, descendant string) (bool, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return false, err
+	}
+
+	parser := newRevParser(repo)
+	a, err := parser.ResolveCommit(ancestor)
+	if err != nil {
+		return false, err
+	}
+	d, err := parser.ResolveCommit(descendant)
+	if err != nil {
+		return false, err
+	}
+
+	return revwalk.IsAncestor(repo.Objects, a, d)
+}
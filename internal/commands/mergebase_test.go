@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"testing"
+
+	"sib/internal/core/objects"
+)
+
+func TestMergeBase(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "one"})
+	base, err := RevParse(tmpDir, []string{"HEAD"}, RevParseOptions{})
+	if err != nil {
+		t.Fatalf("RevParse failed: %v", err)
+	}
+
+	if err := Switch(tmpDir, "", SwitchOptions{Create: "feature"}); err != nil {
+		t.Fatalf("Switch -c failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "feature", map[string]string{"b.txt": "feature"})
+	if err := Switch(tmpDir, "master", SwitchOptions{}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "master", map[string]string{"c.txt": "master"})
+
+	t.Run("Two branches", func(t *testing.T) {
+		bases, err := MergeBase(tmpDir, []string{"master", "feature"}, MergeBaseOptions{})
+		if err != nil {
+			t.Fatalf("MergeBase failed: %v", err)
+		}
+		if len(bases) != 1 || bases[0] != objects.Hash(base[0]) {
+			t.Errorf("MergeBase = %v, want %s", bases, base[0])
+		}
+	})
+
+	t.Run("Octopus", func(t *testing.T) {
+		bases, err := MergeBase(tmpDir, []string{"master", "feature", "HEAD~1"}, MergeBaseOptions{Octopus: true})
+		if err != nil {
+			t.Fatalf("MergeBase --octopus failed: %v", err)
+		}
+		if len(bases) != 1 || bases[0] != objects.Hash(base[0]) {
+			t.Errorf("Octopus = %v, want %s", bases, base[0])
+		}
+	})
+
+	t.Run("Needs two commits", func(t *testing.T) {
+		if _, err := MergeBase(tmpDir, []string{"master"}, MergeBaseOptions{}); err == nil {
+			t.Error("Expected error for a single commit")
+		}
+	})
+
+	t.Run("Is ancestor", func(t *testing.T) {
+		if ok, err := IsAncestor(tmpDir, "HEAD~1", "feature"); err != nil || !ok {
+			t.Errorf("HEAD~1 should be an ancestor of feature (ok=%v, err=%v)", ok, err)
+		}
+		if ok, err := IsAncestor(tmpDir, "master", "feature"); err != nil || ok {
+			t.Errorf("master should not be an ancestor of feature (ok=%v, err=%v)", ok, err)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sib/internal/core/index"
+	"sib/internal/core/repository"
+)
+
+// MvOptions - параметры mv
+type MvOptions struct {
+	Force bool // -f: перезаписать существующий файл назначения
+}
+
+// Mv переименовывает отслеживаемый файл или каталог в рабочем каталоге и индексе.
+// Если dest - существующий каталог, источники перемещаются внутрь него
+// (источников может быть несколько), иначе источник должен быть один
+func Mv(repoPath string, sources []string, dest string, opts MvOptions) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("usage: sib mv <source>... <destination>")
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+
+	dest, err = cleanRepoPath(repo.Prefix, dest)
+	if err != nil {
+		return err
+	}
+	destIsDir := false
+	if info, err := os.Stat(filepath.Join(repo.WorkTree, filepath.FromSlash(dest))); err == nil && info.IsDir() {
+		destIsDir = true
+	}
+	if !destIsDir && len(sources) > 1 {
+		return fmt.Errorf("destination '%s' is not a directory", dest)
+	}
+
+	// Сначала проверяем все пары, чтобы не оставить перемещение наполовину выполненным
+	type move struct{ from, to string }
+	var moves []move
+	for _, source := range sources {
+		source, err := cleanRepoPath(repo.Prefix, source)
+		if err != nil {
+			return err
+		}
+		target := dest
+		if destIsDir {
+			target = path.Join(dest, path.Base(source))
+		}
+		if source == "" || source == target || strings.HasPrefix(target, source+"/") {
+			return fmt.Errorf("cannot move '%s' to '%s'", source, target)
+		}
+		if !worktreeExists(repo.WorkTree, source) {
+			return fmt.Errorf("bad source '%s': no such file or directory", source)
+		}
+		if idx.ConflictStages(source) != nil {
+			return fmt.Errorf("'%s' is unmerged", source)
+		}
+		if len(trackedUnder(idx, source)) == 0 {
+			return fmt.Errorf("'%s' is not under version control", source)
+		}
+		if worktreeExists(repo.WorkTree, target) {
+			info, _ := os.Lstat(filepath.Join(repo.WorkTree, filepath.FromSlash(target)))
+			if info.IsDir() || !opts.Force {
+				return fmt.Errorf("destination '%s' exists", target)
+			}
+		}
+		moves = append(moves, move{source, target})
+	}
+
+	for _, m := range moves {
+		fullTarget := filepath.Join(repo.WorkTree, filepath.FromSlash(m.to))
+		if err := os.MkdirAll(filepath.Dir(fullTarget), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", m.to, err)
+		}
+		if err := os.Rename(filepath.Join(repo.WorkTree, filepath.FromSlash(m.from)), fullTarget); err != nil {
+			return fmt.Errorf("failed to move %s: %w", m.from, err)
+		}
+
+		// Перенос сохраняет содержимое и время изменения - записи индекса переезжают как есть
+		if _, err := idx.Get(m.to); err == nil {
+			if err := idx.Remove(m.to); err != nil {
+				return err
+			}
+		}
+		for _, entry := range trackedUnder(idx, m.from) {
+			newPath := m.to + strings.TrimPrefix(entry.Path, m.from)
+			if err := idx.Remove(entry.Path); err != nil {
+				return err
+			}
+			if err := idx.Add(newPath, entry.Hash, entry.Size, entry.Mode, entry.Mtime); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Renamed %s -> %s\n", m.from, m.to)
+	}
+
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	return nil
+}
+
+// trackedUnder возвращает записи индекса для файла path или файлов внутри каталога path
+func trackedUnder(idx *index.Index, dir string) []index.IndexEntry {
+	var entries []index.IndexEntry
+	for p, entry := range idx.Entries {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// cleanRepoPath приводит путь из командной строки, заданный из подкаталога
+// prefix, к виду путей индекса. Пути за пределами рабочего каталога - ошибка
+// (как в pathspec); корень рабочего каталога дает ""
+func cleanRepoPath(prefix, arg string) (string, error) {
+	p := path.Clean(path.Join(prefix, filepath.ToSlash(arg)))
+	if p == ".." || strings.HasPrefix(p, "../") || strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("'%s' is outside repository", arg)
+	}
+	if p == "." {
+		return "", nil
+	}
+	return p, nil
+}
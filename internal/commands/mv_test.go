@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMv(t *testing.T) {
+	t.Run("Renames a file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+
+		if err := Mv(tmpDir, []string{"a.txt"}, "docs/renamed.txt", MvOptions{}); err != nil {
+			t.Fatalf("Mv failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "docs/renamed.txt"); got != "a\n" {
+			t.Errorf("docs/renamed.txt = %q", got)
+		}
+		if got := indexPaths(t, tmpDir); got != "b.txt docs/renamed.txt" {
+			t.Errorf("Index = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 2 || len(status.Unstaged) != 0 || len(status.Untracked) != 0 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+
+		if err := Mv(tmpDir, []string{"b.txt"}, "docs/renamed.txt", MvOptions{}); err == nil {
+			t.Error("Overwriting without -f should fail")
+		}
+		if err := Mv(tmpDir, []string{"b.txt"}, "docs/renamed.txt", MvOptions{Force: true}); err != nil {
+			t.Fatalf("Mv -f failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "docs/renamed.txt" {
+			t.Errorf("Index = %q", got)
+		}
+	})
+
+	t.Run("Moves into a directory", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "src/x.go": "x\n", "dest/keep": "k\n"})
+		writeFiles(t, tmpDir, map[string]string{"untracked.txt": "u\n"})
+
+		if err := Mv(tmpDir, []string{"a.txt", "src"}, "dest", MvOptions{}); err != nil {
+			t.Fatalf("Mv failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "dest/a.txt dest/keep dest/src/x.go" {
+			t.Errorf("Index = %q", got)
+		}
+
+		if err := Mv(tmpDir, []string{"untracked.txt"}, "u.txt", MvOptions{}); err == nil {
+			t.Error("Moving an untracked file should fail")
+		}
+		if err := Mv(tmpDir, []string{"dest"}, "dest/inner", MvOptions{}); err == nil {
+			t.Error("Moving a directory into itself should fail")
+		}
+	})
+
+	t.Run("Refuses paths outside the repository", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "src/x.go": "x\n"})
+
+		for _, dest := range []string{"../escaped.txt", "..", "src/../../escaped.txt"} {
+			if err := Mv(tmpDir, []string{"a.txt"}, dest, MvOptions{}); err == nil || !strings.Contains(err.Error(), "outside repository") {
+				t.Errorf("Mv to %q: expected outside repository error, got %v", dest, err)
+			}
+		}
+		if err := Mv(filepath.Join(tmpDir, "src"), []string{"../../a.txt"}, "y.go", MvOptions{}); err == nil {
+			t.Error("Source outside the repository should be refused")
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "a\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(tmpDir), "escaped.txt")); !os.IsNotExist(err) {
+			t.Error("File escaped the repository")
+		}
+		if got := indexPaths(t, tmpDir); got != "a.txt src/x.go" {
+			t.Errorf("Index = %q", got)
+		}
+
+		// Из подкаталога ".." - это корень рабочего каталога, он допустим
+		if err := Mv(filepath.Join(tmpDir, "src"), []string{"x.go"}, "..", MvOptions{}); err != nil {
+			t.Fatalf("Mv to the work tree root failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "a.txt x.go" {
+			t.Errorf("Index = %q", got)
+		}
+	})
+}
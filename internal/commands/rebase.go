@@ -0,0 +1,796 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sib/internal/core/index"
+	"sib/internal/core/merge"
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/revparse"
+	"sib/internal/core/revwalk"
+)
+
+// rebaseDir - каталог состояния rebase внутри .sib
+const rebaseDir = "rebase-merge"
+
+// Файлы состояния rebase
+const (
+	rebaseHeadName   = "head-name"   // Перебазируемая ветка (пусто - отсоединенный HEAD)
+	rebaseOrigHead   = "orig-head"   // HEAD до начала rebase
+	rebaseOnto       = "onto"        // Новая база
+	rebaseTodo       = "todo"        // Оставшиеся шаги
+	rebaseDone       = "done"        // Выполненные шаги; последний - текущий
+	rebaseStoppedSHA = "stopped-sha" // Коммит, применение которого не завершено
+)
+
+// ErrRebaseConflict - rebase остановился на конфликте, который нужно разрешить вручную
+var ErrRebaseConflict = errors.New("could not apply commit; resolve conflicts and run \"sib rebase --continue\"")
+
+// ErrRebaseExec - rebase остановился, потому что команда шага exec завершилась с ошибкой
+var ErrRebaseExec = errors.New("execution failed")
+
+// RebaseOptions - параметры команды rebase
+type RebaseOptions struct {
+	Onto        string // --onto: новая база (по умолчанию upstream)
+	Interactive bool   // -i: отредактировать список шагов через $SIB_SEQUENCE_EDITOR
+}
+
+// rebaseSession - открытый репозиторий с незавершенным rebase
+type rebaseSession struct {
+	*repository.Repository
+	parser *revparse.Parser
+	dir    string
+}
+
+// Rebase переносит коммиты текущей ветки, которых нет в upstream, на новую базу.
+// Возвращает ErrRebaseConflict, если шаг не удалось применить автоматически;
+// при остановке на edit возвращает nil, оставляя rebase незавершенным
+func Rebase(repoPath, upstream string, opts RebaseOptions) error {
+	s, err := openRebaseSession(repoPath)
+	if err != nil {
+		return err
+	}
+	if s.inProgress() {
+		return fmt.Errorf("a rebase is already in progress; use --continue, --skip or --abort")
+	}
+	if upstream == "" {
+		return fmt.Errorf("no upstream given")
+	}
+	if mergeHead, err := readStateFile(s.SibDir, mergeHeadFile); err != nil {
+		return err
+	} else if mergeHead != "" {
+		return fmt.Errorf("you have not concluded your merge (MERGE_HEAD exists)")
+	}
+
+	status, err := collectStatus(s.Repository)
+	if err != nil {
+		return err
+	}
+	if len(status.Unmerged) > 0 || len(status.Staged) > 0 || len(status.Unstaged) > 0 {
+		return fmt.Errorf("cannot rebase: you have uncommitted changes; commit them first")
+	}
+
+	branch, head := status.Branch, status.Head
+	if head.IsEmpty() {
+		return fmt.Errorf("your current branch '%s' does not have any commits yet", refDisplayName(branch))
+	}
+	upstreamHash, err := s.parser.ResolveCommit(upstream)
+	if err != nil {
+		return err
+	}
+	onto := upstreamHash
+	if opts.Onto != "" {
+		if onto, err = s.parser.ResolveCommit(opts.Onto); err != nil {
+			return err
+		}
+	}
+
+	// Коммиты для переноса: достижимы из HEAD, но не из upstream; слияния не переносятся
+	walker := revwalk.NewWalker(s.Objects, revwalk.OrderTopo)
+	if err := walker.Push(head); err != nil {
+		return err
+	}
+	if err := walker.Hide(upstreamHash); err != nil {
+		return err
+	}
+	var commits []*objects.Commit
+	if err := walker.ForEach(func(commit *objects.Commit) error {
+		if !commit.IsMerge() {
+			commits = append(commits, commit)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if !opts.Interactive && onto == upstreamHash {
+		upToDate, err := revwalk.IsAncestor(s.Objects, onto, head)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			fmt.Printf("Current branch %s is up to date.\n", refDisplayName(branch))
+			return nil
+		}
+	}
+
+	items := make([]todoItem, 0, len(commits))
+	for i := len(commits) - 1; i >= 0; i-- {
+		items = append(items, todoItem{
+			Command: todoPick,
+			Commit:  commits[i].Hash().String(),
+			Subject: firstLine(commits[i].Message()),
+		})
+	}
+
+	if opts.Interactive {
+		if items, err = s.editTodo(items, onto); err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Println("Nothing to do")
+			return nil
+		}
+	}
+
+	// Сохраняем состояние до любых изменений: после сбоя сработают --continue и --abort
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create rebase state: %w", err)
+	}
+	for name, value := range map[string]string{
+		rebaseHeadName: branch,
+		rebaseOrigHead: head.String(),
+		rebaseOnto:     onto.String(),
+		rebaseTodo:     formatTodo(items),
+		rebaseDone:     "",
+	} {
+		if err := s.write(name, value); err != nil {
+			return err
+		}
+	}
+	if err := writeStateFile(s.SibDir, origHeadFile, head.String()); err != nil {
+		return err
+	}
+
+	// Встаем на новую базу с отсоединенным HEAD; ветка передвигается в самом конце
+	if err := s.moveHead(head, onto); err != nil {
+		os.RemoveAll(s.dir)
+		return err
+	}
+
+	return s.run()
+}
+
+// RebaseContinue продолжает rebase после разрешения конфликта или остановки на edit
+func RebaseContinue(repoPath string) error {
+	s, err := openRebaseSession(repoPath)
+	if err != nil {
+		return err
+	}
+	if !s.inProgress() {
+		return fmt.Errorf("no rebase in progress")
+	}
+
+	idx, err := s.Index()
+	if err != nil {
+		return err
+	}
+	if idx.HasConflicts() {
+		return fmt.Errorf("you must edit all merge conflicts and then mark them as resolved using sib add:\n\t%s",
+			strings.Join(idx.UnmergedPaths(), "\n\t"))
+	}
+
+	stopped, err := s.read(rebaseStoppedSHA)
+	if err != nil {
+		return err
+	}
+	if stopped != "" {
+		// Завершаем шаг, на котором остановились из-за конфликта
+		item, err := s.current()
+		if err != nil {
+			return err
+		}
+		stop, err := s.commitStep(idx, item)
+		if err != nil || stop {
+			return err
+		}
+	} else if err := s.amendStaged(idx); err != nil {
+		return err
+	}
+
+	return s.run()
+}
+
+// RebaseSkip пропускает текущий шаг, сбрасывая его изменения
+func RebaseSkip(repoPath string) error {
+	s, err := openRebaseSession(repoPath)
+	if err != nil {
+		return err
+	}
+	if !s.inProgress() {
+		return fmt.Errorf("no rebase in progress")
+	}
+
+	head, _, err := s.head()
+	if err != nil {
+		return err
+	}
+	idx, err := s.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+	if err := resetToTree(s.Repository, idx, head.Tree()); err != nil {
+		return err
+	}
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	if err := s.remove(rebaseStoppedSHA); err != nil {
+		return err
+	}
+
+	return s.run()
+}
+
+// RebaseAbort отменяет rebase: ветка, HEAD, индекс и рабочий каталог возвращаются
+// к состоянию до начала
+func RebaseAbort(repoPath string) error {
+	s, err := openRebaseSession(repoPath)
+	if err != nil {
+		return err
+	}
+	if !s.inProgress() {
+		return fmt.Errorf("no rebase in progress")
+	}
+
+	branch, err := s.read(rebaseHeadName)
+	if err != nil {
+		return err
+	}
+	origValue, err := s.read(rebaseOrigHead)
+	if err != nil {
+		return err
+	}
+	orig, err := s.Objects.ReadCommit(objects.Hash(origValue))
+	if err != nil {
+		return err
+	}
+
+	head, _, err := s.head()
+	if err != nil {
+		return err
+	}
+	idx, err := s.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+	if err := resetToTree(s.Repository, idx, head.Tree()); err != nil {
+		return err
+	}
+	if err := checkoutTree(s.WorkTree, s.Objects, idx, head.Tree(), orig.Tree(), true); err != nil {
+		return err
+	}
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	if branch != "" {
+		if err := s.Refs.SetSymbolic(refs.HEAD, branch); err != nil {
+			return err
+		}
+	} else if err := s.Refs.DetachHead(orig.Hash()); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(s.dir)
+}
+
+func openRebaseSession(repoPath string) (*rebaseSession, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	parser := newRevParser(repo)
+	return &rebaseSession{
+		Repository: repo,
+		parser:     parser,
+		dir:        repo.Path(rebaseDir),
+	}, nil
+}
+
+// inProgress проверяет, есть ли незавершенный rebase
+func (s *rebaseSession) inProgress() bool {
+	_, err := os.Stat(s.dir)
+	return err == nil
+}
+
+// run выполняет оставшиеся шаги списка до конца, конфликта или остановки
+func (s *rebaseSession) run() error {
+	for {
+		todoText, err := s.read(rebaseTodo)
+		if err != nil {
+			return err
+		}
+		items, err := parseTodo(todoText)
+		if err != nil {
+			return fmt.Errorf("invalid rebase todo list: %w", err)
+		}
+		if len(items) == 0 {
+			return s.finish()
+		}
+
+		// Переносим шаг в done до выполнения: после сбоя он останется текущим
+		item := items[0]
+		done, err := s.read(rebaseDone)
+		if err != nil {
+			return err
+		}
+		if err := s.write(rebaseDone, done+item.String()+"\n"); err != nil {
+			return err
+		}
+		if err := s.write(rebaseTodo, formatTodo(items[1:])); err != nil {
+			return err
+		}
+
+		stop, err := s.step(item)
+		if err != nil || stop {
+			return err
+		}
+	}
+}
+
+// step выполняет один шаг; stop означает остановку на edit
+func (s *rebaseSession) step(item todoItem) (bool, error) {
+	switch item.Command {
+	case todoDrop:
+		return false, nil
+	case todoExec:
+		fmt.Printf("Executing: %s\n", item.Exec)
+		cmd := exec.Command("sh", "-c", item.Exec)
+		cmd.Dir = s.WorkTree
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return false, fmt.Errorf("%w: %s\nYou can fix the problem, and then run\n\n  sib rebase --continue", ErrRebaseExec, item.Exec)
+		}
+		return false, nil
+	}
+
+	// В списке шагов полные хеши: берем их как есть, без поиска одноименных ссылок
+	hash := objects.Hash(item.Commit)
+	if !refs.IsValidHash(item.Commit) {
+		var err error
+		if hash, err = s.parser.ResolveCommit(item.Commit); err != nil {
+			return false, err
+		}
+	}
+	commit, err := s.Objects.ReadCommit(hash)
+	if err != nil {
+		return false, err
+	}
+	head, headHash, err := s.head()
+	if err != nil {
+		return false, err
+	}
+
+	// Коммит уже лежит на текущей вершине - переиспользуем его без пересоздания
+	if item.Command == todoPick && len(commit.Parents()) == 1 && commit.Parents()[0] == headHash {
+		return false, s.moveHead(headHash, hash)
+	}
+
+	if err := s.write(rebaseStoppedSHA, hash.String()); err != nil {
+		return false, err
+	}
+
+	idx, err := s.LockIndex()
+	if err != nil {
+		return false, err
+	}
+	defer idx.Unlock()
+	conflicts, err := applyCommit(s.Repository, idx, head, commit, "rebase")
+	if err != nil {
+		return false, err
+	}
+	if len(conflicts) > 0 {
+		for _, conflict := range conflicts {
+			printConflict(conflict)
+		}
+		fmt.Printf("could not apply %s... %s\n", shortHash(hash), firstLine(commit.Message()))
+		fmt.Println("Resolve all conflicts manually, mark them as resolved with \"sib add\", then run \"sib rebase --continue\".")
+		fmt.Println("You can instead skip this commit with \"sib rebase --skip\", or abort with \"sib rebase --abort\".")
+		return false, ErrRebaseConflict
+	}
+
+	return s.commitStep(idx, item)
+}
+
+// commitStep создает коммит для шага pick/reword/edit/squash/fixup из текущего индекса
+func (s *rebaseSession) commitStep(idx *index.Index, item todoItem) (bool, error) {
+	stoppedValue, err := s.read(rebaseStoppedSHA)
+	if err != nil {
+		return false, err
+	}
+	original, err := s.Objects.ReadCommit(objects.Hash(stoppedValue))
+	if err != nil {
+		return false, err
+	}
+	head, headHash, err := s.head()
+	if err != nil {
+		return false, err
+	}
+
+	treeHash, err := idx.WriteTree(s.Objects)
+	if err != nil {
+		return false, fmt.Errorf("failed to write tree: %w", err)
+	}
+
+	parents := []objects.Hash{headHash}
+	author := original.Author()
+	message := original.Message()
+	switch item.Command {
+	case todoSquash, todoFixup:
+		// Сливаем с предыдущим коммитом: заменяем вершину, сохраняя её автора
+		parents = head.Parents()
+		author = head.Author()
+		message = head.Message()
+		if item.Command == todoSquash {
+			message = strings.TrimRight(message, "\n") + "\n\n" + original.Message()
+			if message, err = editMessage(s.dir, message); err != nil {
+				return false, err
+			}
+		}
+	default:
+		if treeHash == head.Tree() {
+			fmt.Printf("dropping %s %s -- patch contents already upstream\n", shortHash(original.Hash()), firstLine(original.Message()))
+			return false, s.remove(rebaseStoppedSHA)
+		}
+		if item.Command == todoReword {
+			if message, err = editMessage(s.dir, message); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	newHash, err := s.writeCommit(treeHash, parents, author, message)
+	if err != nil {
+		return false, err
+	}
+	if err := s.Refs.CompareAndSwap(refs.HEAD, headHash, newHash); err != nil {
+		return false, fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	if err := s.remove(rebaseStoppedSHA); err != nil {
+		return false, err
+	}
+
+	if item.Command == todoEdit {
+		fmt.Printf("Stopped at %s... %s\n", shortHash(original.Hash()), firstLine(original.Message()))
+		fmt.Println("You can amend the commit now, with\n\n  sib commit --amend\n\nOnce you are satisfied with your changes, run\n\n  sib rebase --continue")
+		return true, nil
+	}
+	return false, nil
+}
+
+// amendStaged после остановки на edit дописывает подготовленные изменения в вершину
+func (s *rebaseSession) amendStaged(idx *index.Index) error {
+	head, headHash, err := s.head()
+	if err != nil {
+		return err
+	}
+	treeHash, err := idx.WriteTree(s.Objects)
+	if err != nil {
+		return fmt.Errorf("failed to write tree: %w", err)
+	}
+	if treeHash == head.Tree() {
+		return nil
+	}
+
+	newHash, err := s.writeCommit(treeHash, head.Parents(), head.Author(), head.Message())
+	if err != nil {
+		return err
+	}
+	return s.Refs.CompareAndSwap(refs.HEAD, headHash, newHash)
+}
+
+// finish передвигает перебазируемую ветку на результат и удаляет состояние
+func (s *rebaseSession) finish() error {
+	branch, err := s.read(rebaseHeadName)
+	if err != nil {
+		return err
+	}
+	origHead, err := s.read(rebaseOrigHead)
+	if err != nil {
+		return err
+	}
+	_, headHash, err := s.head()
+	if err != nil {
+		return err
+	}
+
+	if branch != "" {
+		if err := s.Refs.CompareAndSwap(branch, objects.Hash(origHead), headHash); err != nil {
+			return fmt.Errorf("failed to update %s: %w", refDisplayName(branch), err)
+		}
+		if err := s.Refs.SetSymbolic(refs.HEAD, branch); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to remove rebase state: %w", err)
+	}
+
+	if branch != "" {
+		fmt.Printf("Successfully rebased and updated %s.\n", branch)
+	} else {
+		fmt.Println("Successfully rebased.")
+	}
+	return nil
+}
+
+// editTodo записывает список во временный файл, открывает его в $SIB_SEQUENCE_EDITOR
+// и читает результат обратно. Редактор видит сокращенные хеши, а в состояние
+// rebase попадают полные: короткий префикс может совпасть с именем ветки или
+// тега либо стать неоднозначным из-за объектов, которые запишет сам rebase
+func (s *rebaseSession) editTodo(items []todoItem, onto objects.Hash) ([]todoItem, error) {
+	editor := os.Getenv("SIB_SEQUENCE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("SIB_EDITOR")
+	}
+	if editor == "" {
+		return nil, fmt.Errorf("no sequence editor configured (set SIB_SEQUENCE_EDITOR)")
+	}
+
+	// Совпавшие сокращения показываем полностью, чтобы обратное отображение было однозначным
+	count := make(map[string]int, len(items))
+	for _, item := range items {
+		if item.Command != todoExec {
+			count[shortHash(objects.Hash(item.Commit))]++
+		}
+	}
+	full := make(map[string]string, len(items))
+	shown := make([]todoItem, len(items))
+	for i, item := range items {
+		shown[i] = item
+		if short := shortHash(objects.Hash(item.Commit)); item.Command != todoExec && count[short] == 1 {
+			shown[i].Commit = short
+			full[short] = item.Commit
+		}
+	}
+
+	path := s.Path("rebase-todo")
+	header := fmt.Sprintf("\n# Rebase onto %s (%d commands)\n", shortHash(onto), len(items))
+	if err := os.WriteFile(path, []byte(formatTodo(shown)+header+todoHelp), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write todo list: %w", err)
+	}
+	defer os.Remove(path)
+
+	if err := runEditor(editor, path); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read todo list: %w", err)
+	}
+	items, err = parseTodo(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTodo(items); err != nil {
+		return nil, err
+	}
+
+	// Проверяем ревизии сразу, а не посреди rebase, и заменяем их полными хешами.
+	// Свои же сокращения разворачиваем без разбора ревизий
+	for i, item := range items {
+		if item.Command == todoExec {
+			continue
+		}
+		if hash, ok := full[item.Commit]; ok {
+			items[i].Commit = hash
+			continue
+		}
+		hash, err := s.parser.ResolveCommit(item.Commit)
+		if err != nil {
+			return nil, err
+		}
+		items[i].Commit = hash.String()
+	}
+	return items, nil
+}
+
+// moveHead переводит отсоединенный HEAD, индекс и рабочий каталог с from на to
+func (s *rebaseSession) moveHead(from, to objects.Hash) error {
+	fromCommit, err := s.Objects.ReadCommit(from)
+	if err != nil {
+		return err
+	}
+	toCommit, err := s.Objects.ReadCommit(to)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+	if err := checkoutTree(s.WorkTree, s.Objects, idx, fromCommit.Tree(), toCommit.Tree(), false); err != nil {
+		return err
+	}
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	return s.Refs.DetachHead(to)
+}
+
+// head возвращает текущий коммит HEAD
+func (s *rebaseSession) head() (*objects.Commit, objects.Hash, error) {
+	_, hash, err := s.Refs.Head()
+	if err != nil {
+		return nil, "", err
+	}
+	commit, err := s.Objects.ReadCommit(hash)
+	if err != nil {
+		return nil, "", err
+	}
+	return commit, hash, nil
+}
+
+// writeCommit записывает коммит с текущим пользователем в качестве коммитера
+func (s *rebaseSession) writeCommit(tree objects.Hash, parents []objects.Hash, author objects.Signature, message string) (objects.Hash, error) {
+	committer, err := defaultSignature(s.Config, time.Now())
+	if err != nil {
+		return "", err
+	}
+	commit, err := objects.NewCommit(tree, parents, author, *committer, message)
+	if err != nil {
+		return "", fmt.Errorf("failed to create commit: %w", err)
+	}
+	hash, err := s.Objects.WriteObject(commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to write commit: %w", err)
+	}
+	return hash, nil
+}
+
+// current возвращает выполняемый шаг - последнюю строку done
+func (s *rebaseSession) current() (todoItem, error) {
+	done, err := s.read(rebaseDone)
+	if err != nil {
+		return todoItem{}, err
+	}
+	items, err := parseTodo(done)
+	if err != nil || len(items) == 0 {
+		return todoItem{}, fmt.Errorf("rebase state is corrupt: no current step")
+	}
+	return items[len(items)-1], nil
+}
+
+// read читает файл состояния; отсутствующий файл - пустая строка
+func (s *rebaseSession) read(name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read rebase state %s: %w", name, err)
+	}
+	if name == rebaseTodo || name == rebaseDone {
+		return string(data), nil
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// write атомарно записывает файл состояния (через временный файл и rename)
+func (s *rebaseSession) write(name, value string) error {
+	path := filepath.Join(s.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write rebase state %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write rebase state %s: %w", name, err)
+	}
+	return nil
+}
+
+// remove удаляет файл состояния
+func (s *rebaseSession) remove(name string) error {
+	err := os.Remove(filepath.Join(s.dir, name))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove rebase state %s: %w", name, err)
+	}
+	return nil
+}
+
+// applyCommit применяет изменения коммита (относительно его первого родителя)
+// поверх head в индекс и рабочий каталог. Возвращает неразрешенные конфликты
+func applyCommit(repo *repository.Repository, idx *index.Index, head, commit *objects.Commit, op string) ([]merge.Conflict, error) {
+	var baseTree objects.Hash
+	if !commit.IsRoot() {
+		parent, err := repo.Objects.ReadCommit(commit.Parents()[0])
+		if err != nil {
+			return nil, err
+		}
+		baseTree = parent.Tree()
+	}
+
+	label := commitLabel(commit)
+	return applyChange(repo, idx, head.Tree(), baseTree, commit.Tree(),
+		merge.Labels{Base: "parent of " + label, Ours: "HEAD", Theirs: label}, op)
+}
+
+// applyChange переносит разницу между деревьями base и target на дерево ours,
+// которое должно совпадать с индексом, и записывает результат в индекс и рабочий каталог
+func applyChange(repo *repository.Repository, idx *index.Index, ours, base, target objects.Hash, labels merge.Labels, op string) ([]merge.Conflict, error) {
+	result, err := merge.Trees(repo.Objects, base, ours, target, merge.Options{Labels: labels})
+	if err != nil {
+		return nil, err
+	}
+
+	ourFiles, err := flattenCommitTree(repo.Objects, ours)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyMergeResult(repo, idx, op, ourFiles, result); err != nil {
+		return nil, err
+	}
+	if err := idx.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save index: %w", err)
+	}
+
+	return result.Conflicts, nil
+}
+
+// commitLabel подписывает коммит в маркерах конфликта: "abc1234 (subject)"
+func commitLabel(commit *objects.Commit) string {
+	return fmt.Sprintf("%s (%s)", shortHash(commit.Hash()), firstLine(commit.Message()))
+}
+
+// editMessage дает отредактировать сообщение в $SIB_EDITOR; без редактора сообщение не меняется
+func editMessage(dir, message string) (string, error) {
+	editor := os.Getenv("SIB_EDITOR")
+	if editor == "" {
+		return message, nil
+	}
+
+	path := filepath.Join(dir, "message")
+	if err := os.WriteFile(path, []byte(message), 0644); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	defer os.Remove(path)
+
+	if err := runEditor(editor, path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message: %w", err)
+	}
+
+	edited := stripCommentLines(string(data))
+	if edited == "" {
+		return "", fmt.Errorf("aborting commit due to empty commit message")
+	}
+	return edited, nil
+}
+
+// runEditor запускает редактор (команду оболочки) для файла
+func runEditor(editor, path string) error {
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+	return nil
+}
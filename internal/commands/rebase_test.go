@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+)
+
+// rebaseInProgress проверяет наличие каталога состояния rebase
+func rebaseInProgress(repoPath string) bool {
+	_, err := os.Stat(filepath.Join(repoPath, ".sib", rebaseDir))
+	return err == nil
+}
+
+func TestRebase(t *testing.T) {
+	t.Run("Replays commits onto upstream", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"},
+			map[string]string{"f2.txt": "two\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		if err := Rebase(tmpDir, "master", RebaseOptions{}); err != nil {
+			t.Fatalf("Rebase failed: %v", err)
+		}
+		if got := messages(t, tmpDir, LogOptions{}); got != "f2 f1 m1 base" {
+			t.Errorf("History = %q", got)
+		}
+		if got := readFile(t, tmpDir, "m.txt"); got != "master\n" {
+			t.Errorf("m.txt = %q", got)
+		}
+
+		refStore, _ := refs.NewRefStore(tmpDir)
+		branch, _, _ := refStore.Head()
+		if branch != "refs/heads/feature" {
+			t.Errorf("HEAD should be back on feature, got %q", branch)
+		}
+		if rebaseInProgress(tmpDir) {
+			t.Error("Rebase state should be removed")
+		}
+
+		if err := Rebase(tmpDir, "master", RebaseOptions{}); err != nil {
+			t.Errorf("Rebase of an up-to-date branch failed: %v", err)
+		}
+	})
+
+	t.Run("Conflict, resolve and continue", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "master\na2\na3\n"},
+			map[string]string{"a.txt": "feature\na2\na3\n"},
+			map[string]string{"b.txt": "b\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		if err := Rebase(tmpDir, "master", RebaseOptions{}); !errors.Is(err, ErrRebaseConflict) {
+			t.Fatalf("Expected ErrRebaseConflict, got %v", err)
+		}
+		if !rebaseInProgress(tmpDir) {
+			t.Fatal("Rebase state should persist after a conflict")
+		}
+		if err := RebaseContinue(tmpDir); err == nil {
+			t.Error("Continue with unresolved conflicts should fail")
+		}
+
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "both\na2\na3\n"})
+		if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if err := RebaseContinue(tmpDir); err != nil {
+			t.Fatalf("RebaseContinue failed: %v", err)
+		}
+
+		if got := messages(t, tmpDir, LogOptions{}); got != "f2 f1 m1 base" {
+			t.Errorf("History = %q", got)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "both\na2\na3\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "master\na2\na3\n"},
+			map[string]string{"a.txt": "feature\na2\na3\n"},
+			map[string]string{"b.txt": "b\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		if err := Rebase(tmpDir, "master", RebaseOptions{}); !errors.Is(err, ErrRebaseConflict) {
+			t.Fatalf("Expected ErrRebaseConflict, got %v", err)
+		}
+		if err := RebaseSkip(tmpDir); err != nil {
+			t.Fatalf("RebaseSkip failed: %v", err)
+		}
+		if got := messages(t, tmpDir, LogOptions{}); got != "f2 m1 base" {
+			t.Errorf("History = %q", got)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "master\na2\na3\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "master\na2\na3\n"},
+			map[string]string{"a.txt": "feature\na2\na3\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+		before, _ := RevParse(tmpDir, []string{"feature"}, RevParseOptions{})
+
+		if err := Rebase(tmpDir, "master", RebaseOptions{}); !errors.Is(err, ErrRebaseConflict) {
+			t.Fatalf("Expected ErrRebaseConflict, got %v", err)
+		}
+		if err := RebaseAbort(tmpDir); err != nil {
+			t.Fatalf("RebaseAbort failed: %v", err)
+		}
+
+		after, _ := RevParse(tmpDir, []string{"HEAD"}, RevParseOptions{})
+		if before[0] != after[0] {
+			t.Errorf("HEAD should be restored to %s, got %s", before[0], after[0])
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "feature\na2\na3\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if status.Branch != "refs/heads/feature" || !status.IsClean() {
+			t.Errorf("Unexpected status after abort: %+v", status)
+		}
+		if rebaseInProgress(tmpDir) {
+			t.Error("Rebase state should be removed")
+		}
+	})
+
+	t.Run("Interactive todo", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"},
+			map[string]string{"f2.txt": "two\n"},
+			map[string]string{"f3.txt": "three\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		// Строки 1-3 - pick f1, f2, f3; в конец добавляем exec
+		t.Setenv("SIB_SEQUENCE_EDITOR", `sed -i -e '2s/^pick/fixup/' -e '3s/^pick/drop/' -e '$a exec touch executed'`)
+		if err := Rebase(tmpDir, "master", RebaseOptions{Interactive: true}); err != nil {
+			t.Fatalf("Interactive rebase failed: %v", err)
+		}
+
+		if got := messages(t, tmpDir, LogOptions{}); got != "f1 m1 base" {
+			t.Errorf("History = %q", got)
+		}
+		if got := readFile(t, tmpDir, "f2.txt"); got != "two\n" {
+			t.Errorf("Fixup changes should be kept, f2.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "f3.txt"); got != "<missing>" {
+			t.Errorf("Dropped commit should be gone, f3.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "executed"); got != "" {
+			t.Errorf("exec step did not run: %q", got)
+		}
+	})
+
+	t.Run("Failed exec stops", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"},
+			map[string]string{"f2.txt": "two\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		t.Setenv("SIB_SEQUENCE_EDITOR", `sed -i -e '1a exec false'`)
+		if err := Rebase(tmpDir, "master", RebaseOptions{Interactive: true}); !errors.Is(err, ErrRebaseExec) {
+			t.Fatalf("Expected ErrRebaseExec, got %v", err)
+		}
+		if !rebaseInProgress(tmpDir) {
+			t.Fatal("Rebase should stop at the failed exec step")
+		}
+		if err := RebaseContinue(tmpDir); err != nil {
+			t.Fatalf("RebaseContinue failed: %v", err)
+		}
+		if got := messages(t, tmpDir, LogOptions{}); got != "f2 f1 m1 base" {
+			t.Errorf("History = %q", got)
+		}
+	})
+
+	t.Run("Branch named like a short hash", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"},
+			map[string]string{"f2.txt": "two\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		// Ветка с именем сокращенного хеша f2 указывает на другой коммит
+		hashes, _ := RevParse(tmpDir, []string{"feature", "master"}, RevParseOptions{})
+		refStore, _ := refs.NewRefStore(tmpDir)
+		refStore.Set(refs.HeadsPrefix+hashes[0][:7], objects.Hash(hashes[1]))
+
+		t.Setenv("SIB_SEQUENCE_EDITOR", `sed -i -e '1s/^pick/edit/'`)
+		if err := Rebase(tmpDir, "master", RebaseOptions{Interactive: true}); err != nil {
+			t.Fatalf("Interactive rebase failed: %v", err)
+		}
+		todo, _ := os.ReadFile(filepath.Join(tmpDir, ".sib", rebaseDir, rebaseTodo))
+		if want := "pick " + hashes[0] + " f2\n"; string(todo) != want {
+			t.Errorf("todo = %q, want %q", todo, want)
+		}
+		if err := RebaseContinue(tmpDir); err != nil {
+			t.Fatalf("RebaseContinue failed: %v", err)
+		}
+		if got := messages(t, tmpDir, LogOptions{}); got != "f2 f1 m1 base" {
+			t.Errorf("History = %q", got)
+		}
+	})
+
+	t.Run("Edit stops and continues", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"},
+			map[string]string{"f2.txt": "two\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		t.Setenv("SIB_SEQUENCE_EDITOR", `sed -i -e '1s/^pick/edit/'`)
+		if err := Rebase(tmpDir, "master", RebaseOptions{Interactive: true}); err != nil {
+			t.Fatalf("Interactive rebase failed: %v", err)
+		}
+		if !rebaseInProgress(tmpDir) {
+			t.Fatal("Rebase should stop at the edit step")
+		}
+
+		writeFiles(t, tmpDir, map[string]string{"f1.txt": "one, amended\n"})
+		if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if err := RebaseContinue(tmpDir); err != nil {
+			t.Fatalf("RebaseContinue failed: %v", err)
+		}
+
+		if got := messages(t, tmpDir, LogOptions{}); got != "f2 f1 m1 base" {
+			t.Errorf("History = %q", got)
+		}
+		if got := readFile(t, tmpDir, "f1.txt"); got != "one, amended\n" {
+			t.Errorf("f1.txt = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if !status.IsClean() {
+			t.Errorf("Working tree should be clean: %+v", status)
+		}
+	})
+
+	t.Run("Refuses dirty tree", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"m.txt": "master\n"},
+			map[string]string{"f1.txt": "one\n"})
+		Switch(tmpDir, "feature", SwitchOptions{})
+
+		writeFiles(t, tmpDir, map[string]string{"f1.txt": "dirty\n"})
+		if err := Rebase(tmpDir, "master", RebaseOptions{}); err == nil {
+			t.Error("Rebase with unstaged changes should fail")
+		}
+	})
+}
+
+func TestParseTodo(t *testing.T) {
+	items, err := parseTodo("p abc first\n# comment\n\nf def second\nexec make test\nreword 123\n")
+	if err != nil {
+		t.Fatalf("parseTodo failed: %v", err)
+	}
+	want := "pick abc first\nfixup def second\nexec make test\nreword 123\n"
+	if got := formatTodo(items); got != want {
+		t.Errorf("formatTodo = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"frobnicate abc", "pick", "exec"} {
+		if _, err := parseTodo(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+
+	for _, bad := range []string{"squash abc", "drop x\nfixup abc"} {
+		items, err := parseTodo(bad)
+		if err != nil {
+			t.Fatalf("parseTodo failed: %v", err)
+		}
+		if err := checkTodo(items); err == nil {
+			t.Errorf("Expected checkTodo error for %q", bad)
+		}
+	}
+}
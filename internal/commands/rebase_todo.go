@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Команды списка rebase
+const (
+	todoPick   = "pick"   // Применить коммит
+	todoReword = "reword" // Применить и изменить сообщение
+	todoEdit   = "edit"   // Применить и остановиться для правки
+	todoSquash = "squash" // Слить с предыдущим, объединив сообщения
+	todoFixup  = "fixup"  // Слить с предыдущим, отбросив сообщение
+	todoDrop   = "drop"   // Пропустить коммит
+	todoExec   = "exec"   // Выполнить команду оболочки
+)
+
+// todoAliases - однобуквенные сокращения команд, как в git
+var todoAliases = map[string]string{
+	"p": todoPick, "r": todoReword, "e": todoEdit,
+	"s": todoSquash, "f": todoFixup, "d": todoDrop, "x": todoExec,
+}
+
+// todoItem - строка списка rebase
+type todoItem struct {
+	Command string // Полное имя команды
+	Commit  string // Ревизия коммита (для всех команд, кроме exec)
+	Subject string // Заголовок коммита - только для читателя
+	Exec    string // Команда оболочки для exec
+}
+
+// String форматирует строку так, как она записывается в файл списка
+func (item todoItem) String() string {
+	if item.Command == todoExec {
+		return todoExec + " " + item.Exec
+	}
+	if item.Subject == "" {
+		return item.Command + " " + item.Commit
+	}
+	return item.Command + " " + item.Commit + " " + item.Subject
+}
+
+// parseTodo разбирает список rebase. Пустые строки и комментарии ('#') пропускаются
+func parseTodo(text string) ([]todoItem, error) {
+	var items []todoItem
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		word, rest, _ := strings.Cut(line, " ")
+		command := word
+		if full, ok := todoAliases[word]; ok {
+			command = full
+		}
+		rest = strings.TrimSpace(rest)
+
+		switch command {
+		case todoExec:
+			if rest == "" {
+				return nil, fmt.Errorf("line %d: missing command for exec", n+1)
+			}
+			items = append(items, todoItem{Command: todoExec, Exec: rest})
+		case todoPick, todoReword, todoEdit, todoSquash, todoFixup, todoDrop:
+			commit, subject, _ := strings.Cut(rest, " ")
+			if commit == "" {
+				return nil, fmt.Errorf("line %d: missing commit for %s", n+1, command)
+			}
+			items = append(items, todoItem{Command: command, Commit: commit, Subject: strings.TrimSpace(subject)})
+		default:
+			return nil, fmt.Errorf("line %d: invalid command %q", n+1, word)
+		}
+	}
+
+	return items, nil
+}
+
+// checkTodo проверяет полный список перед запуском: squash и fixup сливают коммит
+// с предыдущим, поэтому перед первым из них должен быть применен хотя бы один коммит
+func checkTodo(items []todoItem) error {
+	for _, item := range items {
+		if item.Command == todoSquash || item.Command == todoFixup {
+			return fmt.Errorf("cannot '%s' without a previous commit", item.Command)
+		}
+		if item.Command != todoExec && item.Command != todoDrop {
+			break
+		}
+	}
+	return nil
+}
+
+// formatTodo записывает список rebase по строке на элемент
+func formatTodo(items []todoItem) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(item.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// todoHelp - подсказка в конце списка для интерактивного rebase
+const todoHelp = `
+# Commands:
+# p, pick <commit> = use commit
+# r, reword <commit> = use commit, but edit the commit message
+# e, edit <commit> = use commit, but stop for amending
+# s, squash <commit> = use commit, but meld into previous commit
+# f, fixup <commit> = like "squash", but discard this commit's message
+# x, exec <command> = run command (the rest of the line) using shell
+# d, drop <commit> = remove commit
+#
+# These lines can be re-ordered; they are executed from top to bottom.
+# If you remove a line here THAT COMMIT WILL BE LOST.
+# However, if you remove everything, the rebase will be aborted.
+`
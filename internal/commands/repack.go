@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+
+	"sib/internal/core/repository"
+)
+
+// RepackOptions - параметры repack
+type RepackOptions struct {
+	Delete bool // -d: удалить отдельные файлы упакованных объектов
+}
+
+// RepackResult - итог repack
+type RepackResult struct {
+	Pack    string // Имя нового pack-файла ("" - упаковывать было нечего)
+	Objects int    // Сколько объектов упаковано
+	Removed int    // Сколько отдельных файлов объектов удалено
+}
+
+// Repack упаковывает все объекты, хранящиеся отдельными файлами, в новый pack-файл
+func Repack(repoPath string, opts RepackOptions) (*RepackResult, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	loose, err := repo.Objects.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+	result := &RepackResult{}
+	if len(loose) == 0 {
+		fmt.Println("Nothing new to pack.")
+		return result, nil
+	}
+
+	if result.Pack, err = repo.Objects.WritePack(loose); err != nil {
+		return nil, err
+	}
+	result.Objects = len(loose)
+	fmt.Printf("Packed %d objects into %s\n", result.Objects, result.Pack)
+
+	if opts.Delete {
+		if result.Removed, err = repo.Objects.PrunePacked(); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Removed %d loose objects\n", result.Removed)
+	}
+	return result, nil
+}
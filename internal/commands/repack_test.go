@@ -0,0 +1,33 @@
+package commands
+
+import "testing"
+
+func TestRepack(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir)
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n"})
+	commitFiles(t, tmpDir, "second", map[string]string{"a.txt": "changed\n"})
+
+	result, err := Repack(tmpDir, RepackOptions{Delete: true})
+	if err != nil {
+		t.Fatalf("Repack failed: %v", err)
+	}
+	if result.Pack == "" || result.Objects == 0 || result.Removed != result.Objects {
+		t.Errorf("Result = %+v", result)
+	}
+
+	// История и рабочий каталог читаются из pack-файла
+	if got := messages(t, tmpDir, LogOptions{}); got != "second base" {
+		t.Errorf("History = %q", got)
+	}
+	if err := Checkout(tmpDir, "HEAD~1", SwitchOptions{}); err != nil {
+		t.Fatalf("Checkout from pack failed: %v", err)
+	}
+	if got := readFile(t, tmpDir, "a.txt"); got != "a\n" {
+		t.Errorf("a.txt = %q", got)
+	}
+
+	if result, err := Repack(tmpDir, RepackOptions{}); err != nil || result.Pack != "" {
+		t.Errorf("Second repack = %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"sib/internal/core/index"
+	"sib/internal/core/objects"
+	"sib/internal/core/repository"
+)
+
+// ResetMode - что reset приводит к целевому коммиту помимо ветки
+type ResetMode int
+
+const (
+	ResetMixed ResetMode = iota // Ветка и индекс (по умолчанию)
+	ResetSoft                   // Только ветка
+	ResetHard                   // Ветка, индекс и рабочий каталог
+)
+
+// ResetOptions - параметры reset
+type ResetOptions struct {
+	Mode ResetMode
+}
+
+// Reset передвигает текущую ветку (или отсоединенный HEAD) на коммит rev.
+// В режиме mixed индекс перестраивается из дерева коммита, в режиме hard
+// этим деревом перезаписывается и рабочий каталог. Пустая ревизия - HEAD
+func Reset(repoPath, rev string, opts ResetOptions) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	mergeHead, err := readStateFile(repo.SibDir, mergeHeadFile)
+	if err != nil {
+		return err
+	}
+	if opts.Mode == ResetSoft && mergeHead != "" {
+		return fmt.Errorf("cannot do a soft reset in the middle of a merge")
+	}
+
+	parser := newRevParser(repo)
+	hash, err := parser.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+	commit, err := repo.Objects.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+
+	branch, head, err := repo.Refs.Head()
+	if err != nil {
+		return err
+	}
+
+	if opts.Mode != ResetSoft {
+		idx, err := repo.LockIndex()
+		if errors.Is(err, index.ErrCorrupt) {
+			// Индекс все равно строится заново из дерева коммита, поэтому
+			// поврежденный файл откладываем в сторону и начинаем с пустого
+			backup, rerr := repo.RecoverIndex()
+			if rerr != nil {
+				return rerr
+			}
+			fmt.Printf("warning: index file corrupt, moved to %s\n", backup)
+			idx, err = repo.LockIndex()
+		}
+		if err != nil {
+			return err
+		}
+		defer idx.Unlock()
+		if opts.Mode == ResetHard {
+			err = resetToTree(repo, idx, commit.Tree())
+		} else {
+			err = resetIndex(repo, idx, commit.Tree())
+		}
+		if err != nil {
+			return err
+		}
+		if err := idx.Save(); err != nil {
+			return fmt.Errorf("failed to save index: %w", err)
+		}
+	}
+
+	if head != hash {
+		if !head.IsEmpty() {
+			if err := writeStateFile(repo.SibDir, origHeadFile, head.String()); err != nil {
+				return err
+			}
+		}
+		if err := advanceHead(repo.Refs, branch, head, hash); err != nil {
+			return err
+		}
+	}
+
+	// Незавершенные слияние и перенос теряют смысл после сброса индекса
+	if opts.Mode != ResetSoft {
+		if err := removeStateFiles(repo.SibDir, mergeHeadFile, mergeMsgFile, cherryPickHeadFile, revertHeadFile); err != nil {
+			return err
+		}
+	}
+
+	switch opts.Mode {
+	case ResetHard:
+		fmt.Printf("HEAD is now at %s %s\n", shortHash(hash), firstLine(commit.Message()))
+	case ResetMixed:
+		status, err := collectStatus(repo)
+		if err != nil {
+			return err
+		}
+		if len(status.Unstaged) > 0 {
+			fmt.Println("Unstaged changes after reset:")
+			for _, change := range status.Unstaged {
+				fmt.Printf("%c\t%s\n", change.Kind, change.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resetIndex перестраивает индекс из дерева treeHash, не трогая рабочий каталог
+func resetIndex(repo *repository.Repository, idx *index.Index, treeHash objects.Hash) error {
+	files, err := flattenCommitTree(repo.Objects, treeHash)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range idx.UnmergedPaths() {
+		if err := idx.Remove(path); err != nil {
+			return err
+		}
+	}
+	for path := range idx.Entries {
+		if _, ok := files[path]; !ok {
+			if err := idx.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range files {
+		if err := stageTreeFile(idx, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
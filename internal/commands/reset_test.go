@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sib/internal/core/index"
+)
+
+// twoCommitRepo создает историю base -> second и рабочие изменения поверх second
+func twoCommitRepo(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "one\n"})
+	commitFiles(t, tmpDir, "second", map[string]string{"a.txt": "second\n", "b.txt": "new\n"})
+	return tmpDir
+}
+
+func TestReset(t *testing.T) {
+	t.Run("Soft keeps index and worktree", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		if err := Reset(tmpDir, "HEAD~1", ResetOptions{Mode: ResetSoft}); err != nil {
+			t.Fatalf("Reset --soft failed: %v", err)
+		}
+
+		if got := messages(t, tmpDir, LogOptions{}); got != "base" {
+			t.Errorf("History = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 2 || len(status.Unstaged) != 0 {
+			t.Errorf("Changes of the undone commit should stay staged: %+v", status)
+		}
+		if origHead, _ := readStateFile(filepath.Join(tmpDir, ".sib"), origHeadFile); origHead == "" {
+			t.Error("ORIG_HEAD should be written")
+		}
+	})
+
+	t.Run("Mixed resets the index only", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		if err := Reset(tmpDir, "HEAD~1", ResetOptions{}); err != nil {
+			t.Fatalf("Reset failed: %v", err)
+		}
+
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 0 {
+			t.Errorf("Index should match HEAD, staged: %+v", status.Staged)
+		}
+		if len(status.Unstaged) != 1 || status.Unstaged[0].Path != "a.txt" {
+			t.Errorf("Unstaged = %+v", status.Unstaged)
+		}
+		if len(status.Untracked) != 1 || status.Untracked[0] != "b.txt" {
+			t.Errorf("Untracked = %+v", status.Untracked)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "second\n" {
+			t.Errorf("Worktree should be kept, a.txt = %q", got)
+		}
+	})
+
+	t.Run("Mixed to HEAD unstages changes", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "three\n"})
+		Add(tmpDir, nil, AddOptions{})
+
+		if err := Reset(tmpDir, "", ResetOptions{}); err != nil {
+			t.Fatalf("Reset failed: %v", err)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 0 || len(status.Unstaged) != 1 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("Hard overwrites the worktree", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "dirty\n", "c.txt": "staged\n"})
+		Add(tmpDir, nil, AddOptions{})
+
+		if err := Reset(tmpDir, "HEAD~1", ResetOptions{Mode: ResetHard}); err != nil {
+			t.Fatalf("Reset --hard failed: %v", err)
+		}
+
+		if got := readFile(t, tmpDir, "a.txt"); got != "one\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		for _, path := range []string{"b.txt", "c.txt"} {
+			if got := readFile(t, tmpDir, path); got != "<missing>" {
+				t.Errorf("%s should be removed, got %q", path, got)
+			}
+		}
+		status, _ := CollectStatus(tmpDir)
+		if !status.IsClean() {
+			t.Errorf("Working tree should be clean: %+v", status)
+		}
+
+		if err := Reset(tmpDir, "ORIG_HEAD", ResetOptions{Mode: ResetHard}); err != nil {
+			t.Fatalf("Reset to ORIG_HEAD failed: %v", err)
+		}
+		if got := messages(t, tmpDir, LogOptions{}); got != "second base" {
+			t.Errorf("History = %q", got)
+		}
+	})
+
+	t.Run("Hard aborts a conflicted merge", func(t *testing.T) {
+		tmpDir := divergedRepo(t,
+			map[string]string{"a.txt": "master\n"},
+			map[string]string{"a.txt": "feature\n"})
+		if _, err := Merge(tmpDir, "feature", MergeOptions{}); err == nil {
+			t.Fatal("Expected a conflict")
+		}
+		if err := Reset(tmpDir, "", ResetOptions{Mode: ResetSoft}); err == nil {
+			t.Error("Soft reset during a merge should fail")
+		}
+
+		if err := Reset(tmpDir, "", ResetOptions{Mode: ResetHard}); err != nil {
+			t.Fatalf("Reset --hard failed: %v", err)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if !status.IsClean() || len(status.Unmerged) != 0 {
+			t.Errorf("Working tree should be clean: %+v", status)
+		}
+		if _, err := MergeContinue(tmpDir); err == nil {
+			t.Error("Merge state should be removed")
+		}
+	})
+	t.Run("Corrupt index is rebuilt", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		indexPath := filepath.Join(tmpDir, ".sib", "index")
+		if err := os.WriteFile(indexPath, []byte(`{"entries": {`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CollectStatus(tmpDir); !errors.Is(err, index.ErrCorrupt) {
+			t.Fatalf("Status on a corrupt index: got %v, want ErrCorrupt", err)
+		}
+		if err := Add(tmpDir, []string{"a.txt"}, AddOptions{}); !errors.Is(err, index.ErrCorrupt) {
+			t.Fatalf("Add on a corrupt index: got %v, want ErrCorrupt", err)
+		}
+
+		if err := Reset(tmpDir, "", ResetOptions{}); err != nil {
+			t.Fatalf("Reset failed: %v", err)
+		}
+		status, err := CollectStatus(tmpDir)
+		if err != nil || !status.IsClean() {
+			t.Errorf("Index should be rebuilt from HEAD: %+v, %v", status, err)
+		}
+		if _, err := os.Stat(indexPath + ".corrupt"); err != nil {
+			t.Errorf("Corrupt index should be kept aside: %v", err)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/pathspec"
+	"sib/internal/core/repository"
+	"sib/internal/core/storage"
+)
+
+// RestoreOptions - параметры restore
+type RestoreOptions struct {
+	Source   string // --source: ревизия, из которой берутся файлы
+	Staged   bool   // --staged: восстановить индекс
+	Worktree bool   // --worktree: восстановить рабочий каталог (по умолчанию, если не задан Staged)
+}
+
+// Restore восстанавливает файлы в рабочем каталоге и/или индексе.
+// Без --source рабочий каталог восстанавливается из индекса, а индекс - из HEAD.
+// Файлы, которых нет в источнике, удаляются из восстанавливаемых мест
+func Restore(repoPath string, paths []string, opts RestoreOptions) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("you must specify path(s) to restore")
+	}
+	if !opts.Staged {
+		opts.Worktree = true
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+
+	// Источник: дерево ревизии или индекс
+	fromIndex := opts.Source == "" && !opts.Staged
+	var source map[string]storage.TreeFile
+	if fromIndex {
+		source = make(map[string]storage.TreeFile)
+		for _, entry := range idx.GetAllEntries() {
+			source[entry.Path] = storage.TreeFile{
+				Path: entry.Path,
+				Mode: objects.FileMode(entry.Mode),
+				Hash: objects.Hash(entry.Hash),
+			}
+		}
+	} else {
+		rev := opts.Source
+		if rev == "" {
+			rev = "HEAD"
+		}
+		if source, err = restoreSource(repo, rev); err != nil {
+			return err
+		}
+	}
+
+	// Кандидаты - файлы источника и индекса: отслеживаемые файлы, которых нет
+	// в источнике, тоже восстанавливаются (удалением)
+	candidates := make(map[string]bool)
+	for path := range source {
+		candidates[path] = true
+	}
+	for path := range idx.Entries {
+		candidates[path] = true
+	}
+
+	spec, err := pathspec.ParseRelative(repo.Prefix, paths)
+	if err != nil {
+		return err
+	}
+	known := make([]string, 0, len(candidates))
+	for path := range candidates {
+		known = append(known, path)
+	}
+	if unmatched := spec.Unmatched(known); len(unmatched) > 0 {
+		return fmt.Errorf("pathspec '%s' did not match any file(s) known to sib", unmatched[0])
+	}
+
+	var selected []string
+	for _, path := range known {
+		if spec.Match(path) {
+			selected = append(selected, path)
+		}
+	}
+	sort.Strings(selected)
+
+	for _, path := range selected {
+		file, inSource := source[path]
+		_, inIndex := idx.Entries[path]
+
+		switch {
+		case !inSource:
+			if opts.Staged && inIndex {
+				if err := idx.Remove(path); err != nil {
+					return err
+				}
+			}
+			if opts.Worktree {
+				if err := removeWorktreeFile(repo.WorkTree, path); err != nil {
+					return err
+				}
+			}
+
+		case opts.Worktree && (opts.Staged || fromIndex):
+			// Запись индекса получает актуальные размер и время файла
+			if err := checkoutFile(repo.WorkTree, repo.Objects, idx, path, file.Mode, file.Hash); err != nil {
+				return err
+			}
+
+		case opts.Worktree:
+			fullPath := filepath.Join(repo.WorkTree, filepath.FromSlash(path))
+			if err := writeWorktreeBlob(repo.Objects, fullPath, file.Mode, file.Hash); err != nil {
+				return err
+			}
+
+		default:
+			if err := stageTreeFile(idx, file); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	return nil
+}
+
+// restoreSource возвращает файлы дерева коммита rev; HEAD нерожденной ветки - пустое дерево
+func restoreSource(repo *repository.Repository, rev string) (map[string]storage.TreeFile, error) {
+	if rev == "HEAD" {
+		if _, head, err := repo.Refs.Head(); err != nil {
+			return nil, err
+		} else if head.IsEmpty() {
+			return make(map[string]storage.TreeFile), nil
+		}
+	}
+
+	parser := newRevParser(repo)
+	hash, err := parser.ResolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.Objects.ReadCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	return flattenCommitTree(repo.Objects, commit.Tree())
+}
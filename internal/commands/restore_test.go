@@ -0,0 +1,80 @@
+package commands
+
+import "testing"
+
+func TestRestore(t *testing.T) {
+	t.Run("Worktree from the index", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "staged\n"})
+		Add(tmpDir, nil, AddOptions{})
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "dirty\n"})
+
+		if err := Restore(tmpDir, []string{"a.txt"}, RestoreOptions{}); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "staged\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 1 || len(status.Unstaged) != 0 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("Staged unstages and keeps the worktree", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "three\n", "c.txt": "new\n"})
+		Add(tmpDir, nil, AddOptions{})
+
+		if err := Restore(tmpDir, []string{"."}, RestoreOptions{Staged: true}); err != nil {
+			t.Fatalf("Restore --staged failed: %v", err)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 0 {
+			t.Errorf("Nothing should stay staged: %+v", status.Staged)
+		}
+		if len(status.Unstaged) != 1 || len(status.Untracked) != 1 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "three\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+	})
+
+	t.Run("Source and both targets", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+
+		opts := RestoreOptions{Source: "HEAD~1", Staged: true, Worktree: true}
+		if err := Restore(tmpDir, []string{"a.txt", "b.txt"}, opts); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "one\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		if got := readFile(t, tmpDir, "b.txt"); got != "<missing>" {
+			t.Errorf("File absent in the source should be removed, b.txt = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 2 || len(status.Unstaged) != 0 || len(status.Untracked) != 0 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("Worktree from a source keeps the index", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		if err := Restore(tmpDir, []string{"a.txt"}, RestoreOptions{Source: "HEAD~1"}); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 0 || len(status.Unstaged) != 1 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("Unknown path", func(t *testing.T) {
+		tmpDir := twoCommitRepo(t)
+		if err := Restore(tmpDir, []string{"nope.txt"}, RestoreOptions{}); err == nil {
+			t.Error("Expected error for an unknown path")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/revparse"
+)
+
+// RevParseOptions - параметры команды rev-parse
+type RevParseOptions struct {
+	Verify bool // --verify: ровно одна ревизия, которая должна существовать
+	Short  bool // --short: печатать сокращенные хеши
+}
+
+// RevParse разбирает выражения ревизий и печатает хеши.
+// Для диапазонов печатаются включаемые коммиты и исключаемые с префиксом '^'
+func RevParse(repoPath string, args []string, opts RevParseOptions) ([]string, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := newRevParser(repo)
+
+	if opts.Verify && len(args) != 1 {
+		return nil, fmt.Errorf("--verify requires exactly one revision")
+	}
+
+	format := func(hash objects.Hash) string {
+		if opts.Short {
+			return shortHash(hash)
+		}
+		return hash.String()
+	}
+
+	var lines []string
+	for _, arg := range args {
+		if opts.Verify {
+			hash, err := parser.Resolve(arg)
+			if err != nil {
+				return nil, fmt.Errorf("needed a single revision: %w", err)
+			}
+			lines = append(lines, format(hash))
+			continue
+		}
+
+		rng, err := parser.ParseRange(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range rng.Include {
+			lines = append(lines, format(hash))
+		}
+		for _, hash := range rng.Exclude {
+			lines = append(lines, "^"+format(hash))
+		}
+	}
+
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	return lines, nil
+}
+
+// newRevParser создает парсер ревизий для репозитория.
+// @{upstream} берется из branch.<имя>.remote и branch.<имя>.merge
+func newRevParser(repo *repository.Repository) *revparse.Parser {
+	parser := revparse.NewParser(repo.Objects, repo.Refs)
+	parser.Upstream = func(branch string) (string, error) {
+		return branchUpstream(repo, branch)
+	}
+	return parser
+}
+
+// branchUpstream возвращает ссылку, которую отслеживает ветка: для remote "."
+// это сама ветка из branch.<имя>.merge, иначе - ее копия в refs/remotes/<remote>/
+func branchUpstream(repo *repository.Repository, branch string) (string, error) {
+	name := strings.TrimPrefix(branch, refs.HeadsPrefix)
+	remote, hasRemote := repo.Config.Get("branch." + name + ".remote")
+	merge, hasMerge := repo.Config.Get("branch." + name + ".merge")
+	if !hasRemote || !hasMerge || remote == "" || merge == "" {
+		return "", fmt.Errorf("no upstream configured for branch '%s'", name)
+	}
+
+	if remote == "." {
+		return merge, nil
+	}
+	if !strings.HasPrefix(merge, refs.HeadsPrefix) {
+		return "", fmt.Errorf("upstream branch '%s' of '%s' is not a branch", merge, name)
+	}
+	return "refs/remotes/" + remote + "/" + strings.TrimPrefix(merge, refs.HeadsPrefix), nil
+}
@@ -0,0 +1,80 @@
+// internal/commands/revparse_test.go
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/repository"
+)
+
+func TestRevParse(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	commitFiles(t, tmpDir, "one", map[string]string{"a.txt": "1"})
+	commitFiles(t, tmpDir, "two", map[string]string{"a.txt": "22"})
+
+	head, err := RevParse(tmpDir, []string{"HEAD"}, RevParseOptions{})
+	if err != nil || len(head) != 1 || len(head[0]) != 64 {
+		t.Fatalf("RevParse HEAD = %v, %v", head, err)
+	}
+
+	t.Run("Short hash round trip", func(t *testing.T) {
+		lines, err := RevParse(tmpDir, []string{head[0][:8]}, RevParseOptions{Verify: true})
+		if err != nil || lines[0] != head[0] {
+			t.Errorf("RevParse short = %v, %v", lines, err)
+		}
+	})
+
+	t.Run("Range output", func(t *testing.T) {
+		lines, err := RevParse(tmpDir, []string{"HEAD~1..master"}, RevParseOptions{})
+		if err != nil {
+			t.Fatalf("RevParse range failed: %v", err)
+		}
+		if len(lines) != 2 || lines[0] != head[0] || !strings.HasPrefix(lines[1], "^") {
+			t.Errorf("Unexpected range output: %v", lines)
+		}
+	})
+
+	t.Run("Verify rejects ranges and garbage", func(t *testing.T) {
+		if _, err := RevParse(tmpDir, []string{"nope"}, RevParseOptions{Verify: true}); err == nil {
+			t.Error("Expected error for unknown revision")
+		}
+		if _, err := RevParse(tmpDir, []string{"HEAD", "HEAD~1"}, RevParseOptions{Verify: true}); err == nil {
+			t.Error("Expected error for two revisions with --verify")
+		}
+	})
+	t.Run("Upstream from branch config", func(t *testing.T) {
+		if _, err := RevParse(tmpDir, []string{"@{u}"}, RevParseOptions{}); err == nil || !strings.Contains(err.Error(), "no upstream configured") {
+			t.Errorf("Expected no upstream error, got %v", err)
+		}
+
+		repo, err := repository.Discover(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parent, _ := RevParse(tmpDir, []string{"HEAD~1"}, RevParseOptions{})
+		repo.Refs.Set("refs/heads/base", objects.Hash(parent[0]))
+		repo.Refs.Set("refs/remotes/origin/master", objects.Hash(parent[0]))
+
+		// Локальная ветка как upstream (remote ".")
+		ConfigSet(tmpDir, "branch.master.remote", ".", ConfigOptions{})
+		ConfigSet(tmpDir, "branch.master.merge", "refs/heads/base", ConfigOptions{})
+		if lines, err := RevParse(tmpDir, []string{"@{u}"}, RevParseOptions{}); err != nil || lines[0] != parent[0] {
+			t.Errorf("RevParse @{u} = %v, %v", lines, err)
+		}
+		if got := messages(t, tmpDir, LogOptions{Revisions: []string{"@{u}..master"}}); got != "two" {
+			t.Errorf("log @{u}..master = %q", got)
+		}
+
+		// Ветка удаленного репозитория - через refs/remotes/<remote>/
+		ConfigSet(tmpDir, "branch.master.remote", "origin", ConfigOptions{})
+		ConfigSet(tmpDir, "branch.master.merge", "refs/heads/master", ConfigOptions{})
+		if lines, err := RevParse(tmpDir, []string{"master@{upstream}"}, RevParseOptions{}); err != nil || lines[0] != parent[0] {
+			t.Errorf("RevParse master@{upstream} = %v, %v", lines, err)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"sib/internal/core/index"
+	"sib/internal/core/objects"
+	"sib/internal/core/pathspec"
+	"sib/internal/core/repository"
+	"sib/internal/core/storage"
+)
+
+// RmOptions - параметры rm
+type RmOptions struct {
+	Cached    bool // --cached: удалить только из индекса, оставив файл
+	Force     bool // -f: не проверять локальные изменения
+	Recursive bool // -r: разрешить удаление каталогов
+}
+
+// Rm удаляет отслеживаемые файлы, выбранные pathspec, из индекса и рабочего каталога.
+// Без Force отказывается удалять файлы, изменения которых будут потеряны
+func Rm(repoPath string, paths []string, opts RmOptions) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no pathspec given")
+	}
+	spec, err := pathspec.ParseRelative(repo.Prefix, paths)
+	if err != nil {
+		return err
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	defer idx.Unlock()
+
+	tracked := make([]string, 0, len(idx.Entries))
+	for path := range idx.Entries {
+		tracked = append(tracked, path)
+	}
+	tracked = append(tracked, idx.UnmergedPaths()...)
+	sort.Strings(tracked)
+	if unmatched := spec.Unmatched(tracked); len(unmatched) > 0 {
+		return fmt.Errorf("pathspec '%s' did not match any files", unmatched[0])
+	}
+
+	var selected []string
+	for _, path := range tracked {
+		if !spec.Match(path) {
+			continue
+		}
+		if !opts.Recursive {
+			for _, item := range spec.Items {
+				if !item.Exclude && !item.Glob && item.Pattern != path && item.Match(path) {
+					return fmt.Errorf("not removing '%s' recursively without -r", item.Original)
+				}
+			}
+		}
+		selected = append(selected, path)
+	}
+
+	if !opts.Force {
+		if err := checkRemovable(repo, idx, selected, opts.Cached); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range selected {
+		if err := idx.Remove(path); err != nil {
+			return err
+		}
+		if !opts.Cached {
+			if err := removeWorktreeFile(repo.WorkTree, path); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("rm '%s'\n", path)
+	}
+
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	return nil
+}
+
+// checkRemovable проверяет, что удаление не потеряет данных: содержимое индекса
+// должно совпадать с HEAD или с файлом. Без cached, когда удаляется и файл,
+// должны совпадать все три версии
+func checkRemovable(repo *repository.Repository, idx *index.Index, paths []string, cached bool) error {
+	_, head, err := repo.Refs.Head()
+	if err != nil {
+		return err
+	}
+	var headTree objects.Hash
+	if !head.IsEmpty() {
+		commit, err := repo.Objects.ReadCommit(head)
+		if err != nil {
+			return err
+		}
+		headTree = commit.Tree()
+	}
+	headFiles, err := flattenCommitTree(repo.Objects, headTree)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		entry, err := idx.Get(path)
+		if err != nil {
+			continue // Конфликт: версии в индексе нет, терять нечего
+		}
+		file := storage.TreeFile{Path: path, Mode: objects.FileMode(entry.Mode), Hash: objects.Hash(entry.Hash)}
+
+		headFile, inHead := headFiles[path]
+		stagedChanged := !inHead || headFile.Hash != file.Hash || headFile.Mode != file.Mode
+		worktreeChanged := worktreeExists(repo.WorkTree, path) && !isUpToDate(repo.WorkTree, idx, path, file)
+
+		switch {
+		case stagedChanged && worktreeChanged:
+			return fmt.Errorf("'%s' has staged content different from both the file and the HEAD (use -f to force removal)", path)
+		case cached:
+		case stagedChanged:
+			return fmt.Errorf("'%s' has changes staged in the index (use --cached to keep the file, or -f to force removal)", path)
+		case worktreeChanged:
+			return fmt.Errorf("'%s' has local modifications (use --cached to keep the file, or -f to force removal)", path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package commands
+
+import "testing"
+
+func TestRm(t *testing.T) {
+	t.Run("Removes files and directories", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "dir/b.go": "b\n", "dir/c.txt": "c\n"})
+
+		if err := Rm(tmpDir, []string{"dir"}, RmOptions{}); err == nil {
+			t.Error("Removing a directory without -r should fail")
+		}
+		if err := Rm(tmpDir, []string{"dir", ":!*.go"}, RmOptions{Recursive: true}); err != nil {
+			t.Fatalf("Rm -r failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "a.txt dir/b.go" {
+			t.Errorf("Index = %q", got)
+		}
+		if got := readFile(t, tmpDir, "dir/c.txt"); got != "<missing>" {
+			t.Errorf("dir/c.txt should be deleted, got %q", got)
+		}
+
+		if err := Rm(tmpDir, []string{"missing.txt"}, RmOptions{}); err == nil {
+			t.Error("Expected error for an untracked path")
+		}
+	})
+
+	t.Run("Cached keeps the file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "local change\n"})
+
+		if err := Rm(tmpDir, []string{"a.txt"}, RmOptions{Cached: true}); err != nil {
+			t.Fatalf("Rm --cached failed: %v", err)
+		}
+		if got := readFile(t, tmpDir, "a.txt"); got != "local change\n" {
+			t.Errorf("a.txt = %q", got)
+		}
+		status, _ := CollectStatus(tmpDir)
+		if len(status.Staged) != 1 || status.Staged[0].Kind != ChangeDeleted || len(status.Untracked) != 1 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("Refuses to lose changes", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "base", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "local change\n"})
+
+		if err := Rm(tmpDir, []string{"a.txt"}, RmOptions{}); err == nil {
+			t.Error("Rm of a modified file should fail")
+		}
+		Add(tmpDir, []string{"a.txt"}, AddOptions{})
+		if err := Rm(tmpDir, []string{"a.txt"}, RmOptions{}); err == nil {
+			t.Error("Rm of a staged file should fail")
+		}
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "another change\n"})
+		if err := Rm(tmpDir, []string{"a.txt"}, RmOptions{Cached: true}); err == nil {
+			t.Error("Rm --cached should fail when index differs from both HEAD and the file")
+		}
+
+		if err := Rm(tmpDir, []string{"a.txt"}, RmOptions{Force: true}); err != nil {
+			t.Fatalf("Rm -f failed: %v", err)
+		}
+		if got := indexPaths(t, tmpDir); got != "b.txt" {
+			t.Errorf("Index = %q", got)
+		}
+	})
+}
@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"sib/internal/core/index"
+	"sib/internal/core/objects"
+	"sib/internal/core/pathspec"
+	"sib/internal/core/repository"
+	"sib/internal/core/storage"
+)
+
+// StatusFormat - формат вывода sib status
+type StatusFormat int
+
+const (
+	StatusLong      StatusFormat = iota // Подробный вывод по секциям
+	StatusShort                         // Короткий вывод "XY path"
+	StatusPorcelain                     // Стабильный формат для скриптов
+)
+
+// ChangeKind - вид изменения файла
+type ChangeKind byte
+
+const (
+	ChangeNone     ChangeKind = ' '
+	ChangeAdded    ChangeKind = 'A'
+	ChangeModified ChangeKind = 'M'
+	ChangeDeleted  ChangeKind = 'D'
+)
+
+// FileChange - изменение одного файла
+type FileChange struct {
+	Path string
+	Kind ChangeKind
+}
+
+// StatusResult - состояние рабочего каталога и индекса
+type StatusResult struct {
+	Branch    string       // Текущая ветка (пусто для отсоединенного HEAD)
+	Head      objects.Hash // Коммит HEAD (пусто, если коммитов еще нет)
+	Staged    []FileChange // Индекс относительно дерева HEAD
+	Unstaged  []FileChange // Рабочий каталог относительно индекса
+	Untracked []string     // Файлы, которых нет в индексе
+	Unmerged  []string     // Пути с неразрешенными конфликтами слияния
+}
+
+// IsClean проверяет, что нет ни одного изменения
+func (r *StatusResult) IsClean() bool {
+	return len(r.Staged) == 0 && len(r.Unstaged) == 0 && len(r.Untracked) == 0 && len(r.Unmerged) == 0
+}
+
+// Status вычисляет и печатает состояние репозитория в указанном формате.
+// Непустой paths ограничивает вывод путями, выбранными pathspec
+func Status(repoPath string, format StatusFormat, paths []string) (*StatusResult, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := pathspec.ParseRelative(repo.Prefix, paths)
+	if err != nil {
+		return nil, err
+	}
+	result, err := collectStatus(repo)
+	if err != nil {
+		return nil, err
+	}
+	if !spec.IsEmpty() {
+		result.filter(spec)
+	}
+
+	switch format {
+	case StatusShort, StatusPorcelain:
+		printShortStatus(result)
+	default:
+		printLongStatus(result)
+	}
+
+	return result, nil
+}
+
+// filter оставляет в результате только пути, выбранные pathspec
+func (r *StatusResult) filter(spec *pathspec.Pathspec) {
+	filterChanges := func(changes []FileChange) []FileChange {
+		var kept []FileChange
+		for _, change := range changes {
+			if spec.Match(change.Path) {
+				kept = append(kept, change)
+			}
+		}
+		return kept
+	}
+	filterPaths := func(paths []string) []string {
+		var kept []string
+		for _, path := range paths {
+			if spec.Match(path) {
+				kept = append(kept, path)
+			}
+		}
+		return kept
+	}
+
+	r.Staged = filterChanges(r.Staged)
+	r.Unstaged = filterChanges(r.Unstaged)
+	r.Untracked = filterPaths(r.Untracked)
+	r.Unmerged = filterPaths(r.Unmerged)
+}
+
+// CollectStatus вычисляет состояние репозитория без вывода
+func CollectStatus(repoPath string) (*StatusResult, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return collectStatus(repo)
+}
+
+// collectStatus вычисляет состояние открытого репозитория
+func collectStatus(repo *repository.Repository) (*StatusResult, error) {
+	idx, err := repo.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	store := repo.Objects
+	refStore := repo.Refs
+
+	branch, head, err := refStore.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StatusResult{Branch: branch, Head: head}
+
+	// Staged: индекс против дерева HEAD
+	headFiles := make(map[string]storage.TreeFile)
+	if !head.IsEmpty() {
+		commit, err := store.ReadCommit(head)
+		if err != nil {
+			return nil, err
+		}
+		headFiles, err = store.FlattenTree(commit.Tree())
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Staged = diffIndexAgainstTree(idx, headFiles)
+	result.Unmerged = idx.UnmergedPaths()
+
+	// Unstaged и untracked: рабочий каталог против индекса
+	added, modified, deleted, err := idx.Diff(repo.WorkTree)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range modified {
+		// Size/mtime могут отличаться без изменения содержимого - сверяем хеш
+		entry, err := idx.Get(path)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := hashWorktreeFile(repo.WorkPath(path))
+		if err != nil {
+			return nil, err
+		}
+		if hash.String() != entry.Hash {
+			result.Unstaged = append(result.Unstaged, FileChange{Path: path, Kind: ChangeModified})
+		}
+	}
+	for _, path := range deleted {
+		result.Unstaged = append(result.Unstaged, FileChange{Path: path, Kind: ChangeDeleted})
+	}
+	sortChanges(result.Unstaged)
+
+	for _, path := range added {
+		if idx.ConflictStages(path) != nil {
+			continue
+		}
+		result.Untracked = append(result.Untracked, path)
+	}
+
+	return result, nil
+}
+
+// diffIndexAgainstTree сравнивает записи индекса с развернутым деревом коммита
+func diffIndexAgainstTree(idx *index.Index, treeFiles map[string]storage.TreeFile) []FileChange {
+	var changes []FileChange
+
+	for _, entry := range idx.GetAllEntries() {
+		file, exists := treeFiles[entry.Path]
+		switch {
+		case !exists:
+			changes = append(changes, FileChange{Path: entry.Path, Kind: ChangeAdded})
+		case file.Hash.String() != entry.Hash || string(file.Mode) != entry.Mode:
+			changes = append(changes, FileChange{Path: entry.Path, Kind: ChangeModified})
+		}
+	}
+
+	for path := range treeFiles {
+		if idx.ConflictStages(path) != nil {
+			continue // Конфликт показывается отдельно
+		}
+		if _, err := idx.Get(path); err != nil {
+			changes = append(changes, FileChange{Path: path, Kind: ChangeDeleted})
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// sortChanges сортирует изменения по пути
+func sortChanges(changes []FileChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+}
+
+// changeLabel возвращает подпись изменения для подробного вывода
+func changeLabel(kind ChangeKind) string {
+	switch kind {
+	case ChangeAdded:
+		return "new file:   "
+	case ChangeDeleted:
+		return "deleted:    "
+	default:
+		return "modified:   "
+	}
+}
+
+// printLongStatus печатает подробный статус по секциям
+func printLongStatus(r *StatusResult) {
+	if r.Branch != "" {
+		fmt.Printf("On branch %s\n", refDisplayName(r.Branch))
+	} else {
+		fmt.Printf("HEAD detached at %s\n", shortHash(r.Head))
+	}
+	if r.Head.IsEmpty() {
+		fmt.Println("\nNo commits yet")
+	}
+
+	if len(r.Staged) > 0 {
+		fmt.Println("\nChanges to be committed:")
+		for _, change := range r.Staged {
+			fmt.Printf("\t%s%s\n", changeLabel(change.Kind), change.Path)
+		}
+	}
+
+	if len(r.Unmerged) > 0 {
+		fmt.Println("\nUnmerged paths:")
+		fmt.Println("  (use \"sib add <file>...\" to mark resolution)")
+		for _, path := range r.Unmerged {
+			fmt.Printf("\tboth modified:   %s\n", path)
+		}
+	}
+
+	if len(r.Unstaged) > 0 {
+		fmt.Println("\nChanges not staged for commit:")
+		for _, change := range r.Unstaged {
+			fmt.Printf("\t%s%s\n", changeLabel(change.Kind), change.Path)
+		}
+	}
+
+	if len(r.Untracked) > 0 {
+		fmt.Println("\nUntracked files:")
+		for _, path := range r.Untracked {
+			fmt.Printf("\t%s\n", path)
+		}
+	}
+
+	if r.IsClean() {
+		fmt.Println("\nnothing to commit, working tree clean")
+	}
+}
+
+// printShortStatus печатает статус в формате "XY path":
+// X - состояние в индексе, Y - в рабочем каталоге, "??" - неотслеживаемый файл.
+// Этот же формат используется для --porcelain и не должен меняться
+func printShortStatus(r *StatusResult) {
+	for _, line := range ShortStatusLines(r) {
+		fmt.Println(line)
+	}
+}
+
+// ShortStatusLines формирует строки короткого статуса, отсортированные по пути
+func ShortStatusLines(r *StatusResult) []string {
+	type codes struct{ staged, unstaged ChangeKind }
+	byPath := make(map[string]*codes)
+
+	get := func(path string) *codes {
+		c, ok := byPath[path]
+		if !ok {
+			c = &codes{staged: ChangeNone, unstaged: ChangeNone}
+			byPath[path] = c
+		}
+		return c
+	}
+	for _, change := range r.Staged {
+		get(change.Path).staged = change.Kind
+	}
+	for _, change := range r.Unstaged {
+		get(change.Path).unstaged = change.Kind
+	}
+
+	paths := make([]string, 0, len(byPath))
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	lines := make([]string, 0, len(paths)+len(r.Unmerged)+len(r.Untracked))
+	for _, path := range r.Unmerged {
+		lines = append(lines, "UU "+path)
+	}
+	for _, path := range paths {
+		c := byPath[path]
+		lines = append(lines, fmt.Sprintf("%c%c %s", c.staged, c.unstaged, path))
+	}
+	for _, path := range r.Untracked {
+		lines = append(lines, "?? "+path)
+	}
+
+	return lines
+}
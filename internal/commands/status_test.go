@@ -0,0 +1,110 @@
+// internal/commands/status_test.go
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	t.Run("Fresh repo lists staged files as new", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one"})
+
+		result, err := CollectStatus(tmpDir)
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+
+		lines := strings.Join(ShortStatusLines(result), "\n")
+		if lines != "A  a.txt" {
+			t.Errorf("Unexpected status:\n%s", lines)
+		}
+	})
+
+	t.Run("Clean after commit", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{"a.txt": "one"})
+		if _, err := Commit(tmpDir, CommitOptions{Message: "first"}); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		result, err := CollectStatus(tmpDir)
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+		if !result.IsClean() {
+			t.Errorf("Expected clean status, got %v", ShortStatusLines(result))
+		}
+	})
+
+	t.Run("Staged, unstaged and untracked", func(t *testing.T) {
+		tmpDir := initRepoWithFiles(t, map[string]string{
+			"keep.txt":   "keep",
+			"change.txt": "v1",
+			"gone.txt":   "bye",
+			"staged.txt": "s1",
+		})
+		if _, err := Commit(tmpDir, CommitOptions{Message: "first"}); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		// Изменяем и индексируем staged.txt
+		writeFiles(t, tmpDir, map[string]string{"staged.txt": "s2 longer"})
+		if err := Add(tmpDir, nil, AddOptions{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+
+		// Изменения без индексации
+		writeFiles(t, tmpDir, map[string]string{
+			"change.txt":        "v2 longer",
+			"new.txt":           "untracked",
+			"build/out.o":       "ignored",
+			"x.tmp":             "ignored",
+			".sib/info/exclude": "build/\n*.tmp\n",
+		})
+		os.Remove(filepath.Join(tmpDir, "gone.txt"))
+
+		// Меняем mtime без изменения содержимого - это не изменение
+		future := time.Now().Add(time.Hour)
+		os.Chtimes(filepath.Join(tmpDir, "keep.txt"), future, future)
+
+		result, err := CollectStatus(tmpDir)
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+
+		got := strings.Join(ShortStatusLines(result), "\n")
+		want := strings.Join([]string{
+			" M change.txt",
+			" D gone.txt",
+			"M  staged.txt",
+			"?? new.txt",
+		}, "\n")
+		if got != want {
+			t.Errorf("Unexpected status:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("Pathspec limits the output", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		Init(tmpDir)
+		commitFiles(t, tmpDir, "first", map[string]string{"a.txt": "a\n", "src/b.go": "b\n"})
+		writeFiles(t, tmpDir, map[string]string{"a.txt": "changed\n", "src/b.go": "changed\n", "src/new.go": "new\n"})
+
+		result, err := Status(tmpDir, StatusShort, []string{"*.go", ":!src/new.go"})
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+		if got := strings.Join(ShortStatusLines(result), "\n"); got != " M src/b.go" {
+			t.Errorf("Unexpected status: %q", got)
+		}
+	})
+
+	t.Run("Fails outside repo", func(t *testing.T) {
+		if _, err := CollectStatus(t.TempDir()); err == nil {
+			t.Error("Expected error outside repo")
+		}
+	})
+}
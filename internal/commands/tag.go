@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+)
+
+// TagOptions - параметры создания тега
+type TagOptions struct {
+	Annotate bool   // -a: создать аннотированный тег
+	Message  string // -m: сообщение тега (подразумевает -a)
+	Force    bool   // -f: заменить существующий тег
+}
+
+// TagInfo - тег в выводе sib tag -l
+type TagInfo struct {
+	Name   string       // Короткое имя (без refs/tags/)
+	Target objects.Hash // Значение ссылки: объект тега или сам объект для легковесного
+	Tag    *objects.Tag // Объект тега; nil для легковесного
+}
+
+// CreateTag создает тег на target (HEAD, если пусто).
+// С Annotate или Message создается объект тега с автором и сообщением,
+// иначе - легковесный тег, то есть просто ссылка на объект
+func CreateTag(repoPath, name, target string, opts TagOptions) (objects.Hash, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return "", err
+	}
+	if err := refs.ValidateTagName(name); err != nil {
+		return "", err
+	}
+
+	if target == "" {
+		target = refs.HEAD
+	}
+	parser := newRevParser(repo)
+	hash, err := parser.Resolve(target)
+	if err != nil {
+		return "", err
+	}
+
+	refName := refs.TagsPrefix + name
+	previous, err := repo.Refs.Resolve(refName)
+	exists := err == nil
+	if exists && !opts.Force {
+		return "", fmt.Errorf("tag '%s' already exists", name)
+	}
+
+	if opts.Annotate || opts.Message != "" {
+		if strings.TrimSpace(opts.Message) == "" {
+			return "", fmt.Errorf("no tag message given (use -m)")
+		}
+
+		obj, err := repo.Objects.ReadObject(hash)
+		if err != nil {
+			return "", err
+		}
+		tagger, err := defaultSignature(repo.Config, time.Now())
+		if err != nil {
+			return "", err
+		}
+		tag, err := objects.NewTag(hash, obj.Type(), name, *tagger, opts.Message)
+		if err != nil {
+			return "", err
+		}
+		if hash, err = repo.Objects.WriteObject(tag); err != nil {
+			return "", fmt.Errorf("failed to write tag: %w", err)
+		}
+	}
+
+	if exists {
+		if err := repo.Refs.CompareAndSwap(refName, previous, hash); err != nil {
+			return "", err
+		}
+		if previous != hash {
+			fmt.Printf("Updated tag '%s' (was %s)\n", name, shortHash(previous))
+		}
+		return hash, nil
+	}
+
+	if err := repo.Refs.CompareAndSwap(refName, "", hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// ListTags возвращает теги, имена которых подходят под glob-шаблон (все, если шаблон пуст)
+func ListTags(repoPath, pattern string) ([]TagInfo, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := repo.Refs.List(refs.TagsPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []TagInfo
+	for _, ref := range list {
+		name := strings.TrimPrefix(ref.Name, refs.TagsPrefix)
+		if pattern != "" {
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		info := TagInfo{Name: name, Target: ref.Target}
+		if obj, err := repo.Objects.ReadObject(ref.Target); err == nil && obj.Type() == objects.TagObject {
+			if info.Tag, err = repo.Objects.ReadTag(ref.Target); err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, info)
+	}
+
+	return tags, nil
+}
+
+// PrintTags печатает имена тегов. При lines > 0 рядом выводятся первые строки
+// сообщения тега (или коммита для легковесного тега)
+func PrintTags(repoPath, pattern string, lines int) error {
+	tags, err := ListTags(repoPath, pattern)
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if lines <= 0 {
+			fmt.Println(tag.Name)
+			continue
+		}
+
+		var message string
+		if tag.Tag != nil {
+			message = tag.Tag.Message()
+		} else if commit, err := repo.Objects.ReadCommit(tag.Target); err == nil {
+			message = commit.Message()
+		}
+
+		annotation := strings.Split(strings.TrimRight(message, "\n"), "\n")
+		if len(annotation) > lines {
+			annotation = annotation[:lines]
+		}
+		fmt.Printf("%-15s %s\n", tag.Name, strings.Join(annotation, "\n                "))
+	}
+
+	return nil
+}
+
+// DeleteTag удаляет тег
+func DeleteTag(repoPath, name string) error {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return err
+	}
+
+	refName := refs.TagsPrefix + name
+	hash, err := repo.Refs.Resolve(refName)
+	if err != nil {
+		return fmt.Errorf("tag '%s' not found", name)
+	}
+	if err := repo.Refs.Delete(refName, hash); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted tag '%s' (was %s)\n", name, shortHash(hash))
+	return nil
+}
+
+// VerifyTag проверяет аннотированный тег и печатает его содержимое.
+// Тег корректен, если объект, на который он указывает, существует и имеет
+// указанный в теге тип, а имя в объекте совпадает с именем ссылки
+func VerifyTag(repoPath, name string) (*objects.Tag, error) {
+	repo, err := repository.Discover(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := repo.Refs.Resolve(refs.TagsPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("tag '%s' not found", name)
+	}
+
+	obj, err := repo.Objects.ReadObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Type() != objects.TagObject {
+		return nil, fmt.Errorf("%s: cannot verify a non-tag object of type %s", name, obj.Type())
+	}
+	tag, err := repo.Objects.ReadTag(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tagger := tag.Tagger()
+	fmt.Printf("object %s\n", tag.Object())
+	fmt.Printf("type %s\n", tag.ObjectType())
+	fmt.Printf("tag %s\n", tag.TagName())
+	fmt.Printf("tagger %s <%s> %s\n\n", tagger.Name(), tagger.Email(), tagger.Time().Format("Mon Jan 2 15:04:05 2006 -0700"))
+	fmt.Print(tag.Message())
+	if !strings.HasSuffix(tag.Message(), "\n") {
+		fmt.Println()
+	}
+
+	if tag.TagName() != name {
+		return nil, fmt.Errorf("tag '%s' is stored under a different name: '%s'", tag.TagName(), name)
+	}
+	target, err := repo.Objects.ReadObject(tag.Object())
+	if err != nil {
+		return nil, fmt.Errorf("tag '%s' points to a missing object %s: %w", name, tag.Object(), err)
+	}
+	if target.Type() != tag.ObjectType() {
+		return nil, fmt.Errorf("tag '%s' claims %s is a %s, but it is a %s", name, tag.Object(), tag.ObjectType(), target.Type())
+	}
+
+	return tag, nil
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/repository"
+	"sib/internal/core/storage"
+)
+
+// tagNames возвращает имена тегов через пробел
+func tagNames(t *testing.T, repoPath, pattern string) string {
+	t.Helper()
+	tags, err := ListTags(repoPath, pattern)
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestTag(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Init(tmpDir); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Setenv("SIB_AUTHOR_NAME", "Release Bot")
+	t.Setenv("SIB_AUTHOR_EMAIL", "bot@example.com")
+
+	commitFiles(t, tmpDir, "one", map[string]string{"a.txt": "1"})
+	commitFiles(t, tmpDir, "two", map[string]string{"a.txt": "2"})
+
+	store, _ := storage.NewObjectStore(tmpDir)
+	refStore, _ := refs.NewRefStore(tmpDir)
+	_, head, _ := refStore.Head()
+
+	t.Run("Lightweight", func(t *testing.T) {
+		hash, err := CreateTag(tmpDir, "light", "HEAD~1", TagOptions{})
+		if err != nil {
+			t.Fatalf("CreateTag failed: %v", err)
+		}
+		commit, err := store.ReadCommit(hash)
+		if err != nil || commit.Message() != "one" {
+			t.Errorf("Lightweight tag should point to commit 'one': %v", err)
+		}
+	})
+
+	t.Run("Annotated", func(t *testing.T) {
+		hash, err := CreateTag(tmpDir, "v1.0", "", TagOptions{Annotate: true, Message: "Release 1.0"})
+		if err != nil {
+			t.Fatalf("CreateTag failed: %v", err)
+		}
+		tag, err := store.ReadTag(hash)
+		if err != nil {
+			t.Fatalf("ReadTag failed: %v", err)
+		}
+		tagger := tag.Tagger()
+		if tag.Object() != head || tag.ObjectType() != objects.CommitObject || tagger.Name() != "Release Bot" {
+			t.Errorf("Unexpected tag: %s %s by %s", tag.ObjectType(), tag.Object(), tagger.Name())
+		}
+
+		// Аннотированный тег разыменовывается до коммита
+		repo, _ := repository.Discover(tmpDir)
+		parser := newRevParser(repo)
+		if got, err := parser.ResolveCommit("v1.0"); err != nil || got != head {
+			t.Errorf("ResolveCommit(v1.0) = %s, %v", got, err)
+		}
+
+		if _, err := VerifyTag(tmpDir, "v1.0"); err != nil {
+			t.Errorf("VerifyTag failed: %v", err)
+		}
+		if _, err := VerifyTag(tmpDir, "light"); err == nil {
+			t.Error("Expected VerifyTag to reject a lightweight tag")
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		if _, err := CreateTag(tmpDir, "v1.0", "", TagOptions{}); err == nil {
+			t.Error("Expected error for existing tag")
+		}
+		if _, err := CreateTag(tmpDir, "bad name", "", TagOptions{}); err == nil {
+			t.Error("Expected error for invalid name")
+		}
+		if _, err := CreateTag(tmpDir, "empty", "", TagOptions{Annotate: true}); err == nil {
+			t.Error("Expected error for annotated tag without message")
+		}
+		if _, err := CreateTag(tmpDir, "light", "HEAD", TagOptions{Force: true}); err != nil {
+			t.Errorf("Force replace failed: %v", err)
+		}
+	})
+
+	t.Run("List and delete", func(t *testing.T) {
+		if _, err := CreateTag(tmpDir, "v2.0", "", TagOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := tagNames(t, tmpDir, ""); got != "light v1.0 v2.0" {
+			t.Errorf("Tags = %q", got)
+		}
+		if got := tagNames(t, tmpDir, "v*"); got != "v1.0 v2.0" {
+			t.Errorf("Tags v* = %q", got)
+		}
+		if err := DeleteTag(tmpDir, "v2.0"); err != nil {
+			t.Fatalf("DeleteTag failed: %v", err)
+		}
+		if err := DeleteTag(tmpDir, "v2.0"); err == nil {
+			t.Error("Expected error deleting a missing tag")
+		}
+		if got := tagNames(t, tmpDir, "v*"); got != "v1.0" {
+			t.Errorf("Tags after delete = %q", got)
+		}
+	})
+}
@@ -0,0 +1,417 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"sib/internal/core/index"
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+	"sib/internal/utils"
+)
+
+// ErrLocalChanges - операция перезаписала бы незакоммиченные изменения
+var ErrLocalChanges = errors.New("local changes would be overwritten")
+
+// readWorktreeFile читает файл рабочего каталога так, как он хранится в blob:
+// для символической ссылки содержимым является путь, на который она указывает
+func readWorktreeFile(fullPath string) ([]byte, os.FileInfo, error) {
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(fullPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read link %s: %w", fullPath, err)
+		}
+		return []byte(filepath.ToSlash(target)), info, nil
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", fullPath, err)
+	}
+	return content, info, nil
+}
+
+// openWorktreeFile открывает файл рабочего каталога для потокового чтения
+// как blob: возвращает поток и размер содержимого. Для символической ссылки
+// содержимым является путь, на который она указывает
+func openWorktreeFile(fullPath string) (io.ReadCloser, int64, error) {
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(fullPath)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read link %s: %w", fullPath, err)
+		}
+		target = filepath.ToSlash(target)
+		return io.NopCloser(strings.NewReader(target)), int64(len(target)), nil
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", fullPath, err)
+	}
+	// Размер берем у открытого файла: Lstat мог увидеть другую версию
+	if info, err = file.Stat(); err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to stat %s: %w", fullPath, err)
+	}
+	return file, info.Size(), nil
+}
+
+// blobHash вычисляет хеш содержимого как blob'а, ничего не записывая
+func blobHash(content []byte) (objects.Hash, error) {
+	data, err := objects.NewBlob(content).Serialize()
+	if err != nil {
+		return "", err
+	}
+	return objects.Hash(utils.CalculateSHA256(data)), nil
+}
+
+// hashWorktreeFile вычисляет хеш blob'а для файла рабочего каталога
+// без записи в хранилище. Файл читается потоком
+func hashWorktreeFile(fullPath string) (objects.Hash, error) {
+	file, size, err := openWorktreeFile(fullPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	sum := sha256.New()
+	sum.Write(objects.Header(objects.BlobObject, size))
+	n, err := io.Copy(sum, file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", fullPath, err)
+	}
+	if n != size {
+		return "", fmt.Errorf("%s changed while reading", fullPath)
+	}
+	return objects.Hash(fmt.Sprintf("%x", sum.Sum(nil))), nil
+}
+
+// writeWorktreeFile записывает содержимое blob'а в рабочий каталог с нужным режимом.
+// Существующий файл (или ссылка) на этом месте заменяется
+func writeWorktreeFile(fullPath string, mode objects.FileMode, content []byte) error {
+	return writeWorktreeStream(fullPath, mode, bytes.NewReader(content))
+}
+
+// writeWorktreeBlob записывает blob из хранилища в рабочий каталог потоком,
+// не загружая его в память целиком
+func writeWorktreeBlob(store *storage.ObjectStore, fullPath string, mode objects.FileMode, hash objects.Hash) error {
+	r, err := store.OpenObject(hash)
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	defer r.Close()
+	if r.Type() != objects.BlobObject {
+		return fmt.Errorf("object %s is a %s, not a blob", hash, r.Type())
+	}
+	return writeWorktreeStream(fullPath, mode, r)
+}
+
+// writeWorktreeStream записывает содержимое из потока в рабочий каталог с нужным
+// режимом. Если поток оборвался ошибкой (например, не совпал хеш), недописанный
+// файл удаляется
+func writeWorktreeStream(fullPath string, mode objects.FileMode, content io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", fullPath, err)
+	}
+
+	// Удаляем старую версию: иначе не сменить тип (ссылка <-> файл) и права
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace %s: %w", fullPath, err)
+	}
+
+	if mode == objects.FileModeSymlink {
+		target, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", fullPath, err)
+		}
+		if err := os.Symlink(filepath.FromSlash(string(target)), fullPath); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", fullPath, err)
+		}
+		return nil
+	}
+
+	perm := os.FileMode(0644)
+	if mode == objects.FileModeExec {
+		perm = 0755
+	}
+	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", fullPath, err)
+	}
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fullPath)
+		return fmt.Errorf("failed to write %s: %w", fullPath, err)
+	}
+	return nil
+}
+
+// removeWorktreeFile удаляет файл и опустевшие каталоги над ним (не выше корня репозитория)
+func removeWorktreeFile(workTree, path string) error {
+	fullPath := filepath.Join(workTree, filepath.FromSlash(path))
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	root := filepath.Clean(workTree)
+	for dir := filepath.Dir(fullPath); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break // Каталог не пуст или уже удален
+		}
+	}
+	return nil
+}
+
+// checkoutFile записывает blob в рабочий каталог и обновляет запись индекса
+// свежими размером и временем изменения
+func checkoutFile(workTree string, store *storage.ObjectStore, idx *index.Index, path string, mode objects.FileMode, hash objects.Hash) error {
+	fullPath := filepath.Join(workTree, filepath.FromSlash(path))
+	if err := writeWorktreeBlob(store, fullPath, mode, hash); err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	return idx.Add(path, hash.String(), info.Size(), string(mode), info.ModTime())
+}
+
+// flattenCommitTree возвращает файлы дерева; пустой хеш - пустое дерево
+func flattenCommitTree(store *storage.ObjectStore, treeHash objects.Hash) (map[string]storage.TreeFile, error) {
+	if treeHash.IsEmpty() {
+		return make(map[string]storage.TreeFile), nil
+	}
+	return store.FlattenTree(treeHash)
+}
+
+// checkoutTree переводит рабочий каталог и индекс с дерева fromTree (текущий HEAD)
+// на дерево toTree. Затрагиваются только пути, различающиеся в двух деревьях,
+// поэтому локальные изменения остальных файлов сохраняются.
+// Без force операция отказывается перезаписывать незакоммиченные изменения
+// и неотслеживаемые файлы; с force индекс и рабочий каталог приводятся к toTree полностью
+func checkoutTree(workTree string, store *storage.ObjectStore, idx *index.Index, fromTree, toTree objects.Hash, force bool) error {
+	oldFiles, err := flattenCommitTree(store, fromTree)
+	if err != nil {
+		return err
+	}
+	newFiles, err := flattenCommitTree(store, toTree)
+	if err != nil {
+		return err
+	}
+
+	paths := make(map[string]bool)
+	for path, old := range oldFiles {
+		if file, ok := newFiles[path]; !ok || file.Hash != old.Hash || file.Mode != old.Mode {
+			paths[path] = true
+		}
+	}
+	for path := range newFiles {
+		if _, ok := oldFiles[path]; !ok {
+			paths[path] = true
+		}
+	}
+	if force {
+		// Также сбрасываем локальные изменения и убираем файлы, добавленные только в индекс
+		for path := range newFiles {
+			paths[path] = true
+		}
+		for path := range idx.Entries {
+			paths[path] = true
+		}
+	}
+
+	changed := make([]string, 0, len(paths))
+	for path := range paths {
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+
+	if !force {
+		if err := checkLocalChanges(workTree, idx, "checkout", changed, oldFiles, newFiles); err != nil {
+			return err
+		}
+	}
+
+	// Сначала удаляем: файл мог смениться каталогом с тем же именем
+	for _, path := range changed {
+		if _, ok := newFiles[path]; ok {
+			continue
+		}
+		if err := removeWorktreeFile(workTree, path); err != nil {
+			return err
+		}
+		if _, err := idx.Get(path); err == nil {
+			if err := idx.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, path := range changed {
+		file, ok := newFiles[path]
+		if !ok {
+			continue
+		}
+		if force && isUpToDate(workTree, idx, path, file) {
+			continue
+		}
+		if err := checkoutFile(workTree, store, idx, path, file.Mode, file.Hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkLocalChanges проверяет, что переключение не потеряет данных:
+// ни индекс, ни рабочий каталог не содержат изменений затрагиваемых путей,
+// и на месте новых файлов нет неотслеживаемых. op - имя операции для сообщения
+func checkLocalChanges(workTree string, idx *index.Index, op string, paths []string, oldFiles, newFiles map[string]storage.TreeFile) error {
+	var dirty, untracked []string
+
+	for _, path := range paths {
+		old, inOld := oldFiles[path]
+		target, inTarget := newFiles[path]
+		entry, err := idx.Get(path)
+		inIndex := err == nil
+
+		// Индекс уже совпадает с целью - переписывать нечего
+		if inIndex && inTarget && entry.Hash == target.Hash.String() && entry.Mode == string(target.Mode) {
+			continue
+		}
+
+		// Индекс отличается от HEAD - есть подготовленные изменения
+		if inOld != inIndex || (inOld && (entry.Hash != old.Hash.String() || entry.Mode != string(old.Mode))) {
+			if inIndex || inTarget {
+				dirty = append(dirty, path)
+			}
+			continue
+		}
+
+		fullPath := filepath.Join(workTree, filepath.FromSlash(path))
+		info, err := os.Lstat(fullPath)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			// ENOTDIR: на месте родительского каталога файл, который будет удален
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !inIndex {
+			if inTarget {
+				// Каталог из одних отслеживаемых файлов будет удален раньше,
+				// чем на его месте появится файл
+				if info.IsDir() {
+					if loose, err := hasUntrackedFiles(workTree, idx, path); err != nil {
+						return err
+					} else if !loose {
+						continue
+					}
+				}
+				untracked = append(untracked, path)
+			}
+			continue
+		}
+
+		hash, err := hashWorktreeFile(fullPath)
+		if err != nil {
+			return err
+		}
+		if hash.String() != entry.Hash {
+			dirty = append(dirty, path)
+		}
+	}
+
+	action := op
+	if op == "checkout" {
+		action = "switch branches"
+	}
+	if len(dirty) > 0 {
+		return fmt.Errorf("%w by %s:\n\t%s\nPlease commit your changes before you %s",
+			ErrLocalChanges, op, strings.Join(dirty, "\n\t"), action)
+	}
+	if len(untracked) > 0 {
+		return fmt.Errorf("%w: untracked working tree files would be overwritten by %s:\n\t%s\nPlease move or remove them before you %s",
+			ErrLocalChanges, op, strings.Join(untracked, "\n\t"), action)
+	}
+	return nil
+}
+
+// hasUntrackedFiles проверяет, есть ли в каталоге dir рабочего каталога файлы,
+// которых нет в индексе
+func hasUntrackedFiles(workTree string, idx *index.Index, dir string) (bool, error) {
+	found := false
+	root := filepath.Join(workTree, filepath.FromSlash(dir))
+	err := filepath.WalkDir(root, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(workTree, fullPath)
+		if err != nil {
+			return err
+		}
+		if _, err := idx.Get(filepath.ToSlash(rel)); err != nil && idx.ConflictStages(filepath.ToSlash(rel)) == nil {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// isUpToDate проверяет, что индекс и рабочий каталог уже содержат нужную версию файла
+func isUpToDate(workTree string, idx *index.Index, path string, file storage.TreeFile) bool {
+	entry, err := idx.Get(path)
+	if err != nil || entry.Hash != file.Hash.String() || entry.Mode != string(file.Mode) {
+		return false
+	}
+
+	fullPath := filepath.Join(workTree, filepath.FromSlash(path))
+	info, err := os.Lstat(fullPath)
+	if err != nil || index.DetectFileMode(info) != string(file.Mode) {
+		return false
+	}
+	hash, err := hashWorktreeFile(fullPath)
+	return err == nil && hash == file.Hash
+}
+
+// stageTreeFile записывает файл дерева в индекс, не трогая рабочий каталог.
+// Если запись уже указывает на тот же blob, её размер и время сохраняются;
+// иначе они обнуляются, и status сверит содержимое файла по хешу
+func stageTreeFile(idx *index.Index, file storage.TreeFile) error {
+	if entry, err := idx.Get(file.Path); err == nil && entry.Hash == file.Hash.String() && entry.Mode == string(file.Mode) {
+		return idx.Add(file.Path, entry.Hash, entry.Size, entry.Mode, entry.Mtime)
+	}
+	return idx.Add(file.Path, file.Hash.String(), 0, string(file.Mode), time.Time{})
+}
+
+// worktreeExists проверяет, что путь существует в рабочем каталоге
+func worktreeExists(workTree, path string) bool {
+	_, err := os.Lstat(filepath.Join(workTree, filepath.FromSlash(path)))
+	return err == nil
+}
@@ -0,0 +1,360 @@
+// Package config читает и изменяет настройки sib в формате git config.
+//
+// Настройки собираются из нескольких уровней, более поздний перекрывает
+// более ранний:
+//
+//   - системный файл (/etc/sibconfig или SIB_CONFIG_SYSTEM, отключается SIB_CONFIG_NOSYSTEM);
+//   - глобальный файл пользователя (~/.sibconfig или SIB_CONFIG_GLOBAL);
+//   - локальный файл репозитория (.sib/config);
+//   - переменные окружения SIB_CONFIG_COUNT, SIB_CONFIG_KEY_<n> и SIB_CONFIG_VALUE_<n>.
+//
+// Файл может подключать другие файлы через include.path; относительный путь
+// отсчитывается от каталога подключающего файла.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Переменные окружения, управляющие уровнями настроек
+const (
+	EnvSystem   = "SIB_CONFIG_SYSTEM"   // Путь к системному файлу
+	EnvNoSystem = "SIB_CONFIG_NOSYSTEM" // Не читать системный файл
+	EnvGlobal   = "SIB_CONFIG_GLOBAL"   // Путь к глобальному файлу
+	EnvCount    = "SIB_CONFIG_COUNT"    // Количество пар SIB_CONFIG_KEY_<n> / SIB_CONFIG_VALUE_<n>
+	EnvKey      = "SIB_CONFIG_KEY_"
+	EnvValue    = "SIB_CONFIG_VALUE_"
+)
+
+// maxIncludeDepth ограничивает вложенность include.path (и защищает от циклов)
+const maxIncludeDepth = 10
+
+// Scope - уровень, из которого прочитана настройка
+type Scope int
+
+const (
+	ScopeSystem Scope = iota + 1
+	ScopeGlobal
+	ScopeLocal
+	ScopeEnv
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeSystem:
+		return "system"
+	case ScopeGlobal:
+		return "global"
+	case ScopeLocal:
+		return "local"
+	case ScopeEnv:
+		return "command"
+	}
+	return "unknown"
+}
+
+// ErrNotFound - переменная не задана
+var ErrNotFound = errors.New("key not found")
+
+// Entry - одно значение переменной
+type Entry struct {
+	Key    string // Полное имя: section[.subsection].name
+	Value  string
+	Scope  Scope
+	Origin string // Откуда взято значение: "file:<путь>" или "env:<переменная>"
+}
+
+// Config - значения всех прочитанных уровней в порядке чтения
+type Config struct {
+	entries []Entry
+}
+
+// Load читает все уровни настроек. sibDir - каталог репозитория;
+// пустая строка - вне репозитория, локальный уровень пропускается
+func Load(sibDir string) (*Config, error) {
+	c := &Config{}
+	if os.Getenv(EnvNoSystem) == "" {
+		if err := c.readFile(SystemPath(), ScopeSystem, 0); err != nil {
+			return nil, err
+		}
+	}
+	if path := GlobalPath(); path != "" {
+		if err := c.readFile(path, ScopeGlobal, 0); err != nil {
+			return nil, err
+		}
+	}
+	if sibDir != "" {
+		if err := c.readFile(LocalPath(sibDir), ScopeLocal, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.readEnv(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadFile читает один файл (с подключаемыми им файлами) как уровень scope
+func LoadFile(path string, scope Scope) (*Config, error) {
+	c := &Config{}
+	if err := c.readFile(path, scope, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SystemPath возвращает путь к системному файлу настроек
+func SystemPath() string {
+	if path := os.Getenv(EnvSystem); path != "" {
+		return path
+	}
+	return "/etc/sibconfig"
+}
+
+// GlobalPath возвращает путь к файлу настроек пользователя ("" - домашний каталог неизвестен)
+func GlobalPath() string {
+	if path := os.Getenv(EnvGlobal); path != "" {
+		return path
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".sibconfig")
+	}
+	return ""
+}
+
+// LocalPath возвращает путь к файлу настроек репозитория
+func LocalPath(sibDir string) string {
+	return filepath.Join(sibDir, "config")
+}
+
+// readFile добавляет значения из файла; отсутствующий файл пропускается
+func (c *Config) readFile(path string, scope Scope, depth int) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	items, err := parse(data, path)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		if it.Name == "" {
+			continue
+		}
+		c.entries = append(c.entries, Entry{Key: it.Key(), Value: it.Value, Scope: scope, Origin: "file:" + path})
+
+		if it.Key() == "include.path" {
+			if depth >= maxIncludeDepth {
+				return fmt.Errorf("exceeded maximum include depth (%d) while including %s from %s", maxIncludeDepth, it.Value, path)
+			}
+			if err := c.readFile(includePath(path, it.Value), scope, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// includePath раскрывает '~/' и делает путь абсолютным относительно подключающего файла
+func includePath(from, path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(from), path)
+}
+
+// readEnv добавляет значения из SIB_CONFIG_KEY_<n> / SIB_CONFIG_VALUE_<n>
+func (c *Config) readEnv() error {
+	countStr := os.Getenv(EnvCount)
+	if countStr == "" {
+		return nil
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return fmt.Errorf("bogus count in %s: %q", EnvCount, countStr)
+	}
+
+	for i := 0; i < count; i++ {
+		keyVar := EnvKey + strconv.Itoa(i)
+		key, ok := os.LookupEnv(keyVar)
+		if !ok {
+			return fmt.Errorf("missing config key %s", keyVar)
+		}
+		value, ok := os.LookupEnv(EnvValue + strconv.Itoa(i))
+		if !ok {
+			return fmt.Errorf("missing config value %s%d", EnvValue, i)
+		}
+		canonical, err := CanonicalKey(key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", keyVar, err)
+		}
+		c.entries = append(c.entries, Entry{Key: canonical, Value: value, Scope: ScopeEnv, Origin: "env:" + keyVar})
+	}
+	return nil
+}
+
+// Entries возвращает все значения в порядке чтения
+func (c *Config) Entries() []Entry {
+	return c.entries
+}
+
+// Get возвращает последнее значение переменной
+func (c *Config) Get(key string) (string, bool) {
+	entry, ok := c.Lookup(key)
+	return entry.Value, ok
+}
+
+// Lookup возвращает последнее значение переменной вместе с его источником
+func (c *Config) Lookup(key string) (Entry, bool) {
+	key, err := CanonicalKey(key)
+	if err != nil {
+		return Entry{}, false
+	}
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		if c.entries[i].Key == key {
+			return c.entries[i], true
+		}
+	}
+	return Entry{}, false
+}
+
+// GetAll возвращает все значения многозначной переменной в порядке чтения
+func (c *Config) GetAll(key string) []Entry {
+	key, err := CanonicalKey(key)
+	if err != nil {
+		return nil
+	}
+	var values []Entry
+	for _, entry := range c.entries {
+		if entry.Key == key {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
+
+// Bool возвращает булево значение переменной или def, если она не задана
+func (c *Config) Bool(key string, def bool) (bool, error) {
+	value, ok := c.Get(key)
+	if !ok {
+		return def, nil
+	}
+	b, err := ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("bad boolean config value '%s' for '%s'", value, key)
+	}
+	return b, nil
+}
+
+// Int возвращает целое значение переменной или def, если она не задана
+func (c *Config) Int(key string, def int64) (int64, error) {
+	value, ok := c.Get(key)
+	if !ok {
+		return def, nil
+	}
+	n, err := ParseInt(value)
+	if err != nil {
+		return 0, fmt.Errorf("bad numeric config value '%s' for '%s': %w", value, key, err)
+	}
+	return n, nil
+}
+
+// ParseBool разбирает булево значение: true/yes/on/1 или false/no/off/0/пустая строка
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", value)
+}
+
+// ParseInt разбирает целое с необязательным суффиксом k, m или g (степени 1024)
+func ParseInt(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	factor := int64(1)
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'k', 'K':
+			factor = 1 << 10
+		case 'm', 'M':
+			factor = 1 << 20
+		case 'g', 'G':
+			factor = 1 << 30
+		}
+		if factor != 1 {
+			value = value[:len(value)-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unit")
+	}
+	if n > math.MaxInt64/factor || n < math.MinInt64/factor {
+		return 0, fmt.Errorf("out of range")
+	}
+	return n * factor, nil
+}
+
+// CanonicalKey проверяет имя переменной и приводит секцию и имя к нижнему
+// регистру; подсекция сохраняет регистр
+func CanonicalKey(key string) (string, error) {
+	section, subsection, name, err := splitKey(key)
+	if err != nil {
+		return "", err
+	}
+	if subsection != "" {
+		return section + "." + subsection + "." + name, nil
+	}
+	return section + "." + name, nil
+}
+
+// splitKey разбирает "section[.subsection].name"
+func splitKey(key string) (section, subsection, name string, err error) {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	if first <= 0 || last == len(key)-1 {
+		return "", "", "", fmt.Errorf("key does not contain a section: %s", key)
+	}
+
+	section = strings.ToLower(key[:first])
+	name = strings.ToLower(key[last+1:])
+	if first != last {
+		subsection = key[first+1 : last]
+	}
+
+	for i := 0; i < len(section); i++ {
+		if !isKeyChar(section[i]) {
+			return "", "", "", fmt.Errorf("invalid key: %s", key)
+		}
+	}
+	if !isKeyStart(name[0]) {
+		return "", "", "", fmt.Errorf("invalid key: %s", key)
+	}
+	for i := 0; i < len(name); i++ {
+		if !isKeyChar(name[i]) {
+			return "", "", "", fmt.Errorf("invalid key: %s", key)
+		}
+	}
+	if strings.ContainsRune(subsection, '\n') {
+		return "", "", "", fmt.Errorf("invalid key (newline): %s", key)
+	}
+	return section, subsection, name, nil
+}
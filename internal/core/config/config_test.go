@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// isolate отключает системный и глобальный файлы пользователя
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(EnvNoSystem, "1")
+	t.Setenv(EnvGlobal, filepath.Join(dir, "global"))
+	t.Setenv(EnvCount, "")
+	return dir
+}
+
+func TestParse(t *testing.T) {
+	data := `# comment
+[core]
+	repositoryformatversion = 0
+	Bare ; no value means true
+[remote "Origin"]
+	url = "/srv/repo.git" # trailing comment
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+[Alias.Legacy]
+	msg = "a ; b" \
+continued\t"end"
+[user] name =   Jane  Doe
+`
+	items, err := parse([]byte(data), "test")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	var got []string
+	for _, it := range items {
+		if it.Name != "" {
+			got = append(got, it.Key()+"="+it.Value)
+		}
+	}
+	want := []string{
+		"core.repositoryformatversion=0",
+		"core.bare=true",
+		"remote.Origin.url=/srv/repo.git",
+		"remote.Origin.fetch=+refs/heads/*:refs/remotes/origin/*",
+		"remote.Origin.fetch=+refs/tags/*:refs/tags/*",
+		"alias.legacy.msg=a ; b continued\tend",
+		"user.name=Jane  Doe",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, bad := range []string{"x = 1\n", "[core\n", "[a \"b]\n", "[core]\n\tname = \"open\n", "[core]\n\t1x = 2\n", "[core]\n\tx = \\q\n"} {
+		if _, err := parse([]byte(bad), "bad"); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoadScopes(t *testing.T) {
+	dir := isolate(t)
+	system := filepath.Join(dir, "system")
+	t.Setenv(EnvNoSystem, "")
+	t.Setenv(EnvSystem, system)
+	sibDir := filepath.Join(dir, "repo", ".sib")
+
+	writeFile(t, system, "[user]\n\tname = System\n[core]\n\tcompression = 1k\n")
+	writeFile(t, filepath.Join(dir, "global"), "[user]\n\tname = Global\n\temail = g@example.com\n[include]\n\tpath = extra\n")
+	writeFile(t, filepath.Join(dir, "extra"), "[alias]\n\tco = checkout\n")
+	writeFile(t, LocalPath(sibDir), "[user]\n\tname = Local\n[core]\n\tbare = off\n")
+	t.Setenv(EnvCount, "1")
+	t.Setenv(EnvKey+"0", "User.Email")
+	t.Setenv(EnvValue+"0", "env@example.com")
+
+	cfg, err := Load(sibDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if name, _ := cfg.Get("user.name"); name != "Local" {
+		t.Errorf("user.name = %q, want Local", name)
+	}
+	entry, _ := cfg.Lookup("USER.EMAIL")
+	if entry.Value != "env@example.com" || entry.Scope != ScopeEnv {
+		t.Errorf("user.email = %+v", entry)
+	}
+	if alias, ok := cfg.Get("alias.co"); !ok || alias != "checkout" {
+		t.Errorf("include.path was not followed: %q", alias)
+	}
+	if names := cfg.GetAll("user.name"); len(names) != 3 || names[0].Scope != ScopeSystem {
+		t.Errorf("GetAll(user.name) = %+v", names)
+	}
+	if n, err := cfg.Int("core.compression", 0); err != nil || n != 1024 {
+		t.Errorf("Int = %d, %v", n, err)
+	}
+	if b, err := cfg.Bool("core.bare", true); err != nil || b {
+		t.Errorf("Bool = %v, %v", b, err)
+	}
+	if b, _ := cfg.Bool("core.missing", true); !b {
+		t.Error("Bool should return the default for a missing key")
+	}
+
+	t.Setenv(EnvCount, "2")
+	if _, err := Load(sibDir); err == nil {
+		t.Error("Expected error for missing SIB_CONFIG_KEY_1")
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir := isolate(t)
+	path := filepath.Join(dir, "loop")
+	writeFile(t, path, "[include]\n\tpath = loop\n")
+	if _, err := LoadFile(path, ScopeLocal); err == nil {
+		t.Error("Expected error for recursive include")
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	cases := map[string]int64{"0": 0, "12": 12, "-3": -3, "2k": 2048, "1M": 1 << 20, "3g": 3 << 30}
+	for input, want := range cases {
+		if got, err := ParseInt(input); err != nil || got != want {
+			t.Errorf("ParseInt(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "k", "1x", "9999999999g"} {
+		if _, err := ParseInt(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestSetAndUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeFile(t, path, "# keep me\n[core]\n\trepositoryformatversion = 0\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n")
+
+	steps := []struct{ key, value string }{
+		{"core.repositoryformatversion", "1"},
+		{"core.editor", "vim -f"},
+		{"user.name", " Jane ; Doe "},
+		{"branch.Main.remote", "origin"},
+	}
+	for _, step := range steps {
+		if err := SetValue(path, step.key, step.value); err != nil {
+			t.Fatalf("SetValue(%s) failed: %v", step.key, err)
+		}
+	}
+	if err := SetValue(path, "remote.origin.fetch", "c"); err == nil {
+		t.Error("Expected error when overwriting a multi-valued key")
+	}
+
+	cfg, err := LoadFile(path, ScopeLocal)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	for _, step := range steps {
+		if got, _ := cfg.Get(step.key); got != step.value {
+			t.Errorf("%s = %q, want %q", step.key, got, step.value)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "# keep me\n[core]\n\trepositoryformatversion = 1\n\teditor = vim -f\n" +
+		"[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n" +
+		"[user]\n\tname = \" Jane ; Doe \"\n[branch \"Main\"]\n\tremote = origin\n"
+	if string(data) != want {
+		t.Errorf("File content:\n%s\nwant:\n%s", data, want)
+	}
+
+	if err := UnsetValue(path, "core.editor"); err != nil {
+		t.Fatalf("UnsetValue failed: %v", err)
+	}
+	if err := UnsetValue(path, "core.editor"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Got %v, want ErrNotFound", err)
+	}
+	if err := UnsetValue(path, "remote.origin.fetch"); err == nil {
+		t.Error("Expected error when unsetting a multi-valued key")
+	}
+	if cfg, _ = LoadFile(path, ScopeLocal); len(cfg.GetAll("core.editor")) != 0 {
+		t.Error("core.editor should be removed")
+	}
+}
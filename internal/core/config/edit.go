@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sib/internal/core/lockfile"
+)
+
+// SetValue записывает значение переменной в файл path, сохраняя остальное
+// содержимое. Существующее значение заменяется на месте, новая переменная
+// дописывается в конец своей секции (секция создается при необходимости).
+// Многозначную переменную заменить одним значением нельзя
+func SetValue(path, key, value string) error {
+	section, subsection, name, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	key, _ = CanonicalKey(key)
+
+	lock, err := lockfile.Acquire(path, lockfile.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	lines, items, err := readForEdit(path)
+	if err != nil {
+		return err
+	}
+
+	line := "\t" + name + " = " + quoteValue(value)
+	matches := matching(items, key)
+	switch {
+	case len(matches) > 1:
+		return fmt.Errorf("cannot overwrite multiple values of '%s' with a single value", key)
+	case len(matches) == 1:
+		it := matches[0]
+		lines = splice(lines, it.Line-1, it.EndLine, line)
+	default:
+		sectionName := section
+		if subsection != "" {
+			sectionName += "." + subsection
+		}
+		last := -1
+		for _, it := range items {
+			if it.Section == sectionName {
+				last = it.EndLine
+			}
+		}
+		if last >= 0 {
+			lines = splice(lines, last, last, line)
+		} else {
+			header := "[" + section + "]"
+			if subsection != "" {
+				header = "[" + section + " \"" + escapeSubsection(subsection) + "\"]"
+			}
+			lines = append(lines, header, line)
+		}
+	}
+
+	return writeLines(lock, lines)
+}
+
+// UnsetValue удаляет переменную из файла path.
+// Отсутствующая переменная - ErrNotFound; многозначную удалить нельзя
+func UnsetValue(path, key string) error {
+	key, err := CanonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockfile.Acquire(path, lockfile.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	lines, items, err := readForEdit(path)
+	if err != nil {
+		return err
+	}
+
+	matches := matching(items, key)
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	case 1:
+		it := matches[0]
+		return writeLines(lock, splice(lines, it.Line-1, it.EndLine))
+	default:
+		return fmt.Errorf("'%s' has multiple values", key)
+	}
+}
+
+// readForEdit читает файл построчно и разбирает его; отсутствующий файл пуст
+func readForEdit(path string) ([]string, []item, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	items, err := parse(data, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil, items, nil
+	}
+	return strings.Split(text, "\n"), items, nil
+}
+
+// matching возвращает переменные с именем key
+func matching(items []item, key string) []item {
+	var result []item
+	for _, it := range items {
+		if it.Name != "" && it.Key() == key {
+			result = append(result, it)
+		}
+	}
+	return result
+}
+
+// splice заменяет строки [from, to) на insert
+func splice(lines []string, from, to int, insert ...string) []string {
+	result := make([]string, 0, len(lines)-(to-from)+len(insert))
+	result = append(result, lines[:from]...)
+	result = append(result, insert...)
+	return append(result, lines[to:]...)
+}
+
+// writeLines атомарно заменяет файл через захваченный config.lock
+func writeLines(lock *lockfile.Lock, lines []string) error {
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if err := lock.Commit([]byte(content)); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// quoteValue записывает значение так, чтобы parse прочитал его обратно без изменений
+func quoteValue(value string) string {
+	needQuotes := value != strings.TrimSpace(value) || strings.ContainsAny(value, "#;")
+	var b strings.Builder
+	for _, c := range value {
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	if needQuotes {
+		return `"` + b.String() + `"`
+	}
+	return b.String()
+}
+
+// escapeSubsection экранирует '"' и '\' в имени подсекции
+func escapeSubsection(name string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name)
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// item - заголовок секции или переменная, прочитанная из файла
+type item struct {
+	Section string // Секция в виде "section" или "section.subsection"
+	Name    string // Имя переменной в нижнем регистре; пусто для заголовка секции
+	Value   string // Значение с раскрытыми кавычками и escape-последовательностями
+	Line    int    // Первая строка (с 1)
+	EndLine int    // Последняя строка: значение может продолжаться через '\' в конце строки
+}
+
+// Key возвращает полное имя переменной
+func (it item) Key() string {
+	return it.Section + "." + it.Name
+}
+
+// parser разбирает INI в формате git config:
+//
+//	# комментарий            ; тоже комментарий
+//	[section]                [section "Subsection"]   [section.subsection]
+//	name = value             name = "value with ; and #"   name (без '=' - true)
+//
+// Имена секций и переменных нечувствительны к регистру, подсекции в кавычках -
+// чувствительны. В значениях поддерживаются \", \\, \n, \t, \b и перенос строки через '\'
+type parser struct {
+	data   []byte
+	pos    int
+	line   int
+	source string // Имя файла для сообщений об ошибках
+}
+
+// parse разбирает содержимое файла в список секций и переменных
+func parse(data []byte, source string) ([]item, error) {
+	p := &parser{data: data, line: 1, source: source}
+	var items []item
+	section := ""
+
+	for {
+		p.skipBlanks()
+		if p.pos >= len(p.data) {
+			return items, nil
+		}
+
+		switch c := p.data[p.pos]; {
+		case c == '\n':
+			p.advance()
+		case c == '#' || c == ';':
+			p.skipLine()
+		case c == '[':
+			line := p.line
+			name, err := p.parseSection()
+			if err != nil {
+				return nil, err
+			}
+			section = name
+			items = append(items, item{Section: section, Line: line, EndLine: line})
+		case isKeyStart(c):
+			if section == "" {
+				return nil, p.errorf("variable outside of a section")
+			}
+			line := p.line
+			name, value, err := p.parseVariable()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item{Section: section, Name: name, Value: value, Line: line, EndLine: p.line})
+			if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+				p.advance()
+			}
+		default:
+			return nil, p.errorf("unexpected character %q", c)
+		}
+	}
+}
+
+// parseSection разбирает заголовок секции вместе с остатком строки
+func (p *parser) parseSection() (string, error) {
+	p.advance() // '['
+	start := p.pos
+	for p.pos < len(p.data) && (isKeyChar(p.data[p.pos]) || p.data[p.pos] == '.') {
+		p.advance()
+	}
+	name := strings.ToLower(string(p.data[start:p.pos]))
+	if name == "" || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return "", p.errorf("invalid section name")
+	}
+
+	if p.pos < len(p.data) && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		if strings.Contains(name, ".") {
+			return "", p.errorf("invalid section name %q", name)
+		}
+		p.skipBlanks()
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return "", p.errorf("expected '\"' in section header")
+		}
+		p.advance()
+
+		var sub strings.Builder
+		for {
+			if p.pos >= len(p.data) || p.data[p.pos] == '\n' {
+				return "", p.errorf("unterminated subsection name")
+			}
+			c := p.advance()
+			if c == '"' {
+				break
+			}
+			if c == '\\' {
+				if p.pos >= len(p.data) || p.data[p.pos] == '\n' {
+					return "", p.errorf("unterminated subsection name")
+				}
+				c = p.advance()
+			}
+			sub.WriteByte(c)
+		}
+		name += "." + sub.String()
+	}
+
+	if p.pos >= len(p.data) || p.data[p.pos] != ']' {
+		return "", p.errorf("expected ']' in section header")
+	}
+	p.advance()
+	return name, nil
+}
+
+// parseVariable разбирает "name [= value]" до конца строки (не включая '\n')
+func (p *parser) parseVariable() (string, string, error) {
+	start := p.pos
+	for p.pos < len(p.data) && isKeyChar(p.data[p.pos]) {
+		p.advance()
+	}
+	name := strings.ToLower(string(p.data[start:p.pos]))
+
+	p.skipBlanks()
+	if p.pos >= len(p.data) || p.data[p.pos] == '\n' {
+		return name, "true", nil
+	}
+	switch p.data[p.pos] {
+	case '#', ';':
+		p.skipComment()
+		return name, "true", nil
+	case '=':
+		p.advance()
+	default:
+		return "", "", p.errorf("invalid variable name %q", name+string(p.data[p.pos]))
+	}
+
+	value, err := p.parseValue()
+	return name, value, err
+}
+
+// parseValue читает значение: пробелы по краям вне кавычек отбрасываются,
+// комментарий вне кавычек завершает значение
+func (p *parser) parseValue() (string, error) {
+	var value, spaces strings.Builder
+	quoted := false
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '\n' {
+			if quoted {
+				return "", p.errorf("unterminated quoted value")
+			}
+			break
+		}
+		p.advance()
+
+		switch {
+		case !quoted && (c == '#' || c == ';'):
+			p.skipComment()
+			return value.String(), nil
+		case !quoted && (c == ' ' || c == '\t' || c == '\r'):
+			if value.Len() > 0 {
+				spaces.WriteByte(c)
+			}
+			continue
+		}
+
+		value.WriteString(spaces.String())
+		spaces.Reset()
+
+		switch c {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if p.pos >= len(p.data) {
+				return "", p.errorf("unexpected end of file after '\\'")
+			}
+			switch esc := p.advance(); esc {
+			case '\n':
+				// Значение продолжается на следующей строке
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'b':
+				value.WriteByte('\b')
+			case '"', '\\':
+				value.WriteByte(esc)
+			default:
+				return "", p.errorf("invalid escape sequence '\\%c'", esc)
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	if quoted {
+		return "", p.errorf("unterminated quoted value")
+	}
+	return value.String(), nil
+}
+
+// advance возвращает текущий байт и сдвигается на следующий, считая строки
+func (p *parser) advance() byte {
+	c := p.data[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipBlanks пропускает пробелы и табуляции
+func (p *parser) skipBlanks() {
+	for p.pos < len(p.data) && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t' || p.data[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// skipComment пропускает остаток строки, оставляя '\n' на месте
+func (p *parser) skipComment() {
+	for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// skipLine пропускает остаток строки вместе с '\n'
+func (p *parser) skipLine() {
+	p.skipComment()
+	if p.pos < len(p.data) {
+		p.advance()
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("bad config line %d in %s: %s", p.line, p.source, fmt.Sprintf(format, args...))
+}
+
+func isKeyStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isKeyChar(c byte) bool {
+	return isKeyStart(c) || c >= '0' && c <= '9' || c == '-'
+}
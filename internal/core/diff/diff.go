@@ -0,0 +1,179 @@
+// Package diff вычисляет построчную разницу между двумя версиями текста
+// и форматирует её в unified-формате.
+//
+// Доступны три алгоритма: Myers (по умолчанию), patience и histogram.
+// Все они возвращают одинаковый по форме результат - последовательность
+// операций Equal/Delete/Insert - и отличаются только выбором совпадающих строк.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Algorithm - алгоритм сопоставления строк
+type Algorithm int
+
+const (
+	Myers     Algorithm = iota // Кратчайший скрипт редактирования (O(ND))
+	Patience                   // Сопоставление по уникальным строкам
+	Histogram                  // Сопоставление по самым редким строкам
+)
+
+// String возвращает имя алгоритма
+func (a Algorithm) String() string {
+	switch a {
+	case Patience:
+		return "patience"
+	case Histogram:
+		return "histogram"
+	default:
+		return "myers"
+	}
+}
+
+// ParseAlgorithm разбирает имя алгоритма ("myers", "patience", "histogram")
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "", "myers", "default":
+		return Myers, nil
+	case "patience":
+		return Patience, nil
+	case "histogram":
+		return Histogram, nil
+	default:
+		return Myers, fmt.Errorf("unknown diff algorithm: %s", name)
+	}
+}
+
+// OpKind - вид операции над строкой
+type OpKind byte
+
+const (
+	Equal  OpKind = ' ' // Строка есть в обеих версиях
+	Delete OpKind = '-' // Строка есть только в старой версии
+	Insert OpKind = '+' // Строка есть только в новой версии
+)
+
+// Edit - одна операция скрипта редактирования
+// OldLine/NewLine - индексы строк (с нуля); для Insert OldLine не используется,
+// для Delete не используется NewLine
+type Edit struct {
+	Kind    OpKind
+	OldLine int
+	NewLine int
+}
+
+// SplitLines разбивает текст на строки, сохраняя перевод строки в конце каждой.
+// Последняя строка может не заканчиваться на '\n'
+func SplitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+
+	return lines
+}
+
+// IsBinary определяет бинарные данные так же, как git:
+// по наличию нулевого байта в первых 8000 байтах
+func IsBinary(data []byte) bool {
+	const sniffLen = 8000
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// Lines вычисляет скрипт редактирования, превращающий a в b
+func Lines(a, b []string, algo Algorithm) []Edit {
+	// Заменяем строки на целые числа - сравнение становится дешевым
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		result := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			result[i] = id
+		}
+		return result
+	}
+	x, y := intern(a), intern(b)
+
+	var matches []match
+	switch algo {
+	case Patience:
+		matches = patienceMatches(x, y, 0, len(x), 0, len(y))
+	case Histogram:
+		matches = histogramMatches(x, y, 0, len(x), 0, len(y))
+	default:
+		matches = myersMatches(x, y, 0, len(x), 0, len(y))
+	}
+
+	return editsFromMatches(matches, len(x), len(y))
+}
+
+// match - пара совпадающих строк
+type match struct {
+	a, b int
+}
+
+// editsFromMatches превращает возрастающий список совпадений в скрипт редактирования.
+// Между совпадениями сначала идут удаления, затем вставки
+func editsFromMatches(matches []match, n, m int) []Edit {
+	edits := make([]Edit, 0, n+m)
+	i, j := 0, 0
+
+	emitGap := func(toA, toB int) {
+		for ; i < toA; i++ {
+			edits = append(edits, Edit{Kind: Delete, OldLine: i, NewLine: j})
+		}
+		for ; j < toB; j++ {
+			edits = append(edits, Edit{Kind: Insert, OldLine: i, NewLine: j})
+		}
+	}
+
+	for _, mt := range matches {
+		emitGap(mt.a, mt.b)
+		edits = append(edits, Edit{Kind: Equal, OldLine: i, NewLine: j})
+		i++
+		j++
+	}
+	emitGap(n, m)
+
+	return edits
+}
+
+// trimCommon находит общий префикс и суффикс диапазонов
+// Возвращает совпадения префикса, суженные границы и совпадения суффикса
+func trimCommon(x, y []int, aLo, aHi, bLo, bHi int) (prefix []match, newALo, newAHi, newBLo, newBHi int, suffix []match) {
+	for aLo < aHi && bLo < bHi && x[aLo] == y[bLo] {
+		prefix = append(prefix, match{aLo, bLo})
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && x[aHi-1] == y[bHi-1] {
+		aHi--
+		bHi--
+		suffix = append(suffix, match{aHi, bHi})
+	}
+	// Суффикс собирался с конца
+	for i, j := 0, len(suffix)-1; i < j; i, j = i+1, j-1 {
+		suffix[i], suffix[j] = suffix[j], suffix[i]
+	}
+	return prefix, aLo, aHi, bLo, bHi, suffix
+}
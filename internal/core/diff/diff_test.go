@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// apply применяет скрипт редактирования к a и проверяет, что получилось b
+func apply(t *testing.T, edits []Edit, a, b []string) {
+	t.Helper()
+	var got []string
+	for _, e := range edits {
+		switch e.Kind {
+		case Equal:
+			if a[e.OldLine] != b[e.NewLine] {
+				t.Fatalf("Equal edit on different lines: %q vs %q", a[e.OldLine], b[e.NewLine])
+			}
+			got = append(got, a[e.OldLine])
+		case Insert:
+			got = append(got, b[e.NewLine])
+		}
+	}
+	if strings.Join(got, "") != strings.Join(b, "") {
+		t.Fatalf("Applying edits produced %q, want %q", got, b)
+	}
+}
+
+func countChanges(edits []Edit) int {
+	n := 0
+	for _, e := range edits {
+		if e.Kind != Equal {
+			n++
+		}
+	}
+	return n
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		changes int // Минимальное число изменений (для Майерса)
+	}{
+		{"Identical", "a\nb\nc\n", "a\nb\nc\n", 0},
+		{"Empty to text", "", "a\nb\n", 2},
+		{"Text to empty", "a\nb\n", "", 2},
+		{"Insert in middle", "a\nc\n", "a\nb\nc\n", 1},
+		{"Delete in middle", "a\nb\nc\n", "a\nc\n", 1},
+		{"Replace", "a\nb\nc\n", "a\nx\nc\n", 2},
+		{"Classic", "a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n", 5},
+	}
+
+	for _, algo := range []Algorithm{Myers, Patience, Histogram} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%s", algo, tt.name), func(t *testing.T) {
+				a, b := SplitLines([]byte(tt.a)), SplitLines([]byte(tt.b))
+				edits := Lines(a, b, algo)
+				apply(t, edits, a, b)
+				if algo == Myers && countChanges(edits) != tt.changes {
+					t.Errorf("Expected %d changes, got %d", tt.changes, countChanges(edits))
+				}
+			})
+		}
+	}
+}
+
+func TestLinesRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"a\n", "b\n", "c\n", "d\n", "}\n"}
+	random := func() []string {
+		lines := make([]string, rng.Intn(40))
+		for i := range lines {
+			lines[i] = words[rng.Intn(len(words))]
+		}
+		return lines
+	}
+
+	for i := 0; i < 200; i++ {
+		a, b := random(), random()
+		for _, algo := range []Algorithm{Myers, Patience, Histogram} {
+			apply(t, Lines(a, b, algo), a, b)
+		}
+	}
+}
+
+func TestMyersFullRewrite(t *testing.T) {
+	// Полностью переписанный файл: путь длиной N+M, память должна оставаться линейной
+	var a, b []string
+	for i := 0; i < 20000; i++ {
+		a = append(a, fmt.Sprintf("old %d\n", i))
+		b = append(b, fmt.Sprintf("new %d\n", i))
+	}
+	b[10000] = a[5000]
+
+	apply(t, Lines(a, b, Myers), a, b)
+
+	// Небольшие правки в большом файле по-прежнему дают минимальный diff
+	c := append([]string(nil), a...)
+	for i := 100; i < len(c); i += 2000 {
+		c[i] = "edited\n"
+	}
+	edits := Lines(a, c, Myers)
+	apply(t, edits, a, c)
+	if countChanges(edits) != 20 {
+		t.Errorf("Expected 20 changes, got %d", countChanges(edits))
+	}
+}
+
+func TestPatienceAnchorsUniqueLines(t *testing.T) {
+	// Майерс сопоставляет скобки, patience - уникальные строки функций
+	a := SplitLines([]byte("func a() {\n}\n\nfunc b() {\n}\n"))
+	b := SplitLines([]byte("func a() {\n}\n\nfunc c() {\n}\n\nfunc b() {\n}\n"))
+
+	edits := Lines(a, b, Patience)
+	apply(t, edits, a, b)
+	if countChanges(edits) != 3 {
+		t.Errorf("Expected pure insertion of 3 lines, got %d changes", countChanges(edits))
+	}
+}
+
+func TestUnified(t *testing.T) {
+	var old, cur []string
+	for i := 1; i <= 20; i++ {
+		old = append(old, fmt.Sprintf("line %d\n", i))
+	}
+	cur = append(cur, old...)
+	cur[1] = "changed 2\n"
+	cur[17] = "changed 18\n"
+
+	var buf bytes.Buffer
+	err := Unified(&buf, "a/f", "b/f", []byte(strings.Join(old, "")), []byte(strings.Join(cur, "")), Options{Context: 3})
+	if err != nil {
+		t.Fatalf("Unified failed: %v", err)
+	}
+
+	want := "--- a/f\n+++ b/f\n" +
+		"@@ -1,5 +1,5 @@\n line 1\n-line 2\n+changed 2\n line 3\n line 4\n line 5\n" +
+		"@@ -15,6 +15,6 @@\n line 15\n line 16\n line 17\n-line 18\n+changed 18\n line 19\n line 20\n"
+	if buf.String() != want {
+		t.Errorf("Unified output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestUnifiedEdgeCases(t *testing.T) {
+	t.Run("New file", func(t *testing.T) {
+		var buf bytes.Buffer
+		Unified(&buf, "/dev/null", "b/f", nil, []byte("x\n"), Options{Context: 3})
+		want := "--- /dev/null\n+++ b/f\n@@ -0,0 +1 @@\n+x\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("No newline at end", func(t *testing.T) {
+		var buf bytes.Buffer
+		Unified(&buf, "a/f", "b/f", []byte("x\n"), []byte("x\ny"), Options{Context: 3})
+		want := "--- a/f\n+++ b/f\n@@ -1 +1,2 @@\n x\n+y\n\\ No newline at end of file\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("Identical", func(t *testing.T) {
+		var buf bytes.Buffer
+		Unified(&buf, "a/f", "b/f", []byte("x\n"), []byte("x\n"), Options{Context: 3})
+		if buf.Len() != 0 {
+			t.Errorf("Expected no output, got %q", buf.String())
+		}
+	})
+
+	t.Run("Binary", func(t *testing.T) {
+		var buf bytes.Buffer
+		Unified(&buf, "a/f", "b/f", []byte("a\x00b"), []byte("a\x00c"), Options{Context: 3})
+		if buf.String() != "Binary files a/f and b/f differ\n" {
+			t.Errorf("got %q", buf.String())
+		}
+	})
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, name := range []string{"myers", "patience", "histogram"} {
+		algo, err := ParseAlgorithm(name)
+		if err != nil || algo.String() != name {
+			t.Errorf("ParseAlgorithm(%q) = %v, %v", name, algo, err)
+		}
+	}
+	if _, err := ParseAlgorithm("minimal-ish"); err == nil {
+		t.Error("Expected error for unknown algorithm")
+	}
+}
@@ -0,0 +1,70 @@
+package diff
+
+// maxChainLength - строки, встречающиеся чаще, не используются как якоря
+const maxChainLength = 64
+
+// histogramMatches - вариант patience, допускающий неуникальные строки:
+// якорем становится общий участок, содержащий самую редкую строку старой версии
+// (при равенстве - самый длинный). Затем промежутки обрабатываются рекурсивно.
+// Если подходящих строк нет, используется Майерс
+func histogramMatches(x, y []int, aLo, aHi, bLo, bHi int) []match {
+	prefix, aLo, aHi, bLo, bHi, suffix := trimCommon(x, y, aLo, aHi, bLo, bHi)
+	if aLo == aHi || bLo == bHi {
+		return append(prefix, suffix...)
+	}
+
+	occurrences := make(map[int][]int)
+	for i := aLo; i < aHi; i++ {
+		occurrences[x[i]] = append(occurrences[x[i]], i)
+	}
+
+	found := false
+	var bestAs, bestBs, bestLen, bestCount int
+
+	for j := bLo; j < bHi; {
+		positions := occurrences[y[j]]
+		if len(positions) == 0 || len(positions) > maxChainLength {
+			j++
+			continue
+		}
+
+		next := j + 1
+		for _, i := range positions {
+			// Расширяем совпадение в обе стороны
+			as, bs := i, j
+			for as > aLo && bs > bLo && x[as-1] == y[bs-1] {
+				as--
+				bs--
+			}
+			ae, be := i+1, j+1
+			for ae < aHi && be < bHi && x[ae] == y[be] {
+				ae++
+				be++
+			}
+
+			length := ae - as
+			count := len(positions)
+			if !found || count < bestCount || (count == bestCount && length > bestLen) {
+				found = true
+				bestAs, bestBs, bestLen, bestCount = as, bs, length, count
+			}
+			if be > next {
+				next = be
+			}
+		}
+		j = next
+	}
+
+	if !found {
+		return append(append(prefix, myersMatches(x, y, aLo, aHi, bLo, bHi)...), suffix...)
+	}
+
+	result := prefix
+	result = append(result, histogramMatches(x, y, aLo, bestAs, bLo, bestBs)...)
+	for k := 0; k < bestLen; k++ {
+		result = append(result, match{bestAs + k, bestBs + k})
+	}
+	result = append(result, histogramMatches(x, y, bestAs+bestLen, aHi, bestBs+bestLen, bHi)...)
+
+	return append(result, suffix...)
+}
@@ -0,0 +1,134 @@
+package diff
+
+import "math"
+
+// myersMinCost - нижняя граница числа шагов поиска середины пути (XDL_MAX_COST_MIN в git)
+const myersMinCost = 256
+
+// myersMatches находит совпадающие строки алгоритмом Майерса в линейной памяти:
+// поиск кратчайшего пути в графе редактирования ведется одновременно с начала
+// и с конца, пока фронты не встретятся на середине пути, после чего обе половины
+// решаются рекурсивно (разделяй и властвуй). Снимки фронта не хранятся, поэтому
+// даже полностью переписанный файл требует O(N+M) памяти. Как и в git, поиск
+// середины ограничен sqrt(N+M) шагами (но не меньше myersMinCost): дальше путь
+// делится в самой дальней достигнутой точке, и diff может быть не минимальным
+func myersMatches(x, y []int, aLo, aHi, bLo, bHi int) []match {
+	var result []match
+	myersSplit(x, y, aLo, aHi, bLo, bHi, &result)
+	return result
+}
+
+// myersSplit добавляет в result совпадения участков x[aLo:aHi] и y[bLo:bHi]
+func myersSplit(x, y []int, aLo, aHi, bLo, bHi int, result *[]match) {
+	prefix, aLo, aHi, bLo, bHi, suffix := trimCommon(x, y, aLo, aHi, bLo, bHi)
+	*result = append(*result, prefix...)
+
+	if aLo < aHi && bLo < bHi {
+		if midA, midB, ok := middlePoint(x, y, aLo, aHi, bLo, bHi); ok {
+			myersSplit(x, y, aLo, midA, bLo, midB, result)
+			myersSplit(x, y, midA, aHi, midB, bHi, result)
+		}
+	}
+
+	*result = append(*result, suffix...)
+}
+
+// middlePoint возвращает точку на середине кратчайшего пути редактирования.
+// Фронт хранится только для текущего шага d по диагоналям k = x - y; диагонали,
+// ушедшие за границу графа, дальше не просматриваются. Если за отведенное число
+// шагов фронты не встретились, возвращает самую дальнюю из достигнутых точек.
+// Если общих строк нет, возвращает ok = false. Первые и последние строки
+// участков должны различаться
+func middlePoint(x, y []int, aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	limit := min(maxD, max(myersMinCost, int(math.Sqrt(float64(n+m)))))
+	offset := limit
+	size := 2*limit + 1
+
+	// forward[k] - дальний x на диагонали k от начала,
+	// backward[k] - то же от конца в перевернутых координатах; -1 - не достигнута
+	forward := make([]int, size)
+	backward := make([]int, size)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// При нечетной разнице длин фронты встречаются на шаге вперед, при четной - назад
+	front := delta%2 != 0
+	var fStart, fEnd, bStart, bEnd int
+	// Самая дальняя точка фронтов (по x+y от своего конца) на случай остановки
+	var bestA, bestB, bestCost int
+
+	for d := 0; d < limit; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var px int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				px = forward[offset+k+1] // шаг вниз: вставка
+			} else {
+				px = forward[offset+k-1] + 1 // шаг вправо: удаление
+			}
+			py := px - k
+			for px < n && py < m && x[aLo+px] == y[bLo+py] {
+				px++
+				py++
+			}
+			forward[offset+k] = px
+
+			switch {
+			case px > n:
+				fEnd += 2
+			case py > m:
+				fStart += 2
+			case front:
+				if i := offset + delta - k; i >= 0 && i < size && backward[i] != -1 && px >= n-backward[i] {
+					return aLo + px, bLo + py, true
+				}
+				fallthrough
+			default:
+				if px+py > bestCost {
+					bestA, bestB, bestCost = aLo+px, bLo+py, px+py
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			var px int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				px = backward[offset+k+1]
+			} else {
+				px = backward[offset+k-1] + 1
+			}
+			py := px - k
+			for px < n && py < m && x[aHi-1-px] == y[bHi-1-py] {
+				px++
+				py++
+			}
+			backward[offset+k] = px
+
+			switch {
+			case px > n:
+				bEnd += 2
+			case py > m:
+				bStart += 2
+			case !front:
+				if i := offset + delta - k; i >= 0 && i < size && forward[i] != -1 && forward[i] >= n-px {
+					fx := forward[i]
+					return aLo + fx, bLo + fx - (delta - k), true
+				}
+				fallthrough
+			default:
+				if px+py > bestCost {
+					bestA, bestB, bestCost = aHi-px, bHi-py, px+py
+				}
+			}
+		}
+	}
+
+	if limit < maxD && bestCost > 0 {
+		return bestA, bestB, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,80 @@
+package diff
+
+import "sort"
+
+// patienceMatches сопоставляет строки, уникальные в обеих версиях,
+// выбирает из них самую длинную возрастающую последовательность (якоря)
+// и рекурсивно обрабатывает промежутки между якорями.
+// Если уникальных общих строк нет, используется Майерс
+func patienceMatches(x, y []int, aLo, aHi, bLo, bHi int) []match {
+	prefix, aLo, aHi, bLo, bHi, suffix := trimCommon(x, y, aLo, aHi, bLo, bHi)
+	if aLo == aHi || bLo == bHi {
+		return append(prefix, suffix...)
+	}
+
+	countA := make(map[int]int)
+	posA := make(map[int]int)
+	for i := aLo; i < aHi; i++ {
+		countA[x[i]]++
+		posA[x[i]] = i
+	}
+	countB := make(map[int]int)
+	for j := bLo; j < bHi; j++ {
+		countB[y[j]]++
+	}
+
+	// Кандидаты в порядке новой версии
+	var candidates []match
+	for j := bLo; j < bHi; j++ {
+		if countB[y[j]] == 1 && countA[y[j]] == 1 {
+			candidates = append(candidates, match{posA[y[j]], j})
+		}
+	}
+	if len(candidates) == 0 {
+		return append(append(prefix, myersMatches(x, y, aLo, aHi, bLo, bHi)...), suffix...)
+	}
+
+	anchors := longestIncreasing(candidates)
+
+	result := prefix
+	prevA, prevB := aLo, bLo
+	for _, anchor := range anchors {
+		result = append(result, patienceMatches(x, y, prevA, anchor.a, prevB, anchor.b)...)
+		result = append(result, anchor)
+		prevA, prevB = anchor.a+1, anchor.b+1
+	}
+	result = append(result, patienceMatches(x, y, prevA, aHi, prevB, bHi)...)
+
+	return append(result, suffix...)
+}
+
+// longestIncreasing находит самую длинную подпоследовательность кандидатов,
+// возрастающую по старому индексу (кандидаты уже упорядочены по новому).
+// Реализация - сортировка стопками (patience sorting)
+func longestIncreasing(candidates []match) []match {
+	var tops []int                       // Индекс кандидата на вершине каждой стопки
+	prev := make([]int, len(candidates)) // Ссылка на предыдущий элемент цепочки
+
+	for i, c := range candidates {
+		pile := sort.Search(len(tops), func(p int) bool {
+			return candidates[tops[p]].a > c.a
+		})
+		if pile > 0 {
+			prev[i] = tops[pile-1]
+		} else {
+			prev[i] = -1
+		}
+		if pile == len(tops) {
+			tops = append(tops, i)
+		} else {
+			tops[pile] = i
+		}
+	}
+
+	result := make([]match, len(tops))
+	for i, k := len(tops)-1, tops[len(tops)-1]; i >= 0; i, k = i-1, prev[k] {
+		result[i] = candidates[k]
+	}
+
+	return result
+}
@@ -0,0 +1,156 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultContext - число строк контекста вокруг изменений по умолчанию
+const DefaultContext = 3
+
+// Options - параметры unified-вывода
+type Options struct {
+	Context   int       // Строк контекста вокруг изменений
+	Algorithm Algorithm // Алгоритм сопоставления строк
+}
+
+// HunkLine - строка ханка с видом операции
+// Text включает завершающий '\n', если он был в исходном тексте
+type HunkLine struct {
+	Kind OpKind
+	Text string
+}
+
+// Hunk - группа изменений с окружающим контекстом
+// OldStart/NewStart - номера строк с единицы, как в заголовке "@@ -s,c +s,c @@"
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []HunkLine
+}
+
+// Header возвращает заголовок ханка "@@ -s,c +s,c @@".
+// Счетчик 1 опускается, как в GNU diff
+func (h *Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Hunks группирует скрипт редактирования в ханки.
+// Изменения, между которыми не больше 2*context общих строк, попадают в один ханк
+func Hunks(edits []Edit, a, b []string, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	var hunks []Hunk
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			i++
+			continue
+		}
+
+		// Начало ханка - с контекстом перед первым изменением
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Ищем конец: последнее изменение, после которого идет больше 2*context общих строк
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Kind != Equal {
+				end = j
+				continue
+			}
+			if j-end > 2*context {
+				break
+			}
+		}
+		stop := end + 1 + context
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		hunks = append(hunks, makeHunk(edits[start:stop], a, b))
+		i = stop
+	}
+
+	return hunks
+}
+
+// makeHunk строит ханк из непрерывного участка скрипта
+func makeHunk(edits []Edit, a, b []string) Hunk {
+	h := Hunk{}
+	for _, e := range edits {
+		switch e.Kind {
+		case Equal:
+			h.OldLines++
+			h.NewLines++
+			h.Lines = append(h.Lines, HunkLine{Kind: Equal, Text: a[e.OldLine]})
+		case Delete:
+			h.OldLines++
+			h.Lines = append(h.Lines, HunkLine{Kind: Delete, Text: a[e.OldLine]})
+		case Insert:
+			h.NewLines++
+			h.Lines = append(h.Lines, HunkLine{Kind: Insert, Text: b[e.NewLine]})
+		}
+	}
+
+	// Пустой диапазон указывает на строку перед местом изменения
+	h.OldStart = edits[0].OldLine
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	h.NewStart = edits[0].NewLine
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+
+	return h
+}
+
+// Unified записывает разницу между a и b в unified-формате:
+// строки "--- oldName" и "+++ newName", затем ханки.
+// Для бинарных данных пишется "Binary files ... differ", для одинаковых - ничего
+func Unified(w io.Writer, oldName, newName string, a, b []byte, opts Options) error {
+	if IsBinary(a) || IsBinary(b) {
+		if string(a) == string(b) {
+			return nil
+		}
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return err
+	}
+
+	oldLines, newLines := SplitLines(a), SplitLines(b)
+	hunks := Hunks(Lines(oldLines, newLines, opts.Algorithm), oldLines, newLines, opts.Context)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		sb.WriteString(h.Header())
+		sb.WriteByte('\n')
+		for _, line := range h.Lines {
+			sb.WriteByte(byte(line.Kind))
+			sb.WriteString(line.Text)
+			if !strings.HasSuffix(line.Text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
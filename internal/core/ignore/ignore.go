@@ -0,0 +1,159 @@
+// Package ignore решает, какие неотслеживаемые файлы рабочего каталога
+// не должны попадать в индекс, по правилам в формате .gitignore.
+//
+// Правила читаются (от низшего приоритета к высшему) из глобального файла
+// ($XDG_CONFIG_HOME/sib/ignore или ~/.config/sib/ignore), из .sib/info/exclude
+// и из файлов .sibignore на любом уровне каталогов: более глубокий файл
+// перекрывает более высокий, а внутри файла последнее совпавшее правило
+// перекрывает предыдущие. Файл внутри игнорируемого каталога вернуть
+// правилом '!' нельзя - как и в git, каталог не просматривается вовсе.
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileName - имя файла правил в каталогах рабочего дерева
+const FileName = ".sibignore"
+
+// Matcher проверяет пути по правилам репозитория.
+// Файлы .sibignore читаются лениво и кешируются по каталогам
+type Matcher struct {
+	workTree string
+	base     []*Rule            // Глобальный файл и .sib/info/exclude
+	dirs     map[string][]*Rule // Каталог -> правила его .sibignore
+}
+
+// New создает Matcher для рабочего каталога workTree, читая глобальный файл
+// и info/exclude каталога репозитория sibDir
+func New(workTree, sibDir string) (*Matcher, error) {
+	m := &Matcher{workTree: workTree, dirs: make(map[string][]*Rule)}
+
+	if global := GlobalExcludesFile(); global != "" {
+		rules, err := readRules(global, global, "")
+		if err != nil {
+			return nil, err
+		}
+		m.base = append(m.base, rules...)
+	}
+
+	exclude := filepath.Join(sibDir, "info", "exclude")
+	rules, err := readRules(exclude, ".sib/info/exclude", "")
+	if err != nil {
+		return nil, err
+	}
+	m.base = append(m.base, rules...)
+
+	return m, nil
+}
+
+// GlobalExcludesFile возвращает путь глобального файла правил (пусто, если неизвестен домашний каталог)
+func GlobalExcludesFile() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "sib", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "sib", "ignore")
+	}
+	return ""
+}
+
+// readRules читает файл правил; отсутствующий файл - пустой список
+func readRules(fullPath, source, base string) ([]*Rule, error) {
+	file, err := os.Open(fullPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	defer file.Close()
+
+	var rules []*Rule
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if rule := parseRule(scanner.Text()); rule != nil {
+			rule.Source, rule.Line, rule.base = source, line, base
+			rules = append(rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	return rules, nil
+}
+
+// dirRules возвращает правила файла .sibignore каталога dir ("" - корень)
+func (m *Matcher) dirRules(dir string) ([]*Rule, error) {
+	if rules, ok := m.dirs[dir]; ok {
+		return rules, nil
+	}
+	source := path.Join(dir, FileName)
+	rules, err := readRules(filepath.Join(m.workTree, filepath.FromSlash(source)), source, dir)
+	if err != nil {
+		return nil, err
+	}
+	m.dirs[dir] = rules
+	return rules, nil
+}
+
+// Match возвращает правило, определяющее судьбу пути (относительно корня, через '/'),
+// или nil, если ни одно правило не совпало. Если игнорируется один из
+// родительских каталогов, возвращается его правило
+func (m *Matcher) Match(p string, isDir bool) (*Rule, error) {
+	parts := strings.Split(p, "/")
+	for i := 1; i < len(parts); i++ {
+		rule, err := m.matchPath(strings.Join(parts[:i], "/"), true)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil && !rule.Negate {
+			return rule, nil
+		}
+	}
+	return m.matchPath(p, isDir)
+}
+
+// Ignored проверяет, что путь игнорируется
+func (m *Matcher) Ignored(p string, isDir bool) (bool, error) {
+	rule, err := m.Match(p, isDir)
+	return rule != nil && !rule.Negate, err
+}
+
+// matchPath ищет последнее совпавшее правило для самого пути, без учета родителей
+func (m *Matcher) matchPath(p string, isDir bool) (*Rule, error) {
+	// Каталоги от самого глубокого к корню: у глубоких файлов приоритет выше
+	dir := path.Dir(p)
+	for {
+		if dir == "." {
+			dir = ""
+		}
+		rules, err := m.dirRules(dir)
+		if err != nil {
+			return nil, err
+		}
+		if rule := lastMatch(rules, p, isDir); rule != nil {
+			return rule, nil
+		}
+		if dir == "" {
+			break
+		}
+		dir = path.Dir(dir)
+	}
+	return lastMatch(m.base, p, isDir), nil
+}
+
+// lastMatch возвращает последнее совпавшее правило списка
+func lastMatch(rules []*Rule, p string, isDir bool) *Rule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match(p, isDir) {
+			return rules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile создает файл вместе с каталогами
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.log", "debug.log", false, true},
+		{"*.log", "logs/deep/debug.log", false, true},
+		{"*.log", "debug.log.txt", false, false},
+		{"/build", "build", true, true},
+		{"/build", "src/build", true, false},
+		{"build/", "build", false, false},
+		{"build/", "src/build", true, true},
+		{"doc/*.txt", "doc/a.txt", false, true},
+		{"doc/*.txt", "doc/sub/a.txt", false, false},
+		{"doc/**/*.txt", "doc/a.txt", false, true},
+		{"doc/**/*.txt", "doc/sub/deep/a.txt", false, true},
+		{"**/tmp", "a/b/tmp", true, true},
+		{"out/**", "out/x/y", false, true},
+		{"out/**", "out", true, false},
+		{"file?.[ch]", "file1.c", false, true},
+		{"file?.[!ch]", "file1.c", false, false},
+		{`\#hash`, "#hash", false, true},
+		{`trailing\ `, "trailing ", false, true},
+		{"trailing   ", "trailing", false, true},
+	}
+
+	for _, tt := range tests {
+		rule := parseRule(tt.pattern)
+		if rule == nil {
+			t.Fatalf("parseRule(%q) returned nil", tt.pattern)
+		}
+		if got := rule.match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%q vs %q (dir=%v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	for _, skipped := range []string{"", "   ", "# comment", "!", "/"} {
+		if rule := parseRule(skipped); rule != nil {
+			t.Errorf("parseRule(%q) should be skipped", skipped)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	repo := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(repo, "config"))
+
+	writeFile(t, filepath.Join(repo, "config", "sib", "ignore"), "*.swp\n")
+	writeFile(t, filepath.Join(repo, ".sib", "info", "exclude"), "local/\n")
+	writeFile(t, filepath.Join(repo, FileName), "*.log\nnode_modules/\n!keep.log\n/secret\n")
+	writeFile(t, filepath.Join(repo, "sub", FileName), "!*.log\nsecret\n")
+
+	m, err := New(repo, filepath.Join(repo, ".sib"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		isDir  bool
+		want   bool
+		source string
+	}{
+		{"a.swp", false, true, filepath.Join(repo, "config", "sib", "ignore")},
+		{"local", true, true, ".sib/info/exclude"},
+		{"debug.log", false, true, FileName},
+		{"keep.log", false, false, FileName},
+		{"node_modules/pkg/index.js", false, true, FileName},
+		{"secret", false, true, FileName},
+		{"sub/debug.log", false, false, "sub/" + FileName},
+		{"sub/secret", false, true, "sub/" + FileName},
+		{"src/main.go", false, false, ""},
+	}
+
+	for _, tt := range tests {
+		rule, err := m.Match(tt.path, tt.isDir)
+		if err != nil {
+			t.Fatalf("Match(%q) failed: %v", tt.path, err)
+		}
+		ignored := rule != nil && !rule.Negate
+		if ignored != tt.want {
+			t.Errorf("%q ignored = %v, want %v", tt.path, ignored, tt.want)
+		}
+		source := ""
+		if rule != nil {
+			source = rule.Source
+		}
+		if source != tt.source {
+			t.Errorf("%q matched by %q, want %q", tt.path, source, tt.source)
+		}
+	}
+}
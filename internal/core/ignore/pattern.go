@@ -0,0 +1,145 @@
+package ignore
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rule - одно правило файла игнорирования
+type Rule struct {
+	Source  string // Файл правила относительно корня репозитория (или абсолютный для глобального)
+	Line    int    // Номер строки в файле
+	Pattern string // Строка правила как она записана в файле
+
+	Negate  bool   // Правило начинается с '!' и возвращает путь в отслеживаемые
+	DirOnly bool   // Правило заканчивается на '/' и совпадает только с каталогами
+	base    string // Каталог файла .sibignore; правило действует только внутри него
+	re      *regexp.Regexp
+}
+
+// parseRule разбирает строку в формате .gitignore. Пустые строки
+// и комментарии возвращают nil
+func parseRule(line string) *Rule {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	rule := &Rule{Pattern: line}
+	pattern := line
+	if strings.HasPrefix(pattern, "!") {
+		rule.Negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.DirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if pattern == "" {
+		return nil
+	}
+
+	// Шаблон со слешем (кроме завершающего) привязан к каталогу файла правил,
+	// без слеша - совпадает с именем на любой глубине
+	if strings.Contains(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		pattern = "**/" + pattern
+	}
+
+	re, err := regexp.Compile("^" + translate(pattern) + "$")
+	if err != nil {
+		return nil
+	}
+	rule.re = re
+	return rule
+}
+
+// trimTrailingSpaces убирает завершающие пробелы, кроме экранированных ("\ ")
+func trimTrailingSpaces(line string) string {
+	end := len(line)
+	for end > 0 && line[end-1] == ' ' {
+		if end >= 2 && line[end-2] == '\\' {
+			break
+		}
+		end--
+	}
+	return line[:end]
+}
+
+// translate переводит шаблон .gitignore в регулярное выражение.
+// '*' и '?' не совпадают с '/'; "**/" в начале - любое число каталогов,
+// "/**" в конце - все содержимое, "/**/" в середине - ноль и более каталогов
+func translate(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern) && i > 0 && pattern[i-1] == '/':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := classEnd(pattern, i)
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := pattern[i+1 : end]
+			b.WriteByte('[')
+			if class[0] == '!' || class[0] == '^' {
+				b.WriteByte('^')
+				class = class[1:]
+			}
+			b.WriteString(strings.ReplaceAll(class, `\`, `\\`))
+			b.WriteByte(']')
+			i = end
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// classEnd возвращает позицию ']' класса, начинающегося в start, или -1
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++ // ']' сразу после '[' - обычный символ класса
+	}
+	for ; i < len(pattern); i++ {
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+	return -1
+}
+
+// match проверяет путь относительно корня репозитория
+func (r *Rule) match(path string, isDir bool) bool {
+	if r.DirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(path, r.base+"/") {
+			return false
+		}
+		path = path[len(r.base)+1:]
+	}
+	return r.re.MatchString(path)
+}
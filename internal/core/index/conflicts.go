@@ -0,0 +1,77 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Стадии записей индекса во время слияния
+const (
+	StageMerged = 0 // Обычная запись
+	StageBase   = 1 // Версия общего предка
+	StageOurs   = 2 // Наша версия (HEAD)
+	StageTheirs = 3 // Их версия (сливаемая ветка)
+)
+
+// SetConflict записывает путь как неразрешенный конфликт.
+// Каждая запись должна иметь стадию 1-3; отсутствующая сторона (например,
+// файл удален в одной из веток) просто не передается.
+// Обычная запись по этому пути удаляется
+func (idx *Index) SetConflict(path string, stages ...IndexEntry) error {
+	if path == "" {
+		return fmt.Errorf("path cannot be empty")
+	}
+	if len(stages) == 0 {
+		return fmt.Errorf("conflict for %s has no stages", path)
+	}
+
+	normalizedPath := normalizePath(path)
+	seen := make(map[int]bool)
+	entries := make([]IndexEntry, 0, len(stages))
+	for _, entry := range stages {
+		if entry.Stage < StageBase || entry.Stage > StageTheirs {
+			return fmt.Errorf("invalid conflict stage %d for %s", entry.Stage, path)
+		}
+		if seen[entry.Stage] {
+			return fmt.Errorf("duplicate conflict stage %d for %s", entry.Stage, path)
+		}
+		if !isValidMode(entry.Mode) {
+			return fmt.Errorf("invalid file mode: %s", entry.Mode)
+		}
+		seen[entry.Stage] = true
+
+		entry.Path = normalizedPath
+		entry.ctime = time.Now()
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Stage < entries[j].Stage })
+
+	if idx.Unmerged == nil {
+		idx.Unmerged = make(map[string][]IndexEntry)
+	}
+	idx.Unmerged[normalizedPath] = entries
+	delete(idx.Entries, normalizedPath)
+
+	return nil
+}
+
+// ConflictStages возвращает записи стадий 1-3 для пути (nil, если конфликта нет)
+func (idx *Index) ConflictStages(path string) []IndexEntry {
+	return idx.Unmerged[normalizePath(path)]
+}
+
+// UnmergedPaths возвращает отсортированный список путей с неразрешенными конфликтами
+func (idx *Index) UnmergedPaths() []string {
+	paths := make([]string, 0, len(idx.Unmerged))
+	for path := range idx.Unmerged {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// HasConflicts проверяет, есть ли неразрешенные конфликты
+func (idx *Index) HasConflicts() bool {
+	return len(idx.Unmerged) > 0
+}
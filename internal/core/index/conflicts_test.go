@@ -0,0 +1,86 @@
+package index
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexConflicts(t *testing.T) {
+	tmpDir := repoDir(t)
+	idx, err := NewIndex(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+
+	if err := idx.Add("file.txt", "merged", 1, "100644", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	err = idx.SetConflict("file.txt",
+		IndexEntry{Hash: "base", Mode: "100644", Stage: StageBase},
+		IndexEntry{Hash: "theirs", Mode: "100644", Stage: StageTheirs},
+		IndexEntry{Hash: "ours", Mode: "100644", Stage: StageOurs},
+	)
+	if err != nil {
+		t.Fatalf("SetConflict failed: %v", err)
+	}
+	if err := idx.SetConflict("gone.txt", IndexEntry{Hash: "ours", Mode: "100644", Stage: StageOurs}); err != nil {
+		t.Fatalf("SetConflict failed: %v", err)
+	}
+
+	t.Run("Stages replace the normal entry", func(t *testing.T) {
+		if _, err := idx.Get("file.txt"); err == nil {
+			t.Error("Conflicted path should not have a stage 0 entry")
+		}
+		stages := idx.ConflictStages("file.txt")
+		if len(stages) != 3 || stages[0].Hash != "base" || stages[1].Hash != "ours" || stages[2].Hash != "theirs" {
+			t.Errorf("Unexpected stages: %+v", stages)
+		}
+		if got := strings.Join(idx.UnmergedPaths(), " "); got != "file.txt gone.txt" {
+			t.Errorf("UnmergedPaths = %q", got)
+		}
+	})
+
+	t.Run("Survives save and load", func(t *testing.T) {
+		if err := idx.Save(); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := NewIndex(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.HasConflicts() || len(loaded.ConflictStages("file.txt")) != 3 {
+			t.Errorf("Conflicts lost after reload: %+v", loaded.Unmerged)
+		}
+		if loaded.ConflictStages("file.txt")[2].Stage != StageTheirs {
+			t.Error("Stage numbers lost after reload")
+		}
+	})
+
+	t.Run("Invalid stages", func(t *testing.T) {
+		if err := idx.SetConflict("x", IndexEntry{Hash: "h", Mode: "100644", Stage: 0}); err == nil {
+			t.Error("Expected error for stage 0")
+		}
+		if err := idx.SetConflict("x",
+			IndexEntry{Hash: "h", Mode: "100644", Stage: StageOurs},
+			IndexEntry{Hash: "h", Mode: "100644", Stage: StageOurs}); err == nil {
+			t.Error("Expected error for duplicate stage")
+		}
+	})
+
+	t.Run("Add and Remove resolve", func(t *testing.T) {
+		if err := idx.Add("file.txt", "resolved", 1, "100644", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.Remove("gone.txt"); err != nil {
+			t.Fatalf("Remove of conflicted path failed: %v", err)
+		}
+		if idx.HasConflicts() {
+			t.Errorf("Expected no conflicts, got %v", idx.UnmergedPaths())
+		}
+		if entry, err := idx.Get("file.txt"); err != nil || entry.Hash != "resolved" {
+			t.Errorf("Resolved entry = %+v, %v", entry, err)
+		}
+	})
+}
@@ -2,11 +2,16 @@ package index
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
+
+	"sib/internal/core/ignore"
+	"sib/internal/core/lockfile"
 )
 
 type IndexEntry struct {
@@ -17,10 +22,13 @@ type IndexEntry struct {
 	Mtime time.Time `json:"mtime"` // Время последнего изменения файла
 	Path  string    `json:"path"`  // Относительный путь (от корня репозитория)
 
+	// Стадия: 0 = нормальная, 1-3 = конфликт слияния (база, наша, их версия)
+	// Записи со стадиями 1-3 хранятся отдельно, в Index.Unmerged
+	Stage int `json:"stage,omitempty"`
+
 	// Служебные поля (не обязательны в JSON):
 	ctime     time.Time // Время создания записи в индексе (для отладки)
 	validated bool      // Проверен ли файл на целостность
-	stage     int       // Стадия: 0 = нормальная, 1-3 = конфликт слияния
 	/*
 	   Почему такие поля:
 	     Hash — найти содержимое в CAS-хранилище
@@ -31,54 +39,112 @@ type IndexEntry struct {
 
 type Index struct {
 	// Приватные поля:
-	path    string // Путь к файлу .sib/index
-	version int    // Версия формата (начинаем с 1)
+	path        string         // Путь к файлу .sib/index
+	version     int            // Версия формата (начинаем с 1)
+	lock        *lockfile.Lock // index.lock, если индекс открыт через LockIndex
+	lockTimeout time.Duration  // Сколько ждать index.lock другого процесса при Save
 
 	// Публичные (для JSON):
 	Version int                   `json:"version"` // Версия формата
 	Entries map[string]IndexEntry `json:"entries"` // Ключ: путь к файлу
+
+	// Неразрешенные конфликты слияния: путь -> записи стадий 1-3
+	// Путь с конфликтом не имеет записи в Entries, пока конфликт не разрешен
+	Unmerged map[string][]IndexEntry `json:"unmerged,omitempty"`
 }
 
-// NewIndex создает или загружает индекс из файла
+// ErrCorrupt - файл индекса поврежден. Индекс не подменяется пустым, чтобы
+// не потерять подготовленные изменения: его можно пересобрать через RecoverIndex
+var ErrCorrupt = errors.New("index file corrupt")
+
+// NewIndex загружает индекс репозитория с корнем repoPath (каталог .sib внутри него)
 func NewIndex(repoPath string) (*Index, error) {
-	// Формируем путь к файлу индекса
-	indexPath := filepath.Join(repoPath, ".sib", "index")
-
-	// Создаем директорию .sib если её нет
-	sibDir := filepath.Join(repoPath, ".sib")
-	if err := os.MkdirAll(sibDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create .sib directory: %w", err)
-	}
-
-	// Создаем сам индекс (файл) если его нет
-	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
-		// Создаем пустой файл индекса
-		emptyIndex := &Index{
-			path:    indexPath,
-			version: 1,
-			Version: 1,
-			Entries: make(map[string]IndexEntry),
-		}
-		if err := emptyIndex.Save(); err != nil {
-			return nil, fmt.Errorf("failed to create index file: %w", err)
-		}
+	return OpenIndex(filepath.Join(repoPath, ".sib"))
+}
+
+// OpenIndex загружает индекс каталога репозитория sibDir для чтения, создавая
+// пустой файл индекса при первом обращении. Сам каталог репозитория должен
+// существовать. Save такого индекса захватывает index.lock только на время записи,
+// поэтому для изменения на основе прочитанного состояния нужен LockIndex
+func OpenIndex(sibDir string) (*Index, error) {
+	idx, err := newIndex(sibDir, lockfile.DefaultTimeout)
+	if err != nil {
+		return nil, err
 	}
 
-	// Дальше загружаем существующий индекс...
-	idx := &Index{
-		path:    indexPath,
-		version: 1,
-		Version: 1,
-		Entries: make(map[string]IndexEntry),
+	// Создаем сам индекс (файл) если его нет. Если index.lock занят,
+	// файл вот-вот запишет другой процесс
+	if _, err := os.Stat(idx.path); os.IsNotExist(err) {
+		if lock, err := lockfile.Acquire(idx.path, 0); err == nil {
+			idx.lock = lock
+			if err := idx.Save(); err != nil {
+				return nil, fmt.Errorf("failed to create index file: %w", err)
+			}
+		}
 	}
 
 	if err := idx.load(); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("failed to load index: %w", err)
+		return nil, err
+	}
+	return idx, nil
+}
+
+// LockIndex захватывает index.lock (ожидая чужую блокировку не дольше timeout)
+// и загружает индекс. Блокировка держится до Save или Unlock, поэтому
+// параллельные изменения индекса выполняются по очереди и не теряются
+func LockIndex(sibDir string, timeout time.Duration) (*Index, error) {
+	idx, err := newIndex(sibDir, timeout)
+	if err != nil {
+		return nil, err
 	}
 
+	lock, err := lockfile.Acquire(idx.path, timeout)
+	if err != nil {
+		return nil, err
+	}
+	idx.lock = lock
+
+	if err := idx.load(); err != nil && !os.IsNotExist(err) {
+		idx.Unlock()
+		return nil, err
+	}
 	return idx, nil
 }
 
+// RecoverIndex убирает поврежденный файл индекса в index.corrupt, после чего
+// индекс открывается пустым и может быть пересобран (например, sib reset).
+// Возвращает путь к сохраненной копии
+func RecoverIndex(sibDir string, timeout time.Duration) (string, error) {
+	indexPath := filepath.Join(sibDir, "index")
+	lock, err := lockfile.Acquire(indexPath, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	backup := indexPath + ".corrupt"
+	if err := os.Rename(indexPath, backup); err != nil {
+		return "", fmt.Errorf("failed to move corrupt index aside: %w", err)
+	}
+	return backup, nil
+}
+
+// newIndex создает пустой индекс каталога репозитория sibDir
+func newIndex(sibDir string, timeout time.Duration) (*Index, error) {
+	// Каталог репозитория не создаем: иначе вне репозитория появится лишний .sib
+	if info, err := os.Stat(sibDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("not a sib repository: %s not found", sibDir)
+	}
+
+	return &Index{
+		path:        filepath.Join(sibDir, "index"),
+		version:     1,
+		lockTimeout: timeout,
+		Version:     1,
+		Entries:     make(map[string]IndexEntry),
+	}, nil
+}
+
 // load загружает индекс из файла (приватный метод)
 func (idx *Index) load() error {
 	// Читаем файл
@@ -95,19 +161,22 @@ func (idx *Index) load() error {
 
 	// Парсим JSON
 	var loadedIndex struct {
-		Version int                   `json:"version"`
-		Entries map[string]IndexEntry `json:"entries"`
+		Version  int                     `json:"version"`
+		Entries  map[string]IndexEntry   `json:"entries"`
+		Unmerged map[string][]IndexEntry `json:"unmerged"`
 	}
 
 	if err := json.Unmarshal(data, &loadedIndex); err != nil {
-		// Если JSON поврежден, создаем новый пустой индекс
-		idx.Entries = make(map[string]IndexEntry)
-		return nil
+		return fmt.Errorf("%w: %s: %v", ErrCorrupt, idx.path, err)
 	}
 
 	// Копируем загруженные данные
 	idx.Version = loadedIndex.Version
 	idx.Entries = loadedIndex.Entries
+	if idx.Entries == nil {
+		idx.Entries = make(map[string]IndexEntry)
+	}
+	idx.Unmerged = loadedIndex.Unmerged
 
 	return nil
 }
@@ -134,22 +203,31 @@ func (idx *Index) Save() error {
 		return fmt.Errorf("failed to marshal index: %w", err)
 	}
 
-	// Атомарная запись через временный файл
-	tmpPath := idx.path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write temporary index file: %w", err)
+	// Атомарная запись через index.lock. Индекс, открытый без LockIndex,
+	// захватывает блокировку только на время записи
+	if idx.lock == nil {
+		lock, err := lockfile.Acquire(idx.path, idx.lockTimeout)
+		if err != nil {
+			return err
+		}
+		idx.lock = lock
 	}
-
-	// Атомарное переименование
-	if err := os.Rename(tmpPath, idx.path); err != nil {
-		// Пытаемся удалить временный файл в случае ошибки
-		os.Remove(tmpPath)
-		return fmt.Errorf("failed to rename index file: %w", err)
+	lock := idx.lock
+	idx.lock = nil
+	if err := lock.Commit(data); err != nil {
+		return fmt.Errorf("failed to write index file: %w", err)
 	}
 
 	return nil
 }
 
+// Unlock снимает index.lock, если индекс еще держит его (Save уже снял).
+// Безопасно вызывать повторно, удобно через defer
+func (idx *Index) Unlock() {
+	idx.lock.Release()
+	idx.lock = nil
+}
+
 // Add добавляет или обновляет файл в индексе
 func (idx *Index) Add(path string, hash string, size int64, mode string, mtime time.Time) error {
 	// Валидация входных данных
@@ -180,11 +258,11 @@ func (idx *Index) Add(path string, hash string, size int64, mode string, mtime t
 		Path:      normalizedPath,
 		ctime:     time.Now(),
 		validated: true,
-		stage:     0,
 	}
 
-	// Добавляем в мапу
+	// Добавляем в мапу; добавление файла разрешает конфликт по этому пути
 	idx.Entries[normalizedPath] = entry
+	delete(idx.Unmerged, normalizedPath)
 
 	return nil
 }
@@ -198,8 +276,15 @@ func (idx *Index) Remove(path string) error {
 	// Нормализуем путь
 	normalizedPath := normalizePath(path)
 
+	// Удаление конфликтующего пути разрешает конфликт
+	_, conflicted := idx.Unmerged[normalizedPath]
+	delete(idx.Unmerged, normalizedPath)
+
 	// Проверяем, существует ли запись
 	if _, exists := idx.Entries[normalizedPath]; !exists {
+		if conflicted {
+			return nil
+		}
 		return fmt.Errorf("file not found in index: %s", path)
 	}
 
@@ -212,6 +297,7 @@ func (idx *Index) Remove(path string) error {
 // Clear очищает индекс (удаляет все записи)
 func (idx *Index) Clear() error {
 	idx.Entries = make(map[string]IndexEntry)
+	idx.Unmerged = nil
 	return nil
 }
 
@@ -287,10 +373,30 @@ func (idx *Index) Validate(repoPath string) ([]string, error) {
 	return nil, nil
 }
 
-// Diff сравнивает индекс с рабочим каталогом
-// Возвращает: новые файлы, измененные файлы, удаленные файлы
-func (idx *Index) Diff(repoPath string) (added []string, modified []string, deleted []string, err error) {
-	// Получаем все файлы в рабочем каталоге
+// WorkingFiles возвращает файлы рабочего каталога (ключ - путь относительно корня):
+// отслеживаемые и неотслеживаемые, кроме игнорируемых правилами .sibignore.
+// Игнорируемые каталоги без отслеживаемых файлов не просматриваются
+func (idx *Index) WorkingFiles(repoPath string) (map[string]os.FileInfo, error) {
+	sibDir := filepath.Dir(idx.path)
+	matcher, err := ignore.New(repoPath, sibDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Каталоги, содержащие отслеживаемые файлы, просматриваются даже игнорируемыми
+	trackedDirs := make(map[string]bool)
+	markDirs := func(path string) {
+		for dir := filepath.ToSlash(filepath.Dir(path)); dir != "." && !trackedDirs[dir]; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			trackedDirs[dir] = true
+		}
+	}
+	for path := range idx.Entries {
+		markDirs(path)
+	}
+	for path := range idx.Unmerged {
+		markDirs(path)
+	}
+
 	workingFiles := make(map[string]os.FileInfo)
 
 	// Рекурсивно обходим директорию
@@ -299,8 +405,8 @@ func (idx *Index) Diff(repoPath string) (added []string, modified []string, dele
 			return walkErr
 		}
 
-		// Пропускаем директорию .sib
-		if info.IsDir() && info.Name() == ".sib" {
+		// Пропускаем директорию .sib (и каталог репозитория, если он лежит отдельно)
+		if info.IsDir() && (info.Name() == ".sib" || path == sibDir) {
 			return filepath.SkipDir
 		}
 
@@ -314,18 +420,51 @@ func (idx *Index) Diff(repoPath string) (added []string, modified []string, dele
 		if relPath == "." {
 			return nil
 		}
+		normalizedPath := normalizePath(relPath)
+
+		if info.IsDir() {
+			if trackedDirs[normalizedPath] {
+				return nil
+			}
+			ignored, err := matcher.Ignored(normalizedPath, true)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
-		// Добавляем только файлы (не директории)
-		if !info.IsDir() {
-			normalizedPath := normalizePath(relPath)
-			workingFiles[normalizedPath] = info
+		// Добавляем только файлы (не директории); отслеживаемые - даже если игнорируются
+		_, tracked := idx.Entries[normalizedPath]
+		if _, conflicted := idx.Unmerged[normalizedPath]; !tracked && !conflicted {
+			ignored, err := matcher.Ignored(normalizedPath, false)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				return nil
+			}
 		}
+		workingFiles[normalizedPath] = info
 
 		return nil
 	})
 
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to scan working directory: %w", err)
+		return nil, fmt.Errorf("failed to scan working directory: %w", err)
+	}
+	return workingFiles, nil
+}
+
+// Diff сравнивает индекс с рабочим каталогом
+// Возвращает: новые файлы, измененные файлы, удаленные файлы
+func (idx *Index) Diff(repoPath string) (added []string, modified []string, deleted []string, err error) {
+	// Получаем все файлы в рабочем каталоге
+	workingFiles, err := idx.WorkingFiles(repoPath)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	// Находим добавленные файлы (есть в рабочем каталоге, нет в индексе)
@@ -345,15 +484,7 @@ func (idx *Index) Diff(repoPath string) (added []string, modified []string, dele
 	// Находим измененные файлы (есть в обоих, но разные метаданные)
 	for path, info := range workingFiles {
 		if entry, exists := idx.Entries[path]; exists {
-			// Проверяем размер
-			if info.Size() != entry.Size {
-				modified = append(modified, path)
-				continue
-			}
-
-			// Проверяем время модификации (с учетом погрешности)
-			diff := info.ModTime().Sub(entry.Mtime)
-			if diff < -time.Second || diff > time.Second {
+			if !entry.StatMatches(info) {
 				modified = append(modified, path)
 			}
 		}
@@ -367,6 +498,16 @@ func (idx *Index) Diff(repoPath string) (added []string, modified []string, dele
 	return added, modified, deleted, nil
 }
 
+// StatMatches сообщает, что размер и время изменения файла совпадают с записью
+// (время - с погрешностью в секунду). Тогда файл считается неизмененным без чтения
+func (e IndexEntry) StatMatches(info os.FileInfo) bool {
+	if info.Size() != e.Size {
+		return false
+	}
+	diff := info.ModTime().Sub(e.Mtime)
+	return diff >= -time.Second && diff <= time.Second
+}
+
 // Path возвращает путь к файлу индекса (для отладки)
 func (idx *Index) Path() string {
 	return idx.path
@@ -401,7 +542,7 @@ func (idx *Index) UpdateEntry(path string, updates map[string]interface{}) error
 			}
 		case "stage":
 			if stage, ok := value.(int); ok {
-				entry.stage = stage
+				entry.Stage = stage
 			}
 		case "ctime":
 			if ctime, ok := value.(time.Time); ok {
@@ -418,8 +559,8 @@ func (idx *Index) UpdateEntry(path string, updates map[string]interface{}) error
 
 // normalizePath нормализует путь для использования в индексе
 func normalizePath(path string) string {
-	// Очищаем путь
-	cleanPath := filepath.Clean(path)
+	// Приводим Windows-разделители к общему виду и очищаем путь
+	cleanPath := filepath.Clean(strings.ReplaceAll(path, "\\", "/"))
 
 	// Используем forward slash для кросс-платформенности
 	return filepath.ToSlash(cleanPath)
@@ -432,6 +573,7 @@ func isValidMode(mode string) bool {
 		"100644": true, // Обычный файл
 		"100755": true, // Исполняемый файл
 		"040000": true, // Директория
+		"120000": true, // Символическая ссылка
 	}
 
 	return validModes[mode]
@@ -443,6 +585,10 @@ func DetectFileMode(info os.FileInfo) string {
 		return "040000"
 	}
 
+	if info.Mode()&os.ModeSymlink != 0 {
+		return "120000"
+	}
+
 	if IsExecutable(info) {
 		return "100755"
 	}
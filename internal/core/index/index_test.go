@@ -2,30 +2,37 @@
 package index
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
+
+	"sib/internal/core/lockfile"
 )
 
+// repoDir создает временный каталог с пустым .sib
+func repoDir(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, ".sib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return tmpDir
+}
+
 func TestNewIndex(t *testing.T) {
 	// Создаем временную директорию для тестов
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 
-	t.Run("Create new index in empty directory", func(t *testing.T) {
+	t.Run("Create new index in repository", func(t *testing.T) {
 		idx, err := NewIndex(tmpDir)
 		if err != nil {
 			t.Fatalf("Failed to create index: %v", err)
 		}
 
-		// Проверяем, что .sib директория создана
-		sibDir := filepath.Join(tmpDir, ".sib")
-		if _, err := os.Stat(sibDir); os.IsNotExist(err) {
-			t.Error(".sib directory was not created")
-		}
-
 		// Проверяем, что файл индекса создан
-		indexPath := filepath.Join(sibDir, "index")
+		indexPath := filepath.Join(tmpDir, ".sib", "index")
 		if _, err := os.Stat(indexPath); os.IsNotExist(err) {
 			t.Error("index file was not created")
 		}
@@ -40,6 +47,16 @@ func TestNewIndex(t *testing.T) {
 		}
 	})
 
+	t.Run("Refuses outside repository", func(t *testing.T) {
+		outside := t.TempDir()
+		if _, err := NewIndex(outside); err == nil {
+			t.Error("Expected error outside repository")
+		}
+		if _, err := os.Stat(filepath.Join(outside, ".sib")); !os.IsNotExist(err) {
+			t.Error(".sib directory should not be created")
+		}
+	})
+
 	t.Run("Load existing index", func(t *testing.T) {
 		// Сначала создаем индекс
 		idx1, err := NewIndex(tmpDir)
@@ -81,7 +98,7 @@ func TestNewIndex(t *testing.T) {
 }
 
 func TestIndexAdd(t *testing.T) {
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 	idx, err := NewIndex(tmpDir)
 	if err != nil {
 		t.Fatalf("Failed to create index: %v", err)
@@ -227,7 +244,7 @@ func TestIndexAdd(t *testing.T) {
 }
 
 func TestIndexRemove(t *testing.T) {
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 	idx, err := NewIndex(tmpDir)
 	if err != nil {
 		t.Fatalf("Failed to create index: %v", err)
@@ -280,7 +297,7 @@ func TestIndexRemove(t *testing.T) {
 }
 
 func TestIndexSaveAndLoad(t *testing.T) {
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 
 	// Создаем и заполняем индекс
 	idx1, err := NewIndex(tmpDir)
@@ -355,7 +372,7 @@ func TestIndexSaveAndLoad(t *testing.T) {
 }
 
 func TestIndexDiff(t *testing.T) {
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 	idx, err := NewIndex(tmpDir)
 	if err != nil {
 		t.Fatalf("Failed to create index: %v", err)
@@ -426,7 +443,7 @@ func TestIndexDiff(t *testing.T) {
 }
 
 func TestIndexValidate(t *testing.T) {
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 	idx, err := NewIndex(tmpDir)
 	if err != nil {
 		t.Fatalf("Failed to create index: %v", err)
@@ -479,7 +496,7 @@ func TestIndexValidate(t *testing.T) {
 }
 
 func TestIndexGetAllEntries(t *testing.T) {
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 	idx, err := NewIndex(tmpDir)
 	if err != nil {
 		t.Fatalf("Failed to create index: %v", err)
@@ -513,7 +530,7 @@ func TestIndexGetAllEntries(t *testing.T) {
 
 func TestIndexEdgeCases(t *testing.T) {
 	t.Run("Path normalization", func(t *testing.T) {
-		tmpDir := t.TempDir()
+		tmpDir := repoDir(t)
 		idx, err := NewIndex(tmpDir)
 		if err != nil {
 			t.Fatalf("Failed to create index: %v", err)
@@ -541,7 +558,7 @@ func TestIndexEdgeCases(t *testing.T) {
 	})
 
 	t.Run("Clear index", func(t *testing.T) {
-		tmpDir := t.TempDir()
+		tmpDir := repoDir(t)
 		idx, err := NewIndex(tmpDir)
 		if err != nil {
 			t.Fatalf("Failed to create index: %v", err)
@@ -576,7 +593,7 @@ func TestIndexEdgeCases(t *testing.T) {
 }
 
 func TestIndexCorruptFile(t *testing.T) {
-	tmpDir := t.TempDir()
+	tmpDir := repoDir(t)
 
 	// Создаем битый JSON файл
 	indexPath := filepath.Join(tmpDir, ".sib", "index")
@@ -592,14 +609,72 @@ func TestIndexCorruptFile(t *testing.T) {
 		t.Fatalf("Failed to write corrupt file: %v", err)
 	}
 
-	// Пытаемся загрузить
+	// Поврежденный индекс - ошибка, а не молча пустой индекс
+	if _, err := NewIndex(tmpDir); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Expected ErrCorrupt, got %v", err)
+	}
+	if _, err := LockIndex(sibDir, 0); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Expected ErrCorrupt from LockIndex, got %v", err)
+	}
+	if _, err := os.Stat(indexPath + ".lock"); !os.IsNotExist(err) {
+		t.Error("index.lock should be released after a failed load")
+	}
+	data, _ := os.ReadFile(indexPath)
+	if string(data) != string(corruptJSON) {
+		t.Error("Corrupt index must not be overwritten")
+	}
+
+	// Восстановление откладывает файл в сторону, дальше индекс пустой
+	backup, err := RecoverIndex(sibDir, 0)
+	if err != nil {
+		t.Fatalf("RecoverIndex failed: %v", err)
+	}
+	if data, _ := os.ReadFile(backup); string(data) != string(corruptJSON) {
+		t.Errorf("Backup content = %q", data)
+	}
 	idx, err := NewIndex(tmpDir)
 	if err != nil {
-		t.Fatalf("Failed to load corrupt index: %v", err)
+		t.Fatalf("Failed to load index after recovery: %v", err)
 	}
-
-	// Должен создать новый пустой индекс
 	if idx.Count() != 0 {
-		t.Errorf("Corrupt index should be replaced with empty, got %d entries", idx.Count())
+		t.Errorf("Recovered index should be empty, got %d entries", idx.Count())
+	}
+}
+
+func TestIndexLock(t *testing.T) {
+	tmpDir := repoDir(t)
+	sibDir := filepath.Join(tmpDir, ".sib")
+
+	idx, err := LockIndex(sibDir, 0)
+	if err != nil {
+		t.Fatalf("LockIndex failed: %v", err)
+	}
+	idx.Entries["a.txt"] = IndexEntry{Path: "a.txt", Hash: "1111111111111111111111111111111111111111111111111111111111111111"}
+
+	// Пока индекс захвачен, второй писатель ждет и получает ошибку по таймауту
+	if _, err := LockIndex(sibDir, 20*time.Millisecond); !errors.Is(err, lockfile.ErrLocked) {
+		t.Fatalf("Expected ErrLocked, got %v", err)
+	}
+	other, err := OpenIndex(sibDir)
+	if err != nil {
+		t.Fatalf("OpenIndex failed: %v", err)
+	}
+	other.lockTimeout = 0
+	if err := other.Save(); !errors.Is(err, lockfile.ErrLocked) {
+		t.Errorf("Save without the lock should fail while it is held, got %v", err)
+	}
+
+	// Save снимает блокировку, и следующий писатель видит изменения
+	if err := idx.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	idx.Unlock()
+	next, err := LockIndex(sibDir, 0)
+	if err != nil {
+		t.Fatalf("LockIndex after Save failed: %v", err)
+	}
+	defer next.Unlock()
+	if _, ok := next.Entries["a.txt"]; !ok {
+		t.Error("Saved entry is missing")
 	}
 }
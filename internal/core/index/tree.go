@@ -0,0 +1,105 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// treeNode - промежуточное представление директории при построении tree
+type treeNode struct {
+	files map[string]IndexEntry // Файлы непосредственно в этой директории
+	dirs  map[string]*treeNode  // Поддиректории
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{
+		files: make(map[string]IndexEntry),
+		dirs:  make(map[string]*treeNode),
+	}
+}
+
+// WriteTree строит вложенные tree объекты из записей индекса
+// (по одному tree на каждую директорию) и сохраняет их в хранилище.
+// Возвращает хеш корневого tree. Пустой индекс дает пустое дерево.
+func (idx *Index) WriteTree(store *storage.ObjectStore) (objects.Hash, error) {
+	if idx.HasConflicts() {
+		return "", fmt.Errorf("cannot write tree with unmerged paths: %s", strings.Join(idx.UnmergedPaths(), ", "))
+	}
+	root := newTreeNode()
+	for _, entry := range idx.GetAllEntries() {
+		if entry.Mode == "040000" {
+			return "", fmt.Errorf("directory entry in index: %s", entry.Path)
+		}
+
+		parts := strings.Split(entry.Path, "/")
+		node := root
+		for _, dir := range parts[:len(parts)-1] {
+			child, exists := node.dirs[dir]
+			if !exists {
+				child = newTreeNode()
+				node.dirs[dir] = child
+			}
+			node = child
+		}
+
+		name := parts[len(parts)-1]
+		if _, isDir := node.dirs[name]; isDir {
+			return "", fmt.Errorf("path is both a file and a directory: %s", entry.Path)
+		}
+		node.files[name] = entry
+	}
+
+	return writeTreeNode(store, root)
+}
+
+// writeTreeNode рекурсивно сохраняет директорию и все её поддиректории
+func writeTreeNode(store *storage.ObjectStore, node *treeNode) (objects.Hash, error) {
+	tree := objects.NewTree()
+
+	// Сначала поддиректории - их хеши нужны для записей родителя
+	dirNames := make([]string, 0, len(node.dirs))
+	for name := range node.dirs {
+		dirNames = append(dirNames, name)
+	}
+	sort.Strings(dirNames)
+
+	for _, name := range dirNames {
+		if _, conflict := node.files[name]; conflict {
+			return "", fmt.Errorf("path is both a file and a directory: %s", name)
+		}
+
+		subHash, err := writeTreeNode(store, node.dirs[name])
+		if err != nil {
+			return "", err
+		}
+
+		entry, err := objects.NewTreeEntry(objects.FileModeDir, name, subHash, objects.TreeObject)
+		if err != nil {
+			return "", fmt.Errorf("failed to create tree entry for %s: %w", name, err)
+		}
+		if err := tree.AddEntry(*entry); err != nil {
+			return "", err
+		}
+	}
+
+	for name, file := range node.files {
+		entry, err := objects.NewTreeEntry(objects.FileMode(file.Mode), name, objects.Hash(file.Hash), objects.BlobObject)
+		if err != nil {
+			return "", fmt.Errorf("failed to create tree entry for %s: %w", file.Path, err)
+		}
+		if err := tree.AddEntry(*entry); err != nil {
+			return "", err
+		}
+	}
+
+	hash, err := store.WriteObject(tree)
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree: %w", err)
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,106 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+func TestWriteTree(t *testing.T) {
+	tmpDir := repoDir(t)
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".sib", "objects"), 0755); err != nil {
+		t.Fatalf("Failed to create objects directory: %v", err)
+	}
+
+	store, err := storage.NewObjectStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	idx, err := NewIndex(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+
+	t.Run("Empty index", func(t *testing.T) {
+		hash, err := idx.WriteTree(store)
+		if err != nil {
+			t.Fatalf("WriteTree of empty index failed: %v", err)
+		}
+		tree, err := store.ReadTree(hash)
+		if err != nil || len(tree.Entries()) != 0 {
+			t.Errorf("Expected empty tree, got %v, %v", tree, err)
+		}
+	})
+
+	// Сохраняем blob'ы, чтобы хеши в индексе были настоящими
+	files := map[string]string{
+		"README.md":        "# readme",
+		"src/main.go":      "package main",
+		"src/util/util.go": "package util",
+	}
+	for path, content := range files {
+		hash, err := store.WriteObject(objects.NewBlob([]byte(content)))
+		if err != nil {
+			t.Fatalf("Failed to write blob: %v", err)
+		}
+		if err := idx.Add(path, hash.String(), int64(len(content)), "100644", time.Now()); err != nil {
+			t.Fatalf("Failed to add %s: %v", path, err)
+		}
+	}
+
+	t.Run("Nested directories", func(t *testing.T) {
+		rootHash, err := idx.WriteTree(store)
+		if err != nil {
+			t.Fatalf("WriteTree failed: %v", err)
+		}
+
+		obj, err := store.ReadObject(rootHash)
+		if err != nil {
+			t.Fatalf("Failed to read root tree: %v", err)
+		}
+		root := obj.(*objects.Tree)
+
+		if len(root.Entries()) != 2 {
+			t.Fatalf("Expected 2 root entries, got %d", len(root.Entries()))
+		}
+
+		src, ok := root.GetEntry("src")
+		if !ok {
+			t.Fatal("Expected src entry in root tree")
+		}
+		if src.Type() != objects.TreeObject || src.Mode() != objects.FileModeDir {
+			t.Errorf("src should be a tree, got %s %s", src.Type(), src.Mode())
+		}
+
+		obj, err = store.ReadObject(src.Hash())
+		if err != nil {
+			t.Fatalf("Failed to read src tree: %v", err)
+		}
+		srcTree := obj.(*objects.Tree)
+		if _, ok := srcTree.GetEntry("util"); !ok {
+			t.Error("Expected util subtree in src")
+		}
+		if _, ok := srcTree.GetEntry("main.go"); !ok {
+			t.Error("Expected main.go in src")
+		}
+	})
+
+	t.Run("Deterministic hash", func(t *testing.T) {
+		first, err := idx.WriteTree(store)
+		if err != nil {
+			t.Fatalf("WriteTree failed: %v", err)
+		}
+		second, err := idx.WriteTree(store)
+		if err != nil {
+			t.Fatalf("WriteTree failed: %v", err)
+		}
+		if first != second {
+			t.Errorf("Tree hash is not deterministic: %s != %s", first, second)
+		}
+	})
+}
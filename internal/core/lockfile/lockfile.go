@@ -0,0 +1,177 @@
+// Package lockfile реализует блокировку файлов через соседний <path>.lock.
+//
+// Протокол тот же, что у git: lock-файл создается с O_EXCL, поэтому держать
+// его может только один процесс. Новое содержимое пишется в lock-файл и
+// переименовывается поверх оригинала, так что читатели видят либо старую,
+// либо новую версию целиком. Пока блокировка захвачена, в lock-файле лежит
+// "<pid> <host>" владельца - по нему распознаются брошенные блокировки
+// процессов, завершившихся аварийно.
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Suffix - суффикс lock-файла рядом с защищаемым файлом
+const Suffix = ".lock"
+
+// DefaultTimeout - сколько ждать освобождения чужой блокировки по умолчанию
+const DefaultTimeout = 5 * time.Second
+
+// StaleAge - lock-файл старше этого возраста считается брошенным, даже если
+// проверить владельца не удалось (другая машина или другая ОС)
+const StaleAge = 10 * time.Minute
+
+// ErrLocked - блокировку держит другой процесс, и ожидание истекло
+var ErrLocked = errors.New("another sib process seems to be running")
+
+// Lock - захваченная блокировка файла
+type Lock struct {
+	path     string   // Защищаемый файл
+	lockPath string   // Путь к lock-файлу
+	file     *os.File // Открытый lock-файл (nil после Commit/Release)
+}
+
+// Acquire захватывает блокировку файла path. Если lock-файл уже существует,
+// брошенная блокировка снимается, а живая ожидается не дольше timeout
+// (0 - не ждать)
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	lockPath := path + Suffix
+	deadline := time.Now().Add(timeout)
+	backoff := time.Millisecond
+
+	for {
+		file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			l := &Lock{path: path, lockPath: lockPath, file: file}
+			if _, err := fmt.Fprintf(file, "%d %s\n", os.Getpid(), hostname()); err != nil {
+				l.Release()
+				return nil, fmt.Errorf("unable to write %s: %w", lockPath, err)
+			}
+			return l, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("unable to create %s: %w", lockPath, err)
+		}
+
+		if removeStale(lockPath) {
+			continue
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("unable to create %s: file exists; %w.\n"+
+				"If no other sib process is running, remove the file manually to continue", lockPath, ErrLocked)
+		}
+
+		time.Sleep(min(backoff, time.Until(deadline)))
+		backoff = min(backoff*2, 100*time.Millisecond)
+	}
+}
+
+// Path возвращает путь к lock-файлу
+func (l *Lock) Path() string {
+	return l.lockPath
+}
+
+// Commit записывает данные в lock-файл и атомарно заменяет им оригинал.
+// После Commit блокировка освобождена
+func (l *Lock) Commit(data []byte) error {
+	if l.file == nil {
+		return fmt.Errorf("lock %s already released", l.lockPath)
+	}
+
+	// Убираем сведения о владельце, оставленные при захвате
+	if err := l.file.Truncate(0); err != nil {
+		l.Release()
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if _, err := l.file.WriteAt(data, 0); err != nil {
+		l.Release()
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := l.file.Close(); err != nil {
+		l.file = nil
+		os.Remove(l.lockPath)
+		return fmt.Errorf("failed to close lock file: %w", err)
+	}
+	l.file = nil
+
+	if err := os.Rename(l.lockPath, l.path); err != nil {
+		os.Remove(l.lockPath)
+		return fmt.Errorf("failed to rename lock file: %w", err)
+	}
+
+	return nil
+}
+
+// Release снимает блокировку без изменения файла.
+// Безопасно вызывать после Commit и повторно
+func (l *Lock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	l.file.Close()
+	l.file = nil
+	os.Remove(l.lockPath)
+}
+
+// removeStale удаляет lock-файл, если его владелец точно завершился
+// (тот же хост, процесса с таким pid нет) или файл старше StaleAge
+func removeStale(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		// Блокировку только что сняли - можно пробовать снова
+		return os.IsNotExist(err)
+	}
+
+	stale := time.Since(info.ModTime()) > StaleAge
+	if !stale {
+		data, err := os.ReadFile(lockPath)
+		if err != nil {
+			return false
+		}
+		pid, host, ok := parseOwner(string(data))
+		stale = ok && host == hostname() && pid != os.Getpid() && !processAlive(pid)
+	}
+	if !stale {
+		return false
+	}
+
+	// Удаляем только тот файл, который проверяли: за это время его мог
+	// снять и захватить заново другой процесс
+	if current, err := os.Stat(lockPath); err != nil || !os.SameFile(info, current) {
+		return false
+	}
+	return os.Remove(lockPath) == nil
+}
+
+// parseOwner разбирает "<pid> <host>" из lock-файла
+func parseOwner(data string) (int, string, bool) {
+	pidStr, host, ok := strings.Cut(strings.TrimSpace(data), " ")
+	if !ok {
+		return 0, "", false
+	}
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil || pid <= 0 {
+		return 0, "", false
+	}
+	return pid, host, true
+}
+
+// hostname возвращает имя машины для записи о владельце
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "localhost"
+	}
+	return strings.ReplaceAll(host, " ", "_")
+}
@@ -0,0 +1,113 @@
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestAcquireAndCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if _, err := Acquire(path, 20*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected ErrLocked while the lock is held, got %v", err)
+	}
+
+	if err := lock.Commit([]byte("new\n")); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("Content = %q", data)
+	}
+	if _, err := os.Stat(lock.Path()); !os.IsNotExist(err) {
+		t.Error("Lock file should be gone after Commit")
+	}
+	lock.Release()
+
+	// Release без Commit оставляет файл как есть
+	lock, err = Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquire after Commit failed: %v", err)
+	}
+	lock.Release()
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("Release changed the file: %q", data)
+	}
+}
+
+func TestAcquireWaits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	lock, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		lock.Release()
+	}()
+
+	second, err := Acquire(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Acquire should wait for the holder, got %v", err)
+	}
+	second.Release()
+}
+
+func TestStaleLock(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("Dead owner on this host", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("process liveness is not checked on this platform")
+		}
+		path := filepath.Join(dir, "dead")
+		// pid больше максимального в Linux - такого процесса точно нет
+		owner := fmt.Sprintf("%d %s\n", 1<<30, hostname())
+		if err := os.WriteFile(path+Suffix, []byte(owner), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lock, err := Acquire(path, 0)
+		if err != nil {
+			t.Fatalf("Stale lock should be removed, got %v", err)
+		}
+		lock.Release()
+	})
+
+	t.Run("Live owner", func(t *testing.T) {
+		path := filepath.Join(dir, "live")
+		owner := fmt.Sprintf("%d %s\n", os.Getppid(), hostname())
+		if err := os.WriteFile(path+Suffix, []byte(owner), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Acquire(path, 0); !errors.Is(err, ErrLocked) {
+			t.Errorf("Lock of a running process must be kept, got %v", err)
+		}
+	})
+
+	t.Run("Old lock", func(t *testing.T) {
+		path := filepath.Join(dir, "old")
+		if err := os.WriteFile(path+Suffix, []byte("1 other-host\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-2 * StaleAge)
+		if err := os.Chtimes(path+Suffix, old, old); err != nil {
+			t.Fatal(err)
+		}
+		lock, err := Acquire(path, 0)
+		if err != nil {
+			t.Fatalf("Old lock should be removed, got %v", err)
+		}
+		lock.Release()
+	})
+}
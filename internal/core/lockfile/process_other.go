@@ -0,0 +1,9 @@
+//go:build !unix
+
+package lockfile
+
+// processAlive на этой платформе не проверяется: блокировка считается живой,
+// пока не устареет по возрасту
+func processAlive(pid int) bool {
+	return true
+}
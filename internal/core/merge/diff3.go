@@ -0,0 +1,172 @@
+package merge
+
+import (
+	"bytes"
+
+	"sib/internal/core/diff"
+)
+
+// ConflictStyle - формат маркеров конфликта
+type ConflictStyle int
+
+const (
+	StyleMerge ConflictStyle = iota // <<<<<<< ours ======= theirs >>>>>>>
+	StyleDiff3                      // Дополнительно версия базы после |||||||
+)
+
+// Labels - подписи сторон в маркерах конфликта
+type Labels struct {
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// Длина маркеров конфликта, как в git
+const markerSize = 7
+
+// Text сливает три версии текста построчно (diff3).
+// Участки, измененные только одной стороной, принимаются автоматически;
+// участки, по-разному измененные обеими сторонами, обрамляются маркерами конфликта.
+// Возвращает результат и число конфликтов
+func Text(base, ours, theirs []byte, algo diff.Algorithm, style ConflictStyle, labels Labels) ([]byte, int) {
+	b := diff.SplitLines(base)
+	o := diff.SplitLines(ours)
+	t := diff.SplitLines(theirs)
+
+	// Для каждой строки базы - номер совпавшей строки в каждой из версий (-1, если нет)
+	oursMatch := matchBase(diff.Lines(b, o, algo), len(b))
+	theirsMatch := matchBase(diff.Lines(b, t, algo), len(b))
+
+	w := &conflictWriter{style: style, labels: labels}
+	i, oi, ti := 0, 0, 0
+	for i < len(b) || oi < len(o) || ti < len(t) {
+		// Строка базы сохранена в обеих версиях на ожидаемом месте - стабильный участок
+		if i < len(b) && oursMatch[i] == oi && theirsMatch[i] == ti {
+			w.lines(b[i : i+1])
+			i, oi, ti = i+1, oi+1, ti+1
+			continue
+		}
+
+		// Ищем следующую строку базы, сохраненную в обеих версиях
+		j := i
+		for j < len(b) && (oursMatch[j] < 0 || theirsMatch[j] < 0) {
+			j++
+		}
+		oEnd, tEnd := len(o), len(t)
+		if j < len(b) {
+			oEnd, tEnd = oursMatch[j], theirsMatch[j]
+		}
+
+		w.chunk(b[i:j], o[oi:oEnd], t[ti:tEnd])
+		i, oi, ti = j, oEnd, tEnd
+	}
+
+	return w.buf.Bytes(), w.conflicts
+}
+
+// matchBase строит отображение строк базы в строки другой версии по скрипту редактирования
+func matchBase(edits []diff.Edit, n int) []int {
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	for _, e := range edits {
+		if e.Kind == diff.Equal {
+			match[e.OldLine] = e.NewLine
+		}
+	}
+	return match
+}
+
+// conflictWriter собирает результат слияния
+type conflictWriter struct {
+	buf       bytes.Buffer
+	style     ConflictStyle
+	labels    Labels
+	conflicts int
+}
+
+// lines дописывает строки как есть
+func (w *conflictWriter) lines(lines []string) {
+	for _, line := range lines {
+		w.buf.WriteString(line)
+	}
+}
+
+// chunk разрешает нестабильный участок: изменения одной стороны принимаются,
+// одинаковые изменения обеих сторон тоже, остальное - конфликт
+func (w *conflictWriter) chunk(base, ours, theirs []string) {
+	switch {
+	case equalLines(ours, base):
+		w.lines(theirs)
+	case equalLines(theirs, base), equalLines(ours, theirs):
+		w.lines(ours)
+	default:
+		w.conflict(base, ours, theirs)
+	}
+}
+
+// conflict записывает конфликт с маркерами. Общие строки в начале и в конце
+// версий выносятся за маркеры, чтобы конфликт был как можно меньше
+func (w *conflictWriter) conflict(base, ours, theirs []string) {
+	prefix := 0
+	for prefix < len(ours) && prefix < len(theirs) && ours[prefix] == theirs[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ours)-prefix && suffix < len(theirs)-prefix &&
+		ours[len(ours)-1-suffix] == theirs[len(theirs)-1-suffix] {
+		suffix++
+	}
+
+	// В стиле diff3 показываем базу целиком, поэтому общие строки не выносим
+	if w.style == StyleDiff3 {
+		prefix, suffix = 0, 0
+	}
+
+	w.lines(ours[:prefix])
+	w.conflicts++
+
+	w.marker('<', w.labels.Ours)
+	w.section(ours[prefix : len(ours)-suffix])
+	if w.style == StyleDiff3 {
+		w.marker('|', w.labels.Base)
+		w.section(base)
+	}
+	w.marker('=', "")
+	w.section(theirs[prefix : len(theirs)-suffix])
+	w.marker('>', w.labels.Theirs)
+
+	w.lines(ours[len(ours)-suffix:])
+}
+
+// section дописывает строки внутри конфликта; последняя строка без перевода
+// строки получает его, чтобы маркер начинался с новой строки
+func (w *conflictWriter) section(lines []string) {
+	w.lines(lines)
+	if n := len(lines); n > 0 && lines[n-1][len(lines[n-1])-1] != '\n' {
+		w.buf.WriteByte('\n')
+	}
+}
+
+// marker пишет строку маркера, например "<<<<<<< HEAD"
+func (w *conflictWriter) marker(ch byte, label string) {
+	w.buf.Write(bytes.Repeat([]byte{ch}, markerSize))
+	if label != "" {
+		w.buf.WriteByte(' ')
+		w.buf.WriteString(label)
+	}
+	w.buf.WriteByte('\n')
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
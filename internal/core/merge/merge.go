@@ -0,0 +1,483 @@
+// Package merge выполняет трехстороннее слияние деревьев.
+//
+// Сначала слияние идет на уровне tree: поддерево, измененное только одной
+// стороной (или одинаково обеими), берется целиком без чтения файлов.
+// Файлы, измененные обеими сторонами по-разному, сливаются построчно (diff3).
+// Неразрешенные пути возвращаются как конфликты с версиями всех трех сторон,
+// чтобы вызывающий код мог записать их в индекс как стадии 1-3.
+// Если путь в одной версии файл, а в другой каталог, каталог остается на месте,
+// а файл уходит в рабочий каталог под именем path~<сторона> (file/directory).
+package merge
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"sib/internal/core/diff"
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// Options - параметры слияния
+type Options struct {
+	Algorithm diff.Algorithm // Алгоритм построчного сравнения
+	Style     ConflictStyle  // Формат маркеров конфликта
+	Labels    Labels         // Подписи сторон в маркерах
+}
+
+// ConflictKind - вид конфликта
+type ConflictKind int
+
+const (
+	ConflictContent       ConflictKind = iota // Обе стороны по-разному изменили файл
+	ConflictAddAdd                            // Обе стороны по-разному добавили файл
+	ConflictModifyDelete                      // Одна сторона изменила файл, другая удалила
+	ConflictBinary                            // Бинарный файл изменен обеими сторонами
+	ConflictFileDirectory                     // На месте файла другая сторона создала каталог
+)
+
+// String возвращает имя вида конфликта, как в сообщениях git
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictAddAdd:
+		return "add/add"
+	case ConflictModifyDelete:
+		return "modify/delete"
+	case ConflictBinary:
+		return "binary"
+	case ConflictFileDirectory:
+		return "file/directory"
+	default:
+		return "content"
+	}
+}
+
+// Conflict - путь, который не удалось слить автоматически
+type Conflict struct {
+	Path   string
+	Kind   ConflictKind
+	Base   *storage.TreeFile // Версия базы; nil, если файла не было
+	Ours   *storage.TreeFile // Наша версия; nil, если удален у нас
+	Theirs *storage.TreeFile // Их версия; nil, если удален у них
+
+	Mode    objects.FileMode // Режим файла для рабочего каталога
+	Content []byte           // Содержимое для рабочего каталога (с маркерами конфликта)
+	Renamed string           // Путь файла в рабочем каталоге, если Path занят каталогом
+}
+
+// WorktreePath возвращает путь, по которому Content записывается в рабочий каталог
+func (c Conflict) WorktreePath() string {
+	if c.Renamed != "" {
+		return c.Renamed
+	}
+	return c.Path
+}
+
+// Result - результат слияния
+type Result struct {
+	Files     map[string]storage.TreeFile // Успешно слитые файлы (blob'ы уже записаны)
+	Conflicts []Conflict                  // Конфликты, отсортированные по пути
+}
+
+// Trees сливает деревья ours и theirs относительно общего предка base.
+// Пустой хеш означает пустое дерево
+func Trees(store *storage.ObjectStore, base, ours, theirs objects.Hash, opts Options) (*Result, error) {
+	m := &merger{
+		store:  store,
+		opts:   opts,
+		result: &Result{Files: make(map[string]storage.TreeFile)},
+	}
+	if err := m.mergeDir("", base, ours, theirs); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(m.result.Conflicts, func(i, j int) bool {
+		return m.result.Conflicts[i].Path < m.result.Conflicts[j].Path
+	})
+	return m.result, nil
+}
+
+type merger struct {
+	store  *storage.ObjectStore
+	opts   Options
+	result *Result
+}
+
+// mergeDir сливает три версии каталога prefix
+func (m *merger) mergeDir(prefix string, base, ours, theirs objects.Hash) error {
+	// Тривиальные случаи на уровне tree
+	switch {
+	case ours == theirs:
+		return m.take(prefix, ours)
+	case base == ours:
+		return m.take(prefix, theirs)
+	case base == theirs:
+		return m.take(prefix, ours)
+	}
+
+	baseEntries, err := m.entries(base)
+	if err != nil {
+		return err
+	}
+	oursEntries, err := m.entries(ours)
+	if err != nil {
+		return err
+	}
+	theirsEntries, err := m.entries(theirs)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for _, entries := range []map[string]*objects.TreeEntry{baseEntries, oursEntries, theirsEntries} {
+		for name := range entries {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		fullPath := path.Join(prefix, name)
+		b, o, t := baseEntries[name], oursEntries[name], theirsEntries[name]
+
+		trees, blobs := 0, 0
+		for _, e := range []*objects.TreeEntry{b, o, t} {
+			switch {
+			case e == nil:
+			case e.Type() == objects.TreeObject:
+				trees++
+			default:
+				blobs++
+			}
+		}
+
+		switch {
+		case blobs == 0:
+			if err := m.mergeDir(fullPath, treeHash(b), treeHash(o), treeHash(t)); err != nil {
+				return err
+			}
+		case trees == 0:
+			if err := m.mergeFile(fullPath, b, o, t); err != nil {
+				return err
+			}
+		default:
+			if err := m.mergeFileDir(fullPath, b, o, t); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeFileDir сливает путь, который в одних версиях файл, а в других каталог.
+// Каталоги и файлы сливаются по отдельности. Если в результате остались и
+// каталог, и файл, каталог сохраняет путь, а файл становится конфликтом
+// file/directory: в индексе он остается на своем пути стадиями 1-3, а в
+// рабочий каталог записывается рядом, под именем path~<сторона>
+func (m *merger) mergeFileDir(filePath string, b, o, t *objects.TreeEntry) error {
+	files, conflicts := len(m.result.Files), len(m.result.Conflicts)
+	if err := m.mergeDir(filePath, treeHash(onlyType(b, objects.TreeObject)),
+		treeHash(onlyType(o, objects.TreeObject)), treeHash(onlyType(t, objects.TreeObject))); err != nil {
+		return err
+	}
+	dirKept := len(m.result.Files) > files || len(m.result.Conflicts) > conflicts
+
+	conflicts = len(m.result.Conflicts)
+	base, ours, theirs := onlyType(b, objects.BlobObject), onlyType(o, objects.BlobObject), onlyType(t, objects.BlobObject)
+	if err := m.mergeFile(filePath, base, ours, theirs); err != nil {
+		return err
+	}
+	if !dirKept {
+		return nil
+	}
+
+	var conflict Conflict
+	if file, ok := m.result.Files[filePath]; ok {
+		// Файл слился бы чисто, но его путь занят каталогом
+		delete(m.result.Files, filePath)
+		content, err := m.content(&file)
+		if err != nil {
+			return err
+		}
+		conflict = Conflict{
+			Path: filePath, Base: treeFile(filePath, base), Ours: treeFile(filePath, ours), Theirs: treeFile(filePath, theirs),
+			Mode: file.Mode, Content: content,
+		}
+	} else if len(m.result.Conflicts) > conflicts {
+		conflict = m.result.Conflicts[conflicts]
+		m.result.Conflicts = m.result.Conflicts[:conflicts]
+	} else {
+		// Файл удален - остается только каталог
+		return nil
+	}
+
+	// Каталог есть только у одной стороны, значит файл - у другой
+	side := sideSuffix(m.opts.Labels.Ours, "ours")
+	if conflict.Ours == nil {
+		side = sideSuffix(m.opts.Labels.Theirs, "theirs")
+	}
+	conflict.Kind = ConflictFileDirectory
+	conflict.Renamed = filePath + "~" + side
+	m.result.Conflicts = append(m.result.Conflicts, conflict)
+	return nil
+}
+
+// take добавляет в результат все файлы дерева
+func (m *merger) take(prefix string, tree objects.Hash) error {
+	if tree.IsEmpty() {
+		return nil
+	}
+	files, err := m.store.FlattenTree(tree)
+	if err != nil {
+		return err
+	}
+	for p, file := range files {
+		file.Path = path.Join(prefix, p)
+		m.result.Files[file.Path] = file
+	}
+	return nil
+}
+
+// mergeFile сливает три версии файла (любая может отсутствовать)
+func (m *merger) mergeFile(filePath string, b, o, t *objects.TreeEntry) error {
+	base, ours, theirs := treeFile(filePath, b), treeFile(filePath, o), treeFile(filePath, t)
+
+	switch {
+	case sameFile(ours, theirs):
+		m.add(ours)
+		return nil
+	case sameFile(base, ours):
+		m.add(theirs)
+		return nil
+	case sameFile(base, theirs):
+		m.add(ours)
+		return nil
+	}
+
+	conflict := Conflict{Path: filePath, Base: base, Ours: ours, Theirs: theirs}
+
+	// Одна сторона удалила файл, другая изменила: в рабочем каталоге остается измененная версия
+	if ours == nil || theirs == nil {
+		survivor := ours
+		if survivor == nil {
+			survivor = theirs
+		}
+		content, err := m.content(survivor)
+		if err != nil {
+			return err
+		}
+		conflict.Kind = ConflictModifyDelete
+		conflict.Mode = survivor.Mode
+		conflict.Content = content
+		m.result.Conflicts = append(m.result.Conflicts, conflict)
+		return nil
+	}
+
+	mode, modeClean := mergeModes(base, ours, theirs)
+	conflict.Mode = mode
+
+	baseContent, err := m.content(base)
+	if err != nil {
+		return err
+	}
+	oursContent, err := m.content(ours)
+	if err != nil {
+		return err
+	}
+	theirsContent, err := m.content(theirs)
+	if err != nil {
+		return err
+	}
+
+	if ours.Hash == theirs.Hash {
+		// Содержимое совпадает, конфликт только в режиме
+		conflict.Content = oursContent
+		m.result.Conflicts = append(m.result.Conflicts, conflict)
+		return nil
+	}
+
+	if diff.IsBinary(baseContent) || diff.IsBinary(oursContent) || diff.IsBinary(theirsContent) {
+		conflict.Kind = ConflictBinary
+		conflict.Content = oursContent
+		m.result.Conflicts = append(m.result.Conflicts, conflict)
+		return nil
+	}
+
+	merged, conflicts := Text(baseContent, oursContent, theirsContent, m.opts.Algorithm, m.opts.Style, m.opts.Labels)
+	if conflicts == 0 && modeClean {
+		hash, err := m.store.WriteObject(objects.NewBlob(merged))
+		if err != nil {
+			return fmt.Errorf("failed to write merged %s: %w", filePath, err)
+		}
+		m.add(&storage.TreeFile{Path: filePath, Mode: mode, Hash: hash})
+		return nil
+	}
+
+	if base == nil {
+		conflict.Kind = ConflictAddAdd
+	}
+	conflict.Content = merged
+	m.result.Conflicts = append(m.result.Conflicts, conflict)
+	return nil
+}
+
+// add добавляет файл в результат (nil - файл удален)
+func (m *merger) add(file *storage.TreeFile) {
+	if file != nil {
+		m.result.Files[file.Path] = *file
+	}
+}
+
+// content читает содержимое версии файла (пустое для отсутствующей)
+func (m *merger) content(file *storage.TreeFile) ([]byte, error) {
+	if file == nil {
+		return nil, nil
+	}
+	blob, err := m.store.ReadBlob(file.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return blob.Content(), nil
+}
+
+// entries читает записи дерева по именам; пустой хеш - пустое дерево
+func (m *merger) entries(hash objects.Hash) (map[string]*objects.TreeEntry, error) {
+	result := make(map[string]*objects.TreeEntry)
+	if hash.IsEmpty() {
+		return result, nil
+	}
+
+	tree, err := m.store.ReadTree(hash)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range tree.Entries() {
+		result[entry.Name()] = &entry
+	}
+	return result, nil
+}
+
+// mergeModes сливает режимы файла; при конфликте возвращается наш режим и false
+func mergeModes(base, ours, theirs *storage.TreeFile) (objects.FileMode, bool) {
+	switch {
+	case ours.Mode == theirs.Mode:
+		return ours.Mode, true
+	case base != nil && base.Mode == ours.Mode:
+		return theirs.Mode, true
+	case base != nil && base.Mode == theirs.Mode:
+		return ours.Mode, true
+	default:
+		return ours.Mode, false
+	}
+}
+
+// treeHash возвращает хеш поддерева (пустой для отсутствующего)
+func treeHash(entry *objects.TreeEntry) objects.Hash {
+	if entry == nil {
+		return ""
+	}
+	return entry.Hash()
+}
+
+// onlyType возвращает запись, если она имеет тип objType, иначе nil
+func onlyType(entry *objects.TreeEntry, objType objects.ObjectType) *objects.TreeEntry {
+	if entry == nil || (entry.Type() == objects.TreeObject) != (objType == objects.TreeObject) {
+		return nil
+	}
+	return entry
+}
+
+// sideSuffix превращает подпись стороны в суффикс имени файла, как git:
+// "feature/x" -> "feature_x", "abc1234 (subject)" -> "abc1234"
+func sideSuffix(label, fallback string) string {
+	fields := strings.Fields(label)
+	if len(fields) == 0 {
+		return fallback
+	}
+	return strings.ReplaceAll(fields[0], "/", "_")
+}
+
+// treeFile превращает запись дерева в файл результата (nil для отсутствующей)
+func treeFile(filePath string, entry *objects.TreeEntry) *storage.TreeFile {
+	if entry == nil {
+		return nil
+	}
+	return &storage.TreeFile{Path: filePath, Mode: entry.Mode(), Hash: entry.Hash()}
+}
+
+// sameFile сравнивает версии файла: обе отсутствуют или совпадают хеш и режим
+func sameFile(a, b *storage.TreeFile) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
+
+// WriteTree сохраняет результат слияния как дерево. Конфликтные файлы попадают
+// в него с маркерами по своему пути в рабочем каталоге - так строится
+// виртуальная база, когда у сливаемых коммитов несколько общих предков
+func (r *Result) WriteTree(store *storage.ObjectStore) (objects.Hash, error) {
+	files := make([]storage.TreeFile, 0, len(r.Files)+len(r.Conflicts))
+	for _, file := range r.Files {
+		files = append(files, file)
+	}
+	for _, conflict := range r.Conflicts {
+		hash, err := store.WriteObject(objects.NewBlob(conflict.Content))
+		if err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", conflict.Path, err)
+		}
+		files = append(files, storage.TreeFile{Path: conflict.WorktreePath(), Mode: conflict.Mode, Hash: hash})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	return saveTree(store, files)
+}
+
+// saveTree сохраняет дерево из файлов, отсортированных по пути:
+// файлы одного подкаталога идут подряд и сохраняются рекурсивно
+func saveTree(store *storage.ObjectStore, files []storage.TreeFile) (objects.Hash, error) {
+	tree := objects.NewTree()
+	for i := 0; i < len(files); {
+		name, _, isDir := strings.Cut(files[i].Path, "/")
+		mode, hash, objType := files[i].Mode, files[i].Hash, objects.BlobObject
+
+		if isDir {
+			var sub []storage.TreeFile
+			for ; i < len(files); i++ {
+				dir, rest, ok := strings.Cut(files[i].Path, "/")
+				if !ok || dir != name {
+					break
+				}
+				sub = append(sub, storage.TreeFile{Path: rest, Mode: files[i].Mode, Hash: files[i].Hash})
+			}
+			subHash, err := saveTree(store, sub)
+			if err != nil {
+				return "", err
+			}
+			mode, hash, objType = objects.FileModeDir, subHash, objects.TreeObject
+		} else {
+			i++
+		}
+
+		entry, err := objects.NewTreeEntry(mode, name, hash, objType)
+		if err != nil {
+			return "", fmt.Errorf("failed to create tree entry for %s: %w", name, err)
+		}
+		if err := tree.AddEntry(*entry); err != nil {
+			return "", err
+		}
+	}
+
+	hash, err := store.WriteObject(tree)
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree: %w", err)
+	}
+	return hash, nil
+}
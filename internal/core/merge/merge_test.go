@@ -0,0 +1,254 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+
+	"sib/internal/core/diff"
+	"sib/internal/testutil"
+)
+
+var testLabels = Labels{Base: "base", Ours: "ours", Theirs: "theirs"}
+
+func TestText(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts int
+	}{
+		{"Only ours changed", "a\nB\nc\nd\ne\n", base, "a\nB\nc\nd\ne\n", 0},
+		{"Only theirs changed", base, "a\nb\nc\nD\ne\n", "a\nb\nc\nD\ne\n", 0},
+		{"Both changed different places", "a\nB\nc\nd\ne\n", "a\nb\nc\nD\ne\n", "a\nB\nc\nD\ne\n", 0},
+		{"Same change on both sides", "a\nX\nc\nd\ne\n", "a\nX\nc\nd\ne\n", "a\nX\nc\nd\ne\n", 0},
+		{"Insertions at both ends", "top\n" + base, base + "bottom\n", "top\n" + base + "bottom\n", 0},
+		{
+			"Conflict",
+			"a\nours\nc\nd\ne\n", "a\ntheirs\nc\nd\ne\n",
+			"a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\nc\nd\ne\n", 1,
+		},
+		{
+			"Common lines moved out of the conflict",
+			"a\nsame\nours\nc\nd\ne\n", "a\nsame\ntheirs\nc\nd\ne\n",
+			"a\nsame\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\nc\nd\ne\n", 1,
+		},
+		{
+			"Delete versus edit",
+			"a\nc\nd\ne\n", "a\nB\nc\nd\ne\n",
+			"a\n<<<<<<< ours\n=======\nB\n>>>>>>> theirs\nc\nd\ne\n", 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Text([]byte(base), []byte(tt.ours), []byte(tt.theirs), diff.Myers, StyleMerge, testLabels)
+			if string(got) != tt.want || conflicts != tt.conflicts {
+				t.Errorf("Text() = %q (%d conflicts), want %q (%d)", got, conflicts, tt.want, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestTextDiff3StyleAndMissingNewline(t *testing.T) {
+	got, conflicts := Text([]byte("x"), []byte("ours"), []byte("theirs"), diff.Myers, StyleDiff3, testLabels)
+	want := "<<<<<<< ours\nours\n||||||| base\nx\n=======\ntheirs\n>>>>>>> theirs\n"
+	if string(got) != want || conflicts != 1 {
+		t.Errorf("Text() = %q (%d conflicts), want %q", got, conflicts, want)
+	}
+}
+
+func TestTrees(t *testing.T) {
+	store := testutil.NewStore(t)
+
+	base := testutil.WriteTree(t, store, map[string]string{
+		"lib/a.go":     "package lib\n",
+		"merge.txt":    "1\n2\n3\n4\n5\n",
+		"conflict.txt": "original\n",
+		"deleted.txt":  "will be edited\n",
+		"removed.txt":  "both agree\n",
+	})
+	ours := testutil.WriteTree(t, store, map[string]string{
+		"lib/a.go":     "package lib\n",
+		"merge.txt":    "one\n2\n3\n4\n5\n",
+		"conflict.txt": "ours\n",
+		"deleted.txt":  "edited\n",
+		"new.txt":      "added by us\n",
+		"both.txt":     "ours\n",
+	})
+	theirs := testutil.WriteTree(t, store, map[string]string{
+		"lib/a.go":     "package lib // theirs\n",
+		"merge.txt":    "1\n2\n3\n4\nfive\n",
+		"conflict.txt": "theirs\n",
+		"both.txt":     "theirs\n",
+	})
+
+	result, err := Trees(store, base, ours, theirs, Options{Labels: testLabels})
+	if err != nil {
+		t.Fatalf("Trees failed: %v", err)
+	}
+
+	content := func(path string) string {
+		file, ok := result.Files[path]
+		if !ok {
+			return "<missing>"
+		}
+		blob, err := store.ReadBlob(file.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(blob.Content())
+	}
+
+	if got := content("lib/a.go"); got != "package lib // theirs\n" {
+		t.Errorf("lib/a.go = %q", got)
+	}
+	if got := content("merge.txt"); got != "one\n2\n3\n4\nfive\n" {
+		t.Errorf("merge.txt = %q", got)
+	}
+	if got := content("new.txt"); got != "added by us\n" {
+		t.Errorf("new.txt = %q", got)
+	}
+	if got := content("removed.txt"); got != "<missing>" {
+		t.Errorf("removed.txt should stay deleted, got %q", got)
+	}
+
+	var kinds []string
+	for _, c := range result.Conflicts {
+		kinds = append(kinds, c.Path+":"+c.Kind.String())
+	}
+	if got := strings.Join(kinds, " "); got != "both.txt:add/add conflict.txt:content deleted.txt:modify/delete" {
+		t.Errorf("Conflicts = %q", got)
+	}
+
+	for _, c := range result.Conflicts {
+		switch c.Path {
+		case "conflict.txt":
+			if c.Base == nil || c.Ours == nil || c.Theirs == nil || !strings.Contains(string(c.Content), "<<<<<<< ours") {
+				t.Errorf("Unexpected content conflict: %+v", c)
+			}
+		case "deleted.txt":
+			if c.Theirs != nil || string(c.Content) != "edited\n" {
+				t.Errorf("Unexpected modify/delete conflict: %+v", c)
+			}
+		}
+	}
+
+	t.Run("Trivial tree-level merge", func(t *testing.T) {
+		result, err := Trees(store, base, base, theirs, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Conflicts) != 0 || len(result.Files) != 4 {
+			t.Errorf("Expected theirs tree as is, got %d files and %d conflicts", len(result.Files), len(result.Conflicts))
+		}
+	})
+}
+
+func TestTreesFileDirectory(t *testing.T) {
+	store := testutil.NewStore(t)
+	labels := Labels{Base: "base", Ours: "HEAD", Theirs: "feature/x"}
+
+	merged := func(base, ours, theirs map[string]string) *Result {
+		t.Helper()
+		result, err := Trees(store, testutil.WriteTree(t, store, base), testutil.WriteTree(t, store, ours), testutil.WriteTree(t, store, theirs), Options{Labels: labels})
+		if err != nil {
+			t.Fatalf("Trees failed: %v", err)
+		}
+		return result
+	}
+
+	t.Run("File edited, directory created", func(t *testing.T) {
+		result := merged(
+			map[string]string{"keep.txt": "k\n", "p": "file\n"},
+			map[string]string{"keep.txt": "k\n", "p": "changed\n"},
+			map[string]string{"keep.txt": "k\n", "p/x.txt": "x\n"},
+		)
+		if _, ok := result.Files["p/x.txt"]; !ok || len(result.Conflicts) != 1 {
+			t.Fatalf("Files = %v, conflicts = %+v", result.Files, result.Conflicts)
+		}
+		c := result.Conflicts[0]
+		if c.Path != "p" || c.Kind != ConflictFileDirectory || c.WorktreePath() != "p~HEAD" ||
+			c.Base == nil || c.Ours == nil || c.Theirs != nil || string(c.Content) != "changed\n" {
+			t.Errorf("Unexpected conflict: %+v", c)
+		}
+	})
+
+	t.Run("File added against directory", func(t *testing.T) {
+		result := merged(
+			map[string]string{"keep.txt": "k\n"},
+			map[string]string{"keep.txt": "k\n", "q/y.txt": "y\n"},
+			map[string]string{"keep.txt": "k\n", "q": "file\n"},
+		)
+		if _, ok := result.Files["q"]; ok || len(result.Conflicts) != 1 {
+			t.Fatalf("Files = %v, conflicts = %+v", result.Files, result.Conflicts)
+		}
+		c := result.Conflicts[0]
+		if c.Kind != ConflictFileDirectory || c.WorktreePath() != "q~feature_x" || c.Ours != nil || c.Theirs == nil {
+			t.Errorf("Unexpected conflict: %+v", c)
+		}
+	})
+
+	t.Run("Unchanged file replaced by directory", func(t *testing.T) {
+		result := merged(
+			map[string]string{"p": "file\n"},
+			map[string]string{"p": "file\n"},
+			map[string]string{"p/x.txt": "x\n"},
+		)
+		if len(result.Conflicts) != 0 || len(result.Files) != 1 {
+			t.Errorf("Files = %v, conflicts = %+v", result.Files, result.Conflicts)
+		}
+	})
+}
+
+func TestResultWriteTree(t *testing.T) {
+	store := testutil.NewStore(t)
+	base := testutil.WriteTree(t, store, map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n", "dir/c.txt": "c\n"})
+	ours := testutil.WriteTree(t, store, map[string]string{"a.txt": "ours\n", "dir/b.txt": "b\n", "dir/c.txt": "c\n", "p": "file\n"})
+	theirs := testutil.WriteTree(t, store, map[string]string{"a.txt": "theirs\n", "dir/b.txt": "B\n", "p/x.txt": "x\n"})
+
+	result, err := Trees(store, base, ours, theirs, Options{Labels: testLabels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := result.WriteTree(store)
+	if err != nil {
+		t.Fatalf("WriteTree failed: %v", err)
+	}
+	files, err := store.FlattenTree(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Конфликты сохраняются с маркерами, файл из file/directory - под новым именем
+	want := map[string]string{
+		"a.txt":     "<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\n",
+		"dir/b.txt": "B\n",
+		"p/x.txt":   "x\n",
+		"p~ours":    "file\n",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("Tree files = %v", files)
+	}
+	for path, content := range want {
+		file, ok := files[path]
+		if !ok {
+			t.Errorf("%s is missing", path)
+			continue
+		}
+		blob, err := store.ReadBlob(file.Hash)
+		if err != nil || string(blob.Content()) != content {
+			t.Errorf("%s = %q, want %q (%v)", path, blob.Content(), content, err)
+		}
+	}
+
+	empty, err := (&Result{}).WriteTree(store)
+	if err != nil {
+		t.Fatalf("WriteTree of an empty result failed: %v", err)
+	}
+	if files, _ := store.FlattenTree(empty); len(files) != 0 {
+		t.Errorf("Empty result gave %v", files)
+	}
+}
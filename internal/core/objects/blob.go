@@ -1,6 +1,10 @@
 package objects
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
 
 // Blob представляет содержимое файла
 // В Git blob хранит только данные файла, без имени и пути
@@ -72,10 +76,54 @@ func (b *Blob) Serialize() ([]byte, error) {
 	}
 
 	// Создаем заголовок в формате Git
-	header := fmt.Sprintf("%s %d", b.Type(), b.size)
-
-	data := append([]byte(header), 0) // Это байт со значением 0, а не строка "\0"
+	data := Header(b.Type(), b.size)
 	data = append(data, b.content...)
 
 	return data, nil
 }
+
+// ContentReader возвращает поток содержимого (реализует Streamable)
+func (b *Blob) ContentReader() (io.Reader, error) {
+	if b.content == nil {
+		return nil, fmt.Errorf("blob content is nil")
+	}
+	return bytes.NewReader(b.content), nil
+}
+
+// BlobStream - blob, содержимое которого читается из потока, а не хранится
+// в памяти. Так записываются большие файлы: хранилище хеширует и сжимает
+// данные по мере чтения (см. ObjectStore.WriteStream)
+type BlobStream struct {
+	reader io.Reader
+	size   int64
+	hash   Hash
+}
+
+// NewBlobStream создает blob из потока r длиной size байт.
+// Поток читается один раз - при записи в хранилище
+func NewBlobStream(r io.Reader, size int64) *BlobStream {
+	return &BlobStream{reader: r, size: size}
+}
+
+// Type возвращает тип объекта
+func (b *BlobStream) Type() ObjectType { return BlobObject }
+
+// Size возвращает размер содержимого в байтах
+func (b *BlobStream) Size() int64 { return b.size }
+
+// ContentReader возвращает поток содержимого
+func (b *BlobStream) ContentReader() (io.Reader, error) {
+	if b.reader == nil {
+		return nil, fmt.Errorf("blob stream is nil")
+	}
+	if b.size < 0 {
+		return nil, fmt.Errorf("invalid blob size: %d", b.size)
+	}
+	return b.reader, nil
+}
+
+// GetHash возвращает хеш объекта (после записи в хранилище)
+func (b *BlobStream) GetHash() Hash { return b.hash }
+
+// SetHash устанавливает хеш объекта
+func (b *BlobStream) SetHash(h Hash) { b.hash = h }
@@ -1,5 +1,7 @@
 package objects
 
+import "io"
+
 // Serializable определяет контракт для сериализации объектов
 // Используется CAS-хранилищем для единообразной работы со всеми типами объектов
 // "Любой объект, который можно сериализовать, должен иметь эти методы"
@@ -8,6 +10,15 @@ type Serializable interface {
 	Type() ObjectType           // Type возвращает тип объекта (blob, tree, commit, tag)
 }
 
+// Streamable дополняет Serializable для объектов, которые не нужно держать
+// в памяти целиком: заголовок "type size\0" строится по Type и Size,
+// а содержимое читается потоком из ContentReader
+type Streamable interface {
+	Type() ObjectType                  // Type возвращает тип объекта
+	Size() int64                       // Size возвращает размер содержимого без заголовка
+	ContentReader() (io.Reader, error) // ContentReader возвращает поток ровно из Size байт содержимого
+}
+
 // Hashable определяет контракт для работы с хешами
 // Позволяет единообразно работать с хешами разных объектов
 // "Любой объект с хешом должен уметь его устанавливать и возвращать"
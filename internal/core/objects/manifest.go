@@ -0,0 +1,136 @@
+package objects
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Chunk - кусок blob'а, записанного частями. Сам кусок хранится как обычный blob
+type Chunk struct {
+	hash Hash  // Приватно: хеш blob'а с содержимым куска
+	size int64 // Приватно: размер куска в байтах
+}
+
+// NewChunk создает описание куска
+func NewChunk(hash Hash, size int64) (*Chunk, error) {
+	if hash.IsEmpty() {
+		return nil, fmt.Errorf("chunk hash cannot be empty")
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid chunk size: %d", size)
+	}
+	return &Chunk{hash: hash, size: size}, nil
+}
+
+// Hash возвращает хеш куска
+func (c *Chunk) Hash() Hash { return c.hash }
+
+// Size возвращает размер куска
+func (c *Chunk) Size() int64 { return c.size }
+
+// Manifest - большой blob, разбитый на куски по содержимому (content-defined
+// chunking). Хранилище кладет манифест под хешем исходного blob'а, поэтому для
+// деревьев, индекса и команд это тот же blob: содержимое собирается из кусков
+// при чтении. Одинаковые куски разных версий файла хранятся один раз
+type Manifest struct {
+	chunks []Chunk // Приватно: куски по порядку
+	size   int64   // Приватно: размер собранного содержимого
+	hash   Hash    // Приватно: хеш собранного blob'а
+}
+
+// NewManifest создает манифест из кусков в порядке следования
+func NewManifest(chunks []Chunk) (*Manifest, error) {
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("manifest cannot be empty")
+	}
+	m := &Manifest{chunks: make([]Chunk, len(chunks))}
+	for i, chunk := range chunks {
+		if _, err := NewChunk(chunk.hash, chunk.size); err != nil {
+			return nil, fmt.Errorf("invalid chunk %d: %w", i, err)
+		}
+		m.chunks[i] = chunk
+		m.size += chunk.size
+	}
+	return m, nil
+}
+
+// Chunks возвращает копию списка кусков
+func (m *Manifest) Chunks() []Chunk {
+	chunks := make([]Chunk, len(m.chunks))
+	copy(chunks, m.chunks)
+	return chunks
+}
+
+// Size возвращает размер собранного содержимого
+func (m *Manifest) Size() int64 { return m.size }
+
+// GetHash возвращает хеш собранного blob'а
+func (m *Manifest) GetHash() Hash { return m.hash }
+
+// SetHash устанавливает хеш собранного blob'а
+func (m *Manifest) SetHash(h Hash) { m.hash = h }
+
+// Type возвращает тип объекта
+func (m *Manifest) Type() ObjectType { return ChunkedObject }
+
+// chunkJSON - приватная структура для JSON сериализации
+type chunkJSON struct {
+	Hash Hash  `json:"hash"`
+	Size int64 `json:"size"`
+}
+
+// manifestJSON - приватная структура для JSON сериализации
+type manifestJSON struct {
+	Type   ObjectType  `json:"type"`
+	Size   int64       `json:"size"`
+	Chunks []chunkJSON `json:"chunks"`
+}
+
+// Serialize преобразует манифест в байтовое представление
+func (m *Manifest) Serialize() ([]byte, error) {
+	mj := manifestJSON{Type: ChunkedObject, Size: m.size, Chunks: make([]chunkJSON, len(m.chunks))}
+	for i, chunk := range m.chunks {
+		mj.Chunks[i] = chunkJSON{Hash: chunk.hash, Size: chunk.size}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(mj); err != nil {
+		return nil, fmt.Errorf("failed to serialize manifest: %w", err)
+	}
+
+	data := bytes.TrimSpace(buf.Bytes())
+	result := Header(ChunkedObject, int64(len(data)))
+	return append(result, data...), nil
+}
+
+// DeserializeManifest создает Manifest из байтового представления
+func DeserializeManifest(data []byte) (*Manifest, error) {
+	parts := bytes.SplitN(data, []byte{0}, 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid manifest data format")
+	}
+
+	var mj manifestJSON
+	if err := json.Unmarshal(parts[1], &mj); err != nil {
+		return nil, fmt.Errorf("failed to deserialize manifest: %w", err)
+	}
+	if mj.Type != ChunkedObject {
+		return nil, fmt.Errorf("invalid object type: expected chunked, got %s", mj.Type)
+	}
+
+	chunks := make([]Chunk, len(mj.Chunks))
+	for i, cj := range mj.Chunks {
+		chunks[i] = Chunk{hash: cj.Hash, size: cj.Size}
+	}
+	m, err := NewManifest(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("deserialized manifest validation failed: %w", err)
+	}
+	if m.size != mj.Size {
+		return nil, fmt.Errorf("manifest size %d does not match chunks total %d", mj.Size, m.size)
+	}
+	return m, nil
+}
@@ -64,23 +64,24 @@ func (t *Tag) SetHash(h Hash) { t.hash = h }
 // Type возвращает тип объекта
 func (t *Tag) Type() ObjectType { return TagObject }
 
+// tagJSON - приватная структура для JSON сериализации
+type tagJSON struct {
+	Type    ObjectType    `json:"type"`
+	Object  Hash          `json:"object"`
+	ObjType ObjectType    `json:"objType"`
+	Tag     string        `json:"tag"`
+	Tagger  signatureJSON `json:"tagger"`
+	Message string        `json:"message"`
+}
+
 // Serialize преобразует tag в байтовое представление
 func (t *Tag) Serialize() ([]byte, error) {
-	type serializableTag struct {
-		Type    ObjectType `json:"type"`
-		Object  Hash       `json:"object"`
-		ObjType ObjectType `json:"objType"`
-		Tag     string     `json:"tag"`
-		Tagger  Signature  `json:"tagger"`
-		Message string     `json:"message"`
-	}
-
-	st := serializableTag{
+	tj := tagJSON{
 		Type:    TagObject,
 		Object:  t.object,
 		ObjType: t.objType,
 		Tag:     t.tagName,
-		Tagger:  t.tagger,
+		Tagger:  t.tagger.toJSONSignature(),
 		Message: t.message,
 	}
 
@@ -89,7 +90,7 @@ func (t *Tag) Serialize() ([]byte, error) {
 	encoder.SetEscapeHTML(false)
 	encoder.SetIndent("", "")
 
-	if err := encoder.Encode(st); err != nil {
+	if err := encoder.Encode(tj); err != nil {
 		return nil, fmt.Errorf("failed to serialize tag: %w", err)
 	}
 
@@ -100,3 +101,32 @@ func (t *Tag) Serialize() ([]byte, error) {
 
 	return result, nil
 }
+
+// DeserializeTag создает Tag из байтового представления
+func DeserializeTag(data []byte) (*Tag, error) {
+	parts := bytes.SplitN(data, []byte{0}, 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid tag data format")
+	}
+
+	var tj tagJSON
+	if err := json.Unmarshal(parts[1], &tj); err != nil {
+		return nil, fmt.Errorf("failed to deserialize tag: %w", err)
+	}
+
+	if tj.Type != TagObject {
+		return nil, fmt.Errorf("invalid object type: expected tag, got %s", tj.Type)
+	}
+
+	tagger, err := fromJSONSignature(tj.Tagger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tagger signature: %w", err)
+	}
+
+	tag, err := NewTag(tj.Object, tj.ObjType, tj.Tag, *tagger, tj.Message)
+	if err != nil {
+		return nil, fmt.Errorf("deserialized tag validation failed: %w", err)
+	}
+
+	return tag, nil
+}
@@ -172,12 +172,9 @@ func fromJSONEntry(je treeEntryJSON) (*TreeEntry, error) {
 	return NewTreeEntry(je.Mode, je.Name, je.Hash, je.ObjType)
 }
 
-// Serialize преобразует tree в байтовое представление
+// Serialize преобразует tree в байтовое представление.
+// Пустое дерево допустимо - это корень коммита без файлов
 func (t *Tree) Serialize() ([]byte, error) {
-	if len(t.entries) == 0 {
-		return nil, fmt.Errorf("tree cannot be empty")
-	}
-
 	// Конвертируем entries в JSON формат
 	jsonEntries := make([]treeEntryJSON, len(t.entries))
 	for i, entry := range t.entries {
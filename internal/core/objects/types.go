@@ -13,6 +13,10 @@ const (
 	TreeObject   ObjectType = "tree"
 	CommitObject ObjectType = "commit"
 	TagObject    ObjectType = "tag"
+
+	// ChunkedObject - манифест blob'а, записанного частями (см. Manifest).
+	// Встречается только внутри хранилища: в деревьях и ссылках это обычный blob
+	ChunkedObject ObjectType = "chunked"
 )
 
 // Validate проверяет валидность типа объекта
@@ -25,6 +29,11 @@ func (ot ObjectType) Validate() error {
 	}
 }
 
+// Header возвращает заголовок сериализованного объекта "type size\0"
+func Header(objType ObjectType, size int64) []byte {
+	return append([]byte(fmt.Sprintf("%s %d", objType, size)), 0)
+}
+
 // Hash представляет хеш SHA-256 объекта
 // Отдельный тип для типобезопасности и предотвращения ошибок
 type Hash string
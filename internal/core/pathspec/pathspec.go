@@ -0,0 +1,264 @@
+// Package pathspec выбирает пути по шаблонам командной строки, как pathspec в git.
+//
+// Поддерживаются:
+//   - литеральные пути: файл или каталог со всем содержимым ("src", "a.txt");
+//   - шаблоны с *, ? и [...]: "*.go" совпадает с файлами .go на любой глубине,
+//     поскольку '*' захватывает и '/';
+//   - магия ":(exclude)" (коротко ":!" или ":^") - исключить подходящие пути;
+//   - магия ":(top)" (коротко ":/") - путь от корня репозитория.
+//
+// Пути сравниваются в виде относительно корня репозитория с разделителем '/'.
+package pathspec
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Item - один разобранный элемент pathspec
+type Item struct {
+	Original string // Как элемент был задан в командной строке
+	Pattern  string // Нормализованный путь или шаблон; пустой - весь репозиторий
+	Glob     bool   // Pattern содержит символы шаблона
+	Exclude  bool   // Элемент исключает пути
+}
+
+// Match проверяет, что путь совпадает с элементом или лежит внутри него
+func (it Item) Match(p string) bool {
+	if it.Pattern == "" {
+		return true
+	}
+	if !it.Glob {
+		return p == it.Pattern || strings.HasPrefix(p, it.Pattern+"/")
+	}
+
+	// Шаблон, совпавший с каталогом, выбирает и все его содержимое
+	for {
+		if wildmatch(it.Pattern, p) {
+			return true
+		}
+		slash := strings.LastIndexByte(p, '/')
+		if slash < 0 {
+			return false
+		}
+		p = p[:slash]
+	}
+}
+
+// Pathspec - набор элементов. Путь выбран, если он совпадает хотя бы
+// с одним включающим элементом (или включающих нет вовсе) и ни с одним исключающим
+type Pathspec struct {
+	Items []Item
+}
+
+// Parse разбирает аргументы командной строки. Пустой список выбирает все пути
+func Parse(specs []string) (*Pathspec, error) {
+	return ParseRelative("", specs)
+}
+
+// ParseRelative разбирает аргументы, заданные из подкаталога prefix
+// (относительно корня, через '/'): пути без ":(top)" отсчитываются от него
+func ParseRelative(prefix string, specs []string) (*Pathspec, error) {
+	ps := &Pathspec{}
+	for _, spec := range specs {
+		item, err := parseItem(prefix, spec)
+		if err != nil {
+			return nil, err
+		}
+		ps.Items = append(ps.Items, item)
+	}
+	return ps, nil
+}
+
+// parseItem разбирает магию и нормализует путь элемента
+func parseItem(prefix, spec string) (Item, error) {
+	item := Item{Original: spec}
+	pattern := spec
+	top := false
+
+	switch {
+	case strings.HasPrefix(pattern, ":("):
+		end := strings.IndexByte(pattern, ')')
+		if end < 0 {
+			return Item{}, fmt.Errorf("missing ')' at the end of pathspec magic in '%s'", spec)
+		}
+		for _, magic := range strings.Split(pattern[2:end], ",") {
+			switch strings.TrimSpace(magic) {
+			case "exclude":
+				item.Exclude = true
+			case "top":
+				top = true
+			default:
+				return Item{}, fmt.Errorf("invalid pathspec magic '%s' in '%s'", magic, spec)
+			}
+		}
+		pattern = pattern[end+1:]
+	case strings.HasPrefix(pattern, ":"):
+		pattern = pattern[1:]
+		for len(pattern) > 0 && strings.IndexByte("!^/", pattern[0]) >= 0 {
+			if pattern[0] == '/' {
+				top = true
+			} else {
+				item.Exclude = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+
+	pattern = filepath.ToSlash(pattern)
+	if !top && prefix != "" {
+		pattern = prefix + "/" + pattern
+	}
+	pattern = path.Clean(pattern)
+	if pattern == ".." || strings.HasPrefix(pattern, "../") || strings.HasPrefix(pattern, "/") {
+		return Item{}, fmt.Errorf("'%s' is outside repository", spec)
+	}
+	if pattern == "." {
+		pattern = ""
+	}
+
+	item.Pattern = pattern
+	item.Glob = strings.ContainsAny(pattern, "*?[")
+	return item, nil
+}
+
+// IsEmpty проверяет, что pathspec выбирает все пути без ограничений
+func (ps *Pathspec) IsEmpty() bool {
+	for _, item := range ps.Items {
+		if item.Exclude || item.Pattern != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Match проверяет, выбран ли путь
+func (ps *Pathspec) Match(p string) bool {
+	included, hasInclude := false, false
+	for _, item := range ps.Items {
+		if item.Exclude {
+			if item.Match(p) {
+				return false
+			}
+			continue
+		}
+		hasInclude = true
+		if !included && item.Match(p) {
+			included = true
+		}
+	}
+	return included || !hasInclude
+}
+
+// Unmatched возвращает включающие элементы, не совпавшие ни с одним из путей -
+// обычно это опечатка, о которой команда должна сообщить
+func (ps *Pathspec) Unmatched(paths []string) []string {
+	var unmatched []string
+	for _, item := range ps.Items {
+		if item.Exclude {
+			continue
+		}
+		found := false
+		for _, p := range paths {
+			if item.Match(p) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, item.Original)
+		}
+	}
+	return unmatched
+}
+
+// wildmatch сопоставляет путь с шаблоном целиком. '*' совпадает с любой
+// последовательностью символов, включая '/', '?' - с одним символом, кроме '/',
+// [...] - с символом из класса ([!...] или [^...] - не из класса)
+func wildmatch(pattern, name string) bool {
+	// Позиция последней '*' для отката: '*' забирает на символ больше
+	starP, starN := -1, 0
+	p, n := 0, 0
+	for n < len(name) {
+		if p < len(pattern) {
+			switch c := pattern[p]; c {
+			case '*':
+				starP, starN = p, n
+				p++
+				continue
+			case '?':
+				if name[n] != '/' {
+					p++
+					n++
+					continue
+				}
+			case '[':
+				if width, ok := matchClass(pattern[p:], name[n]); width > 0 {
+					if ok {
+						p += width
+						n++
+						continue
+					}
+				} else if name[n] == '[' {
+					// Незакрытая скобка - обычный символ
+					p++
+					n++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == name[n] {
+					p += 2
+					n++
+					continue
+				}
+			default:
+				if c == name[n] {
+					p++
+					n++
+					continue
+				}
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		starN++
+		p, n = starP+1, starN
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass проверяет символ по классу в начале шаблона ("[...]").
+// Возвращает длину класса в шаблоне (0 - класс не закрыт) и результат сравнения
+func matchClass(pattern string, c byte) (int, bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+
+	matched := false
+	for first := true; i < len(pattern); first = false {
+		if pattern[i] == ']' && !first {
+			return i + 1, matched != negate
+		}
+		lo := pattern[i]
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+		i++
+	}
+	return 0, false
+}
@@ -0,0 +1,92 @@
+package pathspec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	paths := []string{"README.md", "main.go", "src/util.go", "src/lib/deep.go", "src/data.txt", "docs/a[1].txt"}
+
+	tests := []struct {
+		specs []string
+		want  []string
+	}{
+		{nil, paths},
+		{[]string{"."}, paths},
+		{[]string{"src"}, []string{"src/util.go", "src/lib/deep.go", "src/data.txt"}},
+		{[]string{"./src/"}, []string{"src/util.go", "src/lib/deep.go", "src/data.txt"}},
+		{[]string{"sr"}, nil},
+		{[]string{"*.go"}, []string{"main.go", "src/util.go", "src/lib/deep.go"}},
+		{[]string{"src/*.go"}, []string{"src/util.go", "src/lib/deep.go"}},
+		{[]string{"src/l?b"}, []string{"src/lib/deep.go"}},
+		{[]string{"[A-Z]*"}, []string{"README.md"}},
+		{[]string{"docs/a[1].txt"}, nil},
+		{[]string{`docs/a\[1\].txt`}, []string{"docs/a[1].txt"}},
+		{[]string{"src", ":(exclude)*.txt"}, []string{"src/util.go", "src/lib/deep.go"}},
+		{[]string{":!src"}, []string{"README.md", "main.go", "docs/a[1].txt"}},
+		{[]string{"*.go", ":^src/lib"}, []string{"main.go", "src/util.go"}},
+		{[]string{":/main.go", ":(top)README.md"}, []string{"README.md", "main.go"}},
+	}
+
+	for _, tt := range tests {
+		ps, err := Parse(tt.specs)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.specs, err)
+		}
+		var got []string
+		for _, p := range paths {
+			if ps.Match(p) {
+				got = append(got, p)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q matched %q, want %q", tt.specs, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, bad := range []string{":(icase)a", ":(exclude", "../outside", "/abs"} {
+		if _, err := Parse([]string{bad}); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+
+	ps, _ := Parse([]string{"."})
+	if !ps.IsEmpty() {
+		t.Error("'.' should select everything")
+	}
+	ps, _ = Parse([]string{":!x"})
+	if ps.IsEmpty() {
+		t.Error("Exclude is a restriction")
+	}
+}
+
+func TestParseRelative(t *testing.T) {
+	ps, err := ParseRelative("src", []string{".", "../README", ":/docs", ":(top,exclude)src/gen"})
+	if err != nil {
+		t.Fatalf("ParseRelative failed: %v", err)
+	}
+	want := []string{"src", "README", "docs", "src/gen"}
+	for i, item := range ps.Items {
+		if item.Pattern != want[i] {
+			t.Errorf("Item %d: got %q, want %q", i, item.Pattern, want[i])
+		}
+	}
+	if !ps.Items[3].Exclude {
+		t.Error(":(top,exclude) should exclude")
+	}
+
+	if _, err := ParseRelative("src", []string{"../.."}); err == nil {
+		t.Error("Expected error for path above the root")
+	}
+}
+
+func TestUnmatched(t *testing.T) {
+	ps, _ := Parse([]string{"a.txt", "*.go", "missing", ":!b.txt"})
+	got := ps.Unmatched([]string{"a.txt", "b.txt"})
+	if want := []string{"*.go", "missing"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmatched = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package refs
+
+import (
+	"fmt"
+	"strings"
+
+	"sib/internal/core/lockfile"
+)
+
+// ValidateName проверяет имя ссылки по правилам git check-ref-format:
+//   - имя состоит из компонентов через '/', ни один не пустой
+//   - компонент не начинается с '.' и не заканчивается на ".lock"
+//   - нет "..", "@{", управляющих символов, пробела и символов ~ ^ : ? * [ \
+//   - имя не заканчивается на '.' и не равно "@"
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("ref name cannot be empty")
+	}
+	if name == "@" {
+		return fmt.Errorf("invalid ref name %q", name)
+	}
+	if strings.HasSuffix(name, ".") {
+		return fmt.Errorf("invalid ref name %q: cannot end with '.'", name)
+	}
+	if strings.Contains(name, "..") {
+		return fmt.Errorf("invalid ref name %q: cannot contain '..'", name)
+	}
+	if strings.Contains(name, "@{") {
+		return fmt.Errorf("invalid ref name %q: cannot contain '@{'", name)
+	}
+
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f {
+			return fmt.Errorf("invalid ref name %q: control characters are not allowed", name)
+		}
+		switch c {
+		case ' ', '~', '^', ':', '?', '*', '[', '\\':
+			return fmt.Errorf("invalid ref name %q: character %q is not allowed", name, c)
+		}
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if component == "" {
+			return fmt.Errorf("invalid ref name %q: empty path component", name)
+		}
+		if strings.HasPrefix(component, ".") {
+			return fmt.Errorf("invalid ref name %q: component cannot start with '.'", name)
+		}
+		if strings.HasSuffix(component, lockfile.Suffix) {
+			return fmt.Errorf("invalid ref name %q: component cannot end with %q", name, lockfile.Suffix)
+		}
+	}
+
+	return nil
+}
+
+// ValidateBranchName проверяет короткое имя ветки ("feature/x")
+func ValidateBranchName(name string) error {
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid branch name %q: cannot start with '-'", name)
+	}
+	if name == HEAD {
+		return fmt.Errorf("invalid branch name %q", name)
+	}
+	return ValidateName(HeadsPrefix + name)
+}
+
+// ValidateTagName проверяет короткое имя тега ("v1.0.0")
+func ValidateTagName(name string) error {
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid tag name %q: cannot start with '-'", name)
+	}
+	return ValidateName(TagsPrefix + name)
+}
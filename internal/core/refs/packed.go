@@ -0,0 +1,145 @@
+package refs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sib/internal/core/lockfile"
+	"sib/internal/core/objects"
+)
+
+// packedHeader - первая строка файла packed-refs
+const packedHeader = "# pack-refs with: sorted\n"
+
+// readPacked читает файл packed-refs
+// Формат: строки "<hash> <refname>", комментарии начинаются с '#',
+// строки '^<hash>' (разыменованные теги) пропускаются
+func (rs *RefStore) readPacked() (map[string]objects.Hash, error) {
+	result := make(map[string]objects.Hash)
+
+	data, err := os.ReadFile(rs.packedPath())
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read packed-refs: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok || !IsValidHash(hash) || ValidateName(name) != nil {
+			return nil, fmt.Errorf("malformed packed-refs line %d: %q", lineNo, line)
+		}
+		result[name] = objects.Hash(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse packed-refs: %w", err)
+	}
+
+	return result, nil
+}
+
+// writePacked сериализует packed-refs через уже захваченную блокировку
+func writePacked(lock *lockfile.Lock, packed map[string]objects.Hash) error {
+	names := make([]string, 0, len(packed))
+	for name := range packed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(packedHeader)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s %s\n", packed[name], name)
+	}
+
+	return lock.Commit(buf.Bytes())
+}
+
+// removePacked удаляет ссылку из packed-refs, если она там есть.
+// Блокировку packed-refs захватывает вызывающий
+func (rs *RefStore) removePacked(lock *lockfile.Lock, name string) error {
+	packed, err := rs.readPacked()
+	if err != nil {
+		return err
+	}
+	if _, ok := packed[name]; !ok {
+		return nil
+	}
+	delete(packed, name)
+
+	return writePacked(lock, packed)
+}
+
+// Pack переносит все loose-ссылки с указанным префиксом в packed-refs
+// и удаляет loose-файлы. Символические ссылки не упаковываются.
+// Нужно для репозиториев с тысячами тегов.
+// Порядок блокировок: сначала packed-refs, затем ссылка - как в Delete
+func (rs *RefStore) Pack(prefix string) (int, error) {
+	lock, err := rs.acquireLock(rs.packedPath())
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock packed-refs: %w", err)
+	}
+	defer lock.Release()
+
+	packed, err := rs.readPacked()
+	if err != nil {
+		return 0, err
+	}
+
+	all, err := rs.List(prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var loose []Ref
+	for _, ref := range all {
+		if ref.IsSymbolic() {
+			continue
+		}
+		if current, ok := packed[ref.Name]; ok && current == ref.Target {
+			// Уже упакована, но loose-файл мог остаться
+			if _, err := os.Stat(rs.refPath(ref.Name)); err != nil {
+				continue
+			}
+		}
+		packed[ref.Name] = ref.Target
+		loose = append(loose, ref)
+	}
+
+	if err := writePacked(lock, packed); err != nil {
+		return 0, err
+	}
+
+	// Удаляем loose-файлы только если они не изменились за время упаковки
+	for _, ref := range loose {
+		refLock, err := rs.acquireLock(rs.refPath(ref.Name))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(rs.refPath(ref.Name))
+		removed := err == nil && strings.TrimSpace(string(data)) == ref.Target.String()
+		if removed {
+			os.Remove(rs.refPath(ref.Name))
+		}
+		refLock.Release()
+		if removed {
+			rs.pruneEmptyDirs(filepath.Dir(rs.refPath(ref.Name)))
+		}
+	}
+
+	return len(loose), nil
+}
@@ -0,0 +1,447 @@
+// Package refs реализует базу ссылок репозитория: HEAD, ветки и теги.
+// Ссылки хранятся как файлы под .sib/ (loose) и в общем файле packed-refs.
+package refs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sib/internal/core/lockfile"
+	"sib/internal/core/objects"
+)
+
+const (
+	HEAD        = "HEAD"        // Имя ссылки на текущую ветку или коммит
+	HeadsPrefix = "refs/heads/" // Префикс веток
+	TagsPrefix  = "refs/tags/"  // Префикс тегов
+
+	symbolicPrefix  = "ref: " // Префикс содержимого символической ссылки
+	maxSymlinkDepth = 5       // Защита от циклов символических ссылок
+)
+
+var (
+	// ErrNotFound - ссылка не существует
+	ErrNotFound = errors.New("ref not found")
+	// ErrRefChanged - ссылка изменилась с момента чтения (compare-and-swap не прошел)
+	ErrRefChanged = errors.New("ref changed concurrently")
+)
+
+// Ref - одна ссылка
+// Для символической ссылки заполнено Symbolic, для обычной - Target
+type Ref struct {
+	Name     string       // Полное имя: "HEAD", "refs/heads/master"
+	Target   objects.Hash // Хеш объекта, на который указывает ссылка
+	Symbolic string       // Имя ссылки, на которую указывает символическая ссылка
+}
+
+// IsSymbolic проверяет, является ли ссылка символической
+func (r Ref) IsSymbolic() bool { return r.Symbolic != "" }
+
+// RefStore - хранилище ссылок репозитория
+type RefStore struct {
+	sibDir string // Путь к директории .sib
+
+	// LockTimeout - сколько ждать lock-файла ссылки, захваченного другим процессом
+	LockTimeout time.Duration
+}
+
+// NewRefStore открывает базу ссылок репозитория
+func NewRefStore(repoPath string) (*RefStore, error) {
+	return OpenRefStore(filepath.Join(repoPath, ".sib"))
+}
+
+// OpenRefStore открывает хранилище ссылок каталога репозитория sibDir
+func OpenRefStore(sibDir string) (*RefStore, error) {
+	if _, err := os.Stat(sibDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("not a sib repository: .sib not found")
+	}
+
+	return &RefStore{sibDir: sibDir, LockTimeout: lockfile.DefaultTimeout}, nil
+}
+
+// acquireLock захватывает <path>.lock для изменения файла ссылки или packed-refs
+func (rs *RefStore) acquireLock(path string) (*lockfile.Lock, error) {
+	return lockfile.Acquire(path, rs.LockTimeout)
+}
+
+// refPath преобразует имя ссылки в путь к loose-файлу
+func (rs *RefStore) refPath(name string) string {
+	return filepath.Join(rs.sibDir, filepath.FromSlash(name))
+}
+
+// packedPath возвращает путь к файлу packed-refs
+func (rs *RefStore) packedPath() string {
+	return filepath.Join(rs.sibDir, "packed-refs")
+}
+
+// Read читает ссылку без разыменования символических ссылок
+// Сначала ищет loose-файл, затем packed-refs
+func (rs *RefStore) Read(name string) (*Ref, error) {
+	if name != HEAD {
+		if err := ValidateName(name); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(rs.refPath(name))
+	if err == nil {
+		return parseRef(name, data)
+	}
+	if !os.IsNotExist(err) && !isDirError(err) {
+		return nil, fmt.Errorf("failed to read ref %s: %w", name, err)
+	}
+
+	// Loose-файла нет - смотрим в packed-refs
+	packed, err := rs.readPacked()
+	if err != nil {
+		return nil, err
+	}
+	if hash, ok := packed[name]; ok {
+		return &Ref{Name: name, Target: hash}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// parseRef разбирает содержимое loose-файла ссылки
+func parseRef(name string, data []byte) (*Ref, error) {
+	content := strings.TrimSpace(string(data))
+
+	if strings.HasPrefix(content, symbolicPrefix) {
+		target := strings.TrimSpace(strings.TrimPrefix(content, symbolicPrefix))
+		if err := ValidateName(target); err != nil {
+			return nil, fmt.Errorf("invalid symbolic ref %s: %w", name, err)
+		}
+		return &Ref{Name: name, Symbolic: target}, nil
+	}
+
+	if !IsValidHash(content) {
+		return nil, fmt.Errorf("invalid ref %s: bad object id %q", name, content)
+	}
+
+	return &Ref{Name: name, Target: objects.Hash(content)}, nil
+}
+
+// ResolveName следует по цепочке символических ссылок и возвращает
+// имя конечной (несимволической) ссылки. Конечная ссылка может не существовать
+// (например, ветка в только что созданном репозитории)
+func (rs *RefStore) ResolveName(name string) (string, error) {
+	for depth := 0; depth < maxSymlinkDepth; depth++ {
+		ref, err := rs.Read(name)
+		if errors.Is(err, ErrNotFound) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !ref.IsSymbolic() {
+			return name, nil
+		}
+		name = ref.Symbolic
+	}
+
+	return "", fmt.Errorf("symbolic ref loop at %s", name)
+}
+
+// Resolve возвращает хеш, на который в итоге указывает ссылка
+func (rs *RefStore) Resolve(name string) (objects.Hash, error) {
+	target, err := rs.ResolveName(name)
+	if err != nil {
+		return "", err
+	}
+
+	ref, err := rs.Read(target)
+	if err != nil {
+		return "", err
+	}
+
+	return ref.Target, nil
+}
+
+// Head возвращает имя текущей ветки (пусто для отсоединенного HEAD)
+// и хеш коммита (пусто, если у ветки еще нет коммитов)
+func (rs *RefStore) Head() (string, objects.Hash, error) {
+	ref, err := rs.Read(HEAD)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if !ref.IsSymbolic() {
+		return "", ref.Target, nil
+	}
+
+	branch, err := rs.ResolveName(HEAD)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash, err := rs.Resolve(branch)
+	if errors.Is(err, ErrNotFound) {
+		return branch, "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return branch, hash, nil
+}
+
+// Set безусловно записывает хеш в ссылку
+// Символические ссылки разыменовываются: обновляется конечная ссылка
+func (rs *RefStore) Set(name string, hash objects.Hash) error {
+	return rs.update(name, hash, "", false)
+}
+
+// CompareAndSwap атомарно обновляет ссылку, только если её текущее
+// значение равно oldHash. Пустой oldHash означает "ссылка не должна существовать"
+func (rs *RefStore) CompareAndSwap(name string, oldHash, newHash objects.Hash) error {
+	return rs.update(name, newHash, oldHash, true)
+}
+
+// update - общая реализация Set и CompareAndSwap
+func (rs *RefStore) update(name string, newHash, oldHash objects.Hash, check bool) error {
+	if !IsValidHash(newHash.String()) {
+		return fmt.Errorf("invalid object id: %q", newHash)
+	}
+
+	target, err := rs.ResolveName(name)
+	if err != nil {
+		return err
+	}
+	if target != HEAD {
+		if err := ValidateName(target); err != nil {
+			return err
+		}
+	}
+
+	lock, err := rs.acquireLock(rs.refPath(target))
+	if err != nil {
+		return fmt.Errorf("failed to lock ref %s: %w", target, err)
+	}
+	defer lock.Release()
+
+	// Проверяем текущее значение уже под блокировкой
+	if check {
+		current, err := rs.Resolve(target)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if current != oldHash {
+			return fmt.Errorf("%w: %s is at %s, expected %s", ErrRefChanged, target, displayHash(current), displayHash(oldHash))
+		}
+	}
+
+	if err := lock.Commit([]byte(newHash.String() + "\n")); err != nil {
+		return fmt.Errorf("failed to update ref %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// SetSymbolic делает name символической ссылкой на target
+func (rs *RefStore) SetSymbolic(name, target string) error {
+	if name != HEAD {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+	}
+	if err := ValidateName(target); err != nil {
+		return err
+	}
+
+	lock, err := rs.acquireLock(rs.refPath(name))
+	if err != nil {
+		return fmt.Errorf("failed to lock ref %s: %w", name, err)
+	}
+	defer lock.Release()
+
+	if err := lock.Commit([]byte(symbolicPrefix + target + "\n")); err != nil {
+		return fmt.Errorf("failed to update ref %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// DetachHead записывает хеш прямо в HEAD, не трогая текущую ветку
+func (rs *RefStore) DetachHead(hash objects.Hash) error {
+	if !IsValidHash(hash.String()) {
+		return fmt.Errorf("invalid object id: %q", hash)
+	}
+
+	lock, err := rs.acquireLock(rs.refPath(HEAD))
+	if err != nil {
+		return fmt.Errorf("failed to lock ref %s: %w", HEAD, err)
+	}
+	defer lock.Release()
+
+	if err := lock.Commit([]byte(hash.String() + "\n")); err != nil {
+		return fmt.Errorf("failed to update ref %s: %w", HEAD, err)
+	}
+
+	return nil
+}
+
+// Delete удаляет ссылку из loose-файлов и из packed-refs
+// Если oldHash не пустой, удаление выполняется только при совпадении значения
+func (rs *RefStore) Delete(name string, oldHash objects.Hash) error {
+	if name == HEAD {
+		return fmt.Errorf("refusing to delete HEAD")
+	}
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	// packed-refs блокируется раньше ссылки, как в Pack, иначе они ждут друг друга
+	packedLock, err := rs.acquireLock(rs.packedPath())
+	if err != nil {
+		return fmt.Errorf("failed to lock packed-refs: %w", err)
+	}
+	defer packedLock.Release()
+
+	lock, err := rs.acquireLock(rs.refPath(name))
+	if err != nil {
+		return fmt.Errorf("failed to lock ref %s: %w", name, err)
+	}
+	defer lock.Release()
+
+	current, err := rs.Read(name)
+	if err != nil {
+		return err
+	}
+	if !oldHash.IsEmpty() && current.Target != oldHash {
+		return fmt.Errorf("%w: %s is at %s, expected %s", ErrRefChanged, name, displayHash(current.Target), oldHash)
+	}
+
+	if err := rs.removePacked(packedLock, name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(rs.refPath(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete ref %s: %w", name, err)
+	}
+	rs.pruneEmptyDirs(filepath.Dir(rs.refPath(name)))
+
+	return nil
+}
+
+// pruneEmptyDirs удаляет опустевшие директории вроде refs/heads/feature/
+// не поднимаясь выше refs/heads и refs/tags
+func (rs *RefStore) pruneEmptyDirs(dir string) {
+	stop := map[string]bool{
+		filepath.Join(rs.sibDir, "refs"):          true,
+		filepath.Join(rs.sibDir, "refs", "heads"): true,
+		filepath.Join(rs.sibDir, "refs", "tags"):  true,
+	}
+
+	for strings.HasPrefix(dir, rs.sibDir) && !stop[dir] && dir != rs.sibDir {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// List возвращает все ссылки с указанным префиксом ("refs/heads/", "refs/tags/"...)
+// Loose-ссылки перекрывают packed. Результат отсортирован по имени
+func (rs *RefStore) List(prefix string) ([]Ref, error) {
+	found := make(map[string]Ref)
+
+	packed, err := rs.readPacked()
+	if err != nil {
+		return nil, err
+	}
+	for name, hash := range packed {
+		if strings.HasPrefix(name, prefix) {
+			found[name] = Ref{Name: name, Target: hash}
+		}
+	}
+
+	refsDir := filepath.Join(rs.sibDir, "refs")
+	err = filepath.Walk(refsDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			return walkErr
+		}
+		if info.IsDir() || strings.HasSuffix(path, lockfile.Suffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(rs.sibDir, path)
+		if err != nil {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) || ValidateName(name) != nil {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read ref %s: %w", name, err)
+		}
+		ref, err := parseRef(name, data)
+		if err != nil {
+			return err
+		}
+		found[name] = *ref
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	result := make([]Ref, 0, len(found))
+	for _, ref := range found {
+		result = append(result, ref)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// Exists проверяет, существует ли ссылка (loose или packed)
+func (rs *RefStore) Exists(name string) bool {
+	_, err := rs.Read(name)
+	return err == nil
+}
+
+// IsValidHash проверяет, что строка - полный SHA-256 хеш в hex-формате
+func IsValidHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// displayHash форматирует хеш для сообщений об ошибках
+func displayHash(h objects.Hash) string {
+	if h.IsEmpty() {
+		return "(none)"
+	}
+	return h.String()
+}
+
+// isDirError проверяет, что по пути ссылки лежит директория
+// (например, запрошена refs/heads/feature при наличии refs/heads/feature/x)
+func isDirError(err error) bool {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		if info, statErr := os.Stat(pathErr.Path); statErr == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
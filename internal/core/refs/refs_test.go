@@ -0,0 +1,276 @@
+package refs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sib/internal/core/lockfile"
+	"sib/internal/core/objects"
+)
+
+// testHash возвращает валидный хеш, состоящий из одного символа
+func testHash(c string) objects.Hash {
+	return objects.Hash(strings.Repeat(c, 64))
+}
+
+// initTestRefs создает минимальный репозиторий с HEAD на master
+func initTestRefs(t *testing.T) (*RefStore, string) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{"refs/heads", "refs/tags"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, ".sib", dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	rs, err := NewRefStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRefStore failed: %v", err)
+	}
+	if err := rs.SetSymbolic(HEAD, "refs/heads/master"); err != nil {
+		t.Fatalf("SetSymbolic failed: %v", err)
+	}
+
+	return rs, tmpDir
+}
+
+func TestNewRefStore_ErrorWhenNoRepo(t *testing.T) {
+	if _, err := NewRefStore(t.TempDir()); err == nil {
+		t.Error("Expected error outside repository")
+	}
+}
+
+func TestHeadAndResolve(t *testing.T) {
+	rs, tmpDir := initTestRefs(t)
+
+	t.Run("Unborn branch", func(t *testing.T) {
+		branch, hash, err := rs.Head()
+		if err != nil {
+			t.Fatalf("Head failed: %v", err)
+		}
+		if branch != "refs/heads/master" || !hash.IsEmpty() {
+			t.Errorf("Unexpected HEAD: %s %s", branch, hash)
+		}
+
+		if _, err := rs.Resolve(HEAD); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Update through symbolic HEAD", func(t *testing.T) {
+		if err := rs.Set(HEAD, testHash("a")); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".sib", "refs", "heads", "master"))
+		if err != nil {
+			t.Fatalf("Branch file not written: %v", err)
+		}
+		if strings.TrimSpace(string(data)) != testHash("a").String() {
+			t.Errorf("Unexpected branch content: %s", data)
+		}
+
+		hash, err := rs.Resolve(HEAD)
+		if err != nil || hash != testHash("a") {
+			t.Errorf("Resolve(HEAD) = %s, %v", hash, err)
+		}
+	})
+
+	t.Run("Detached HEAD", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(tmpDir, ".sib", "HEAD"), []byte(testHash("b")+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to detach HEAD: %v", err)
+		}
+
+		branch, hash, err := rs.Head()
+		if err != nil {
+			t.Fatalf("Head failed: %v", err)
+		}
+		if branch != "" || hash != testHash("b") {
+			t.Errorf("Unexpected detached HEAD: %q %s", branch, hash)
+		}
+	})
+
+	t.Run("DetachHead", func(t *testing.T) {
+		if err := rs.SetSymbolic(HEAD, "refs/heads/master"); err != nil {
+			t.Fatal(err)
+		}
+		before, _ := rs.Resolve("refs/heads/master")
+		if err := rs.DetachHead(testHash("c")); err != nil {
+			t.Fatalf("DetachHead failed: %v", err)
+		}
+
+		branch, hash, err := rs.Head()
+		if err != nil || branch != "" || hash != testHash("c") {
+			t.Errorf("Head after DetachHead = %q %s %v", branch, hash, err)
+		}
+		if after, _ := rs.Resolve("refs/heads/master"); after != before {
+			t.Error("DetachHead moved the branch")
+		}
+		if err := rs.DetachHead("bogus"); err == nil {
+			t.Error("Expected error for invalid hash")
+		}
+	})
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	rs, _ := initTestRefs(t)
+	name := "refs/heads/feature"
+
+	if err := rs.CompareAndSwap(name, "", testHash("1")); err != nil {
+		t.Fatalf("Create via CAS failed: %v", err)
+	}
+
+	if err := rs.CompareAndSwap(name, "", testHash("2")); !errors.Is(err, ErrRefChanged) {
+		t.Errorf("Expected ErrRefChanged when ref exists, got %v", err)
+	}
+
+	if err := rs.CompareAndSwap(name, testHash("3"), testHash("2")); !errors.Is(err, ErrRefChanged) {
+		t.Errorf("Expected ErrRefChanged for stale old value, got %v", err)
+	}
+
+	if err := rs.CompareAndSwap(name, testHash("1"), testHash("2")); err != nil {
+		t.Errorf("CAS with correct old value failed: %v", err)
+	}
+
+	t.Run("Held lock blocks update", func(t *testing.T) {
+		lock, err := rs.acquireLock(rs.refPath(name))
+		if err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer lock.Release()
+
+		rs.LockTimeout = 20 * time.Millisecond
+		if err := rs.Set(name, testHash("4")); !errors.Is(err, lockfile.ErrLocked) {
+			t.Errorf("Expected ErrLocked while ref is locked, got %v", err)
+		}
+	})
+}
+
+func TestPackedRefs(t *testing.T) {
+	rs, tmpDir := initTestRefs(t)
+
+	for _, tag := range []string{"v1", "v2", "release/v3"} {
+		if err := rs.Set(TagsPrefix+tag, testHash("c")); err != nil {
+			t.Fatalf("Set %s failed: %v", tag, err)
+		}
+	}
+
+	packed, err := rs.Pack(TagsPrefix)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if packed != 3 {
+		t.Errorf("Expected 3 packed refs, got %d", packed)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, ".sib", "refs", "tags", "v1")); !os.IsNotExist(err) {
+		t.Error("Loose ref should be removed after packing")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".sib", "refs", "tags", "release")); !os.IsNotExist(err) {
+		t.Error("Empty ref directory should be pruned after packing")
+	}
+
+	t.Run("Resolve packed ref", func(t *testing.T) {
+		hash, err := rs.Resolve("refs/tags/release/v3")
+		if err != nil || hash != testHash("c") {
+			t.Errorf("Resolve = %s, %v", hash, err)
+		}
+	})
+
+	t.Run("Loose overrides packed", func(t *testing.T) {
+		if err := rs.Set("refs/tags/v1", testHash("d")); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		hash, _ := rs.Resolve("refs/tags/v1")
+		if hash != testHash("d") {
+			t.Errorf("Expected loose value, got %s", hash)
+		}
+	})
+
+	t.Run("List merges loose and packed", func(t *testing.T) {
+		list, err := rs.List(TagsPrefix)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		var names []string
+		for _, ref := range list {
+			names = append(names, ref.Name)
+		}
+		want := "refs/tags/release/v3,refs/tags/v1,refs/tags/v2"
+		if strings.Join(names, ",") != want {
+			t.Errorf("List = %v, want %s", names, want)
+		}
+	})
+
+	t.Run("Delete removes packed ref", func(t *testing.T) {
+		if err := rs.Delete("refs/tags/v2", ""); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if rs.Exists("refs/tags/v2") {
+			t.Error("Ref still exists after delete")
+		}
+		if err := rs.Delete("refs/tags/v2", ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+		}
+	})
+}
+
+func TestDeleteLockOrder(t *testing.T) {
+	rs, _ := initTestRefs(t)
+	if err := rs.Set("refs/heads/topic", testHash("a")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Пока packed-refs занят (как во время Pack), Delete ждет его,
+	// не удерживая блокировку ссылки, которую Pack захватит следующей
+	packedLock, err := lockfile.Acquire(rs.packedPath(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- rs.Delete("refs/heads/topic", "") }()
+
+	time.Sleep(50 * time.Millisecond)
+	refLock, err := lockfile.Acquire(rs.refPath("refs/heads/topic"), 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Delete holds the ref lock while waiting for packed-refs: %v", err)
+	}
+	refLock.Release()
+	packedLock.Release()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if rs.Exists("refs/heads/topic") {
+		t.Error("Ref still exists after delete")
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	valid := []string{"refs/heads/master", "refs/heads/feature/x", "refs/tags/v1.0.0", "HEAD"}
+	for _, name := range valid {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) unexpected error: %v", name, err)
+		}
+	}
+
+	invalid := []string{
+		"", "@", "refs/heads/a..b", "refs/heads/x.lock", "refs/heads/.hidden",
+		"refs/heads/a b", "refs/heads/a~1", "refs/heads/a^", "refs/heads/a:b",
+		"refs/heads/a?", "refs/heads/a*", "refs/heads/a[", "refs/heads/a\\b",
+		"refs/heads//x", "refs/heads/x/", "refs/heads/x.", "refs/heads/a@{1}",
+	}
+	for _, name := range invalid {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) expected error", name)
+		}
+	}
+
+	if err := ValidateBranchName("-bad"); err == nil {
+		t.Error("Branch name starting with '-' should be rejected")
+	}
+}
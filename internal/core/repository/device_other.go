@@ -0,0 +1,8 @@
+//go:build !unix
+
+package repository
+
+// device недоступен на этой платформе: граница файловой системы не проверяется
+func device(dir string) (uint64, bool) {
+	return 0, false
+}
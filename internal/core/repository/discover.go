@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Переменные окружения, влияющие на поиск репозитория
+const (
+	EnvDir              = "SIB_DIR"                         // Каталог репозитория; отключает поиск
+	EnvWorkTree         = "SIB_WORK_TREE"                   // Корень рабочего каталога
+	EnvCeilingDirs      = "SIB_CEILING_DIRECTORIES"         // Каталоги, выше которых поиск не поднимается
+	EnvAcrossFilesystem = "SIB_DISCOVERY_ACROSS_FILESYSTEM" // Разрешить поиску пересекать границу файловой системы
+)
+
+// Discover находит репозиторий, содержащий каталог start ("" - текущий).
+//
+// Если задан SIB_DIR, он и есть каталог репозитория, а рабочий каталог -
+// SIB_WORK_TREE или сам start. Иначе поиск идет от start вверх до первого
+// каталога с .sib, не поднимаясь в каталоги из SIB_CEILING_DIRECTORIES
+// (список через ':') и не пересекая границу файловой системы.
+// SIB_WORK_TREE в этом случае только переопределяет найденный рабочий каталог
+func Discover(start string) (*Repository, error) {
+	if start == "" {
+		start = "."
+	}
+	start, err := filepath.Abs(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	var workTree, sibDir string
+	if dir := os.Getenv(EnvDir); dir != "" {
+		workTree, sibDir = start, dir
+	} else if workTree, sibDir, err = search(start); err != nil {
+		return nil, err
+	}
+	if tree := os.Getenv(EnvWorkTree); tree != "" {
+		workTree = tree
+	}
+
+	repo, err := Open(workTree, sibDir)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := filepath.Rel(repo.WorkTree, start)
+	if err != nil || prefix == ".." || strings.HasPrefix(prefix, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("'%s' is outside the work tree '%s'", start, repo.WorkTree)
+	}
+	if prefix != "." {
+		repo.Prefix = filepath.ToSlash(prefix)
+	}
+	return repo, nil
+}
+
+// search поднимается от start к корню в поисках каталога .sib
+func search(start string) (workTree, sibDir string, err error) {
+	ceilings := ceilingDirs()
+	acrossFS := os.Getenv(EnvAcrossFilesystem) != ""
+	startDevice, hasDevice := device(start)
+
+	for dir := start; ; {
+		if candidate := filepath.Join(dir, DirName); isRepoDir(candidate) {
+			return dir, candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || ceilings[parent] {
+			return "", "", ErrNotRepository
+		}
+		if !acrossFS && hasDevice {
+			if dev, ok := device(parent); ok && dev != startDevice {
+				return "", "", fmt.Errorf("%w (stopping at filesystem boundary %s)", ErrNotRepository, dir)
+			}
+		}
+		dir = parent
+	}
+}
+
+// ceilingDirs разбирает SIB_CEILING_DIRECTORIES; относительные пути игнорируются
+func ceilingDirs() map[string]bool {
+	ceilings := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv(EnvCeilingDirs)) {
+		if filepath.IsAbs(dir) {
+			ceilings[filepath.Clean(dir)] = true
+		}
+	}
+	return ceilings
+}
@@ -0,0 +1,200 @@
+// Package repository находит репозиторий sib и открывает его части.
+//
+// Repository - единая точка доступа команд к репозиторию: он знает корень
+// рабочего каталога и каталог .sib и владеет хранилищем объектов, ссылками
+// и настройками.
+// Команды получают его через Discover, который поднимается от текущего
+// каталога вверх, поэтому sib можно запускать из любого подкаталога.
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sib/internal/core/config"
+	"sib/internal/core/index"
+	"sib/internal/core/lockfile"
+	"sib/internal/core/refs"
+	"sib/internal/core/storage"
+)
+
+// DirName - имя каталога репозитория в корне рабочего каталога
+const DirName = ".sib"
+
+// ErrNotRepository - ни стартовый каталог, ни его родители не содержат репозитория
+var ErrNotRepository = errors.New("not a sib repository (or any of the parent directories): " + DirName)
+
+// ErrExists - Init в каталоге, где репозиторий уже есть
+var ErrExists = errors.New("already a sib repository")
+
+// Repository - открытый репозиторий
+type Repository struct {
+	WorkTree string // Абсолютный путь корня рабочего каталога
+	SibDir   string // Абсолютный путь каталога репозитория
+	Prefix   string // Стартовый каталог относительно WorkTree через '/' ("" - корень)
+
+	Objects *storage.ObjectStore
+	Refs    *refs.RefStore
+	Config  *config.Config // Настройки всех уровней, прочитанные при открытии
+
+	lockTimeout time.Duration // Ожидание чужих lock-файлов (core.lockTimeout)
+}
+
+// formatVersion - поддерживаемая версия формата репозитория (core.repositoryformatversion)
+const formatVersion = 0
+
+// Open открывает репозиторий с заданными рабочим каталогом и каталогом репозитория
+func Open(workTree, sibDir string) (*Repository, error) {
+	workTree, err := filepath.Abs(workTree)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	sibDir, err = filepath.Abs(sibDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	if !isRepoDir(sibDir) {
+		return nil, fmt.Errorf("not a sib repository: %s", sibDir)
+	}
+
+	cfg, err := config.Load(sibDir)
+	if err != nil {
+		return nil, err
+	}
+	version, err := cfg.Int("core.repositoryformatversion", formatVersion)
+	if err != nil {
+		return nil, err
+	}
+	if version > formatVersion {
+		return nil, fmt.Errorf("expected repository format version <= %d, found %d", formatVersion, version)
+	}
+
+	// core.lockTimeout - сколько миллисекунд ждать index.lock и lock-файлы ссылок
+	lockTimeout := lockfile.DefaultTimeout
+	if ms, err := cfg.Int("core.locktimeout", -1); err != nil {
+		return nil, err
+	} else if ms >= 0 {
+		lockTimeout = time.Duration(ms) * time.Millisecond
+	}
+
+	objects, err := storage.OpenObjectStore(sibDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object store: %w", err)
+	}
+	// core.chunkThreshold - с какого размера blob'ы записываются частями (0 - никогда)
+	if objects.ChunkThreshold, err = cfg.Int("core.chunkthreshold", storage.DefaultChunkThreshold); err != nil {
+		return nil, err
+	}
+	refStore, err := refs.OpenRefStore(sibDir)
+	if err != nil {
+		return nil, err
+	}
+	refStore.LockTimeout = lockTimeout
+
+	return &Repository{
+		WorkTree:    workTree,
+		SibDir:      sibDir,
+		Objects:     objects,
+		Refs:        refStore,
+		Config:      cfg,
+		lockTimeout: lockTimeout,
+	}, nil
+}
+
+// Init создает пустой репозиторий в каталоге path: хранилище объектов,
+// каталоги ссылок и HEAD, указывающий на еще не родившуюся ветку master
+func Init(path string) (*Repository, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("path %s is not a directory", absPath)
+	}
+
+	sibDir := filepath.Join(absPath, DirName)
+	if _, err := os.Stat(sibDir); err == nil {
+		return nil, ErrExists
+	}
+
+	for _, dir := range []string{
+		filepath.Join(sibDir, "objects"),
+		filepath.Join(sibDir, "refs", "heads"),
+		filepath.Join(sibDir, "refs", "tags"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directories: %w", err)
+		}
+	}
+
+	refStore, err := refs.OpenRefStore(sibDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := refStore.SetSymbolic(refs.HEAD, refs.HeadsPrefix+"master"); err != nil {
+		return nil, fmt.Errorf("failed to create HEAD: %w", err)
+	}
+
+	if err := config.SetValue(config.LocalPath(sibDir), "core.repositoryformatversion", fmt.Sprint(formatVersion)); err != nil {
+		return nil, err
+	}
+
+	return Open(absPath, sibDir)
+}
+
+// Index читает индекс с диска. Каждый вызов возвращает свежую копию:
+// изменения нужно сохранить через Save
+func (r *Repository) Index() (*index.Index, error) {
+	idx, err := index.OpenIndex(r.SibDir)
+	if err != nil {
+		return nil, indexError("failed to load index", err)
+	}
+	return idx, nil
+}
+
+// LockIndex захватывает index.lock и читает индекс. Блокировка снимается
+// при Save или Unlock; команды, изменяющие индекс, открывают его только так
+func (r *Repository) LockIndex() (*index.Index, error) {
+	idx, err := index.LockIndex(r.SibDir, r.lockTimeout)
+	if err != nil {
+		return nil, indexError("failed to lock index", err)
+	}
+	return idx, nil
+}
+
+// RecoverIndex убирает поврежденный индекс в .sib/index.corrupt и
+// возвращает путь к копии; дальше индекс читается пустым
+func (r *Repository) RecoverIndex() (string, error) {
+	return index.RecoverIndex(r.SibDir, r.lockTimeout)
+}
+
+// indexError дополняет ошибку поврежденного индекса подсказкой о восстановлении
+func indexError(msg string, err error) error {
+	if errors.Is(err, index.ErrCorrupt) {
+		return fmt.Errorf("%w\nhint: run \"sib reset\" to rebuild the index from HEAD (staged changes will be lost)", err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// Path возвращает путь внутри каталога репозитория
+func (r *Repository) Path(elem ...string) string {
+	return filepath.Join(append([]string{r.SibDir}, elem...)...)
+}
+
+// WorkPath возвращает путь в рабочем каталоге для пути относительно корня (через '/')
+func (r *Repository) WorkPath(path string) string {
+	return filepath.Join(r.WorkTree, filepath.FromSlash(path))
+}
+
+// isRepoDir проверяет, что каталог похож на каталог репозитория
+func isRepoDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "objects"))
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, "HEAD"))
+	return err == nil
+}
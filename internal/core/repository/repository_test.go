@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// initRepo создает репозиторий во временном каталоге и возвращает его корень
+func initRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	// Каталоги из TempDir могут быть символическими ссылками (macOS)
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Init(root); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	return root
+}
+
+func TestInit(t *testing.T) {
+	root := initRepo(t)
+	if _, err := Init(root); !errors.Is(err, ErrExists) {
+		t.Errorf("Second Init: got %v, want ErrExists", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, DirName, "HEAD"))
+	if err != nil || string(data) != "ref: refs/heads/master\n" {
+		t.Errorf("HEAD = %q, %v", data, err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := initRepo(t)
+	sub := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("From root", func(t *testing.T) {
+		repo, err := Discover(root)
+		if err != nil {
+			t.Fatalf("Discover failed: %v", err)
+		}
+		if repo.WorkTree != root || repo.SibDir != filepath.Join(root, DirName) || repo.Prefix != "" {
+			t.Errorf("Unexpected repository: %+v", repo)
+		}
+	})
+
+	t.Run("From subdirectory", func(t *testing.T) {
+		repo, err := Discover(sub)
+		if err != nil {
+			t.Fatalf("Discover failed: %v", err)
+		}
+		if repo.WorkTree != root || repo.Prefix != "src/pkg" {
+			t.Errorf("WorkTree = %s, Prefix = %q", repo.WorkTree, repo.Prefix)
+		}
+		if _, err := repo.Index(); err != nil {
+			t.Errorf("Index failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(sub, DirName)); !os.IsNotExist(err) {
+			t.Error("Discover should not create .sib in the subdirectory")
+		}
+	})
+
+	t.Run("Ceiling directories", func(t *testing.T) {
+		t.Setenv(EnvCeilingDirs, filepath.Join(root, "src"))
+		if _, err := Discover(sub); !errors.Is(err, ErrNotRepository) {
+			t.Errorf("Got %v, want ErrNotRepository", err)
+		}
+		// Сам потолок и каталоги выше него по-прежнему проверяются
+		if _, err := Discover(root); err != nil {
+			t.Errorf("Discover from root failed: %v", err)
+		}
+	})
+
+	t.Run("Explicit SIB_DIR and SIB_WORK_TREE", func(t *testing.T) {
+		outside := t.TempDir()
+		t.Setenv(EnvDir, filepath.Join(root, DirName))
+		t.Setenv(EnvWorkTree, root)
+		repo, err := Discover(sub)
+		if err != nil {
+			t.Fatalf("Discover failed: %v", err)
+		}
+		if repo.WorkTree != root || repo.Prefix != "src/pkg" {
+			t.Errorf("WorkTree = %s, Prefix = %q", repo.WorkTree, repo.Prefix)
+		}
+		if _, err := Discover(outside); err == nil {
+			t.Error("Expected error for a directory outside the work tree")
+		}
+	})
+
+	t.Run("Not a repository", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv(EnvCeilingDirs, filepath.Dir(dir))
+		if _, err := Discover(dir); !errors.Is(err, ErrNotRepository) {
+			t.Errorf("Got %v, want ErrNotRepository", err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package revparse
+
+import (
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/revwalk"
+)
+
+// Range - набор коммитов в виде "достижимы из Include, но не из Exclude"
+type Range struct {
+	Include []objects.Hash // Стартовые точки
+	Exclude []objects.Hash // Коммиты, предки которых исключаются
+}
+
+// IsSingle проверяет, что выражение - одиночная ревизия, а не диапазон
+func (r *Range) IsSingle() bool {
+	return len(r.Include) == 1 && len(r.Exclude) == 0
+}
+
+// ParseRange разбирает выражение, которое может быть диапазоном:
+//
+//	A..B   коммиты из B, недостижимые из A (пустая сторона означает HEAD)
+//	A...B  коммиты, достижимые ровно из одного из A и B
+//	^A     исключение A и его предков
+//	A      одиночная ревизия
+func (p *Parser) ParseRange(expr string) (*Range, error) {
+	if left, right, ok := strings.Cut(expr, "..."); ok {
+		a, err := p.ResolveCommit(orHead(left))
+		if err != nil {
+			return nil, err
+		}
+		b, err := p.ResolveCommit(orHead(right))
+		if err != nil {
+			return nil, err
+		}
+
+		bases, err := p.MergeBases(a, b)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Range{Include: []objects.Hash{a, b}, Exclude: bases}, nil
+	}
+
+	if left, right, ok := strings.Cut(expr, ".."); ok {
+		a, err := p.ResolveCommit(orHead(left))
+		if err != nil {
+			return nil, err
+		}
+		b, err := p.ResolveCommit(orHead(right))
+		if err != nil {
+			return nil, err
+		}
+
+		return &Range{Include: []objects.Hash{b}, Exclude: []objects.Hash{a}}, nil
+	}
+
+	if strings.HasPrefix(expr, "^") {
+		hash, err := p.ResolveCommit(expr[1:])
+		if err != nil {
+			return nil, err
+		}
+		return &Range{Exclude: []objects.Hash{hash}}, nil
+	}
+
+	hash, err := p.Resolve(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Range{Include: []objects.Hash{hash}}, nil
+}
+
+// orHead подставляет HEAD вместо пустой стороны диапазона
+func orHead(side string) string {
+	if side == "" {
+		return refs.HEAD
+	}
+	return side
+}
+
+// MergeBases находит лучших общих предков двух коммитов
+func (p *Parser) MergeBases(a, b objects.Hash) ([]objects.Hash, error) {
+	return revwalk.NewGraph(p.store).MergeBases(a, b)
+}
@@ -0,0 +1,396 @@
+// Package revparse превращает пользовательские выражения ревизий в хеши объектов.
+//
+// Поддерживаемый синтаксис:
+//
+//	HEAD, @                   текущий коммит
+//	master, v1.0, refs/...    имена веток, тегов и полные имена ссылок
+//	3f2a9c                    уникальный префикс хеша (не короче 4 символов)
+//	<rev>~N, <rev>^N          N-й предок по первым родителям / N-й родитель
+//	<rev>^{commit}, ^{tree}   разыменование до объекта указанного типа
+//	<branch>@{upstream}, @{u} ветка, которую отслеживает branch
+//	A..B, A...B, ^A           диапазоны (в ParseRange)
+package revparse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/storage"
+)
+
+// MinPrefixLength - минимальная длина сокращенного хеша
+const MinPrefixLength = 4
+
+var (
+	// ErrUnknownRevision - выражение не соответствует ни одному объекту
+	ErrUnknownRevision = errors.New("unknown revision")
+	// ErrAmbiguous - сокращенный хеш или имя соответствует нескольким объектам
+	ErrAmbiguous = errors.New("ambiguous argument")
+)
+
+// UpstreamFunc возвращает полное имя ссылки, которую отслеживает ветка
+// branch - полное имя ветки ("refs/heads/master")
+type UpstreamFunc func(branch string) (string, error)
+
+// Parser разбирает выражения ревизий
+type Parser struct {
+	store    *storage.ObjectStore
+	refStore *refs.RefStore
+
+	// Upstream определяет отслеживаемую ветку для @{upstream}
+	// По умолчанию у веток нет upstream
+	Upstream UpstreamFunc
+}
+
+// NewParser создает парсер ревизий
+func NewParser(store *storage.ObjectStore, refStore *refs.RefStore) *Parser {
+	return &Parser{
+		store:    store,
+		refStore: refStore,
+		Upstream: func(branch string) (string, error) {
+			return "", fmt.Errorf("no upstream configured for branch '%s'", strings.TrimPrefix(branch, refs.HeadsPrefix))
+		},
+	}
+}
+
+// Resolve разбирает одиночную ревизию и возвращает хеш объекта
+func (p *Parser) Resolve(expr string) (objects.Hash, error) {
+	if expr == "" {
+		return "", fmt.Errorf("%w: empty revision", ErrUnknownRevision)
+	}
+
+	base, suffix := splitSuffix(expr)
+
+	hash, err := p.resolveBase(base, &suffix)
+	if err != nil {
+		return "", err
+	}
+
+	return p.applySuffixes(expr, hash, suffix)
+}
+
+// ResolveCommit разбирает ревизию и разыменовывает её до коммита
+func (p *Parser) ResolveCommit(expr string) (objects.Hash, error) {
+	hash, err := p.Resolve(expr)
+	if err != nil {
+		return "", err
+	}
+	return p.peel(expr, hash, objects.CommitObject)
+}
+
+// splitSuffix отделяет базовое имя от суффиксов ~, ^ и @{...}
+// Имена ссылок не могут содержать эти символы, поэтому разбор однозначен
+func splitSuffix(expr string) (string, string) {
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '~', '^':
+			return expr[:i], expr[i:]
+		case '@':
+			if i+1 < len(expr) && expr[i+1] == '{' {
+				return expr[:i], expr[i:]
+			}
+		}
+	}
+	return expr, ""
+}
+
+// resolveBase разрешает базовое имя; суффикс @{upstream} обрабатывается здесь,
+// потому что он меняет имя ветки, а не коммит
+func (p *Parser) resolveBase(base string, suffix *string) (objects.Hash, error) {
+	if strings.HasPrefix(*suffix, "@{") {
+		end := strings.IndexByte(*suffix, '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unterminated @{ in '%s%s'", ErrUnknownRevision, base, *suffix)
+		}
+		selector := (*suffix)[2:end]
+		*suffix = (*suffix)[end+1:]
+
+		if selector != "upstream" && selector != "u" {
+			return "", fmt.Errorf("%w: unsupported selector @{%s}", ErrUnknownRevision, selector)
+		}
+
+		branch, err := p.branchName(base)
+		if err != nil {
+			return "", err
+		}
+		upstream, err := p.Upstream(branch)
+		if err != nil {
+			return "", err
+		}
+		return p.resolveRef(upstream)
+	}
+
+	if base == "@" || base == "" {
+		if base == "" && *suffix == "" {
+			return "", fmt.Errorf("%w: empty revision", ErrUnknownRevision)
+		}
+		base = refs.HEAD
+	}
+
+	return p.resolveName(base)
+}
+
+// branchName возвращает полное имя ветки для @{upstream}
+// Пустое имя и HEAD означают текущую ветку
+func (p *Parser) branchName(base string) (string, error) {
+	if base == "" || base == "@" || base == refs.HEAD {
+		branch, _, err := p.refStore.Head()
+		if err != nil {
+			return "", err
+		}
+		if branch == "" {
+			return "", fmt.Errorf("HEAD does not point to a branch")
+		}
+		return branch, nil
+	}
+
+	if strings.HasPrefix(base, refs.HeadsPrefix) {
+		return base, nil
+	}
+	if !p.refStore.Exists(refs.HeadsPrefix + base) {
+		return "", fmt.Errorf("%w: no such branch: '%s'", ErrUnknownRevision, base)
+	}
+	return refs.HeadsPrefix + base, nil
+}
+
+// resolveName разрешает имя ссылки или (сокращенный) хеш
+func (p *Parser) resolveName(name string) (objects.Hash, error) {
+	// Имена ссылок имеют приоритет над префиксами хешей
+	hash, err := p.resolveRef(name)
+	if err == nil {
+		return hash, nil
+	}
+	if !errors.Is(err, refs.ErrNotFound) {
+		return "", err
+	}
+
+	if isHex(name) {
+		if len(name) == 64 {
+			if !p.store.ObjectExists(objects.Hash(name)) {
+				return "", fmt.Errorf("%w: '%s'", ErrUnknownRevision, name)
+			}
+			return objects.Hash(name), nil
+		}
+		if len(name) >= MinPrefixLength {
+			return p.resolvePrefix(name)
+		}
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrUnknownRevision, name)
+}
+
+// resolveRef ищет ссылку в порядке, принятом в git:
+// <name>, refs/<name>, refs/tags/<name>, refs/heads/<name>, refs/remotes/<name>
+func (p *Parser) resolveRef(name string) (objects.Hash, error) {
+	candidates := []string{
+		name,
+		"refs/" + name,
+		refs.TagsPrefix + name,
+		refs.HeadsPrefix + name,
+		"refs/remotes/" + name,
+		"refs/remotes/" + name + "/HEAD",
+	}
+
+	for _, candidate := range candidates {
+		if candidate != refs.HEAD && refs.ValidateName(candidate) != nil {
+			continue
+		}
+		// Без префикса refs/ допускаются только ссылки верхнего уровня вроде HEAD
+		if candidate == name && !strings.HasPrefix(name, "refs/") && strings.ToUpper(name) != name {
+			continue
+		}
+
+		hash, err := p.refStore.Resolve(candidate)
+		if err == nil {
+			return hash, nil
+		}
+		if !errors.Is(err, refs.ErrNotFound) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", refs.ErrNotFound, name)
+}
+
+// resolvePrefix находит единственный объект по префиксу хеша
+func (p *Parser) resolvePrefix(prefix string) (objects.Hash, error) {
+	matches, err := p.store.FindByPrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: '%s'", ErrUnknownRevision, prefix)
+	case 1:
+		return matches[0], nil
+	}
+
+	var candidates []string
+	for _, hash := range matches {
+		kind := "unknown"
+		if obj, err := p.store.ReadObject(hash); err == nil {
+			kind = string(obj.Type())
+		}
+		candidates = append(candidates, fmt.Sprintf("  %s %s", hash, kind))
+	}
+
+	return "", fmt.Errorf("%w: short object ID %s is ambiguous; candidates are:\n%s",
+		ErrAmbiguous, prefix, strings.Join(candidates, "\n"))
+}
+
+// applySuffixes применяет цепочку ~N и ^N / ^{type}
+func (p *Parser) applySuffixes(expr string, hash objects.Hash, suffix string) (objects.Hash, error) {
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+
+		if op != '~' && op != '^' {
+			return "", fmt.Errorf("%w: invalid suffix in '%s'", ErrUnknownRevision, expr)
+		}
+
+		// ^{type} - разыменование
+		if op == '^' && strings.HasPrefix(suffix, "{") {
+			end := strings.IndexByte(suffix, '}')
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated ^{ in '%s'", ErrUnknownRevision, expr)
+			}
+			target := objects.ObjectType(suffix[1:end])
+			suffix = suffix[end+1:]
+
+			var err error
+			if target == "" {
+				hash, err = p.peelTags(expr, hash)
+			} else {
+				hash, err = p.peel(expr, hash, target)
+			}
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		n := 1
+		if digits > 0 {
+			var err error
+			n, err = strconv.Atoi(suffix[:digits])
+			if err != nil {
+				return "", fmt.Errorf("%w: invalid number in '%s'", ErrUnknownRevision, expr)
+			}
+		}
+		suffix = suffix[digits:]
+
+		commitHash, err := p.peel(expr, hash, objects.CommitObject)
+		if err != nil {
+			return "", err
+		}
+
+		if op == '~' {
+			hash, err = p.nthAncestor(expr, commitHash, n)
+		} else {
+			hash, err = p.nthParent(expr, commitHash, n)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hash, nil
+}
+
+// nthParent возвращает N-го родителя (^0 - сам коммит)
+func (p *Parser) nthParent(expr string, hash objects.Hash, n int) (objects.Hash, error) {
+	if n == 0 {
+		return hash, nil
+	}
+
+	commit, err := p.store.ReadCommit(hash)
+	if err != nil {
+		return "", err
+	}
+
+	parents := commit.Parents()
+	if n > len(parents) {
+		return "", fmt.Errorf("%w: '%s': commit %s has %d parent(s)", ErrUnknownRevision, expr, hash, len(parents))
+	}
+
+	return parents[n-1], nil
+}
+
+// nthAncestor идет N раз по первому родителю
+func (p *Parser) nthAncestor(expr string, hash objects.Hash, n int) (objects.Hash, error) {
+	for i := 0; i < n; i++ {
+		parent, err := p.nthParent(expr, hash, 1)
+		if err != nil {
+			return "", err
+		}
+		hash = parent
+	}
+	return hash, nil
+}
+
+// peelTags разыменовывает аннотированные теги до нетегового объекта
+func (p *Parser) peelTags(expr string, hash objects.Hash) (objects.Hash, error) {
+	for {
+		obj, err := p.store.ReadObject(hash)
+		if err != nil {
+			return "", fmt.Errorf("%w: '%s': %v", ErrUnknownRevision, expr, err)
+		}
+		tag, ok := obj.(*objects.Tag)
+		if !ok {
+			return hash, nil
+		}
+		hash = tag.Object()
+	}
+}
+
+// peel разыменовывает объект до указанного типа:
+// тег -> его объект, коммит -> его tree
+func (p *Parser) peel(expr string, hash objects.Hash, target objects.ObjectType) (objects.Hash, error) {
+	if err := target.Validate(); err != nil {
+		return "", fmt.Errorf("%w: '%s': %v", ErrUnknownRevision, expr, err)
+	}
+
+	for {
+		obj, err := p.store.ReadObject(hash)
+		if err != nil {
+			return "", fmt.Errorf("%w: '%s': %v", ErrUnknownRevision, expr, err)
+		}
+		if obj.Type() == target {
+			return hash, nil
+		}
+
+		switch o := obj.(type) {
+		case *objects.Tag:
+			hash = o.Object()
+		case *objects.Commit:
+			if target != objects.TreeObject {
+				return "", fmt.Errorf("'%s': expected %s, got commit", expr, target)
+			}
+			hash = o.Tree()
+		default:
+			return "", fmt.Errorf("'%s': expected %s, got %s", expr, target, obj.Type())
+		}
+	}
+}
+
+// isHex проверяет, что строка состоит из hex-символов в нижнем регистре
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+package revparse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/refs"
+	"sib/internal/core/storage"
+)
+
+// testRepo - репозиторий с историей для тестов парсера
+type testRepo struct {
+	t        *testing.T
+	store    *storage.ObjectStore
+	refStore *refs.RefStore
+	tree     objects.Hash
+	clock    time.Time
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"objects", "refs/heads", "refs/tags"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, ".sib", dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	store, err := storage.NewObjectStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	refStore, err := refs.NewRefStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create ref store: %v", err)
+	}
+	refStore.SetSymbolic(refs.HEAD, "refs/heads/master")
+
+	blobHash, _ := store.WriteObject(objects.NewBlob([]byte("content")))
+	entry, _ := objects.NewTreeEntry(objects.FileModeRegular, "file.txt", blobHash, objects.BlobObject)
+	tree := objects.NewTree()
+	tree.AddEntry(*entry)
+	treeHash, _ := store.WriteObject(tree)
+
+	return &testRepo{
+		t:        t,
+		store:    store,
+		refStore: refStore,
+		tree:     treeHash,
+		clock:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (r *testRepo) commit(message string, parents ...objects.Hash) objects.Hash {
+	r.clock = r.clock.Add(time.Minute)
+	sig, _ := objects.NewSignature("Tester", "test@example.com", r.clock)
+	commit, err := objects.NewCommit(r.tree, parents, *sig, *sig, message)
+	if err != nil {
+		r.t.Fatalf("Failed to create commit: %v", err)
+	}
+	hash, err := r.store.WriteObject(commit)
+	if err != nil {
+		r.t.Fatalf("Failed to write commit: %v", err)
+	}
+	return hash
+}
+
+// buildHistory создает историю:
+//
+//	A - B - C - M  (master)
+//	     \     /
+//	      D - E    (feature)
+func buildHistory(r *testRepo) map[string]objects.Hash {
+	h := make(map[string]objects.Hash)
+	h["A"] = r.commit("A")
+	h["B"] = r.commit("B", h["A"])
+	h["C"] = r.commit("C", h["B"])
+	h["D"] = r.commit("D", h["B"])
+	h["E"] = r.commit("E", h["D"])
+	h["M"] = r.commit("M", h["C"], h["E"])
+
+	r.refStore.Set("refs/heads/master", h["M"])
+	r.refStore.Set("refs/heads/feature", h["E"])
+	r.refStore.Set("refs/tags/v1", h["B"])
+	return h
+}
+
+func TestResolve(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildHistory(r)
+	p := NewParser(r.store, r.refStore)
+
+	tests := []struct {
+		expr string
+		want objects.Hash
+	}{
+		{"HEAD", h["M"]},
+		{"@", h["M"]},
+		{"master", h["M"]},
+		{"refs/heads/feature", h["E"]},
+		{"heads/feature", h["E"]},
+		{"v1", h["B"]},
+		{"HEAD~1", h["C"]},
+		{"HEAD~", h["C"]},
+		{"HEAD~2", h["B"]},
+		{"HEAD^", h["C"]},
+		{"HEAD^2", h["E"]},
+		{"master^2~1", h["D"]},
+		{"HEAD^^", h["B"]},
+		{"HEAD^0", h["M"]},
+		{"@~3", h["A"]},
+		{string(h["E"]), h["E"]},
+		{string(h["E"])[:12], h["E"]},
+		{"HEAD^{tree}", r.tree},
+	}
+
+	for _, tt := range tests {
+		got, err := p.Resolve(tt.expr)
+		if err != nil {
+			t.Errorf("Resolve(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	errorCases := []string{"", "nope", "HEAD~10", "HEAD^3", "abc", "feature@{u}"}
+	for _, expr := range errorCases {
+		if _, err := p.Resolve(expr); err == nil {
+			t.Errorf("Resolve(%q) expected error", expr)
+		}
+	}
+}
+
+func TestResolveUpstream(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildHistory(r)
+	p := NewParser(r.store, r.refStore)
+
+	p.Upstream = func(branch string) (string, error) {
+		if branch == "refs/heads/master" {
+			return "refs/heads/feature", nil
+		}
+		return "", errors.New("no upstream")
+	}
+
+	for _, expr := range []string{"@{u}", "master@{upstream}", "HEAD@{u}"} {
+		got, err := p.Resolve(expr)
+		if err != nil || got != h["E"] {
+			t.Errorf("Resolve(%q) = %s, %v; want %s", expr, got, err, h["E"])
+		}
+	}
+
+	got, err := p.Resolve("@{u}~1")
+	if err != nil || got != h["D"] {
+		t.Errorf("Resolve(@{u}~1) = %s, %v; want %s", got, err, h["D"])
+	}
+}
+
+func TestAmbiguousPrefix(t *testing.T) {
+	r := newTestRepo(t)
+	p := NewParser(r.store, r.refStore)
+
+	// Пишем blob'ы, пока два хеша не совпадут в первых 4 символах
+	seen := make(map[string]bool)
+	var prefix string
+	for i := 0; prefix == "" && i < 5000; i++ {
+		hash, err := r.store.WriteObject(objects.NewBlob([]byte{byte(i), byte(i >> 8)}))
+		if err != nil {
+			t.Fatalf("WriteObject failed: %v", err)
+		}
+		key := hash.String()[:MinPrefixLength]
+		if seen[key] {
+			prefix = key
+		}
+		seen[key] = true
+	}
+	if prefix == "" {
+		t.Fatal("Failed to produce a shared hash prefix")
+	}
+
+	_, err := p.Resolve(prefix)
+	if !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("Expected ErrAmbiguous for %s, got %v", prefix, err)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildHistory(r)
+	p := NewParser(r.store, r.refStore)
+
+	t.Run("Two dots", func(t *testing.T) {
+		rng, err := p.ParseRange("feature..master")
+		if err != nil {
+			t.Fatalf("ParseRange failed: %v", err)
+		}
+		if len(rng.Include) != 1 || rng.Include[0] != h["M"] || len(rng.Exclude) != 1 || rng.Exclude[0] != h["E"] {
+			t.Errorf("Unexpected range: %+v", rng)
+		}
+	})
+
+	t.Run("Empty side means HEAD", func(t *testing.T) {
+		rng, err := p.ParseRange("v1..")
+		if err != nil {
+			t.Fatalf("ParseRange failed: %v", err)
+		}
+		if rng.Include[0] != h["M"] || rng.Exclude[0] != h["B"] {
+			t.Errorf("Unexpected range: %+v", rng)
+		}
+	})
+
+	t.Run("Three dots", func(t *testing.T) {
+		rng, err := p.ParseRange("master~1...feature")
+		if err != nil {
+			t.Fatalf("ParseRange failed: %v", err)
+		}
+		if len(rng.Include) != 2 || len(rng.Exclude) != 1 || rng.Exclude[0] != h["B"] {
+			t.Errorf("Expected merge base B in exclude, got %+v", rng)
+		}
+	})
+
+	t.Run("Negation", func(t *testing.T) {
+		rng, err := p.ParseRange("^feature")
+		if err != nil {
+			t.Fatalf("ParseRange failed: %v", err)
+		}
+		if len(rng.Include) != 0 || rng.Exclude[0] != h["E"] {
+			t.Errorf("Unexpected range: %+v", rng)
+		}
+	})
+}
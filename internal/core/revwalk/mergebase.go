@@ -0,0 +1,374 @@
+package revwalk
+
+import (
+	"container/heap"
+	"sort"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// Graph отвечает на вопросы о предках коммитов: общие предки и достижимость.
+// Прочитанные коммиты и их номера поколений кешируются, поэтому один Graph
+// выгодно переиспользовать для серии запросов (например, по всем веткам).
+//
+// Номер поколения корневого коммита - 1, остальных - 1 + максимум по родителям.
+// Предок всегда имеет меньший номер, чем потомок, что позволяет обрывать
+// обход, как только поколение опустилось ниже искомого коммита.
+// Номера, записанные gc в commit-graph, берутся из файла; обходом считаются
+// только поколения коммитов, появившихся после последнего gc
+type Graph struct {
+	store       *storage.ObjectStore
+	commits     map[objects.Hash]*objects.Commit
+	generations map[objects.Hash]int
+	stored      *storage.CommitGraph // nil - файла нет или он поврежден
+	storedRead  bool
+}
+
+// NewGraph создает граф коммитов поверх хранилища объектов
+func NewGraph(store *storage.ObjectStore) *Graph {
+	return &Graph{
+		store:       store,
+		commits:     make(map[objects.Hash]*objects.Commit),
+		generations: make(map[objects.Hash]int),
+	}
+}
+
+// Generation возвращает номер поколения коммита
+func (g *Graph) Generation(hash objects.Hash) (int, error) {
+	if gen, ok := g.knownGeneration(hash); ok {
+		return gen, nil
+	}
+
+	// Итеративный обход в глубину: длинная линейная история не переполнит стек
+	stack := []objects.Hash{hash}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if _, ok := g.knownGeneration(top); ok {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		commit, err := g.commit(top)
+		if err != nil {
+			return 0, err
+		}
+
+		gen, pending := 1, false
+		for _, parent := range commit.Parents() {
+			parentGen, ok := g.knownGeneration(parent)
+			if !ok {
+				stack = append(stack, parent)
+				pending = true
+				continue
+			}
+			if parentGen+1 > gen {
+				gen = parentGen + 1
+			}
+		}
+		if pending {
+			continue
+		}
+
+		g.generations[top] = gen
+		stack = stack[:len(stack)-1]
+	}
+
+	return g.generations[hash], nil
+}
+
+// knownGeneration возвращает уже посчитанный или записанный в commit-graph номер
+// поколения. Поврежденный commit-graph не мешает работе: номера считаются обходом
+func (g *Graph) knownGeneration(hash objects.Hash) (int, bool) {
+	if gen, ok := g.generations[hash]; ok {
+		return gen, true
+	}
+	if !g.storedRead {
+		g.storedRead = true
+		g.stored, _ = g.store.ReadCommitGraph()
+	}
+	if gen, ok := g.stored.Generation(hash); ok {
+		g.generations[hash] = gen
+		return gen, true
+	}
+	return 0, false
+}
+
+// WriteCommitGraph записывает в commit-graph номера поколений коммитов commits
+// (обычно всех достижимых) и возвращает их число
+func WriteCommitGraph(store *storage.ObjectStore, commits []objects.Hash) (int, error) {
+	g := NewGraph(store)
+	generations := make(map[objects.Hash]int, len(commits))
+	for _, hash := range commits {
+		gen, err := g.Generation(hash)
+		if err != nil {
+			return 0, err
+		}
+		generations[hash] = gen
+	}
+	if err := store.WriteCommitGraph(generations); err != nil {
+		return 0, err
+	}
+	return len(generations), nil
+}
+
+// IsAncestor проверяет, достижим ли ancestor из descendant по ссылкам на родителей.
+// Коммит считается предком самого себя
+func (g *Graph) IsAncestor(ancestor, descendant objects.Hash) (bool, error) {
+	minGen, err := g.Generation(ancestor)
+	if err != nil {
+		return false, err
+	}
+
+	seen := make(map[objects.Hash]bool)
+	stack := []objects.Hash{descendant}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if hash == ancestor {
+			return true, nil
+		}
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		gen, err := g.Generation(hash)
+		if err != nil {
+			return false, err
+		}
+		// Ниже этого поколения ancestor встретиться уже не может
+		if gen <= minGen {
+			continue
+		}
+
+		commit, err := g.commit(hash)
+		if err != nil {
+			return false, err
+		}
+		stack = append(stack, commit.Parents()...)
+	}
+
+	return false, nil
+}
+
+// Флаги раскраски при поиске общих предков
+const (
+	paintOne    = 1 << iota // Достижим из первого коммита
+	paintOthers             // Достижим из одного из остальных
+	paintStale              // Предок уже найденного общего предка
+	paintResult             // Кандидат в ответ
+)
+
+// MergeBases возвращает лучших общих предков коммита one и любого из others:
+// общих предков, не являющихся предками других общих предков.
+// Результат отсортирован по хешу; пустой - если общей истории нет
+func (g *Graph) MergeBases(one objects.Hash, others ...objects.Hash) ([]objects.Hash, error) {
+	candidates, err := g.paintDown(one, others)
+	if err != nil {
+		return nil, err
+	}
+	return g.Independent(candidates...)
+}
+
+// OctopusBases возвращает лучших общих предков всех переданных коммитов сразу
+func (g *Graph) OctopusBases(hashes ...objects.Hash) ([]objects.Hash, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	result := []objects.Hash{hashes[0]}
+	for _, next := range hashes[1:] {
+		var merged []objects.Hash
+		for _, current := range result {
+			bases, err := g.MergeBases(current, next)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, bases...)
+		}
+		independent, err := g.Independent(merged...)
+		if err != nil {
+			return nil, err
+		}
+		if len(independent) == 0 {
+			return nil, nil
+		}
+		result = independent
+	}
+
+	sortHashes(result)
+	return result, nil
+}
+
+// Independent убирает из списка коммиты, достижимые из других коммитов списка
+func (g *Graph) Independent(hashes ...objects.Hash) ([]objects.Hash, error) {
+	unique := make([]objects.Hash, 0, len(hashes))
+	seen := make(map[objects.Hash]bool, len(hashes))
+	for _, hash := range hashes {
+		if !seen[hash] {
+			seen[hash] = true
+			unique = append(unique, hash)
+		}
+	}
+
+	var result []objects.Hash
+	for i, candidate := range unique {
+		redundant := false
+		for j, other := range unique {
+			if i == j {
+				continue
+			}
+			ok, err := g.IsAncestor(candidate, other)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			result = append(result, candidate)
+		}
+	}
+
+	sortHashes(result)
+	return result, nil
+}
+
+// paintDown раскрашивает историю от one и others, двигаясь от старших поколений
+// к младшим. Коммит, получивший оба цвета, - кандидат; его предки помечаются
+// устаревшими. Обход заканчивается, когда в очереди остались только устаревшие коммиты
+func (g *Graph) paintDown(one objects.Hash, others []objects.Hash) ([]objects.Hash, error) {
+	flags := make(map[objects.Hash]int)
+	queue := &generationQueue{}
+	fresh := 0 // Число коммитов в очереди без отметки paintStale
+
+	// Новые флаги коммит получает только от потомков, а они выходят из очереди раньше
+	// (у них старше поколение), поэтому уже обработанный коммит повторно не красится
+	push := func(hash objects.Hash, flag int) error {
+		old, known := flags[hash]
+		if known && old&flag == flag {
+			return nil
+		}
+		flags[hash] = old | flag
+
+		if !known {
+			gen, err := g.Generation(hash)
+			if err != nil {
+				return err
+			}
+			heap.Push(queue, generationItem{hash: hash, gen: gen})
+			if flag&paintStale == 0 {
+				fresh++
+			}
+			return nil
+		}
+		if old&paintStale == 0 && flag&paintStale != 0 {
+			fresh--
+		}
+		return nil
+	}
+
+	if err := push(one, paintOne); err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if other == one {
+			return []objects.Hash{one}, nil
+		}
+		if err := push(other, paintOthers); err != nil {
+			return nil, err
+		}
+	}
+
+	var candidates []objects.Hash
+	for fresh > 0 {
+		item := heap.Pop(queue).(generationItem)
+		flag := flags[item.hash] & (paintOne | paintOthers | paintStale)
+		if flag&paintStale == 0 {
+			fresh--
+		}
+
+		if flag == paintOne|paintOthers {
+			flags[item.hash] |= paintResult
+			candidates = append(candidates, item.hash)
+			flag |= paintStale
+		}
+
+		commit, err := g.commit(item.hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range commit.Parents() {
+			if err := push(parent, flag); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Кандидат мог позже оказаться предком другого кандидата
+	var result []objects.Hash
+	for _, hash := range candidates {
+		if flags[hash]&paintStale == 0 {
+			result = append(result, hash)
+		}
+	}
+	return result, nil
+}
+
+// commit читает коммит с кешированием
+func (g *Graph) commit(hash objects.Hash) (*objects.Commit, error) {
+	if commit, ok := g.commits[hash]; ok {
+		return commit, nil
+	}
+
+	commit, err := g.store.ReadCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	g.commits[hash] = commit
+
+	return commit, nil
+}
+
+// IsAncestor проверяет, достижим ли ancestor из descendant (разовый запрос без общего кеша)
+func IsAncestor(store *storage.ObjectStore, ancestor, descendant objects.Hash) (bool, error) {
+	return NewGraph(store).IsAncestor(ancestor, descendant)
+}
+
+// generationItem - элемент очереди раскраски
+type generationItem struct {
+	hash objects.Hash
+	gen  int
+}
+
+// generationQueue - приоритетная очередь: сначала старшие поколения
+type generationQueue []generationItem
+
+func (q generationQueue) Len() int { return len(q) }
+
+func (q generationQueue) Less(i, j int) bool {
+	if q[i].gen != q[j].gen {
+		return q[i].gen > q[j].gen
+	}
+	return q[i].hash < q[j].hash
+}
+
+func (q generationQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *generationQueue) Push(x any) { *q = append(*q, x.(generationItem)) }
+
+func (q *generationQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// sortHashes сортирует хеши для детерминированного вывода
+func sortHashes(hashes []objects.Hash) {
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+}
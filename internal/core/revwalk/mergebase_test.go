@@ -0,0 +1,175 @@
+package revwalk
+
+import (
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+)
+
+// namesOf переводит хеши в имена коммитов тестовой истории
+func (r *testRepo) namesOf(hashes []objects.Hash) string {
+	var names []string
+	for _, hash := range hashes {
+		names = append(names, r.names[hash])
+	}
+	return strings.Join(names, " ")
+}
+
+func TestGeneration(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildMergeHistory(r)
+	g := NewGraph(r.store)
+
+	want := map[string]int{"A": 1, "B": 2, "C": 2, "D": 3, "M": 4}
+	for name, gen := range want {
+		got, err := g.Generation(h[name])
+		if err != nil {
+			t.Fatalf("Generation failed: %v", err)
+		}
+		if got != gen {
+			t.Errorf("Generation(%s) = %d, want %d", name, got, gen)
+		}
+	}
+}
+
+func TestMergeBases(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildMergeHistory(r)
+	h["E"] = r.commit("E", 5, h["C"])
+	h["F"] = r.commit("F", 6, h["B"])
+
+	// Criss-cross: X1 и X2 сливают B и C в разном порядке, поэтому лучших предков два
+	h["X1"] = r.commit("X1", 7, h["B"], h["C"])
+	h["X2"] = r.commit("X2", 8, h["C"], h["B"])
+	h["Root"] = r.commit("Root", 9)
+
+	g := NewGraph(r.store)
+	tests := []struct {
+		one    string
+		others []string
+		want   string
+	}{
+		{"D", []string{"C"}, "A"},
+		{"M", []string{"E"}, "C"},
+		{"E", []string{"M"}, "C"},
+		{"M", []string{"D"}, "D"},
+		{"M", []string{"M"}, "M"},
+		{"F", []string{"D"}, "B"},
+		{"Root", []string{"M"}, ""},
+		{"F", []string{"E", "D"}, "B"},
+	}
+	for _, tt := range tests {
+		others := make([]objects.Hash, 0, len(tt.others))
+		for _, name := range tt.others {
+			others = append(others, h[name])
+		}
+		bases, err := g.MergeBases(h[tt.one], others...)
+		if err != nil {
+			t.Fatalf("MergeBases failed: %v", err)
+		}
+		if got := r.namesOf(bases); got != tt.want {
+			t.Errorf("MergeBases(%s, %v) = %q, want %q", tt.one, tt.others, got, tt.want)
+		}
+	}
+
+	bases, err := g.MergeBases(h["X1"], h["X2"])
+	if err != nil {
+		t.Fatalf("MergeBases failed: %v", err)
+	}
+	// Порядок - по хешу, поэтому сравниваем как множество
+	if got := r.namesOf(bases); got != "B C" && got != "C B" {
+		t.Errorf("MergeBases(X1, X2) = %q, want B and C", got)
+	}
+}
+
+func TestOctopusBases(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildMergeHistory(r)
+	h["E"] = r.commit("E", 5, h["D"])
+	h["F"] = r.commit("F", 6, h["D"])
+
+	g := NewGraph(r.store)
+
+	bases, err := g.OctopusBases(h["E"], h["F"], h["M"])
+	if err != nil {
+		t.Fatalf("OctopusBases failed: %v", err)
+	}
+	if got := r.namesOf(bases); got != "D" {
+		t.Errorf("OctopusBases(E, F, M) = %q, want D", got)
+	}
+
+	bases, err = g.OctopusBases(h["E"], h["F"], h["C"])
+	if err != nil {
+		t.Fatalf("OctopusBases failed: %v", err)
+	}
+	if got := r.namesOf(bases); got != "A" {
+		t.Errorf("OctopusBases(E, F, C) = %q, want A", got)
+	}
+}
+
+func TestLongHistory(t *testing.T) {
+	r := newTestRepo(t)
+
+	// Длинная линейная история не должна переполнять стек или замедлять запросы
+	tip := r.commit("c0", 0)
+	first := tip
+	for i := 1; i < 2000; i++ {
+		tip = r.commit("c", i, tip)
+	}
+	side := r.commit("side", 3000, first)
+
+	g := NewGraph(r.store)
+	gen, err := g.Generation(tip)
+	if err != nil {
+		t.Fatalf("Generation failed: %v", err)
+	}
+	if gen != 2000 {
+		t.Errorf("Generation = %d, want 2000", gen)
+	}
+
+	bases, err := g.MergeBases(tip, side)
+	if err != nil {
+		t.Fatalf("MergeBases failed: %v", err)
+	}
+	if len(bases) != 1 || bases[0] != first {
+		t.Errorf("MergeBases = %v, want root commit", bases)
+	}
+
+	ok, err := g.IsAncestor(side, tip)
+	if err != nil {
+		t.Fatalf("IsAncestor failed: %v", err)
+	}
+	if ok {
+		t.Error("Side branch is not an ancestor of the tip")
+	}
+}
+
+func TestGenerationFromCommitGraph(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildMergeHistory(r)
+	if n, err := WriteCommitGraph(r.store, []objects.Hash{h["A"], h["B"], h["C"], h["D"], h["M"]}); err != nil || n != 5 {
+		t.Fatalf("WriteCommitGraph = %d, %v", n, err)
+	}
+
+	// Коммиты из commit-graph не читаются: без корня поколения все равно известны
+	if err := r.store.RemoveLoose(h["A"]); err != nil {
+		t.Fatal(err)
+	}
+	h["N"] = r.commit("N", 5, h["M"])
+
+	g := NewGraph(r.store)
+	want := map[string]int{"B": 2, "C": 2, "M": 4, "N": 5}
+	for name, gen := range want {
+		got, err := g.Generation(h[name])
+		if err != nil {
+			t.Fatalf("Generation(%s) failed: %v", name, err)
+		}
+		if got != gen {
+			t.Errorf("Generation(%s) = %d, want %d", name, got, gen)
+		}
+	}
+	if ok, err := g.IsAncestor(h["C"], h["N"]); err != nil || !ok {
+		t.Errorf("IsAncestor(C, N) = %v, %v", ok, err)
+	}
+}
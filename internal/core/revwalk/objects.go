@@ -0,0 +1,113 @@
+package revwalk
+
+import (
+	"fmt"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// ObjectWalker обходит все объекты, достижимые из стартовых точек: коммиты
+// с родителями и деревьями, деревья с содержимым, теги с их целями
+type ObjectWalker struct {
+	store   *storage.ObjectStore
+	pending []pendingObject
+	seen    map[objects.Hash]objects.ObjectType
+}
+
+// pendingObject - объект в очереди обхода и то, откуда на него сослались
+type pendingObject struct {
+	hash    objects.Hash
+	objType objects.ObjectType // "" - тип заранее неизвестен
+	from    string
+}
+
+// NewObjectWalker создает обход объектов поверх хранилища
+func NewObjectWalker(store *storage.ObjectStore) *ObjectWalker {
+	return &ObjectWalker{store: store, seen: make(map[objects.Hash]objects.ObjectType)}
+}
+
+// Push добавляет стартовую точку. objType - ожидаемый тип ("" - любой),
+// from - откуда взят объект, для сообщений об ошибках
+func (w *ObjectWalker) Push(hash objects.Hash, objType objects.ObjectType, from string) {
+	w.pending = append(w.pending, pendingObject{hash: hash, objType: objType, from: from})
+}
+
+// Walk обходит объекты и возвращает все достижимые с их типами.
+// Отсутствующий или не того типа объект - ошибка: по неполному графу нельзя
+// судить, какие объекты не нужны. Содержимое blob-объектов не читается,
+// только манифест у записанных частями
+func (w *ObjectWalker) Walk() (map[objects.Hash]objects.ObjectType, error) {
+	for len(w.pending) > 0 {
+		item := w.pending[len(w.pending)-1]
+		w.pending = w.pending[:len(w.pending)-1]
+		if _, ok := w.seen[item.hash]; ok {
+			continue
+		}
+
+		if item.objType == objects.BlobObject {
+			if !w.store.ObjectExists(item.hash) {
+				return nil, fmt.Errorf("missing blob %s (referenced by %s)", item.hash, item.from)
+			}
+			w.seen[item.hash] = objects.BlobObject
+
+			// Blob, записанный частями, держит свои куски
+			manifest, err := w.store.ReadManifest(item.hash)
+			if err != nil {
+				return nil, fmt.Errorf("bad object %s (referenced by %s): %w", item.hash, item.from, err)
+			}
+			if manifest != nil {
+				from := fmt.Sprintf("chunked blob %s", item.hash)
+				for _, link := range Links(manifest) {
+					w.Push(link.Hash, link.Type, from)
+				}
+			}
+			continue
+		}
+
+		obj, err := w.store.ReadObject(item.hash)
+		if err != nil {
+			return nil, fmt.Errorf("bad object %s (referenced by %s): %w", item.hash, item.from, err)
+		}
+		if item.objType != "" && obj.Type() != item.objType {
+			return nil, fmt.Errorf("object %s is a %s, expected %s (referenced by %s)", item.hash, obj.Type(), item.objType, item.from)
+		}
+		w.seen[item.hash] = obj.Type()
+
+		from := fmt.Sprintf("%s %s", obj.Type(), item.hash)
+		for _, link := range Links(obj) {
+			w.Push(link.Hash, link.Type, from)
+		}
+	}
+	return w.seen, nil
+}
+
+// Link - ссылка объекта на другой объект с ожидаемым типом
+type Link struct {
+	Hash objects.Hash
+	Type objects.ObjectType
+}
+
+// Links возвращает объекты, на которые ссылается obj: дерево и родителей
+// коммита, записи дерева, цель тега, куски манифеста. У blob ссылок нет
+func Links(obj objects.Serializable) []Link {
+	var links []Link
+	switch obj := obj.(type) {
+	case *objects.Commit:
+		links = append(links, Link{obj.Tree(), objects.TreeObject})
+		for _, parent := range obj.Parents() {
+			links = append(links, Link{parent, objects.CommitObject})
+		}
+	case *objects.Tree:
+		for _, entry := range obj.Entries() {
+			links = append(links, Link{entry.Hash(), entry.Type()})
+		}
+	case *objects.Tag:
+		links = append(links, Link{obj.Object(), obj.ObjectType()})
+	case *objects.Manifest:
+		for _, chunk := range obj.Chunks() {
+			links = append(links, Link{chunk.Hash(), objects.BlobObject})
+		}
+	}
+	return links
+}
@@ -0,0 +1,343 @@
+// Package revwalk обходит историю коммитов по ссылкам на родителей.
+// Поддерживает несколько стартовых точек, исключение предков (A..B)
+// и два порядка обхода: по дате и топологический.
+package revwalk
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// Order - порядок выдачи коммитов
+type Order int
+
+const (
+	// OrderDate - сначала более новые коммиты (по времени коммитера)
+	OrderDate Order = iota
+	// OrderTopo - ни один родитель не выдается раньше своих потомков
+	OrderTopo
+)
+
+// Walker - итератор по истории коммитов
+// Каждый коммит выдается не более одного раза, даже если
+// до него можно дойти через несколько родителей слияния
+type Walker struct {
+	store *storage.ObjectStore
+	order Order
+
+	starts  []objects.Hash                   // Стартовые точки
+	hidden  []objects.Hash                   // Коммиты, предки которых исключаются
+	commits map[objects.Hash]*objects.Commit // Кеш прочитанных коммитов
+
+	prepared      bool
+	limited       bool                  // Результат выгружен заранее в sorted
+	uninteresting map[objects.Hash]bool // Скрытые коммиты и их уже найденные предки
+	seen          map[objects.Hash]bool // Уже поставленные в очередь
+	done          map[objects.Hash]bool // Вынутые из очереди: их родители уже в очереди
+	interesting   int                   // Сколько в очереди не скрытых коммитов
+	queue         commitQueue           // Очередь по дате
+	sorted        []*objects.Commit     // Готовый результат, если limited
+}
+
+// limitSlop - сколько коммитов просматривается после того, как в очереди
+// остались только скрытые: страховка от неверных часов у коммитеров (SLOP в git)
+const limitSlop = 5
+
+// NewWalker создает итератор истории поверх хранилища объектов
+func NewWalker(store *storage.ObjectStore, order Order) *Walker {
+	return &Walker{
+		store:   store,
+		order:   order,
+		commits: make(map[objects.Hash]*objects.Commit),
+	}
+}
+
+// Push добавляет стартовую точку обхода
+func (w *Walker) Push(hash objects.Hash) error {
+	if w.prepared {
+		return fmt.Errorf("cannot push after walking has started")
+	}
+	if _, err := w.commit(hash); err != nil {
+		return err
+	}
+	w.starts = append(w.starts, hash)
+	return nil
+}
+
+// Hide исключает из обхода коммит и всех его предков (как ^A в A..B)
+func (w *Walker) Hide(hash objects.Hash) error {
+	if w.prepared {
+		return fmt.Errorf("cannot hide after walking has started")
+	}
+	if _, err := w.commit(hash); err != nil {
+		return err
+	}
+	w.hidden = append(w.hidden, hash)
+	return nil
+}
+
+// Next возвращает следующий коммит или io.EOF, когда история закончилась
+func (w *Walker) Next() (*objects.Commit, error) {
+	if !w.prepared {
+		if err := w.prepare(); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.limited {
+		if len(w.sorted) == 0 {
+			return nil, io.EOF
+		}
+		next := w.sorted[0]
+		w.sorted = w.sorted[1:]
+		return next, nil
+	}
+
+	if w.queue.Len() == 0 {
+		return nil, io.EOF
+	}
+
+	next := heap.Pop(&w.queue).(*objects.Commit)
+	if err := w.enqueueParents(next); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// ForEach вызывает fn для каждого коммита; io.EOF из fn останавливает обход без ошибки
+func (w *Walker) ForEach(fn func(*objects.Commit) error) error {
+	for {
+		commit, err := w.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(commit); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// prepare заполняет очередь. Без скрытых коммитов обход по дате идет лениво,
+// иначе диапазон выгружается заранее (limit) - но только до его границы
+func (w *Walker) prepare() error {
+	w.prepared = true
+	w.uninteresting = make(map[objects.Hash]bool)
+	w.seen = make(map[objects.Hash]bool)
+	w.done = make(map[objects.Hash]bool)
+
+	for _, hash := range w.hidden {
+		w.markUninteresting(hash)
+		if err := w.enqueue(hash); err != nil {
+			return err
+		}
+	}
+	for _, hash := range w.starts {
+		if err := w.enqueue(hash); err != nil {
+			return err
+		}
+	}
+
+	if len(w.hidden) == 0 && w.order == OrderDate {
+		return nil
+	}
+	w.limited = true
+	all, err := w.limit()
+	if err != nil {
+		return err
+	}
+	if w.order == OrderTopo {
+		w.topoSort(all)
+	} else {
+		w.sorted = all
+	}
+	return nil
+}
+
+// enqueue ставит коммит в очередь, если он еще не встречался.
+// Скрытые коммиты тоже идут в очередь: через них пометка доходит до предков
+func (w *Walker) enqueue(hash objects.Hash) error {
+	if w.seen[hash] {
+		return nil
+	}
+	w.seen[hash] = true
+
+	commit, err := w.commit(hash)
+	if err != nil {
+		return err
+	}
+	heap.Push(&w.queue, commit)
+	if !w.uninteresting[hash] {
+		w.interesting++
+	}
+
+	return nil
+}
+
+// limit выгружает коммиты по дате, как limit_list в git: вынутый скрытый
+// коммит передает пометку родителям. Обход останавливается, как только в
+// очереди остаются одни скрытые коммиты, поэтому A..B стоит O(размер диапазона),
+// а не O(вся история). Коммиты, помеченные уже после выдачи, отбрасываются
+func (w *Walker) limit() ([]*objects.Commit, error) {
+	var result []*objects.Commit
+	slop := limitSlop
+
+	for w.queue.Len() > 0 {
+		commit := heap.Pop(&w.queue).(*objects.Commit)
+		hash := commit.Hash()
+		w.done[hash] = true
+
+		if w.uninteresting[hash] {
+			for _, parent := range commit.Parents() {
+				w.markUninteresting(parent)
+			}
+		} else {
+			w.interesting--
+			result = append(result, commit)
+		}
+		if err := w.enqueueParents(commit); err != nil {
+			return nil, err
+		}
+
+		if w.interesting > 0 {
+			slop = limitSlop
+			continue
+		}
+		if slop--; slop == 0 {
+			break
+		}
+	}
+
+	kept := result[:0]
+	for _, commit := range result {
+		if !w.uninteresting[commit.Hash()] {
+			kept = append(kept, commit)
+		}
+	}
+	return kept, nil
+}
+
+// markUninteresting помечает коммит скрытым. Если коммит уже вынут из
+// очереди, пометка сразу передается его родителям, иначе это сделает limit
+func (w *Walker) markUninteresting(hash objects.Hash) {
+	stack := []objects.Hash{hash}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if w.uninteresting[hash] {
+			continue
+		}
+		w.uninteresting[hash] = true
+
+		switch {
+		case w.done[hash]:
+			stack = append(stack, w.commits[hash].Parents()...)
+		case w.seen[hash]:
+			w.interesting--
+		}
+	}
+}
+
+// enqueueParents ставит в очередь родителей выданного коммита
+func (w *Walker) enqueueParents(commit *objects.Commit) error {
+	for _, parent := range commit.Parents() {
+		if err := w.enqueue(parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// topoSort упорядочивает выгруженные коммиты так, чтобы потомки шли
+// раньше предков (алгоритм Кана, при равенстве - по дате)
+func (w *Walker) topoSort(all []*objects.Commit) {
+	included := make(map[objects.Hash]bool, len(all))
+	for _, commit := range all {
+		included[commit.Hash()] = true
+	}
+
+	// Считаем количество потомков внутри обходимого множества
+	children := make(map[objects.Hash]int, len(all))
+	for _, commit := range all {
+		for _, parent := range commit.Parents() {
+			if included[parent] {
+				children[parent]++
+			}
+		}
+	}
+
+	var ready commitQueue
+	for _, commit := range all {
+		if children[commit.Hash()] == 0 {
+			heap.Push(&ready, commit)
+		}
+	}
+
+	w.sorted = make([]*objects.Commit, 0, len(all))
+	for ready.Len() > 0 {
+		commit := heap.Pop(&ready).(*objects.Commit)
+		w.sorted = append(w.sorted, commit)
+
+		for _, parent := range commit.Parents() {
+			if !included[parent] {
+				continue
+			}
+			children[parent]--
+			if children[parent] == 0 {
+				heap.Push(&ready, w.commits[parent])
+			}
+		}
+	}
+}
+
+// commit читает коммит с кешированием
+func (w *Walker) commit(hash objects.Hash) (*objects.Commit, error) {
+	if commit, ok := w.commits[hash]; ok {
+		return commit, nil
+	}
+
+	commit, err := w.store.ReadCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	w.commits[hash] = commit
+
+	return commit, nil
+}
+
+// commitQueue - приоритетная очередь: сначала более новые коммиты
+type commitQueue []*objects.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	ci, cj := q[i].Committer(), q[j].Committer()
+	ti, tj := ci.Time(), cj.Time()
+	if !ti.Equal(tj) {
+		return ti.After(tj)
+	}
+	// Для детерминизма при одинаковом времени сравниваем хеши
+	return q[i].Hash() < q[j].Hash()
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) { *q = append(*q, x.(*objects.Commit)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
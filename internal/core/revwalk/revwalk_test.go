@@ -0,0 +1,228 @@
+package revwalk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// testRepo строит историю коммитов в тестовом хранилище
+type testRepo struct {
+	t     *testing.T
+	store *storage.ObjectStore
+	tree  objects.Hash
+	base  time.Time
+	names map[objects.Hash]string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".sib", "objects"), 0755); err != nil {
+		t.Fatalf("Failed to create objects directory: %v", err)
+	}
+
+	store, err := storage.NewObjectStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	blobHash, _ := store.WriteObject(objects.NewBlob([]byte("content")))
+	entry, _ := objects.NewTreeEntry(objects.FileModeRegular, "file.txt", blobHash, objects.BlobObject)
+	tree := objects.NewTree()
+	tree.AddEntry(*entry)
+	treeHash, err := store.WriteObject(tree)
+	if err != nil {
+		t.Fatalf("Failed to write tree: %v", err)
+	}
+
+	return &testRepo{
+		t:     t,
+		store: store,
+		tree:  treeHash,
+		base:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		names: make(map[objects.Hash]string),
+	}
+}
+
+// commit создает коммит с сообщением name через minutes минут после базового времени
+func (r *testRepo) commit(name string, minutes int, parents ...objects.Hash) objects.Hash {
+	sig, _ := objects.NewSignature("Tester", "test@example.com", r.base.Add(time.Duration(minutes)*time.Minute))
+	commit, err := objects.NewCommit(r.tree, parents, *sig, *sig, name)
+	if err != nil {
+		r.t.Fatalf("Failed to create commit: %v", err)
+	}
+	hash, err := r.store.WriteObject(commit)
+	if err != nil {
+		r.t.Fatalf("Failed to write commit: %v", err)
+	}
+	r.names[hash] = name
+	return hash
+}
+
+// walk возвращает сообщения коммитов в порядке обхода
+func walk(t *testing.T, w *Walker) string {
+	var names []string
+	if err := w.ForEach(func(c *objects.Commit) error {
+		names = append(names, c.Message())
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	return strings.Join(names, " ")
+}
+
+// buildMergeHistory создает историю:
+//
+//	A - B - D - M
+//	 \         /
+//	  C ------
+//
+// где C создан позже D, чтобы порядок по дате и топологический различались
+func buildMergeHistory(r *testRepo) map[string]objects.Hash {
+	a := r.commit("A", 0)
+	b := r.commit("B", 1, a)
+	d := r.commit("D", 2, b)
+	c := r.commit("C", 3, a)
+	m := r.commit("M", 4, d, c)
+	return map[string]objects.Hash{"A": a, "B": b, "C": c, "D": d, "M": m}
+}
+
+func TestWalkDateOrder(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildMergeHistory(r)
+
+	w := NewWalker(r.store, OrderDate)
+	if err := w.Push(h["M"]); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	if got := walk(t, w); got != "M C D B A" {
+		t.Errorf("Date order = %q, want %q", got, "M C D B A")
+	}
+}
+
+func TestWalkTopoOrder(t *testing.T) {
+	r := newTestRepo(t)
+
+	// Коммит на второй ветке старше родителя первой - в порядке по дате
+	// A оказался бы раньше C, а топологически A всегда последний
+	a := r.commit("A", 10)
+	b := r.commit("B", 20, a)
+	c := r.commit("C", 5, a)
+	m := r.commit("M", 30, b, c)
+
+	w := NewWalker(r.store, OrderTopo)
+	w.Push(m)
+
+	got := walk(t, w)
+	if got != "M B C A" {
+		t.Errorf("Topo order = %q, want %q", got, "M B C A")
+	}
+}
+
+func TestWalkMultipleStartsAndHide(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildMergeHistory(r)
+
+	t.Run("Each commit once", func(t *testing.T) {
+		w := NewWalker(r.store, OrderDate)
+		w.Push(h["D"])
+		w.Push(h["C"])
+		w.Push(h["M"])
+
+		if got := walk(t, w); got != "M C D B A" {
+			t.Errorf("Walk = %q", got)
+		}
+	})
+
+	t.Run("Hide ancestors", func(t *testing.T) {
+		w := NewWalker(r.store, OrderDate)
+		w.Push(h["M"])
+		w.Hide(h["D"])
+
+		if got := walk(t, w); got != "M C" {
+			t.Errorf("Walk D..M = %q, want %q", got, "M C")
+		}
+	})
+
+	t.Run("Push missing commit", func(t *testing.T) {
+		w := NewWalker(r.store, OrderDate)
+		if err := w.Push(objects.Hash(strings.Repeat("0", 64))); err == nil {
+			t.Error("Expected error for missing commit")
+		}
+	})
+}
+
+func TestHideStopsAtRangeBoundary(t *testing.T) {
+	r := newTestRepo(t)
+
+	var chain []objects.Hash
+	for i := 0; i < 200; i++ {
+		var parents []objects.Hash
+		if i > 0 {
+			parents = append(parents, chain[i-1])
+		}
+		chain = append(chain, r.commit(fmt.Sprintf("c%d", i), i, parents...))
+	}
+	side := r.commit("side", 250, chain[197])
+	merge := r.commit("merge", 300, chain[199], side)
+
+	w := NewWalker(r.store, OrderDate)
+	w.Push(merge)
+	w.Hide(chain[197])
+	if got := walk(t, w); got != "merge side c199 c198" {
+		t.Errorf("Walk = %q", got)
+	}
+	// Прочитана только граница диапазона, а не вся история до корня
+	if len(w.commits) > 20 {
+		t.Errorf("Walk read %d commits for a range of 4", len(w.commits))
+	}
+
+	// Коммит с часами в прошлом все равно попадает в диапазон
+	skewed := r.commit("skewed", -10, chain[197])
+	w = NewWalker(r.store, OrderDate)
+	w.Push(skewed)
+	w.Push(chain[199])
+	w.Hide(chain[197])
+	if got := walk(t, w); got != "c199 c198 skewed" {
+		t.Errorf("Walk with clock skew = %q", got)
+	}
+
+	w = NewWalker(r.store, OrderTopo)
+	w.Push(chain[199])
+	w.Hide(merge)
+	if got := walk(t, w); got != "" {
+		t.Errorf("Walk of a hidden range = %q", got)
+	}
+}
+
+func TestIsAncestor(t *testing.T) {
+	r := newTestRepo(t)
+	h := buildMergeHistory(r)
+
+	tests := []struct {
+		ancestor, descendant string
+		want                 bool
+	}{
+		{"A", "M", true},
+		{"D", "M", true},
+		{"M", "M", true},
+		{"C", "D", false},
+		{"M", "A", false},
+	}
+	for _, tt := range tests {
+		got, err := IsAncestor(r.store, h[tt.ancestor], h[tt.descendant])
+		if err != nil {
+			t.Fatalf("IsAncestor failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("IsAncestor(%s, %s) = %v, want %v", tt.ancestor, tt.descendant, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+/*
+Content-defined chunking в стиле FastCDC. Граница куска ставится там, где
+скользящий gear-хеш последних байтов имеет нули в старших битах маски, то есть
+зависит только от содержимого рядом с ней. Вставка или удаление байтов сдвигает
+лишь соседние границы - остальные куски новой версии файла совпадают со старыми
+и хранятся один раз.
+
+До среднего размера используется более строгая маска, после - более мягкая
+(нормализованное разбиение): размеры кусков теснее группируются вокруг среднего.
+*/
+
+const (
+	chunkMin = 16 << 10  // Минимальный размер куска
+	chunkAvg = 64 << 10  // Средний размер куска
+	chunkMax = 256 << 10 // Максимальный размер куска
+
+	chunkMaskS = uint64(1<<18-1) << (64 - 18) // Строгая маска: log2(chunkAvg)+2 бит
+	chunkMaskL = uint64(1<<14-1) << (64 - 14) // Мягкая маска: log2(chunkAvg)-2 бит
+)
+
+// gear - таблица случайных чисел для gear-хеша. Фиксированное начальное
+// значение: границы не должны меняться между запусками и версиями sib
+var gear = func() (table [256]uint64) {
+	x := uint64(0x51b0c0de)
+	for i := range table {
+		// splitmix64
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// cutPoint возвращает длину первого куска data
+func cutPoint(data []byte) int {
+	n := len(data)
+	if n <= chunkMin {
+		return n
+	}
+	n = min(n, chunkMax)
+	normal := min(n, chunkAvg)
+
+	var h uint64
+	i := chunkMin
+	for ; i < normal; i++ {
+		h = (h << 1) + gear[data[i]]
+		if h&chunkMaskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		h = (h << 1) + gear[data[i]]
+		if h&chunkMaskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// chunker разбивает поток на куски, держа в памяти не больше chunkMax байт
+type chunker struct {
+	r          io.Reader
+	buf        []byte
+	start, end int // Непрочитанные данные: buf[start:end]
+	eof        bool
+}
+
+// newChunker создает разбиение потока r
+func newChunker(r io.Reader) *chunker {
+	return &chunker{r: r, buf: make([]byte, chunkMax)}
+}
+
+// next возвращает следующий кусок или io.EOF. Кусок действителен до следующего вызова
+func (c *chunker) next() ([]byte, error) {
+	if c.end-c.start < chunkMax && !c.eof {
+		copy(c.buf, c.buf[c.start:c.end])
+		c.end -= c.start
+		c.start = 0
+
+		n, err := io.ReadFull(c.r, c.buf[c.end:])
+		c.end += n
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			c.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if c.start == c.end {
+		return nil, io.EOF
+	}
+
+	n := cutPoint(c.buf[c.start:c.end])
+	chunk := c.buf[c.start : c.start+n]
+	c.start += n
+	return chunk, nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// chunkAll разбивает данные и возвращает копии кусков
+func chunkAll(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	var chunks [][]byte
+	c := newChunker(bytes.NewReader(data))
+	for {
+		chunk, err := c.next()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, append([]byte(nil), chunk...))
+	}
+}
+
+func TestChunker(t *testing.T) {
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(7)).Read(data)
+
+	chunks := chunkAll(t, data)
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatal("Chunks do not add up to the input")
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > chunkMax || (len(chunk) < chunkMin && i != len(chunks)-1) {
+			t.Errorf("Chunk %d has %d bytes", i, len(chunk))
+		}
+	}
+	if avg := len(data) / len(chunks); avg < chunkAvg/2 || avg > chunkAvg*2 {
+		t.Errorf("Average chunk size %d is far from %d", avg, chunkAvg)
+	}
+
+	// Вставка в середину меняет только соседние куски
+	edited := append(append(append([]byte(nil), data[:len(data)/2]...), "inserted bytes"...), data[len(data)/2:]...)
+	known := make(map[string]bool)
+	for _, chunk := range chunks {
+		known[string(chunk)] = true
+	}
+	changed := 0
+	for _, chunk := range chunkAll(t, edited) {
+		if !known[string(chunk)] {
+			changed++
+		}
+	}
+	if changed > 2 {
+		t.Errorf("%d chunks changed after a small insertion", changed)
+	}
+
+	if got := chunkAll(t, nil); len(got) != 0 {
+		t.Errorf("Empty input gave %d chunks", len(got))
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"sib/internal/core/objects"
+	"sib/internal/utils"
+)
+
+/*
+Commit-graph хранит номера поколений коммитов (см. revwalk.Graph), чтобы
+запросы о предках не обходили всю историю до корня при каждом запуске.
+Коммит неизменяем, поэтому его номер поколения не устаревает: файл может
+лишь не знать о новых коммитах, их поколения считаются обходом.
+
+Файл objects/info/commit-graph:
+
+	"SCGR" <версия uint32> <число коммитов uint32>
+	<хеши: N x 32 байта по возрастанию>
+	<поколения: N x uint32>
+	<SHA-256 всего предыдущего содержимого>
+
+Файл переписывается целиком командой gc.
+*/
+
+const (
+	commitGraphMagic   = "SCGR"
+	commitGraphVersion = 1
+	commitGraphHeader  = 12 // magic + версия + число коммитов
+)
+
+// CommitGraph - прочитанный файл commit-graph
+type CommitGraph struct {
+	hashes      []byte   // Хеши коммитов подряд, по hashSize байт
+	generations []uint32 // Номера поколений в порядке хешей
+}
+
+// commitGraphPath возвращает путь к файлу commit-graph
+func (store *ObjectStore) commitGraphPath() string {
+	return filepath.Join(store.objectsDir, "info", "commit-graph")
+}
+
+// ReadCommitGraph читает файл commit-graph. Если файла нет, возвращает nil без ошибки
+func (store *ObjectStore) ReadCommitGraph() (*CommitGraph, error) {
+	path := store.commitGraphPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit-graph: %w", err)
+	}
+
+	if len(data) < commitGraphHeader+hashSize || string(data[:4]) != commitGraphMagic {
+		return nil, fmt.Errorf("commit-graph %s is corrupt: bad header", path)
+	}
+	if version := binary.BigEndian.Uint32(data[4:8]); version != commitGraphVersion {
+		return nil, fmt.Errorf("commit-graph %s has unsupported version %d", path, version)
+	}
+	body := data[:len(data)-hashSize]
+	if sum := sha256.Sum256(body); !bytes.Equal(sum[:], data[len(body):]) {
+		return nil, fmt.Errorf("commit-graph %s is corrupt: checksum mismatch", path)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+	if len(data) != commitGraphHeader+count*(hashSize+4)+hashSize {
+		return nil, fmt.Errorf("commit-graph %s is corrupt: bad size", path)
+	}
+
+	pos := commitGraphHeader
+	graph := &CommitGraph{hashes: data[pos : pos+count*hashSize], generations: make([]uint32, count)}
+	pos += count * hashSize
+	for i := range graph.generations {
+		graph.generations[i] = binary.BigEndian.Uint32(data[pos+4*i:])
+	}
+	return graph, nil
+}
+
+// Generation возвращает записанный номер поколения коммита; false - коммита в файле нет
+func (g *CommitGraph) Generation(hash objects.Hash) (int, bool) {
+	if g == nil {
+		return 0, false
+	}
+	raw, err := hex.DecodeString(hash.String())
+	if err != nil || len(raw) != hashSize {
+		return 0, false
+	}
+
+	i := sort.Search(len(g.generations), func(i int) bool {
+		return bytes.Compare(g.hashes[i*hashSize:(i+1)*hashSize], raw) >= 0
+	})
+	if i == len(g.generations) || !bytes.Equal(g.hashes[i*hashSize:(i+1)*hashSize], raw) {
+		return 0, false
+	}
+	return int(g.generations[i]), true
+}
+
+// Count возвращает число коммитов в файле
+func (g *CommitGraph) Count() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.generations)
+}
+
+// WriteCommitGraph атомарно заменяет файл commit-graph номерами поколений generations
+func (store *ObjectStore) WriteCommitGraph(generations map[objects.Hash]int) error {
+	raw := make([][]byte, 0, len(generations))
+	byRaw := make(map[string]int, len(generations))
+	for hash, gen := range generations {
+		decoded, err := hex.DecodeString(hash.String())
+		if err != nil || len(decoded) != hashSize {
+			return fmt.Errorf("invalid commit hash %s", hash)
+		}
+		raw = append(raw, decoded)
+		byRaw[string(decoded)] = gen
+	}
+	sort.Slice(raw, func(i, j int) bool { return bytes.Compare(raw[i], raw[j]) < 0 })
+
+	data := make([]byte, 0, commitGraphHeader+len(raw)*(hashSize+4)+hashSize)
+	data = append(data, commitGraphMagic...)
+	data = binary.BigEndian.AppendUint32(data, commitGraphVersion)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(raw)))
+	for _, hash := range raw {
+		data = append(data, hash...)
+	}
+	for _, hash := range raw {
+		data = binary.BigEndian.AppendUint32(data, uint32(byRaw[string(hash)]))
+	}
+	sum := sha256.Sum256(data)
+	data = append(data, sum[:]...)
+
+	path := store.commitGraphPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create info directory: %w", err)
+	}
+	if err := utils.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write commit-graph: %w", err)
+	}
+	return nil
+}
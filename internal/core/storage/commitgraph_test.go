@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+)
+
+func TestCommitGraph(t *testing.T) {
+	store, _ := initTestStore(t)
+
+	// Без файла - пустой граф, а не ошибка
+	graph, err := store.ReadCommitGraph()
+	if err != nil || graph != nil {
+		t.Fatalf("ReadCommitGraph without a file = %v, %v", graph, err)
+	}
+	if _, ok := graph.Generation(objects.Hash(strings.Repeat("a", 64))); ok {
+		t.Error("nil graph should know no commits")
+	}
+
+	generations := make(map[objects.Hash]int)
+	for i, content := range []string{"one", "two", "three", "four"} {
+		generations[store.calculateHash([]byte(content))] = i + 1
+	}
+	if err := store.WriteCommitGraph(generations); err != nil {
+		t.Fatalf("WriteCommitGraph failed: %v", err)
+	}
+
+	graph, err = store.ReadCommitGraph()
+	if err != nil {
+		t.Fatalf("ReadCommitGraph failed: %v", err)
+	}
+	if graph.Count() != len(generations) {
+		t.Errorf("Count = %d, want %d", graph.Count(), len(generations))
+	}
+	for hash, want := range generations {
+		if got, ok := graph.Generation(hash); !ok || got != want {
+			t.Errorf("Generation(%s) = %d, %v, want %d", hash, got, ok, want)
+		}
+	}
+	if _, ok := graph.Generation(store.calculateHash([]byte("missing"))); ok {
+		t.Error("Unknown commit found in commit-graph")
+	}
+	if tmp, _ := store.TempFiles(); len(tmp) != 0 {
+		t.Errorf("Temporary files left: %v", tmp)
+	}
+
+	// Поврежденный файл обнаруживается по контрольной сумме
+	data, _ := os.ReadFile(store.commitGraphPath())
+	data[commitGraphHeader] ^= 0xff
+	os.WriteFile(store.commitGraphPath(), data, 0644)
+	if _, err := store.ReadCommitGraph(); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected checksum error, got %v", err)
+	}
+}
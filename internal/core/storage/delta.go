@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+/*
+Формат дельты (преобразование base -> target):
+
+	<размер base uvarint> <размер target uvarint> <инструкции...>
+
+Инструкции:
+
+	0x01..0x7f          - вставка: за байтом следует столько же байтов данных
+	0x80 <off> <len>    - копирование len байтов base со смещения off (оба uvarint)
+
+Размеры в заголовке позволяют сразу проверить, что дельта применяется
+к нужной базе и дает результат ожидаемой длины.
+*/
+
+const (
+	deltaBlock     = 16   // Минимальная длина совпадения, ради которой стоит копировать
+	deltaMaxInsert = 0x7f // Максимальная длина одной инструкции вставки
+	deltaCopy      = 0x80 // Код инструкции копирования
+)
+
+// errBadDelta - дельта повреждена или не подходит к базе
+var errBadDelta = errors.New("corrupt delta")
+
+// deltaIndex - индекс блоков базы для поиска совпадений
+type deltaIndex struct {
+	base   []byte
+	blocks map[uint64]int // Хеш блока длиной deltaBlock -> смещение в base
+}
+
+// newDeltaIndex индексирует base блоками по deltaBlock байт
+func newDeltaIndex(base []byte) *deltaIndex {
+	di := &deltaIndex{base: base, blocks: make(map[uint64]int, len(base)/deltaBlock+1)}
+	for off := 0; off+deltaBlock <= len(base); off += deltaBlock {
+		key := blockHash(base[off : off+deltaBlock])
+		// Первое вхождение оставляем: копии из начала файла встречаются чаще
+		if _, ok := di.blocks[key]; !ok {
+			di.blocks[key] = off
+		}
+	}
+	return di
+}
+
+// blockHash вычисляет хеш блока для индекса (FNV-1a без выделения памяти)
+func blockHash(block []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, b := range block {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	return h
+}
+
+// computeDelta строит дельту, превращающую base в target.
+// Возвращает nil, если дельта получается не меньше limit байт (0 - без ограничения)
+func (di *deltaIndex) computeDelta(target []byte, limit int) []byte {
+	delta := binary.AppendUvarint(nil, uint64(len(di.base)))
+	delta = binary.AppendUvarint(delta, uint64(len(target)))
+
+	pending := 0 // Начало еще не записанных байтов target для вставки
+	flush := func(end int) {
+		for pending < end {
+			n := min(end-pending, deltaMaxInsert)
+			delta = append(delta, byte(n))
+			delta = append(delta, target[pending:pending+n]...)
+			pending += n
+		}
+	}
+
+	pos := 0
+	for pos+deltaBlock <= len(target) {
+		off, ok := di.blocks[blockHash(target[pos:pos+deltaBlock])]
+		if !ok || string(di.base[off:off+deltaBlock]) != string(target[pos:pos+deltaBlock]) {
+			pos++
+			continue
+		}
+
+		// Расширяем совпадение вперед, а затем назад за счет ожидающей вставки
+		length := deltaBlock
+		for off+length < len(di.base) && pos+length < len(target) && di.base[off+length] == target[pos+length] {
+			length++
+		}
+		for off > 0 && pos > pending && di.base[off-1] == target[pos-1] {
+			off--
+			pos--
+			length++
+		}
+
+		flush(pos)
+		delta = append(delta, deltaCopy)
+		delta = binary.AppendUvarint(delta, uint64(off))
+		delta = binary.AppendUvarint(delta, uint64(length))
+		pos += length
+		pending = pos
+
+		if limit > 0 && len(delta) >= limit {
+			return nil
+		}
+	}
+	flush(len(target))
+
+	if limit > 0 && len(delta) >= limit {
+		return nil
+	}
+	return delta
+}
+
+// applyDelta восстанавливает target из base и дельты
+func applyDelta(base, delta []byte) ([]byte, error) {
+	baseSize, n := binary.Uvarint(delta)
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: bad header", errBadDelta)
+	}
+	delta = delta[n:]
+	targetSize, n := binary.Uvarint(delta)
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: bad header", errBadDelta)
+	}
+	delta = delta[n:]
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("%w: base size %d, expected %d", errBadDelta, len(base), baseSize)
+	}
+
+	// Заранее выделяем не больше разумного: размер из заголовка может быть поврежден
+	target := make([]byte, 0, min(targetSize, 64<<20))
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op == deltaCopy:
+			off, n := binary.Uvarint(delta)
+			if n <= 0 {
+				return nil, fmt.Errorf("%w: bad copy offset", errBadDelta)
+			}
+			delta = delta[n:]
+			length, n := binary.Uvarint(delta)
+			if n <= 0 {
+				return nil, fmt.Errorf("%w: bad copy length", errBadDelta)
+			}
+			delta = delta[n:]
+			if off > uint64(len(base)) || length > uint64(len(base))-off {
+				return nil, fmt.Errorf("%w: copy out of range", errBadDelta)
+			}
+			target = append(target, base[off:off+length]...)
+
+		case op > 0 && op <= deltaMaxInsert:
+			if int(op) > len(delta) {
+				return nil, fmt.Errorf("%w: truncated insert", errBadDelta)
+			}
+			target = append(target, delta[:op]...)
+			delta = delta[op:]
+
+		default:
+			return nil, fmt.Errorf("%w: unknown instruction 0x%02x", errBadDelta, op)
+		}
+
+		if uint64(len(target)) > targetSize {
+			return nil, fmt.Errorf("%w: result exceeds %d bytes", errBadDelta, targetSize)
+		}
+	}
+
+	if uint64(len(target)) != targetSize {
+		return nil, fmt.Errorf("%w: result is %d bytes, expected %d", errBadDelta, len(target), targetSize)
+	}
+	return target, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/utils"
+)
+
+// LooseObjects возвращает хеши всех объектов, хранящихся отдельными файлами
+func (store *ObjectStore) LooseObjects() ([]objects.Hash, error) {
+	dirs, err := os.ReadDir(store.objectsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objects directory: %w", err)
+	}
+
+	var hashes []objects.Hash
+	for _, dir := range dirs {
+		if !dir.IsDir() || !isHexPrefix(dir.Name()) {
+			continue
+		}
+		files, err := utils.ListFiles(filepath.Join(store.objectsDir, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range files {
+			if !strings.HasPrefix(name, "tmp-") {
+				hashes = append(hashes, objects.Hash(dir.Name()+name))
+			}
+		}
+	}
+	return hashes, nil
+}
+
+// StatLoose возвращает сведения о файле объекта (размер, время изменения)
+func (store *ObjectStore) StatLoose(hash objects.Hash) (os.FileInfo, error) {
+	objectPath, err := store.hashToPath(hash)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(objectPath)
+}
+
+// RemoveLoose удаляет файл объекта и опустевший каталог objects/xx
+func (store *ObjectStore) RemoveLoose(hash objects.Hash) error {
+	objectPath, err := store.hashToPath(hash)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(objectPath); err != nil {
+		return fmt.Errorf("failed to remove object %s: %w", hash, err)
+	}
+	// Непустой каталог Remove не удалит
+	os.Remove(filepath.Dir(objectPath))
+	return nil
+}
+
+// TempFiles возвращает пути временных файлов tmp-*, оставшихся в хранилище
+// после прерванной записи (utils.WriteFileAtomic, WriteStream, WritePack, WriteCommitGraph)
+func (store *ObjectStore) TempFiles() ([]string, error) {
+	dirs, err := os.ReadDir(store.objectsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objects directory: %w", err)
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		// WriteStream пишет в корень objects: хеш, а с ним и каталог, известен только в конце
+		if !dir.IsDir() && strings.HasPrefix(dir.Name(), "tmp-") {
+			paths = append(paths, filepath.Join(store.objectsDir, dir.Name()))
+			continue
+		}
+		if !dir.IsDir() || (!isHexPrefix(dir.Name()) && dir.Name() != "pack" && dir.Name() != "info") {
+			continue
+		}
+		files, err := utils.ListFiles(filepath.Join(store.objectsDir, dir.Name()))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, name := range files {
+			if strings.HasPrefix(name, "tmp-") {
+				paths = append(paths, filepath.Join(store.objectsDir, dir.Name(), name))
+			}
+		}
+	}
+	return paths, nil
+}
+
+// isHexPrefix проверяет, что имя - каталог objects/xx из двух hex-символов
+func isHexPrefix(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
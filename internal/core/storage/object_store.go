@@ -1,10 +1,16 @@
 package storage
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"sib/internal/core/objects"
 	"sib/internal/utils"
@@ -12,12 +18,27 @@ import (
 
 // ObjectStore представляет CAS-хранилище объектов
 type ObjectStore struct {
-	objectsDir string // Путь к директории objects (например, .sib/objects)
+	objectsDir  string      // Путь к директории objects (например, .sib/objects)
+	packs       []*packFile // Открытые индексы pack-файлов
+	packErrors  []error     // Ошибки чтения поврежденных индексов
+	packsLoaded bool        // Список pack-файлов уже читался
+
+	// ChunkThreshold - blob'ы от этого размера WriteStream записывает частями
+	// (см. objects.Manifest); 0 - всегда целиком
+	ChunkThreshold int64
 }
 
+// DefaultChunkThreshold - порог записи частями, если core.chunkThreshold не задан
+const DefaultChunkThreshold = 32 << 20
+
 // NewObjectStore создает новое хранилище объектов
 func NewObjectStore(repoPath string) (*ObjectStore, error) {
-	objectsDir := filepath.Join(repoPath, ".sib", "objects")
+	return OpenObjectStore(filepath.Join(repoPath, ".sib"))
+}
+
+// OpenObjectStore открывает хранилище объектов каталога репозитория sibDir
+func OpenObjectStore(sibDir string) (*ObjectStore, error) {
+	objectsDir := filepath.Join(sibDir, "objects")
 
 	// ПРОВЕРЯЕМ, что директория существует
 	if _, err := os.Stat(objectsDir); os.IsNotExist(err) {
@@ -63,35 +84,53 @@ func (store *ObjectStore) WriteObject(obj objects.Serializable) (objects.Hash, e
 	// Вычисляем SHA-256 хеш от сериализованных данных
 	hash := store.calculateHash(data)
 
+	if err := store.writeLoose(hash, data); err != nil {
+		return "", err
+	}
+
+	// Устанавливаем хеш в объект (если он поддерживает Hashable)
+	if hashable, ok := obj.(objects.Hashable); ok {
+		hashable.SetHash(hash)
+	}
+
+	return hash, nil
+}
+
+// writeLoose сохраняет сериализованные данные объекта отдельным файлом.
+// Уже упакованный объект повторно не пишется
+func (store *ObjectStore) writeLoose(hash objects.Hash, data []byte) error {
+	if !store.packsLoaded {
+		if err := store.loadPacks(); err != nil {
+			return err
+		}
+	}
+	if store.inPacks(hash) {
+		return nil
+	}
+
 	// Преобразуем хеш в путь к файлу (структура ab/cdef...)
 	objectPath, err := store.hashToPath(hash)
 	if err != nil {
-		return "", fmt.Errorf("failed to create object path: %w", err)
+		return fmt.Errorf("failed to create object path: %w", err)
 	}
 
 	// Создаем директорию, если её нет (только для первых двух символов хеша)
 	dir := filepath.Dir(objectPath)
 	if err := utils.CreateDirIfNotExists(dir); err != nil {
-		return "", fmt.Errorf("failed to create object directory: %w", err)
+		return fmt.Errorf("failed to create object directory: %w", err)
 	}
 
 	// Сжимаем данные с помощью Zstd
 	compressedData, err := utils.CompressZstd(data)
 	if err != nil {
-		return "", fmt.Errorf("failed to compress object: %w", err)
+		return fmt.Errorf("failed to compress object: %w", err)
 	}
 
 	// Атомарно записываем файл (чтобы избежать частичной записи)
 	if err := utils.WriteFileAtomic(objectPath, compressedData); err != nil {
-		return "", fmt.Errorf("failed to write object file: %w", err)
+		return fmt.Errorf("failed to write object file: %w", err)
 	}
-
-	// Устанавливаем хеш в объект (если он поддерживает Hashable)
-	if hashable, ok := obj.(objects.Hashable); ok {
-		hashable.SetHash(hash)
-	}
-
-	return hash, nil
+	return nil
 }
 
 // ReadObject читает объект из CAS-хранилища по хешу
@@ -102,22 +141,121 @@ func (store *ObjectStore) ReadObject(hash objects.Hash) (objects.Serializable, e
 		return nil, fmt.Errorf("hash cannot be empty")
 	}
 
-	// Преобразуем хеш в путь к файлу
-	objectPath, err := store.hashToPath(hash)
+	// Читаем данные из отдельного файла или pack-файла и проверяем хеш
+	data, err := store.readVerified(hash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create object path: %w", err)
+		return nil, err
 	}
 
-	// Читаем сжатые данные из файла
-	compressedData, err := utils.ReadFile(objectPath)
+	// Определяем тип объекта и десериализуем
+	objType, err := store.detectObjectType(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read object file: %w", err)
+		return nil, fmt.Errorf("failed to detect object type: %w", err)
 	}
 
-	// Декомпрессируем данные
-	data, err := utils.DecompressZstd(compressedData)
+	// Десериализуем объект в зависимости от типа
+	obj, err := store.deserializeByType(objType, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decompress object: %w", err)
+		return nil, fmt.Errorf("failed to deserialize object: %w", err)
+	}
+
+	// Устанавливаем хеш в объект
+	if hashable, ok := obj.(objects.Hashable); ok {
+		hashable.SetHash(hash)
+	}
+
+	return obj, nil
+}
+
+// CheckObject читает объект со строгой проверкой: хеш, заголовок "type size"
+// (объявленный размер должен совпадать с содержимым) и разбор содержимого.
+// Blob проверяется потоком и не загружается в память; obj для него - манифест,
+// если blob записан частями, иначе nil
+func (store *ObjectStore) CheckObject(hash objects.Hash) (objects.ObjectType, objects.Serializable, error) {
+	r, err := store.OpenObject(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	objType := r.Type()
+	if objType == objects.BlobObject {
+		_, err := io.Copy(io.Discard, r)
+		r.Close()
+		if err != nil {
+			return "", nil, err
+		}
+		manifest, err := store.ReadManifest(hash)
+		if err != nil || manifest == nil {
+			return objType, nil, err
+		}
+		return objType, manifest, nil
+	}
+	r.Close()
+
+	data, err := store.readVerified(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	objType, size, content, err := parseHeader(data)
+	if err != nil {
+		return "", nil, err
+	}
+	if size != int64(len(content)) {
+		return "", nil, fmt.Errorf("object %s: header declares %d bytes, content has %d", hash, size, len(content))
+	}
+
+	obj, err := store.deserializeByType(objType, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to deserialize object: %w", err)
+	}
+	if hashable, ok := obj.(objects.Hashable); ok {
+		hashable.SetHash(hash)
+	}
+	return objType, obj, nil
+}
+
+// parseHeader строго разбирает заголовок "type size\0" и возвращает содержимое после него
+func parseHeader(data []byte) (objects.ObjectType, int64, []byte, error) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return "", 0, nil, fmt.Errorf("object data malformed: no null byte separator found")
+	}
+	objType, size, err := parseHeaderLine(data[:end])
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return objType, size, data[end+1:], nil
+}
+
+// parseHeaderLine строго разбирает заголовок "type size" без нулевого байта
+func parseHeaderLine(header []byte) (objects.ObjectType, int64, error) {
+	typeName, sizeStr, ok := strings.Cut(string(header), " ")
+	objType := objects.ObjectType(typeName)
+	if !ok {
+		return "", 0, fmt.Errorf("malformed object header %q", header)
+	}
+	if err := objType.Validate(); err != nil && objType != objects.ChunkedObject {
+		return "", 0, fmt.Errorf("invalid object type in header: %w", err)
+	}
+	size, err := strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil || size < 0 || strconv.FormatInt(size, 10) != sizeStr {
+		return "", 0, fmt.Errorf("malformed object size %q", sizeStr)
+	}
+	return objType, size, nil
+}
+
+// readVerified читает сериализованный объект (отдельный файл или pack-файл)
+// и проверяет его целостность
+func (store *ObjectStore) readVerified(hash objects.Hash) ([]byte, error) {
+	data, err := store.readRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blob, записанный частями, собираем из кусков
+	if bytes.HasPrefix(data, []byte(objects.ChunkedObject+" ")) {
+		if data, err = store.assembleChunks(data); err != nil {
+			return nil, fmt.Errorf("object %s: %w", hash, err)
+		}
 	}
 
 	// Проверяем целостность: вычисляем хеш заново и сравниваем
@@ -125,25 +263,61 @@ func (store *ObjectStore) ReadObject(hash objects.Hash) (objects.Serializable, e
 	if calculatedHash != hash {
 		return nil, fmt.Errorf("object integrity check failed: expected %s, got %s", hash, calculatedHash)
 	}
+	return data, nil
+}
 
-	// Определяем тип объекта и десериализуем
-	objType, err := store.detectObjectType(data)
+// readRaw читает сериализованный объект так, как он хранится, без проверки хеша
+func (store *ObjectStore) readRaw(hash objects.Hash) ([]byte, error) {
+	data, err := store.readLoose(hash)
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = store.readPacked(hash)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect object type: %w", err)
+		return nil, fmt.Errorf("failed to read object file: %w", err)
 	}
+	return data, nil
+}
 
-	// Десериализуем объект в зависимости от типа
-	obj, err := store.deserializeByType(objType, data)
+// assembleChunks собирает сериализованный blob по данным манифеста
+func (store *ObjectStore) assembleChunks(manifestData []byte) ([]byte, error) {
+	manifest, err := objects.DeserializeManifest(manifestData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to deserialize object: %w", err)
+		return nil, err
+	}
+	data := objects.Header(objects.BlobObject, manifest.Size())
+	for _, chunk := range manifest.Chunks() {
+		blob, err := store.ReadBlob(chunk.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		if blob.Size() != chunk.Size() {
+			return nil, fmt.Errorf("chunk %s has %d bytes, expected %d", chunk.Hash(), blob.Size(), chunk.Size())
+		}
+		data = append(data, blob.Content()...)
 	}
+	return data, nil
+}
 
-	// Устанавливаем хеш в объект
-	if hashable, ok := obj.(objects.Hashable); ok {
-		hashable.SetHash(hash)
+// readLoose читает и распаковывает объект, хранящийся отдельным файлом
+func (store *ObjectStore) readLoose(hash objects.Hash) ([]byte, error) {
+	// Преобразуем хеш в путь к файлу
+	objectPath, err := store.hashToPath(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object path: %w", err)
 	}
 
-	return obj, nil
+	// Читаем сжатые данные из файла
+	compressedData, err := utils.ReadFile(objectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Декомпрессируем данные
+	data, err := utils.DecompressZstd(compressedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress object: %w", err)
+	}
+	return data, nil
 }
 
 // detectObjectType определяет тип объекта по его сериализованным данным
@@ -190,8 +364,7 @@ func (store *ObjectStore) deserializeByType(objType objects.ObjectType, data []b
 		return objects.DeserializeCommit(data)
 
 	case objects.TagObject:
-		// TODO: реализовать DeserializeTag когда будешь делать теги
-		return nil, fmt.Errorf("tag deserialization not implemented yet")
+		return objects.DeserializeTag(data)
 
 	default:
 		return nil, fmt.Errorf("unsupported object type: %s", objType)
@@ -204,5 +377,52 @@ func (store *ObjectStore) ObjectExists(hash objects.Hash) bool {
 	if err != nil {
 		return false
 	}
-	return utils.FileExists(objectPath)
+	if utils.FileExists(objectPath) {
+		return true
+	}
+	p, _, err := store.findPacked(hash)
+	return err == nil && p != nil
+}
+
+// FindByPrefix возвращает все хеши объектов, начинающиеся с prefix
+// Сканирует только одну fan-out директорию objects/xx/ и тот же диапазон индексов pack-файлов
+func (store *ObjectStore) FindByPrefix(prefix string) ([]objects.Hash, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < 2 {
+		return nil, fmt.Errorf("hash prefix too short: %s", prefix)
+	}
+
+	dirName := prefix[:2]
+	rest := prefix[2:]
+
+	files, err := utils.ListFiles(filepath.Join(store.objectsDir, dirName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := store.loadPacks(); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[objects.Hash]bool)
+	var matches []objects.Hash
+	for _, p := range store.packs {
+		for _, hash := range p.findPrefix(prefix) {
+			if !seen[hash] {
+				seen[hash] = true
+				matches = append(matches, hash)
+			}
+		}
+	}
+	for _, name := range files {
+		if strings.HasPrefix(name, "tmp-") {
+			continue
+		}
+		if hash := objects.Hash(dirName + name); strings.HasPrefix(name, rest) && !seen[hash] {
+			seen[hash] = true
+			matches = append(matches, hash)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i] < matches[j] })
+
+	return matches, nil
 }
@@ -375,6 +375,52 @@ func TestWriteAndReadCommit(t *testing.T) {
 	}
 }
 
+// TestWriteAndReadTag проверяет, что аннотированный тег сохраняется вместе с автором
+func TestWriteAndReadTag(t *testing.T) {
+	store, _ := initTestStore(t)
+
+	blobHash, err := store.WriteObject(objects.NewBlob([]byte("tagged")))
+	if err != nil {
+		t.Fatalf("Failed to write blob: %v", err)
+	}
+
+	when := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	tagger, err := objects.NewSignature("Tag Author", "tagger@example.com", when)
+	if err != nil {
+		t.Fatalf("Failed to create tagger: %v", err)
+	}
+
+	tag, err := objects.NewTag(blobHash, objects.BlobObject, "v1.0", *tagger, "Release 1.0\n")
+	if err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+
+	tagHash, err := store.WriteObject(tag)
+	if err != nil {
+		t.Fatalf("WriteObject failed for tag: %v", err)
+	}
+
+	readTag, err := store.ReadTag(tagHash)
+	if err != nil {
+		t.Fatalf("ReadTag failed: %v", err)
+	}
+
+	if readTag.Object() != blobHash || readTag.ObjectType() != objects.BlobObject {
+		t.Errorf("Target mismatch: %s %s", readTag.ObjectType(), readTag.Object())
+	}
+	if readTag.TagName() != "v1.0" || readTag.Message() != "Release 1.0\n" {
+		t.Errorf("Name or message mismatch: %q %q", readTag.TagName(), readTag.Message())
+	}
+
+	got := readTag.Tagger()
+	if got.Name() != "Tag Author" || got.Email() != "tagger@example.com" || !got.Time().Equal(when) {
+		t.Errorf("Tagger not preserved: %s <%s> %v", got.Name(), got.Email(), got.Time())
+	}
+	if readTag.Hash() != tagHash {
+		t.Errorf("Hash not set: %s", readTag.Hash())
+	}
+}
+
 // TestObjectExists проверяет проверку существования объектов
 func TestObjectExists(t *testing.T) {
 	store, _ := initTestStore(t)
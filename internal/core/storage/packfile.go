@@ -1,18 +1,823 @@
 package storage
 
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sib/internal/core/objects"
+	"sib/internal/utils"
+)
+
 /*
-Про packfile.go:
-Packfile тебе пока не нужен. Это оптимизация для:
+Pack-файлы хранят множество объектов в одном файле вместо отдельных
+файлов objects/xx/... - это экономит inode и ускоряет обход хранилища.
+
+Pack-файл objects/pack/pack-<sha256>.pack:
+
+	"SPCK" <версия uint32> <число объектов uint32>
+	<записи объектов>
+	<SHA-256 всего предыдущего содержимого>
+
+Запись объекта:
 
-	-Упаковки множества объектов в один файл
-	-Уменьшения количества inode's на диске
-	-Эффективной передачи по сети
+	<вид byte> <размер данных uvarint> [<расстояние до базы uvarint>] <размер сжатого uvarint> <zstd>
 
-Когда понадобится:
+Вид packWhole - сериализованный объект целиком (с заголовком "type size\0"),
+packDelta - дельта (см. delta.go) к записи, лежащей на заданное расстояние
+раньше в том же pack-файле.
 
-	-Когда у тебя будут тысячи объектов
-	-Когда будешь делать сетевые операции (clone/fetch/push)
-	-Когда заметишь проблемы с производительностью
+Индекс objects/pack/pack-<sha256>.idx:
 
-Сейчас сосредоточься на: базовых командах (init, add, commit), а packfile добавишь в Фазе 4 (распределенность).
+	"SIDX" <версия uint32>
+	<fan-out: 256 x uint32 - число объектов с первым байтом хеша <= i>
+	<хеши: N x 32 байта по возрастанию>
+	<смещения записей: N x uint64>
+	<SHA-256 pack-файла> <SHA-256 индекса>
+
+Fan-out сужает двоичный поиск до объектов с тем же первым байтом хеша.
+Все числа фиксированной длины записаны в big-endian.
 */
+
+const (
+	packMagic   = "SPCK"
+	idxMagic    = "SIDX"
+	packVersion = 1
+
+	packWhole byte = 1 // Запись содержит объект целиком
+	packDelta byte = 2 // Запись содержит дельту к более ранней записи
+
+	packWindow   = 10 // Сколько предыдущих объектов пробуем как базу дельты
+	packMaxDepth = 50 // Максимальная длина цепочки дельт
+	packMinDelta = 64 // Объекты меньше этого размера дельтой не сжимаем
+
+	// Объекты больше этого размера пишутся целиком потоком, без поиска дельты:
+	// и объект, и окно кандидатов пришлось бы держать в памяти
+	packMaxBuffered = 16 << 20
+
+	hashSize     = sha256.Size
+	packHeader   = 12                          // magic + версия + число объектов
+	idxHeader    = 8 + 256*4                   // magic + версия + fan-out
+	maxEntryHead = 1 + 3*binary.MaxVarintLen64 // Максимальный размер заголовка записи
+)
+
+// packFile - открытый индекс pack-файла. Сам pack-файл открывается при чтении
+type packFile struct {
+	name    string         // pack-<sha256>
+	path    string         // Путь к .pack
+	fanout  [256]uint32    // fanout[i] - число объектов с первым байтом хеша <= i
+	hashes  []byte         // Хеши объектов подряд, по hashSize байт
+	offsets []uint64       // Смещения записей в pack-файле
+	sum     [hashSize]byte // Контрольная сумма pack-файла
+}
+
+// openPackFile читает индекс pack-файла и проверяет его контрольную сумму
+func openPackFile(idxPath string) (*packFile, error) {
+	data, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack index: %w", err)
+	}
+	if len(data) < idxHeader+2*hashSize || string(data[:4]) != idxMagic {
+		return nil, fmt.Errorf("pack index %s is corrupt: bad header", idxPath)
+	}
+	if version := binary.BigEndian.Uint32(data[4:8]); version != packVersion {
+		return nil, fmt.Errorf("pack index %s has unsupported version %d", idxPath, version)
+	}
+	body := data[:len(data)-hashSize]
+	if sum := sha256.Sum256(body); !bytes.Equal(sum[:], data[len(body):]) {
+		return nil, fmt.Errorf("pack index %s is corrupt: checksum mismatch", idxPath)
+	}
+
+	p := &packFile{
+		name: strings.TrimSuffix(filepath.Base(idxPath), ".idx"),
+		path: strings.TrimSuffix(idxPath, ".idx") + ".pack",
+	}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(data[8+4*i:])
+		if i > 0 && p.fanout[i] < p.fanout[i-1] {
+			return nil, fmt.Errorf("pack index %s is corrupt: bad fan-out table", idxPath)
+		}
+	}
+
+	count := int(p.fanout[255])
+	if len(data) != idxHeader+count*(hashSize+8)+2*hashSize {
+		return nil, fmt.Errorf("pack index %s is corrupt: bad size", idxPath)
+	}
+	pos := idxHeader
+	p.hashes = data[pos : pos+count*hashSize]
+	pos += count * hashSize
+	p.offsets = make([]uint64, count)
+	for i := range p.offsets {
+		p.offsets[i] = binary.BigEndian.Uint64(data[pos+8*i:])
+	}
+	pos += count * 8
+	copy(p.sum[:], data[pos:])
+
+	return p, nil
+}
+
+// count возвращает число объектов в pack-файле
+func (p *packFile) count() int {
+	return len(p.offsets)
+}
+
+// hashAt возвращает хеш i-го объекта индекса
+func (p *packFile) hashAt(i int) objects.Hash {
+	return objects.Hash(hex.EncodeToString(p.hashes[i*hashSize : (i+1)*hashSize]))
+}
+
+// find ищет объект двоичным поиском в диапазоне fan-out
+func (p *packFile) find(hash objects.Hash) (int, bool) {
+	raw, err := hex.DecodeString(hash.String())
+	if err != nil || len(raw) != hashSize {
+		return 0, false
+	}
+
+	lo := 0
+	if raw[0] > 0 {
+		lo = int(p.fanout[raw[0]-1])
+	}
+	hi := int(p.fanout[raw[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.hashes[(lo+i)*hashSize:(lo+i+1)*hashSize], raw) >= 0
+	})
+	if i < hi && bytes.Equal(p.hashes[i*hashSize:(i+1)*hashSize], raw) {
+		return i, true
+	}
+	return 0, false
+}
+
+// findPrefix возвращает хеши объектов, начинающиеся с prefix (не короче 2 символов)
+func (p *packFile) findPrefix(prefix string) []objects.Hash {
+	first, err := hex.DecodeString(prefix[:2])
+	if err != nil {
+		return nil
+	}
+
+	lo := 0
+	if first[0] > 0 {
+		lo = int(p.fanout[first[0]-1])
+	}
+	var matches []objects.Hash
+	for i := lo; i < int(p.fanout[first[0]]); i++ {
+		if hash := p.hashAt(i); strings.HasPrefix(hash.String(), prefix) {
+			matches = append(matches, hash)
+		}
+	}
+	return matches
+}
+
+// read возвращает сериализованные данные i-го объекта, разворачивая цепочку дельт
+func (p *packFile) read(i int) ([]byte, error) {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack file: %w", err)
+	}
+	if info.Size() < packHeader+hashSize {
+		return nil, fmt.Errorf("pack %s is truncated", p.name)
+	}
+
+	// Записи не заходят на контрольную сумму в конце файла
+	return p.readEntry(io.NewSectionReader(file, 0, info.Size()-hashSize), p.offsets[i], 0)
+}
+
+// entryHeader - разобранный заголовок записи pack-файла
+type entryHeader struct {
+	kind           byte
+	size           uint64 // Размер распакованных данных
+	distance       uint64 // Для дельты - расстояние до записи базы
+	dataOffset     int64  // Начало сжатых данных
+	compressedSize int64
+}
+
+// readEntryHeader читает заголовок записи по смещению offset
+func (p *packFile) readEntryHeader(r *io.SectionReader, offset uint64) (entryHeader, error) {
+	head := make([]byte, maxEntryHead)
+	n, err := r.ReadAt(head, int64(offset))
+	if n == 0 && err != nil {
+		return entryHeader{}, fmt.Errorf("pack %s: failed to read entry at %d: %w", p.name, offset, err)
+	}
+	head = head[:n]
+
+	kind := head[0]
+	pos := 1
+	next := func() (uint64, bool) {
+		v, n := binary.Uvarint(head[pos:])
+		if n <= 0 {
+			return 0, false
+		}
+		pos += n
+		return v, true
+	}
+
+	size, ok := next()
+	var distance uint64
+	if ok && kind == packDelta {
+		distance, ok = next()
+		ok = ok && distance > 0 && distance <= offset
+	}
+	compressedSize, ok2 := next()
+	if !ok || !ok2 || (kind != packWhole && kind != packDelta) || compressedSize > uint64(r.Size())-offset {
+		return entryHeader{}, fmt.Errorf("pack %s: corrupt entry header at offset %d", p.name, offset)
+	}
+	return entryHeader{
+		kind:           kind,
+		size:           size,
+		distance:       distance,
+		dataOffset:     int64(offset) + int64(pos),
+		compressedSize: int64(compressedSize),
+	}, nil
+}
+
+// readEntry читает запись по смещению offset
+func (p *packFile) readEntry(r *io.SectionReader, offset uint64, depth int) ([]byte, error) {
+	if depth > packMaxDepth {
+		return nil, fmt.Errorf("pack %s: delta chain too long at offset %d", p.name, offset)
+	}
+
+	header, err := p.readEntryHeader(r, offset)
+	if err != nil {
+		return nil, err
+	}
+	compressed := make([]byte, header.compressedSize)
+	if _, err := r.ReadAt(compressed, header.dataOffset); err != nil {
+		return nil, fmt.Errorf("pack %s: failed to read entry at %d: %w", p.name, offset, err)
+	}
+	data, err := utils.DecompressZstd(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: failed to decompress entry at %d: %w", p.name, offset, err)
+	}
+	if uint64(len(data)) != header.size {
+		return nil, fmt.Errorf("pack %s: entry at %d has size %d, expected %d", p.name, offset, len(data), header.size)
+	}
+
+	if header.kind == packWhole {
+		return data, nil
+	}
+	base, err := p.readEntry(r, offset-header.distance, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	result, err := applyDelta(base, data)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: entry at %d: %w", p.name, offset, err)
+	}
+	return result, nil
+}
+
+// open открывает i-й объект для потокового чтения. Целая запись распаковывается
+// по мере чтения; дельта восстанавливается в памяти - дельтами хранятся только
+// объекты не больше packMaxBuffered
+func (p *packFile) open(i int) (io.Reader, func() error, error) {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pack file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open pack file: %w", err)
+	}
+	if info.Size() < packHeader+hashSize {
+		file.Close()
+		return nil, nil, fmt.Errorf("pack %s is truncated", p.name)
+	}
+	r := io.NewSectionReader(file, 0, info.Size()-hashSize)
+
+	header, err := p.readEntryHeader(r, p.offsets[i])
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if header.kind != packWhole {
+		data, err := p.readEntry(r, p.offsets[i], 0)
+		file.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		return bytes.NewReader(data), func() error { return nil }, nil
+	}
+
+	decompressor, err := utils.NewZstdReader(io.NewSectionReader(r, header.dataOffset, header.compressedSize))
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("pack %s: failed to decompress entry at %d: %w", p.name, p.offsets[i], err)
+	}
+	return decompressor, func() error {
+		decompressor.Close()
+		return file.Close()
+	}, nil
+}
+
+// packDir возвращает каталог pack-файлов
+func (store *ObjectStore) packDir() string {
+	return filepath.Join(store.objectsDir, "pack")
+}
+
+// loadPacks перечитывает список pack-файлов; уже открытые индексы не перечитываются.
+// Поврежденные индексы пропускаются и запоминаются в packErrors
+func (store *ObjectStore) loadPacks() error {
+	names, err := utils.ListFiles(store.packDir())
+	if errors.Is(err, os.ErrNotExist) {
+		store.packs, store.packErrors = nil, nil
+		store.packsLoaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]*packFile, len(store.packs))
+	for _, p := range store.packs {
+		loaded[p.name] = p
+	}
+
+	var packs []*packFile
+	var packErrors []error
+	for _, name := range names {
+		if !strings.HasPrefix(name, "pack-") || !strings.HasSuffix(name, ".idx") {
+			continue
+		}
+		if p, ok := loaded[strings.TrimSuffix(name, ".idx")]; ok {
+			packs = append(packs, p)
+			continue
+		}
+		p, err := openPackFile(filepath.Join(store.packDir(), name))
+		if err != nil {
+			packErrors = append(packErrors, err)
+			continue
+		}
+		packs = append(packs, p)
+	}
+	store.packs, store.packErrors = packs, packErrors
+	store.packsLoaded = true
+	return nil
+}
+
+// findPacked ищет объект в pack-файлах. Если объекта нет, список pack-файлов
+// перечитывается: другой процесс мог упаковать объект и удалить его файл
+func (store *ObjectStore) findPacked(hash objects.Hash) (*packFile, int, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 || !store.packsLoaded {
+			if err := store.loadPacks(); err != nil {
+				return nil, 0, err
+			}
+		}
+		for _, p := range store.packs {
+			if i, ok := p.find(hash); ok {
+				return p, i, nil
+			}
+		}
+	}
+	// Объект мог быть в pack-файле с поврежденным индексом
+	if len(store.packErrors) > 0 {
+		return nil, 0, errors.Join(store.packErrors...)
+	}
+	return nil, 0, nil
+}
+
+// inPacks проверяет наличие объекта в уже загруженных pack-файлах
+func (store *ObjectStore) inPacks(hash objects.Hash) bool {
+	for _, p := range store.packs {
+		if _, ok := p.find(hash); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readPacked читает сериализованные данные объекта из pack-файлов
+func (store *ObjectStore) readPacked(hash objects.Hash) ([]byte, error) {
+	p, i, err := store.findPacked(hash)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("object %s not found: %w", hash, os.ErrNotExist)
+	}
+	return p.read(i)
+}
+
+// packObject - объект, отобранный для записи в pack-файл
+type packObject struct {
+	hash    objects.Hash
+	objType objects.ObjectType
+	size    int64 // Размер сериализованного объекта с заголовком
+}
+
+// windowEntry - недавно записанный объект, кандидат в базы дельт
+type windowEntry struct {
+	packObject
+	data   []byte
+	index  *deltaIndex // Строится при первой попытке дельты
+	offset uint64
+	depth  int
+}
+
+// WritePack упаковывает объекты hashes (свободные или уже упакованные) в новый
+// pack-файл и возвращает его имя. Похожие объекты одного типа хранятся дельтами
+// друг к другу. Исходные файлы объектов не удаляются - см. PrunePacked
+func (store *ObjectStore) WritePack(hashes []objects.Hash) (string, error) {
+	// Первый проход: тип и размер каждого объекта, чтобы поставить похожие рядом
+	seen := make(map[objects.Hash]bool, len(hashes))
+	list := make([]packObject, 0, len(hashes))
+	for _, hash := range hashes {
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		// Достаточно заголовка: содержимое проверится при записи.
+		// Blob, записанный частями, упаковывается как есть - манифестом
+		raw, err := store.openRaw(hash)
+		if err != nil {
+			return "", err
+		}
+		raw.close()
+		list = append(list, packObject{hash: hash, objType: raw.objType, size: int64(len(raw.header)) + raw.size})
+	}
+	if len(list) == 0 {
+		return "", fmt.Errorf("no objects to pack")
+	}
+
+	// Крупные объекты первыми: дельта, удаляющая данные, обычно меньше добавляющей
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].objType != list[j].objType {
+			return list[i].objType < list[j].objType
+		}
+		return list[i].size > list[j].size
+	})
+
+	if err := utils.CreateDirIfNotExists(store.packDir()); err != nil {
+		return "", fmt.Errorf("failed to create pack directory: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(store.packDir(), "tmp-pack-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create pack file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+	}()
+
+	// Второй проход: записи объектов
+	sum := sha256.New()
+	out := bufio.NewWriter(io.MultiWriter(tmpFile, sum))
+	header := make([]byte, packHeader)
+	copy(header, packMagic)
+	binary.BigEndian.PutUint32(header[4:], packVersion)
+	binary.BigEndian.PutUint32(header[8:], uint32(len(list)))
+	if _, err := out.Write(header); err != nil {
+		return "", fmt.Errorf("failed to write pack file: %w", err)
+	}
+
+	offset := uint64(packHeader)
+	offsets := make(map[objects.Hash]uint64, len(list))
+	var window []*windowEntry
+	for _, obj := range list {
+		if obj.size > packMaxBuffered && obj.objType != objects.ChunkedObject {
+			n, err := store.writeStreamedEntry(out, obj)
+			if err != nil {
+				return "", err
+			}
+			offsets[obj.hash] = offset
+			offset += n
+			continue
+		}
+
+		data, err := store.readPackable(obj)
+		if err != nil {
+			return "", err
+		}
+
+		kind, payload, depth := packWhole, data, 0
+		var base *windowEntry
+		if len(data) >= packMinDelta {
+			base, payload = bestDelta(window, obj, data)
+			if base != nil {
+				kind, depth = packDelta, base.depth+1
+			} else {
+				payload = data
+			}
+		}
+
+		compressed, err := utils.CompressZstd(payload)
+		if err != nil {
+			return "", fmt.Errorf("failed to compress object: %w", err)
+		}
+		entry := []byte{kind}
+		entry = binary.AppendUvarint(entry, uint64(len(payload)))
+		if base != nil {
+			entry = binary.AppendUvarint(entry, offset-base.offset)
+		}
+		entry = binary.AppendUvarint(entry, uint64(len(compressed)))
+		if _, err := out.Write(entry); err != nil {
+			return "", fmt.Errorf("failed to write pack file: %w", err)
+		}
+		if _, err := out.Write(compressed); err != nil {
+			return "", fmt.Errorf("failed to write pack file: %w", err)
+		}
+
+		offsets[obj.hash] = offset
+		window = append(window, &windowEntry{packObject: obj, data: data, offset: offset, depth: depth})
+		if len(window) > packWindow {
+			window = window[1:]
+		}
+		offset += uint64(len(entry) + len(compressed))
+	}
+
+	if err := out.Flush(); err != nil {
+		return "", fmt.Errorf("failed to write pack file: %w", err)
+	}
+	packSum := sum.Sum(nil)
+	if _, err := tmpFile.Write(packSum); err != nil {
+		return "", fmt.Errorf("failed to write pack file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write pack file: %w", err)
+	}
+
+	// Pack-файл кладем раньше индекса: читатели видят только pack-файлы с индексом
+	name := "pack-" + hex.EncodeToString(packSum)
+	packPath := filepath.Join(store.packDir(), name+".pack")
+	if err := os.Rename(tmpPath, packPath); err != nil {
+		return "", fmt.Errorf("failed to write pack file: %w", err)
+	}
+	if err := utils.WriteFileAtomic(filepath.Join(store.packDir(), name+".idx"), buildPackIndex(offsets, packSum)); err != nil {
+		return "", fmt.Errorf("failed to write pack index: %w", err)
+	}
+
+	if err := store.loadPacks(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// readPackable читает объект для записи в pack-файл в том виде, в каком он
+// хранится. Хеш манифеста не совпадает с его данными, поэтому манифест
+// проверяется разбором, а не хешем
+func (store *ObjectStore) readPackable(obj packObject) ([]byte, error) {
+	if obj.objType != objects.ChunkedObject {
+		return store.readVerified(obj.hash)
+	}
+	data, err := store.readRaw(obj.hash)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := objects.DeserializeManifest(data); err != nil {
+		return nil, fmt.Errorf("object %s: %w", obj.hash, err)
+	}
+	return data, nil
+}
+
+// writeStreamedEntry записывает объект целой записью, не загружая его в память.
+// Сжатые данные сначала пишутся во временный файл: их размер нужен
+// в заголовке записи раньше самих данных. Возвращает размер записи
+func (store *ObjectStore) writeStreamedEntry(out io.Writer, obj packObject) (uint64, error) {
+	r, err := store.OpenObject(obj.hash)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	tmpFile, err := os.CreateTemp(store.packDir(), "tmp-entry-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create pack file: %w", err)
+	}
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}()
+
+	compressor, err := utils.NewZstdWriter(tmpFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compress object: %w", err)
+	}
+	if _, err := compressor.Write(objects.Header(r.Type(), r.Size())); err != nil {
+		return 0, fmt.Errorf("failed to compress object: %w", err)
+	}
+	// Хеш проверяется по мере чтения: поврежденный объект в pack-файл не попадет
+	if _, err := io.Copy(compressor, r); err != nil {
+		return 0, err
+	}
+	if err := compressor.Close(); err != nil {
+		return 0, fmt.Errorf("failed to compress object: %w", err)
+	}
+	compressedSize, err := tmpFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write pack file: %w", err)
+	}
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to write pack file: %w", err)
+	}
+
+	entry := []byte{packWhole}
+	entry = binary.AppendUvarint(entry, uint64(obj.size))
+	entry = binary.AppendUvarint(entry, uint64(compressedSize))
+	if _, err := out.Write(entry); err != nil {
+		return 0, fmt.Errorf("failed to write pack file: %w", err)
+	}
+	if _, err := io.Copy(out, tmpFile); err != nil {
+		return 0, fmt.Errorf("failed to write pack file: %w", err)
+	}
+	return uint64(len(entry)) + uint64(compressedSize), nil
+}
+
+// bestDelta выбирает из окна базу с самой короткой дельтой. Дельта
+// принимается, только если она меньше половины объекта
+func bestDelta(window []*windowEntry, obj packObject, data []byte) (*windowEntry, []byte) {
+	var best *windowEntry
+	var bestPayload []byte
+	limit := len(data) / 2
+
+	for i := len(window) - 1; i >= 0; i-- {
+		candidate := window[i]
+		if candidate.objType != obj.objType || candidate.depth >= packMaxDepth || len(candidate.data) < packMinDelta {
+			continue
+		}
+		if candidate.index == nil {
+			candidate.index = newDeltaIndex(candidate.data)
+		}
+		if delta := candidate.index.computeDelta(data, limit); delta != nil {
+			best, bestPayload, limit = candidate, delta, len(delta)
+		}
+	}
+	return best, bestPayload
+}
+
+// buildPackIndex строит индекс pack-файла по смещениям записей
+func buildPackIndex(offsets map[objects.Hash]uint64, packSum []byte) []byte {
+	raw := make([][]byte, 0, len(offsets))
+	byRaw := make(map[string]uint64, len(offsets))
+	for hash, offset := range offsets {
+		decoded, _ := hex.DecodeString(hash.String())
+		raw = append(raw, decoded)
+		byRaw[string(decoded)] = offset
+	}
+	sort.Slice(raw, func(i, j int) bool { return bytes.Compare(raw[i], raw[j]) < 0 })
+
+	var fanout [256]uint32
+	for _, hash := range raw {
+		fanout[hash[0]]++
+	}
+	for i := 1; i < 256; i++ {
+		fanout[i] += fanout[i-1]
+	}
+
+	data := make([]byte, 0, idxHeader+len(raw)*(hashSize+8)+2*hashSize)
+	data = append(data, idxMagic...)
+	data = binary.BigEndian.AppendUint32(data, packVersion)
+	for _, n := range fanout {
+		data = binary.BigEndian.AppendUint32(data, n)
+	}
+	for _, hash := range raw {
+		data = append(data, hash...)
+	}
+	for _, hash := range raw {
+		data = binary.BigEndian.AppendUint64(data, byRaw[string(hash)])
+	}
+	data = append(data, packSum...)
+	sum := sha256.Sum256(data)
+	return append(data, sum[:]...)
+}
+
+// PackCheck - результат проверки одного pack-файла
+type PackCheck struct {
+	Name    string         // pack-<sha256>
+	Objects []objects.Hash // Объекты из индекса (пусто, если индекс не читается)
+	Err     error          // Найденная проблема; nil - pack-файл и индекс целы
+}
+
+// VerifyPacks проверяет каждый pack-файл целиком: индекс, заголовок, число
+// объектов и контрольную сумму, а также что индекс построен для этого pack-файла.
+// Сами объекты не разбираются - для этого есть CheckObject
+func (store *ObjectStore) VerifyPacks() ([]PackCheck, error) {
+	names, err := utils.ListFiles(store.packDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []PackCheck
+	for _, name := range names {
+		if !strings.HasPrefix(name, "pack-") || !strings.HasSuffix(name, ".idx") {
+			continue
+		}
+		check := PackCheck{Name: strings.TrimSuffix(name, ".idx")}
+		p, err := openPackFile(filepath.Join(store.packDir(), name))
+		if err == nil {
+			for i := 0; i < p.count(); i++ {
+				check.Objects = append(check.Objects, p.hashAt(i))
+			}
+			err = p.verify()
+		}
+		check.Err = err
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// verify проверяет pack-файл против его индекса
+func (p *packFile) verify() error {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to open pack file: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, packHeader)
+	if _, err := io.ReadFull(file, header); err != nil || string(header[:4]) != packMagic {
+		return fmt.Errorf("pack %s: bad header", p.name)
+	}
+	if version := binary.BigEndian.Uint32(header[4:]); version != packVersion {
+		return fmt.Errorf("pack %s: unsupported version %d", p.name, version)
+	}
+	if count := binary.BigEndian.Uint32(header[8:]); int(count) != p.count() {
+		return fmt.Errorf("pack %s: has %d objects, index lists %d", p.name, count, p.count())
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < packHeader+hashSize {
+		return fmt.Errorf("pack %s is truncated", p.name)
+	}
+	sum := sha256.New()
+	sum.Write(header)
+	if _, err := io.CopyN(sum, file, info.Size()-hashSize-packHeader); err != nil {
+		return fmt.Errorf("pack %s: %w", p.name, err)
+	}
+	trailer := make([]byte, hashSize)
+	if _, err := io.ReadFull(file, trailer); err != nil {
+		return fmt.Errorf("pack %s: %w", p.name, err)
+	}
+	if !bytes.Equal(sum.Sum(nil), trailer) {
+		return fmt.Errorf("pack %s: checksum mismatch", p.name)
+	}
+	if !bytes.Equal(trailer, p.sum[:]) {
+		return fmt.Errorf("pack %s: index belongs to a different pack file", p.name)
+	}
+	for i, offset := range p.offsets {
+		if offset < packHeader || offset >= uint64(info.Size()-hashSize) {
+			return fmt.Errorf("pack %s: object %s has bad offset %d", p.name, p.hashAt(i), offset)
+		}
+	}
+	return nil
+}
+
+// PackedObjects возвращает хеши всех объектов из pack-файлов
+func (store *ObjectStore) PackedObjects() ([]objects.Hash, error) {
+	if err := store.loadPacks(); err != nil {
+		return nil, err
+	}
+	var hashes []objects.Hash
+	for _, p := range store.packs {
+		for i := 0; i < p.count(); i++ {
+			hashes = append(hashes, p.hashAt(i))
+		}
+	}
+	return hashes, nil
+}
+
+// PrunePacked удаляет файлы объектов, которые уже есть в pack-файлах.
+// Возвращает число удаленных файлов
+func (store *ObjectStore) PrunePacked() (int, error) {
+	loose, err := store.LooseObjects()
+	if err != nil {
+		return 0, err
+	}
+	if err := store.loadPacks(); err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, hash := range loose {
+		if !store.inPacks(hash) {
+			continue
+		}
+		if err := store.RemoveLoose(hash); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+)
+
+// similarVersions возвращает несколько версий большого файла с небольшими правками
+func similarVersions(n int) [][]byte {
+	rng := rand.New(rand.NewSource(1))
+	base := make([]byte, 8192)
+	rng.Read(base)
+
+	versions := [][]byte{base}
+	for i := 1; i < n; i++ {
+		prev := versions[i-1]
+		next := append([]byte{}, prev[:1000*i]...)
+		next = append(next, fmt.Sprintf("edit %d", i)...)
+		next = append(next, prev[1000*i+50:]...)
+		versions = append(versions, next)
+	}
+	return versions
+}
+
+func TestDelta(t *testing.T) {
+	versions := similarVersions(3)
+	cases := []struct{ base, target []byte }{
+		{versions[0], versions[1]},
+		{versions[2], versions[0]},
+		{[]byte("short"), []byte("completely different")},
+		{versions[0], nil},
+		{nil, versions[1][:300]},
+	}
+
+	for i, tc := range cases {
+		delta := newDeltaIndex(tc.base).computeDelta(tc.target, 0)
+		got, err := applyDelta(tc.base, delta)
+		if err != nil {
+			t.Fatalf("case %d: applyDelta failed: %v", i, err)
+		}
+		if !bytes.Equal(got, tc.target) {
+			t.Errorf("case %d: result differs from target", i)
+		}
+	}
+
+	delta := newDeltaIndex(versions[0]).computeDelta(versions[1], 0)
+	if len(delta) > 200 {
+		t.Errorf("Delta of a small edit is %d bytes", len(delta))
+	}
+	if newDeltaIndex(versions[0]).computeDelta(versions[1], 10) != nil {
+		t.Error("Delta above the limit should be rejected")
+	}
+	if _, err := applyDelta(versions[1], delta); !errors.Is(err, errBadDelta) {
+		t.Errorf("Delta applied to the wrong base: got %v", err)
+	}
+	if _, err := applyDelta(versions[0], delta[:len(delta)-1]); !errors.Is(err, errBadDelta) {
+		t.Errorf("Truncated delta: got %v", err)
+	}
+}
+
+func TestWritePack(t *testing.T) {
+	store, _ := initTestStore(t)
+
+	var hashes []objects.Hash
+	looseSize := int64(0)
+	for _, content := range similarVersions(5) {
+		hash, err := store.WriteObject(objects.NewBlob(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+		path, _ := store.hashToPath(hash)
+		info, _ := os.Stat(path)
+		looseSize += info.Size()
+	}
+	small, _ := store.WriteObject(objects.NewBlob([]byte("small\n")))
+	hashes = append(hashes, small)
+
+	tree := objects.NewTree()
+	entry, _ := objects.NewTreeEntry(objects.FileModeRegular, "a.bin", hashes[0], objects.BlobObject)
+	tree.AddEntry(*entry)
+	treeHash, err := store.WriteObject(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes = append(hashes, treeHash)
+
+	name, err := store.WritePack(hashes)
+	if err != nil {
+		t.Fatalf("WritePack failed: %v", err)
+	}
+	removed, err := store.PrunePacked()
+	if err != nil || removed != len(hashes) {
+		t.Fatalf("PrunePacked = %d, %v; want %d", removed, err, len(hashes))
+	}
+	if loose, _ := store.LooseObjects(); len(loose) != 0 {
+		t.Errorf("Loose objects left: %v", loose)
+	}
+
+	// Похожие версии хранятся дельтами, поэтому pack-файл заметно меньше
+	info, err := os.Stat(filepath.Join(store.packDir(), name+".pack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > looseSize/2 {
+		t.Errorf("Pack is %d bytes, loose objects were %d", info.Size(), looseSize)
+	}
+
+	// Чтение идет прозрачно из pack-файла, в том числе новым экземпляром хранилища
+	fresh, err := OpenObjectStore(filepath.Dir(store.objectsDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, content := range similarVersions(5) {
+		obj, err := fresh.ReadObject(hashes[i])
+		if err != nil {
+			t.Fatalf("ReadObject from pack failed: %v", err)
+		}
+		if !bytes.Equal(obj.(*objects.Blob).Content(), content) {
+			t.Errorf("Blob %d content differs", i)
+		}
+	}
+	if _, err := fresh.ReadTree(treeHash); err != nil {
+		t.Errorf("ReadTree from pack failed: %v", err)
+	}
+	if !fresh.ObjectExists(small) || fresh.ObjectExists(objects.Hash(strings.Repeat("0", 64))) {
+		t.Error("ObjectExists does not see packed objects correctly")
+	}
+	if matches, err := fresh.FindByPrefix(small.String()[:6]); err != nil || len(matches) != 1 || matches[0] != small {
+		t.Errorf("FindByPrefix = %v, %v", matches, err)
+	}
+	if packed, _ := fresh.PackedObjects(); len(packed) != len(hashes) {
+		t.Errorf("PackedObjects returned %d hashes", len(packed))
+	}
+
+	// Уже упакованный объект не записывается повторно отдельным файлом
+	if _, err := fresh.WriteObject(objects.NewBlob([]byte("small\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if loose, _ := fresh.LooseObjects(); len(loose) != 0 {
+		t.Errorf("Packed object was written loose again: %v", loose)
+	}
+}
+
+func TestCorruptPack(t *testing.T) {
+	store, _ := initTestStore(t)
+	hash, _ := store.WriteObject(objects.NewBlob(bytes.Repeat([]byte("data "), 100)))
+	name, err := store.WritePack([]objects.Hash{hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.PrunePacked()
+
+	// Портим последний байт перед контрольной суммой - данные zstd
+	packPath := filepath.Join(store.packDir(), name+".pack")
+	data, _ := os.ReadFile(packPath)
+	data[len(data)-hashSize-1] ^= 0xff
+	if err := os.WriteFile(packPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.ReadObject(hash); err == nil {
+		t.Error("Expected error for a corrupt pack entry")
+	}
+	checks, err := store.VerifyPacks()
+	if err != nil || len(checks) != 1 || checks[0].Err == nil || len(checks[0].Objects) != 1 {
+		t.Errorf("VerifyPacks = %+v, %v", checks, err)
+	}
+
+	// Поврежденный индекс распознается по контрольной сумме
+	idxPath := filepath.Join(store.packDir(), name+".idx")
+	idx, _ := os.ReadFile(idxPath)
+	idx[idxHeader] ^= 0xff
+	if err := os.WriteFile(idxPath, idx, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := OpenObjectStore(filepath.Dir(store.objectsDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fresh.ReadObject(hash); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected checksum error, got %v", err)
+	}
+}
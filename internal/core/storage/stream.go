@@ -0,0 +1,419 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"sib/internal/core/objects"
+	"sib/internal/utils"
+)
+
+// maxHeaderSize - предел длины заголовка "type size\0" при потоковом чтении
+const maxHeaderSize = 64
+
+// WriteStream записывает объект потоком: содержимое читается из obj.ContentReader,
+// по пути хешируется и сжимается во временный файл, который затем переименовывается
+// в objects/xx/... В памяти держится только буфер сжатия, поэтому так можно
+// записывать файлы любого размера. Результат неотличим от WriteObject
+func (store *ObjectStore) WriteStream(obj objects.Streamable) (objects.Hash, error) {
+	content, err := obj.ContentReader()
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize object: %w", err)
+	}
+	if obj.Type() == objects.BlobObject && store.ChunkThreshold > 0 && obj.Size() >= store.ChunkThreshold {
+		return store.writeChunked(obj, content)
+	}
+
+	tmpFile, err := os.CreateTemp(store.objectsDir, "tmp-obj-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+	}()
+
+	compressor, err := utils.NewZstdWriter(tmpFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress object: %w", err)
+	}
+	sum := sha256.New()
+	out := io.MultiWriter(sum, compressor)
+	if _, err := out.Write(objects.Header(obj.Type(), obj.Size())); err != nil {
+		return "", fmt.Errorf("failed to write object: %w", err)
+	}
+
+	// Размер в заголовке уже записан: поток обязан дать ровно столько байт
+	n, err := io.CopyN(out, content, obj.Size())
+	if errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("content is shorter than declared size: %d of %d bytes", n, obj.Size())
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write object: %w", err)
+	}
+	var extra [1]byte
+	if n, _ := io.ReadFull(content, extra[:]); n > 0 {
+		return "", fmt.Errorf("content is longer than declared size %d", obj.Size())
+	}
+
+	if err := compressor.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress object: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write object: %w", err)
+	}
+	hash := objects.Hash(fmt.Sprintf("%x", sum.Sum(nil)))
+
+	objectPath, err := store.hashToPath(hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to create object path: %w", err)
+	}
+	if !store.packsLoaded {
+		if err := store.loadPacks(); err != nil {
+			return "", err
+		}
+	}
+	if !utils.FileExists(objectPath) && !store.inPacks(hash) {
+		if err := utils.CreateDirIfNotExists(filepath.Dir(objectPath)); err != nil {
+			return "", fmt.Errorf("failed to create object directory: %w", err)
+		}
+		if err := os.Rename(tmpPath, objectPath); err != nil {
+			return "", fmt.Errorf("failed to write object file: %w", err)
+		}
+	}
+
+	if hashable, ok := obj.(objects.Hashable); ok {
+		hashable.SetHash(hash)
+	}
+	return hash, nil
+}
+
+// writeChunked записывает большой blob частями: поток режется на куски по
+// содержимому (см. chunker.go), каждый кусок записывается отдельным blob'ом,
+// а под хешем всего blob'а кладется манифест со списком кусков
+func (store *ObjectStore) writeChunked(obj objects.Streamable, content io.Reader) (objects.Hash, error) {
+	sum := sha256.New()
+	sum.Write(objects.Header(obj.Type(), obj.Size()))
+
+	var chunks []objects.Chunk
+	var total int64
+	c := newChunker(io.TeeReader(io.LimitReader(content, obj.Size()), sum))
+	for {
+		data, err := c.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write object: %w", err)
+		}
+		hash, err := store.WriteObject(objects.NewBlob(data))
+		if err != nil {
+			return "", fmt.Errorf("failed to write chunk: %w", err)
+		}
+		chunk, err := objects.NewChunk(hash, int64(len(data)))
+		if err != nil {
+			return "", err
+		}
+		chunks = append(chunks, *chunk)
+		total += int64(len(data))
+	}
+	if total != obj.Size() {
+		return "", fmt.Errorf("content is shorter than declared size: %d of %d bytes", total, obj.Size())
+	}
+	var extra [1]byte
+	if n, _ := io.ReadFull(content, extra[:]); n > 0 {
+		return "", fmt.Errorf("content is longer than declared size %d", obj.Size())
+	}
+	hash := objects.Hash(fmt.Sprintf("%x", sum.Sum(nil)))
+
+	manifest, err := objects.NewManifest(chunks)
+	if err != nil {
+		return "", err
+	}
+	data, err := manifest.Serialize()
+	if err != nil {
+		return "", err
+	}
+	if err := store.writeLoose(hash, data); err != nil {
+		return "", err
+	}
+
+	if hashable, ok := obj.(objects.Hashable); ok {
+		hashable.SetHash(hash)
+	}
+	return hash, nil
+}
+
+// ObjectReader - поток содержимого объекта (без заголовка). Хеш проверяется
+// по мере чтения: если данные повреждены, последний Read вернет ошибку
+// вместо io.EOF. Закрывать обязательно
+type ObjectReader struct {
+	hash      objects.Hash
+	objType   objects.ObjectType
+	size      int64
+	src       *bufio.Reader
+	close     func() error
+	sum       hash.Hash
+	remaining int64 // Сколько байт содержимого еще не прочитано
+	err       error // Ошибка или io.EOF, которую возвращают все следующие Read
+}
+
+// Type возвращает тип объекта
+func (r *ObjectReader) Type() objects.ObjectType { return r.objType }
+
+// Size возвращает размер содержимого в байтах
+func (r *ObjectReader) Size() int64 { return r.size }
+
+// Read читает содержимое объекта
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.remaining == 0 {
+		r.err = r.finish()
+		return 0, r.err
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.src.Read(p)
+	r.sum.Write(p[:n])
+	r.remaining -= int64(n)
+
+	switch {
+	case errors.Is(err, io.EOF) && r.remaining > 0:
+		r.err = fmt.Errorf("object %s is truncated: %d bytes missing", r.hash, r.remaining)
+	case err != nil && !errors.Is(err, io.EOF):
+		r.err = fmt.Errorf("failed to read object %s: %w", r.hash, err)
+	case r.remaining == 0:
+		// Проверяем сразу, чтобы ошибка пришла вместе с последними данными
+		r.err = r.finish()
+	}
+	if r.err != nil && r.err != io.EOF {
+		return n, r.err
+	}
+	return n, nil
+}
+
+// finish проверяет, что за содержимым ничего нет и хеш совпадает.
+// Возвращает io.EOF, если объект цел
+func (r *ObjectReader) finish() error {
+	if _, err := r.src.ReadByte(); err == nil {
+		return fmt.Errorf("object %s has data beyond declared size %d", r.hash, r.size)
+	} else if !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read object %s: %w", r.hash, err)
+	}
+	if calculated := fmt.Sprintf("%x", r.sum.Sum(nil)); calculated != r.hash.String() {
+		return fmt.Errorf("object integrity check failed: expected %s, got %s", r.hash, calculated)
+	}
+	return io.EOF
+}
+
+// Close освобождает файлы и распаковщик
+func (r *ObjectReader) Close() error {
+	return r.close()
+}
+
+// OpenObject открывает объект для потокового чтения, не загружая его в память.
+// Тип и размер известны сразу после открытия, содержимое читается из ObjectReader.
+// Blob, записанный частями, собирается из кусков по мере чтения
+func (store *ObjectStore) OpenObject(hash objects.Hash) (*ObjectReader, error) {
+	raw, err := store.openRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw.objType == objects.ChunkedObject {
+		manifest, err := raw.manifest()
+		raw.close()
+		if err != nil {
+			return nil, fmt.Errorf("object %s: %w", hash, err)
+		}
+		chunks := &chunkReader{store: store, chunks: manifest.Chunks()}
+		raw = &rawObject{
+			objType: objects.BlobObject,
+			size:    manifest.Size(),
+			header:  objects.Header(objects.BlobObject, manifest.Size()),
+			reader:  bufio.NewReader(chunks),
+			close:   chunks.Close,
+		}
+	}
+
+	sum := sha256.New()
+	sum.Write(raw.header)
+	return &ObjectReader{
+		hash:      hash,
+		objType:   raw.objType,
+		size:      raw.size,
+		src:       raw.reader,
+		close:     raw.close,
+		sum:       sum,
+		remaining: raw.size,
+	}, nil
+}
+
+// ReadManifest возвращает манифест blob'а, записанного частями,
+// или nil, если объект хранится целиком
+func (store *ObjectStore) ReadManifest(hash objects.Hash) (*objects.Manifest, error) {
+	raw, err := store.openRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+	defer raw.close()
+	if raw.objType != objects.ChunkedObject {
+		return nil, nil
+	}
+	manifest, err := raw.manifest()
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", hash, err)
+	}
+	return manifest, nil
+}
+
+// rawObject - объект в том виде, в каком он хранится: для blob'а,
+// записанного частями, это манифест. Хеш не проверяется
+type rawObject struct {
+	objType objects.ObjectType
+	size    int64
+	header  []byte        // Заголовок "type size\0"
+	reader  *bufio.Reader // Данные после заголовка
+	close   func() error
+}
+
+// manifest читает и разбирает манифест
+func (raw *rawObject) manifest() (*objects.Manifest, error) {
+	body, err := io.ReadAll(io.LimitReader(raw.reader, raw.size+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if int64(len(body)) != raw.size {
+		return nil, fmt.Errorf("manifest has %d bytes, header declares %d", len(body), raw.size)
+	}
+	return objects.DeserializeManifest(append(raw.header, body...))
+}
+
+// openRaw открывает объект и разбирает его заголовок
+func (store *ObjectStore) openRaw(hash objects.Hash) (*rawObject, error) {
+	if hash.IsEmpty() {
+		return nil, fmt.Errorf("hash cannot be empty")
+	}
+
+	src, closeSrc, err := store.openLoose(hash)
+	if errors.Is(err, os.ErrNotExist) {
+		src, closeSrc, err = store.openPacked(hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object file: %w", err)
+	}
+
+	reader := bufio.NewReader(src)
+	header, err := reader.Peek(maxHeaderSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		closeSrc()
+		return nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	end := bytes.IndexByte(header, 0)
+	if end < 0 {
+		closeSrc()
+		return nil, fmt.Errorf("object %s: malformed header", hash)
+	}
+	objType, size, err := parseHeaderLine(header[:end])
+	if err != nil {
+		closeSrc()
+		return nil, fmt.Errorf("object %s: %w", hash, err)
+	}
+	header = append([]byte(nil), header[:end+1]...)
+	reader.Discard(end + 1)
+
+	return &rawObject{objType: objType, size: size, header: header, reader: reader, close: closeSrc}, nil
+}
+
+// chunkReader читает куски манифеста подряд, проверяя каждый
+type chunkReader struct {
+	store   *ObjectStore
+	chunks  []objects.Chunk
+	current *ObjectReader
+}
+
+// Read читает содержимое текущего куска и переходит к следующему
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.chunks) == 0 {
+				return 0, io.EOF
+			}
+			chunk := c.chunks[0]
+			c.chunks = c.chunks[1:]
+			r, err := c.store.OpenObject(chunk.Hash())
+			if err != nil {
+				return 0, fmt.Errorf("failed to read chunk: %w", err)
+			}
+			if r.Type() != objects.BlobObject || r.Size() != chunk.Size() {
+				r.Close()
+				return 0, fmt.Errorf("chunk %s is a %s of %d bytes, expected blob of %d", chunk.Hash(), r.Type(), r.Size(), chunk.Size())
+			}
+			c.current = r
+		}
+
+		n, err := c.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			c.current.Close()
+			c.current = nil
+			if n == 0 {
+				continue
+			}
+			return n, nil
+		}
+		return n, err
+	}
+}
+
+// Close закрывает текущий кусок
+func (c *chunkReader) Close() error {
+	if c.current != nil {
+		return c.current.Close()
+	}
+	return nil
+}
+
+// openLoose открывает распакованный поток объекта, хранящегося отдельным файлом
+func (store *ObjectStore) openLoose(hash objects.Hash) (io.Reader, func() error, error) {
+	objectPath, err := store.hashToPath(hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create object path: %w", err)
+	}
+	file, err := os.Open(objectPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	decompressor, err := utils.NewZstdReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to decompress object: %w", err)
+	}
+	return decompressor, func() error {
+		decompressor.Close()
+		return file.Close()
+	}, nil
+}
+
+// openPacked открывает распакованный поток объекта из pack-файла
+func (store *ObjectStore) openPacked(hash objects.Hash) (io.Reader, func() error, error) {
+	p, i, err := store.findPacked(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		return nil, nil, fmt.Errorf("object %s not found: %w", hash, os.ErrNotExist)
+	}
+	return p.open(i)
+}
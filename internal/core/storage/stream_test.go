@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/utils"
+)
+
+func TestWriteStream(t *testing.T) {
+	store, _ := initTestStore(t)
+	content := make([]byte, 3<<20)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	hash, err := store.WriteStream(objects.NewBlobStream(bytes.NewReader(content), int64(len(content))))
+	if err != nil {
+		t.Fatalf("WriteStream failed: %v", err)
+	}
+
+	// Хеш и файл те же, что у обычной записи
+	want, _ := objects.NewBlob(content).Serialize()
+	if hash != store.calculateHash(want) {
+		t.Errorf("Hash = %s, want %s", hash, store.calculateHash(want))
+	}
+	blob, err := store.ReadBlob(hash)
+	if err != nil || !bytes.Equal(blob.Content(), content) {
+		t.Fatalf("ReadBlob after WriteStream: %v", err)
+	}
+
+	// Поток длиннее или короче объявленного размера - ошибка, мусор не остается
+	if _, err := store.WriteStream(objects.NewBlobStream(strings.NewReader("too long"), 3)); err == nil {
+		t.Error("Expected error for a stream longer than size")
+	}
+	if _, err := store.WriteStream(objects.NewBlobStream(strings.NewReader("short"), 100)); err == nil {
+		t.Error("Expected error for a stream shorter than size")
+	}
+	if tmp, _ := store.TempFiles(); len(tmp) != 0 {
+		t.Errorf("Temporary files left: %v", tmp)
+	}
+}
+
+func TestOpenObject(t *testing.T) {
+	store, _ := initTestStore(t)
+	content := bytes.Repeat([]byte("large file contents\n"), 1<<16)
+	hash, err := store.WriteObject(objects.NewBlob(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, _ := store.WriteObject(objects.NewBlob([]byte{}))
+
+	readAll := func(hash objects.Hash) ([]byte, error) {
+		r, err := store.OpenObject(hash)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if r.Type() != objects.BlobObject {
+			t.Errorf("Type = %s", r.Type())
+		}
+		return io.ReadAll(r)
+	}
+
+	if got, err := readAll(hash); err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("OpenObject loose: %v", err)
+	}
+	if got, err := readAll(empty); err != nil || len(got) != 0 {
+		t.Fatalf("OpenObject empty blob: %q, %v", got, err)
+	}
+
+	// Из pack-файла объект читается так же
+	if _, err := store.WritePack([]objects.Hash{hash, empty}); err != nil {
+		t.Fatal(err)
+	}
+	store.PrunePacked()
+	if got, err := readAll(hash); err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("OpenObject packed: %v", err)
+	}
+
+	// Подмененное содержимое обнаруживается в конце потока
+	other := bytes.Repeat([]byte("LARGE FILE CONTENTS\n"), 1<<16)
+	data, _ := objects.NewBlob(other).Serialize()
+	compressed, _ := utils.CompressZstd(data)
+	path, _ := store.hashToPath(hash)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, compressed, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readAll(hash); err == nil || !strings.Contains(err.Error(), "integrity check failed") {
+		t.Errorf("Expected integrity error, got %v", err)
+	}
+}
+
+func TestWritePackStreamsLargeObjects(t *testing.T) {
+	store, _ := initTestStore(t)
+	content := bytes.Repeat([]byte("0123456789abcdef"), packMaxBuffered/16+1)
+	hash, err := store.WriteStream(objects.NewBlobStream(bytes.NewReader(content), int64(len(content))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	small, _ := store.WriteObject(objects.NewBlob([]byte("small\n")))
+
+	if _, err := store.WritePack([]objects.Hash{hash, small}); err != nil {
+		t.Fatalf("WritePack failed: %v", err)
+	}
+	if removed, err := store.PrunePacked(); err != nil || removed != 2 {
+		t.Fatalf("PrunePacked = %d, %v", removed, err)
+	}
+
+	r, err := store.OpenObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("Large packed object differs: %v", err)
+	}
+	if checks, err := store.VerifyPacks(); err != nil || checks[0].Err != nil {
+		t.Errorf("VerifyPacks = %+v, %v", checks, err)
+	}
+}
+
+func TestWriteChunked(t *testing.T) {
+	store, _ := initTestStore(t)
+	store.ChunkThreshold = 1 << 20
+
+	v1 := make([]byte, 3<<20)
+	rand.New(rand.NewSource(3)).Read(v1)
+	v2 := append(append(append([]byte(nil), v1[:1<<20]...), "edit"...), v1[1<<20:]...)
+
+	write := func(content []byte) objects.Hash {
+		t.Helper()
+		hash, err := store.WriteStream(objects.NewBlobStream(bytes.NewReader(content), int64(len(content))))
+		if err != nil {
+			t.Fatalf("WriteStream failed: %v", err)
+		}
+		return hash
+	}
+	h1, h2 := write(v1), write(v2)
+
+	// Хеш тот же, что у целого blob'а: деревья и индекс не замечают разбиения
+	want, _ := objects.NewBlob(v1).Serialize()
+	if h1 != store.calculateHash(want) {
+		t.Errorf("Chunked blob hash = %s, want %s", h1, store.calculateHash(want))
+	}
+
+	m1, err := store.ReadManifest(h1)
+	if err != nil || m1 == nil {
+		t.Fatalf("ReadManifest = %v, %v", m1, err)
+	}
+	m2, _ := store.ReadManifest(h2)
+	shared := make(map[objects.Hash]bool)
+	for _, chunk := range m1.Chunks() {
+		shared[chunk.Hash()] = true
+	}
+	reused := 0
+	for _, chunk := range m2.Chunks() {
+		if shared[chunk.Hash()] {
+			reused++
+		}
+	}
+	if reused < len(m2.Chunks())-2 {
+		t.Errorf("Only %d of %d chunks reused", reused, len(m2.Chunks()))
+	}
+	if m, err := store.ReadManifest(write([]byte("small"))); m != nil || err != nil {
+		t.Errorf("Blob below the threshold should be stored whole: %v", err)
+	}
+
+	check := func(stage string) {
+		t.Helper()
+		for hash, content := range map[objects.Hash][]byte{h1: v1, h2: v2} {
+			blob, err := store.ReadBlob(hash)
+			if err != nil || !bytes.Equal(blob.Content(), content) {
+				t.Fatalf("%s: ReadBlob: %v", stage, err)
+			}
+			r, err := store.OpenObject(hash)
+			if err != nil {
+				t.Fatalf("%s: OpenObject: %v", stage, err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil || r.Type() != objects.BlobObject || !bytes.Equal(got, content) {
+				t.Fatalf("%s: streamed content differs: %v", stage, err)
+			}
+		}
+	}
+	check("loose")
+
+	// В pack-файл манифест попадает как есть, куски - отдельными объектами
+	loose, _ := store.LooseObjects()
+	if _, err := store.WritePack(loose); err != nil {
+		t.Fatalf("WritePack failed: %v", err)
+	}
+	store.PrunePacked()
+	check("packed")
+	if m, err := store.ReadManifest(h2); err != nil || m == nil {
+		t.Errorf("Packed manifest lost: %v, %v", m, err)
+	}
+
+	// Подмененный кусок обнаруживается при сборке
+	chunk := m1.Chunks()[1].Hash()
+	data, _ := objects.NewBlob(bytes.Repeat([]byte("x"), int(m1.Chunks()[1].Size()))).Serialize()
+	compressed, _ := utils.CompressZstd(data)
+	path, _ := store.hashToPath(chunk)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, compressed, 0644)
+	if _, err := store.ReadBlob(h1); err == nil {
+		t.Error("Expected error for a corrupt chunk")
+	}
+	if _, _, err := store.CheckObject(h1); err == nil {
+		t.Error("CheckObject should fail for a corrupt chunk")
+	}
+}
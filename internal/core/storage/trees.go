@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+
+	"sib/internal/core/objects"
+)
+
+// TreeFile - файл из рекурсивно развернутого tree
+type TreeFile struct {
+	Path string           // Путь от корня tree через '/'
+	Mode objects.FileMode // Режим файла
+	Hash objects.Hash     // Хеш blob'а
+}
+
+// ReadCommit читает объект и проверяет, что это коммит
+func (store *ObjectStore) ReadCommit(hash objects.Hash) (*objects.Commit, error) {
+	obj, err := store.ReadObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+
+	commit, ok := obj.(*objects.Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", hash, obj.Type())
+	}
+	commit.SetHash(hash)
+
+	return commit, nil
+}
+
+// ReadTree читает объект и проверяет, что это tree
+func (store *ObjectStore) ReadTree(hash objects.Hash) (*objects.Tree, error) {
+	obj, err := store.ReadObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree %s: %w", hash, err)
+	}
+
+	tree, ok := obj.(*objects.Tree)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", hash, obj.Type())
+	}
+	tree.SetHash(hash)
+
+	return tree, nil
+}
+
+// ReadTag читает объект и проверяет, что это аннотированный тег
+func (store *ObjectStore) ReadTag(hash objects.Hash) (*objects.Tag, error) {
+	obj, err := store.ReadObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag %s: %w", hash, err)
+	}
+
+	tag, ok := obj.(*objects.Tag)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a tag", hash, obj.Type())
+	}
+	tag.SetHash(hash)
+
+	return tag, nil
+}
+
+// ReadBlob читает объект и проверяет, что это blob
+func (store *ObjectStore) ReadBlob(hash objects.Hash) (*objects.Blob, error) {
+	obj, err := store.ReadObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+
+	blob, ok := obj.(*objects.Blob)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a blob", hash, obj.Type())
+	}
+
+	return blob, nil
+}
+
+// FlattenTree рекурсивно разворачивает tree в плоский список файлов
+// Ключ результата - путь файла от корня через '/'
+func (store *ObjectStore) FlattenTree(hash objects.Hash) (map[string]TreeFile, error) {
+	files := make(map[string]TreeFile)
+	if err := store.flattenInto(hash, "", files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// flattenInto добавляет файлы tree в files с префиксом prefix
+func (store *ObjectStore) flattenInto(hash objects.Hash, prefix string, files map[string]TreeFile) error {
+	tree, err := store.ReadTree(hash)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range tree.Entries() {
+		fullPath := path.Join(prefix, entry.Name())
+
+		if entry.Type() == objects.TreeObject {
+			if err := store.flattenInto(entry.Hash(), fullPath, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		files[fullPath] = TreeFile{
+			Path: fullPath,
+			Mode: entry.Mode(),
+			Hash: entry.Hash(),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package treediff
+
+import (
+	"hash/fnv"
+	"path"
+	"sort"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// renameLimit - при большем числе удаленных или добавленных файлов
+// ищутся только точные переименования (сравнение попарно слишком дорогое)
+const renameLimit = 1000
+
+// maxChunk - максимальная длина фрагмента при подсчете сходства,
+// чтобы бинарные файлы без переводов строк тоже разбивались на части
+const maxChunk = 64
+
+// signature - содержимое файла в виде "хеш фрагмента -> число байт"
+type signature struct {
+	chunks map[uint64]int
+	size   int
+}
+
+// renamer хранит состояние поиска переименований
+type renamer struct {
+	store      *storage.ObjectStore
+	threshold  int
+	signatures map[objects.Hash]*signature
+}
+
+// candidate - пара "источник - добавленный файл" со сходством
+type candidate struct {
+	src, dst int
+	score    int
+}
+
+// detectRenames заменяет пары удаление+добавление на переименования,
+// а при DetectCopies помечает добавления, похожие на существующие файлы, как копии
+func detectRenames(store *storage.ObjectStore, changes []Change, opts Options) ([]Change, error) {
+	r := &renamer{
+		store:      store,
+		threshold:  opts.Threshold,
+		signatures: make(map[objects.Hash]*signature),
+	}
+	if r.threshold <= 0 {
+		r.threshold = DefaultThreshold
+	}
+
+	var deleted, added []int
+	for i, c := range changes {
+		switch c.Type {
+		case Deleted:
+			deleted = append(deleted, i)
+		case Added:
+			added = append(added, i)
+		}
+	}
+
+	used := make(map[int]bool)
+	var found []Change
+
+	if opts.DetectRenames {
+		pairs, err := r.match(changes, deleted, added, used)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			found = append(found, Change{
+				Type:       Renamed,
+				Old:        changes[p.src].Old,
+				New:        changes[p.dst].New,
+				Similarity: p.score,
+			})
+			used[p.src] = true
+			used[p.dst] = true
+		}
+	}
+
+	if opts.DetectCopies {
+		// Источниками копий могут быть старые версии любых затронутых файлов,
+		// в том числе уже переименованных; один источник дает несколько копий
+		var sources []int
+		for i, c := range changes {
+			if c.Type == Deleted || c.Type == Modified || c.Type == ModeChanged {
+				sources = append(sources, i)
+			}
+		}
+		var remaining []int
+		for _, i := range added {
+			if !used[i] {
+				remaining = append(remaining, i)
+			}
+		}
+
+		copies, err := r.match(changes, sources, remaining, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range copies {
+			found = append(found, Change{
+				Type:       Copied,
+				Old:        changes[p.src].Old,
+				New:        changes[p.dst].New,
+				Similarity: p.score,
+			})
+			used[p.dst] = true
+		}
+	}
+
+	result := make([]Change, 0, len(changes))
+	for i, c := range changes {
+		if !used[i] {
+			result = append(result, c)
+		}
+	}
+	return append(result, found...), nil
+}
+
+// match сопоставляет добавленные файлы с источниками: сначала по точному совпадению хеша,
+// затем по сходству содержимого, жадно от самых похожих пар.
+// Если exclusive не nil, каждый источник используется один раз и отмечается в нем
+func (r *renamer) match(changes []Change, sources, targets []int, exclusive map[int]bool) ([]candidate, error) {
+	var pairs []candidate
+	srcTaken := make(map[int]bool)
+	dstTaken := make(map[int]bool)
+	isFree := func(src int) bool {
+		return exclusive == nil || (!exclusive[src] && !srcTaken[src])
+	}
+
+	// Точные совпадения; при нескольких кандидатах предпочитаем то же имя файла
+	byHash := make(map[objects.Hash][]int)
+	for _, src := range sources {
+		byHash[changes[src].Old.Hash] = append(byHash[changes[src].Old.Hash], src)
+	}
+	for _, dst := range targets {
+		best := -1
+		for _, src := range byHash[changes[dst].New.Hash] {
+			if !isFree(src) {
+				continue
+			}
+			if best < 0 || path.Base(changes[src].Old.Path) == path.Base(changes[dst].New.Path) {
+				best = src
+			}
+		}
+		if best >= 0 {
+			pairs = append(pairs, candidate{src: best, dst: dst, score: 100})
+			srcTaken[best] = true
+			dstTaken[dst] = true
+		}
+	}
+
+	var freeSources, freeTargets []int
+	for _, src := range sources {
+		if isFree(src) {
+			freeSources = append(freeSources, src)
+		}
+	}
+	for _, dst := range targets {
+		if !dstTaken[dst] {
+			freeTargets = append(freeTargets, dst)
+		}
+	}
+	if len(freeSources) == 0 || len(freeTargets) == 0 ||
+		len(freeSources) > renameLimit || len(freeTargets) > renameLimit {
+		return pairs, nil
+	}
+
+	// Неточные совпадения
+	var candidates []candidate
+	for _, dst := range freeTargets {
+		for _, src := range freeSources {
+			score, err := r.similarity(changes[src].Old.Hash, changes[dst].New.Hash)
+			if err != nil {
+				return nil, err
+			}
+			if score >= r.threshold {
+				candidates = append(candidates, candidate{src: src, dst: dst, score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	for _, c := range candidates {
+		if dstTaken[c.dst] || !isFree(c.src) {
+			continue
+		}
+		pairs = append(pairs, c)
+		srcTaken[c.src] = true
+		dstTaken[c.dst] = true
+	}
+
+	return pairs, nil
+}
+
+// similarity возвращает сходство двух blob'ов в процентах:
+// доля общих байт относительно большего из файлов
+func (r *renamer) similarity(a, b objects.Hash) (int, error) {
+	if a == b {
+		return 100, nil
+	}
+
+	sigA, err := r.signature(a)
+	if err != nil {
+		return 0, err
+	}
+	sigB, err := r.signature(b)
+	if err != nil {
+		return 0, err
+	}
+
+	maxSize := sigA.size
+	if sigB.size > maxSize {
+		maxSize = sigB.size
+	}
+	if maxSize == 0 {
+		return 100, nil
+	}
+
+	// Быстрый отказ: даже полное совпадение меньшего файла не дотянет до порога
+	minSize := sigA.size + sigB.size - maxSize
+	if minSize*100 < r.threshold*maxSize {
+		return 0, nil
+	}
+
+	common := 0
+	for chunk, n := range sigA.chunks {
+		if m := sigB.chunks[chunk]; m > 0 {
+			common += min(n, m)
+		}
+	}
+
+	return common * 100 / maxSize, nil
+}
+
+// signature вычисляет (и кеширует) сигнатуру blob'а
+func (r *renamer) signature(hash objects.Hash) (*signature, error) {
+	if sig, ok := r.signatures[hash]; ok {
+		return sig, nil
+	}
+
+	blob, err := r.store.ReadBlob(hash)
+	if err != nil {
+		return nil, err
+	}
+	content := blob.Content()
+
+	sig := &signature{chunks: make(map[uint64]int), size: len(content)}
+	for start := 0; start < len(content); {
+		end := start
+		for end < len(content) && end-start < maxChunk {
+			end++
+			if content[end-1] == '\n' {
+				break
+			}
+		}
+
+		h := fnv.New64a()
+		h.Write(content[start:end])
+		sig.chunks[h.Sum64()] += end - start
+		start = end
+	}
+
+	r.signatures[hash] = sig
+	return sig, nil
+}
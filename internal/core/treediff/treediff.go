@@ -0,0 +1,232 @@
+// Package treediff сравнивает два tree и возвращает структурированный список изменений:
+// добавления, удаления, изменения содержимого и режима, переименования и копирования.
+//
+// Обход рекурсивный и пропускает поддеревья с одинаковыми хешами,
+// поэтому стоимость сравнения пропорциональна объему изменений, а не размеру дерева.
+package treediff
+
+import (
+	"path"
+	"sort"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// ChangeType - вид изменения
+type ChangeType byte
+
+const (
+	Added       ChangeType = 'A' // Файл появился
+	Deleted     ChangeType = 'D' // Файл удален
+	Modified    ChangeType = 'M' // Изменилось содержимое (и, возможно, режим)
+	ModeChanged ChangeType = 'T' // Изменился только режим
+	Renamed     ChangeType = 'R' // Файл перемещен (возможно, с правками)
+	Copied      ChangeType = 'C' // Файл скопирован из другого (возможно, с правками)
+)
+
+// String возвращает имя вида изменения
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Deleted:
+		return "deleted"
+	case Modified:
+		return "modified"
+	case ModeChanged:
+		return "mode changed"
+	case Renamed:
+		return "renamed"
+	case Copied:
+		return "copied"
+	default:
+		return "unknown"
+	}
+}
+
+// Entry - файл на одной из сторон сравнения
+// Для отсутствующей стороны (Old у Added, New у Deleted) все поля пустые
+type Entry struct {
+	Path string
+	Mode objects.FileMode
+	Hash objects.Hash
+}
+
+// IsEmpty проверяет, что сторона отсутствует
+func (e Entry) IsEmpty() bool {
+	return e.Hash.IsEmpty()
+}
+
+// Change - одно изменение
+type Change struct {
+	Type       ChangeType
+	Old        Entry
+	New        Entry
+	Similarity int // Процент сходства для Renamed и Copied
+}
+
+// Path возвращает путь, под которым изменение показывается:
+// новый путь, а для удалений - старый
+func (c Change) Path() string {
+	if c.New.IsEmpty() {
+		return c.Old.Path
+	}
+	return c.New.Path
+}
+
+// Options - параметры сравнения
+type Options struct {
+	DetectRenames bool // Сопоставлять удаленные и добавленные файлы
+	DetectCopies  bool // Искать источники добавленных файлов среди измененных и удаленных
+	Threshold     int  // Минимальное сходство в процентах; 0 означает DefaultThreshold
+}
+
+// DefaultThreshold - порог сходства по умолчанию, как в git (-M50%)
+const DefaultThreshold = 50
+
+// Trees сравнивает два tree. Пустой хеш означает пустое дерево.
+// Изменения отсортированы по пути
+func Trees(store *storage.ObjectStore, oldTree, newTree objects.Hash, opts Options) ([]Change, error) {
+	w := &walker{store: store}
+	if err := w.walk(oldTree, newTree, ""); err != nil {
+		return nil, err
+	}
+
+	changes := w.changes
+	if opts.DetectRenames || opts.DetectCopies {
+		var err error
+		changes, err = detectRenames(store, changes, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortChanges(changes)
+	return changes, nil
+}
+
+// walker собирает изменения при параллельном обходе двух деревьев
+type walker struct {
+	store   *storage.ObjectStore
+	changes []Change
+}
+
+// walk сравнивает два поддерева, расположенных по пути prefix
+func (w *walker) walk(oldTree, newTree objects.Hash, prefix string) error {
+	if oldTree == newTree {
+		return nil
+	}
+
+	oldEntries, err := w.entries(oldTree)
+	if err != nil {
+		return err
+	}
+	newEntries, err := w.entries(newTree)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(oldEntries)+len(newEntries))
+	for name := range oldEntries {
+		names = append(names, name)
+	}
+	for name := range newEntries {
+		if _, ok := oldEntries[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fullPath := path.Join(prefix, name)
+		o, hasOld := oldEntries[name]
+		n, hasNew := newEntries[name]
+
+		oldIsTree := hasOld && o.Type() == objects.TreeObject
+		newIsTree := hasNew && n.Type() == objects.TreeObject
+
+		switch {
+		case hasOld && hasNew && oldIsTree && newIsTree:
+			if err := w.walk(o.Hash(), n.Hash(), fullPath); err != nil {
+				return err
+			}
+
+		case hasOld && hasNew && !oldIsTree && !newIsTree:
+			change := Change{
+				Old: Entry{Path: fullPath, Mode: o.Mode(), Hash: o.Hash()},
+				New: Entry{Path: fullPath, Mode: n.Mode(), Hash: n.Hash()},
+			}
+			switch {
+			case o.Hash() != n.Hash():
+				change.Type = Modified
+			case o.Mode() != n.Mode():
+				change.Type = ModeChanged
+			default:
+				continue
+			}
+			w.changes = append(w.changes, change)
+
+		default:
+			// Файл заменен каталогом или наоборот - удаление и добавление
+			if hasOld {
+				if err := w.side(o, fullPath, Deleted); err != nil {
+					return err
+				}
+			}
+			if hasNew {
+				if err := w.side(n, fullPath, Added); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// side записывает добавление или удаление записи (для каталога - всех файлов в нем)
+func (w *walker) side(entry objects.TreeEntry, fullPath string, kind ChangeType) error {
+	if entry.Type() == objects.TreeObject {
+		if kind == Deleted {
+			return w.walk(entry.Hash(), "", fullPath)
+		}
+		return w.walk("", entry.Hash(), fullPath)
+	}
+
+	e := Entry{Path: fullPath, Mode: entry.Mode(), Hash: entry.Hash()}
+	if kind == Deleted {
+		w.changes = append(w.changes, Change{Type: Deleted, Old: e})
+	} else {
+		w.changes = append(w.changes, Change{Type: Added, New: e})
+	}
+	return nil
+}
+
+// entries читает записи дерева по именам; пустой хеш - пустое дерево
+func (w *walker) entries(hash objects.Hash) (map[string]objects.TreeEntry, error) {
+	result := make(map[string]objects.TreeEntry)
+	if hash.IsEmpty() {
+		return result, nil
+	}
+
+	tree, err := w.store.ReadTree(hash)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range tree.Entries() {
+		result[entry.Name()] = entry
+	}
+	return result, nil
+}
+
+// sortChanges сортирует изменения по пути, удаления - перед добавлениями на том же пути
+func sortChanges(changes []Change) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		pi, pj := changes[i].Path(), changes[j].Path()
+		if pi != pj {
+			return pi < pj
+		}
+		return changes[i].Type == Deleted && changes[j].Type != Deleted
+	})
+}
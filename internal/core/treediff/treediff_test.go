@@ -0,0 +1,141 @@
+package treediff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"sib/internal/testutil"
+)
+
+// describe записывает изменения компактно: "M a.txt", "R080 old -> new"
+func describe(changes []Change) string {
+	var parts []string
+	for _, c := range changes {
+		switch c.Type {
+		case Renamed, Copied:
+			parts = append(parts, fmt.Sprintf("%c%03d %s -> %s", c.Type, c.Similarity, c.Old.Path, c.New.Path))
+		default:
+			parts = append(parts, fmt.Sprintf("%c %s", c.Type, c.Path()))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lines генерирует текст из n различных строк
+func lines(prefix string, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%s line %d\n", prefix, i)
+	}
+	return sb.String()
+}
+
+func TestTrees(t *testing.T) {
+	store := testutil.NewStore(t)
+
+	oldTree := testutil.WriteTree(t, store, map[string]string{
+		"README":          "readme",
+		"run.sh":          "echo",
+		"src/main.go":     "package main",
+		"src/util/u.go":   "package util",
+		"docs/guide.md":   "guide",
+		"conflict":        "file becomes dir",
+		"unchanged/a.txt": "same",
+	})
+	newTree := testutil.WriteTree(t, store, map[string]string{
+		"README":          "readme v2",
+		"run.sh":          "x:echo",
+		"src/main.go":     "package main",
+		"src/util/u.go":   "package util // changed",
+		"new.txt":         "new",
+		"conflict/inner":  "now a dir",
+		"unchanged/a.txt": "same",
+	})
+
+	changes, err := Trees(store, oldTree, newTree, Options{})
+	if err != nil {
+		t.Fatalf("Trees failed: %v", err)
+	}
+
+	want := "M README, D conflict, A conflict/inner, D docs/guide.md, A new.txt, T run.sh, M src/util/u.go"
+	if got := describe(changes); got != want {
+		t.Errorf("Trees:\n got %s\nwant %s", got, want)
+	}
+
+	t.Run("Identical trees", func(t *testing.T) {
+		changes, err := Trees(store, oldTree, oldTree, Options{})
+		if err != nil || len(changes) != 0 {
+			t.Errorf("Expected no changes, got %v, %v", changes, err)
+		}
+	})
+
+	t.Run("Against empty tree", func(t *testing.T) {
+		changes, err := Trees(store, "", oldTree, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 7 {
+			t.Errorf("Expected 7 additions, got %s", describe(changes))
+		}
+		for _, c := range changes {
+			if c.Type != Added || !c.Old.IsEmpty() {
+				t.Errorf("Unexpected change %+v", c)
+			}
+		}
+	})
+}
+
+func TestRenames(t *testing.T) {
+	store := testutil.NewStore(t)
+	body := lines("body", 20)
+
+	oldTree := testutil.WriteTree(t, store, map[string]string{
+		"exact.txt":   "exactly the same",
+		"edited.txt":  body,
+		"unrelated.c": lines("old", 10),
+		"keep.txt":    body + "keep",
+	})
+	newTree := testutil.WriteTree(t, store, map[string]string{
+		"moved/exact.txt": "exactly the same",
+		"edited2.txt":     body + "one more line\n",
+		"other.c":         lines("new", 10),
+		"keep.txt":        body + "keep changed",
+	})
+
+	changes, err := Trees(store, oldTree, newTree, Options{DetectRenames: true})
+	if err != nil {
+		t.Fatalf("Trees failed: %v", err)
+	}
+	want := "R094 edited.txt -> edited2.txt, M keep.txt, R100 exact.txt -> moved/exact.txt, A other.c, D unrelated.c"
+	if got := describe(changes); got != want {
+		t.Errorf("Renames:\n got %s\nwant %s", got, want)
+	}
+
+	t.Run("Threshold", func(t *testing.T) {
+		changes, err := Trees(store, oldTree, newTree, Options{DetectRenames: true, Threshold: 99})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := describe(changes); strings.Contains(got, "edited.txt -> edited2.txt") {
+			t.Errorf("Rename below threshold reported: %s", got)
+		}
+	})
+
+	t.Run("Copies", func(t *testing.T) {
+		copyTree := testutil.WriteTree(t, store, map[string]string{
+			"exact.txt":   "exactly the same",
+			"edited.txt":  body + "tweak\n",
+			"copy.txt":    body,
+			"unrelated.c": lines("old", 10),
+			"keep.txt":    body + "keep",
+		})
+		changes, err := Trees(store, oldTree, copyTree, Options{DetectRenames: true, DetectCopies: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := describe(changes); got != "C100 edited.txt -> copy.txt, M edited.txt" {
+			t.Errorf("Copies: %s", got)
+		}
+	})
+}
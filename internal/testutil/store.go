@@ -0,0 +1,65 @@
+// Package testutil содержит общие помощники тестов пакетов internal/core:
+// временное хранилище объектов и запись деревьев из описания "путь -> содержимое".
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sib/internal/core/objects"
+	"sib/internal/core/storage"
+)
+
+// NewStore создает пустое хранилище объектов во временном каталоге теста
+func NewStore(t *testing.T) *storage.ObjectStore {
+	t.Helper()
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".sib", "objects"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := storage.NewObjectStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	return store
+}
+
+// WriteTree записывает дерево из "путь -> содержимое"; префикс "x:" делает файл исполняемым
+func WriteTree(t *testing.T, store *storage.ObjectStore, files map[string]string) objects.Hash {
+	t.Helper()
+
+	tree := objects.NewTree()
+	subdirs := make(map[string]map[string]string)
+	for p, content := range files {
+		if dir, rest, ok := strings.Cut(p, "/"); ok {
+			if subdirs[dir] == nil {
+				subdirs[dir] = make(map[string]string)
+			}
+			subdirs[dir][rest] = content
+			continue
+		}
+
+		mode := objects.FileModeRegular
+		if after, ok := strings.CutPrefix(content, "x:"); ok {
+			mode, content = objects.FileModeExec, after
+		}
+		hash, err := store.WriteObject(objects.NewBlob([]byte(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, _ := objects.NewTreeEntry(mode, p, hash, objects.BlobObject)
+		tree.AddEntry(*entry)
+	}
+	for dir, sub := range subdirs {
+		entry, _ := objects.NewTreeEntry(objects.FileModeDir, dir, WriteTree(t, store, sub), objects.TreeObject)
+		tree.AddEntry(*entry)
+	}
+
+	hash, err := store.WriteObject(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
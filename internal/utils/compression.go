@@ -3,6 +3,8 @@
 package utils
 
 import (
+	"io"
+
 	"github.com/klauspost/compress/zstd"
 )
 
@@ -24,6 +26,22 @@ func DecompressZstd(data []byte) ([]byte, error) {
 	return decoder.DecodeAll(data, nil)
 }
 
+// NewZstdWriter возвращает поток, сжимающий записанные данные в w.
+// Close дописывает конец кадра zstd, но не закрывает w
+func NewZstdWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
+
+// NewZstdReader возвращает поток, распаковывающий данные из r.
+// Результат совместим с CompressZstd: кадры, сжатые целиком и потоком, неотличимы
+func NewZstdReader(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
+
 // GetCompressionRatio вычисляет коэффициент сжатия.
 // Возвращает отношение размера сжатых данных к оригинальным (0.0 - 1.0).
 func GetCompressionRatio(original, compressed []byte) float64 {
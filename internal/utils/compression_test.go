@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"bytes"
+	"io"
 	"testing"
 )
 
@@ -26,6 +28,35 @@ func TestCompressDecompressZstd(t *testing.T) {
 	}
 }
 
+func TestZstdStream(t *testing.T) {
+	original := bytes.Repeat([]byte("streamed data "), 10000)
+
+	var compressed bytes.Buffer
+	w, err := NewZstdWriter(&compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(original[:100])
+	w.Write(original[100:])
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Сжатое потоком читается DecompressZstd, и наоборот
+	if decompressed, err := DecompressZstd(compressed.Bytes()); err != nil || !bytes.Equal(decompressed, original) {
+		t.Errorf("DecompressZstd of stream: %v", err)
+	}
+	whole, _ := CompressZstd(original)
+	r, err := NewZstdReader(bytes.NewReader(whole))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if decompressed, err := io.ReadAll(r); err != nil || !bytes.Equal(decompressed, original) {
+		t.Errorf("NewZstdReader of CompressZstd: %v", err)
+	}
+}
+
 func TestGetCompressionRatio(t *testing.T) {
 	original := []byte("test data")
 	compressed := []byte("compressed")